@@ -43,8 +43,13 @@ func main() {
 	// Initialize repositories
 	userRepo := repository.NewUserRepository(database)
 	ruleRepo := repository.NewRuleRepository(database)
-	// executionRepo := repository.NewExecutionRepository(database)
+	executionRepo := repository.NewExecutionRepository(database)
 	portfolioRepo := repository.NewPortfolioRepository(database)
+	marketDataRepo := repository.NewMarketDataRepository(database)
+	notificationRepo := repository.NewNotificationRepository(database)
+	ruleProposalRepo := repository.NewRuleProposalRepository(database)
+	symbolRepo := repository.NewSymbolRepository(database)
+	transactor := repository.NewTransactor(database)
 
 	// Initialize token service
 	tokenService := auth.NewTokenService(cfg)
@@ -53,14 +58,25 @@ func main() {
 	userService := services.NewUserService(userRepo)
 	ruleService := services.NewRuleService(ruleRepo)
 	portfolioService := services.NewPortfolioService(portfolioRepo)
+	marketDataService := services.NewMarketDataService(marketDataRepo)
+	executionService := services.NewExecutionService(executionRepo, ruleRepo)
+	notificationService := services.NewNotificationService(notificationRepo)
+	ruleEngineService := services.NewRuleEngineService(ruleRepo, marketDataService, portfolioService, executionService, transactor)
+	ruleProposalService := services.NewRuleProposalService(ruleRepo, ruleProposalRepo, transactor)
+	symbolMaster := services.NewSymbolMaster(symbolRepo, cfg.MarketData.SymbolCacheTTL)
 
 	// Initialize handlers
 	authHandler := handlers.NewAuthHandler(userService, tokenService)
-	ruleHandler := handlers.NewRuleHandler(ruleService)
+	ruleHandler := handlers.NewRuleHandler(ruleService, ruleEngineService, ruleProposalService)
 	portfolioHandler := handlers.NewPortfolioHandler(portfolioService)
+	executionHandler := handlers.NewExecutionHandler(executionService)
+	notificationHandler := handlers.NewNotificationHandler(notificationService)
+	marketDataHandler := handlers.NewMarketDataHandler(
+		services.NewCachedMarketDataService(marketDataService, cfg.MarketData.CacheTTL), symbolMaster)
 
 	// Create HTTP server
-	srv := server.NewServer(cfg, authHandler, ruleHandler, portfolioHandler, tokenService)
+	srv := server.NewServer(cfg, authHandler, ruleHandler, portfolioHandler, executionHandler, notificationHandler,
+		marketDataHandler, tokenService)
 
 	// Start server in a goroutine
 	go func() {
@@ -0,0 +1,111 @@
+// cmd/marketdata/main.go
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/aquibsayyed9/sentinel/internal/auth"
+	"github.com/aquibsayyed9/sentinel/internal/config"
+	"github.com/aquibsayyed9/sentinel/internal/db"
+	"github.com/aquibsayyed9/sentinel/internal/marketdata"
+	"github.com/aquibsayyed9/sentinel/internal/repository"
+)
+
+func main() {
+	// Load configuration
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	// Initialize logger
+	l := log.New(log.Writer(), "[MARKET DATA] ", log.LstdFlags)
+	l.Println("Initializing market data service...")
+
+	// Connect to database
+	database, err := db.Connect(cfg)
+	if err != nil {
+		l.Fatalf("Failed to connect to database: %v", err)
+	}
+	l.Println("Database connection established")
+
+	marketDataRepo := repository.NewMarketDataRepository(database)
+	symbolRepo := repository.NewSymbolRepository(database)
+
+	provider, err := marketdata.NewRegistry().New(cfg.MarketData.Provider, marketdata.ProviderConfig{
+		StreamURL: cfg.MarketData.StreamURL,
+		DataURL:   cfg.MarketData.DataURL,
+		APIKey:    cfg.MarketData.APIKey,
+		APISecret: cfg.MarketData.APISecret,
+		Replay: marketdata.ReplayConfig{
+			Path:  cfg.MarketData.Replay.Path,
+			Speed: cfg.MarketData.Replay.Speed,
+			Loop:  cfg.MarketData.Replay.Loop,
+		},
+	})
+	if err != nil {
+		l.Fatalf("Failed to create market data provider: %v", err)
+	}
+
+	// Stored updates reach the rule engine through database notifications,
+	// and WebSocket clients through the broadcaster
+	broadcaster := marketdata.NewBroadcaster()
+	ingestor := marketdata.NewIngestor(provider, marketDataRepo, broadcaster, marketdata.IngestorConfig{
+		Symbols:    cfg.MarketData.Symbols,
+		MinBackoff: cfg.MarketData.MinBackoff,
+		MaxBackoff: cfg.MarketData.MaxBackoff,
+		OnError: func(err error) {
+			l.Printf("Ingestion error: %v", err)
+		},
+	})
+
+	streamServer := marketdata.NewStreamServer(broadcaster, auth.NewTokenService(cfg), marketdata.StreamServerConfig{})
+	mux := http.NewServeMux()
+	mux.Handle("/ws", streamServer)
+	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"status":"ok"}`))
+	})
+	httpServer := &http.Server{
+		Addr:    ":" + cfg.MarketData.WSPort,
+		Handler: mux,
+	}
+
+	go func() {
+		l.Printf("Serving WebSocket stream on %s", httpServer.Addr)
+		if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			l.Fatalf("WebSocket server failed: %v", err)
+		}
+	}()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// The API only serves symbols listed in the symbol master
+	listed, err := marketdata.SyncSymbols(ctx, provider, symbolRepo, cfg.MarketData.Symbols)
+	if err != nil {
+		l.Printf("Symbol master sync incomplete: %v", err)
+	}
+	l.Printf("Listed %d symbol(s) in the symbol master", listed)
+
+	l.Printf("Streaming %d symbol(s) from %s...", len(cfg.MarketData.Symbols), provider.Name())
+	if err := ingestor.Run(ctx); err != nil {
+		l.Fatalf("Market data service failed: %v", err)
+	}
+
+	l.Println("Shutting down...")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		l.Printf("WebSocket server shutdown failed: %v", err)
+	}
+	streamServer.Close()
+	l.Println("Market data service stopped")
+}
@@ -4,7 +4,9 @@ package main
 import (
 	"context"
 	"log"
-	"time"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/aquibsayyed9/sentinel/internal/config"
 	"github.com/aquibsayyed9/sentinel/internal/db"
@@ -15,7 +17,7 @@ import (
 
 func main() {
 	// Load configuration
-	cfg, err := config.LoadConfig()
+	cfg, err := config.Load()
 	if err != nil {
 		log.Fatalf("Failed to load config: %v", err)
 	}
@@ -25,7 +27,7 @@ func main() {
 	l.Println("Initializing rule engine...")
 
 	// Connect to database
-	database, err := db.ConnectDatabase(cfg)
+	database, err := db.Connect(cfg)
 	if err != nil {
 		l.Fatalf("Failed to connect to database: %v", err)
 	}
@@ -36,11 +38,17 @@ func main() {
 	marketDataRepo := repository.NewMarketDataRepository(database)
 	portfolioRepo := repository.NewPortfolioRepository(database)
 	executionRepo := repository.NewExecutionRepository(database)
+	transactor := repository.NewTransactor(database)
+	clusterRepo := repository.NewClusterRepository(database)
+	notificationRepo := repository.NewNotificationRepository(database)
+	ruleProposalRepo := repository.NewRuleProposalRepository(database)
+	listener := repository.NewMarketEventListener(database)
 
 	// Initialize services
 	marketDataService := services.NewMarketDataService(marketDataRepo)
 	portfolioService := services.NewPortfolioService(portfolioRepo)
 	executionService := services.NewExecutionService(executionRepo, ruleRepo)
+	notificationService := services.NewNotificationService(notificationRepo)
 
 	// Create rule engine service
 	ruleEngineService := services.NewRuleEngineService(
@@ -48,78 +56,140 @@ func main() {
 		marketDataService,
 		portfolioService,
 		executionService,
+		transactor,
 	)
 
-	l.Println("Services initialized")
-	l.Println("Starting rule evaluation loop...")
-
-	// Rule evaluation loop
-	ticker := time.NewTicker(1 * time.Minute)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-ticker.C:
-			l.Println("Evaluating trading rules...")
-
-			// Get all active rules
-			ctx := context.Background()
-			rules, err := ruleRepo.GetActiveRules(ctx)
-			if err != nil {
-				l.Printf("Failed to get active rules: %v", err)
-				continue
-			}
+	// Instances against the same database split its rules between them
+	cluster := services.NewRuleCluster(clusterRepo, services.RuleClusterConfig{
+		Name:              cfg.RuleEngine.InstanceName,
+		HeartbeatInterval: cfg.RuleEngine.HeartbeatInterval,
+		LeaseTTL:          cfg.RuleEngine.LeaseTTL,
+		OnMembershipChange: func(instances []models.EngineInstance, leader bool) {
+			l.Printf("Cluster now has %d live instance(s); leader: %v", len(instances), leader)
+		},
+		OnError: func(err error) {
+			l.Printf("Cluster error: %v", err)
+		},
+	})
+
+	// Per-rule metrics, and quarantine of rules that keep failing
+	metrics := services.NewRuleMetrics(ruleRepo, notificationService, transactor, services.RuleMetricsConfig{
+		FlushInterval:   cfg.RuleEngine.MetricsFlushInterval,
+		QuarantineAfter: cfg.RuleEngine.QuarantineAfterErrors,
+		OnQuarantine: func(rule *models.TradingRule, err error) {
+			l.Printf("Rule %s quarantined after repeated errors; last error: %v", rule.ID, err)
+		},
+		OnError: func(err error) {
+			l.Printf("Rule metrics error: %v", err)
+		},
+	})
+
+	// Volatility-scaled tuning of AI-managed rules
+	advisor := services.NewRuleAdvisor(
+		services.NewVolatilityAdvisor(services.VolatilityAdvisorConfig{}),
+		ruleRepo,
+		ruleProposalRepo,
+		marketDataService,
+		executionRepo,
+		notificationService,
+		transactor,
+		services.RuleAdvisorConfig{
+			Interval:    cfg.RuleEngine.AdvisorInterval,
+			Coordinator: cluster,
+			OnProposal: func(rule *models.TradingRule, proposal *models.RuleProposal) {
+				l.Printf("Advisor %s proposed adjustments to rule %s (%s)", proposal.Advisor, rule.ID, proposal.Status)
+			},
+			OnError: func(rule *models.TradingRule, err error) {
+				if rule == nil {
+					l.Printf("Rule advisor error: %v", err)
+					return
+				}
+				l.Printf("Error advising on rule %s: %v", rule.ID, err)
+			},
+		},
+	)
 
-			l.Printf("Found %d active rules to evaluate", len(rules))
+	dispatcher := services.NewRuleDispatcher(ruleRepo, ruleEngineService, services.RuleDispatcherConfig{
+		Workers:         cfg.RuleEngine.Workers,
+		QueueSize:       cfg.RuleEngine.QueueSize,
+		RefreshInterval: cfg.RuleEngine.RefreshInterval,
+		Coordinator:     cluster,
+		Metrics:         metrics,
+		OnTrigger: func(rule *models.TradingRule) {
+			l.Printf("Rule %s triggered and executed", rule.ID)
+		},
+		OnError: func(rule *models.TradingRule, err error) {
+			if rule == nil {
+				l.Printf("Rule dispatcher error: %v", err)
+				return
+			}
+			l.Printf("Error processing rule %s: %v", rule.ID, err)
+		},
+	})
+
+	scheduler := services.NewRuleScheduler(ruleRepo, ruleEngineService, services.RuleSchedulerConfig{
+		PollInterval: cfg.RuleEngine.SchedulePollInterval,
+		Coordinator:  cluster,
+		Metrics:      metrics,
+		OnTrigger: func(rule *models.TradingRule) {
+			l.Printf("Scheduled rule %s triggered and executed", rule.ID)
+		},
+		OnError: func(rule *models.TradingRule, err error) {
+			if rule == nil {
+				l.Printf("Rule scheduler error: %v", err)
+				return
+			}
+			l.Printf("Error processing scheduled rule %s: %v", rule.ID, err)
+		},
+	})
 
-			// Evaluate each rule
-			for _, rule := range rules {
-				l.Printf("Evaluating rule %s: %s", rule.ID, rule.Name)
+	l.Println("Services initialized")
 
-				// Check if rule should be executed
-				shouldExecute, err := ruleEngineService.EvaluateRule(ctx, &rule)
-				if err != nil {
-					l.Printf("Error evaluating rule %s: %v", rule.ID, err)
-					continue
-				}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-				if shouldExecute {
-					l.Printf("Rule %s triggered! Executing...", rule.ID)
-
-					// Get current market data
-					marketData, err := marketDataService.GetPrice(ctx, rule.Symbol)
-					if err != nil {
-						l.Printf("Failed to get price for %s: %v", rule.Symbol, err)
-						continue
-					}
-
-					// Execute the rule
-					if err := ruleEngineService.ExecuteRule(ctx, &rule, marketData.Close); err != nil {
-						l.Printf("Error executing rule %s: %v", rule.ID, err)
-					} else {
-						l.Printf("Rule %s executed successfully", rule.ID)
-
-						// Create execution record
-						execution := &models.RuleExecution{
-							RuleID:       rule.ID,
-							UserID:       rule.UserID,
-							Symbol:       rule.Symbol,
-							ExecutedAt:   time.Now(),
-							TriggerPrice: marketData.Close,
-							Status:       "executed",
-							Notes:        "Automated execution by rule engine",
-						}
-
-						if err := executionService.CreateExecution(ctx, execution); err != nil {
-							l.Printf("Failed to record execution for rule %s: %v", rule.ID, err)
-						}
-					}
-				} else {
-					l.Printf("Rule %s conditions not met, skipping execution", rule.ID)
-				}
+	if err := cluster.Join(ctx); err != nil {
+		l.Fatalf("Failed to join the rule engine cluster: %v", err)
+	}
+	l.Printf("Joined the rule engine cluster as %s (%s)", cluster.Name(), cluster.ID())
+
+	// Evaluate rules as market events arrive, and scheduled rules as they
+	// come due, while holding this instance's lease
+	done := make(chan error, 5)
+	go func() {
+		done <- cluster.Run(ctx)
+	}()
+	go func() {
+		done <- metrics.Run(ctx)
+	}()
+	go func() {
+		done <- advisor.Run(ctx)
+	}()
+	go func() {
+		done <- dispatcher.Run(ctx)
+	}()
+	go func() {
+		done <- scheduler.Run(ctx)
+	}()
+
+	l.Println("Listening for market events...")
+	go func() {
+		err := listener.Listen(ctx, func(event models.MarketEvent) {
+			if err := dispatcher.Dispatch(ctx, event); err != nil && ctx.Err() == nil {
+				l.Printf("Failed to dispatch %s event for %s: %v", event.Kind, event.Symbol, err)
 			}
+		}, func(err error) {
+			l.Printf("Market event listener: %v", err)
+		})
+		if err != nil {
+			l.Printf("Market event listener stopped: %v", err)
+		}
+	}()
 
-			l.Println("Rule evaluation cycle completed")
+	for i := 0; i < cap(done); i++ {
+		if err := <-done; err != nil {
+			l.Fatalf("Rule engine failed: %v", err)
 		}
 	}
+	l.Println("Rule engine stopped")
 }
@@ -0,0 +1,110 @@
+// internal/calendar/calendar.go
+package calendar
+
+import (
+	"time"
+
+	// Embeds the time zone database so exchange hours don't depend on the host
+	_ "time/tzdata"
+)
+
+// Session is the span of one trading day during which an exchange is open
+type Session struct {
+	Open       time.Time
+	Close      time.Time
+	EarlyClose bool
+}
+
+// Contains reports whether t falls within the session, which includes its
+// open and excludes its close
+func (s Session) Contains(t time.Time) bool {
+	return !t.Before(s.Open) && t.Before(s.Close)
+}
+
+// Calendar describes the regular trading hours of an exchange: the days it
+// trades, when it opens and closes, and the holidays and early closes of
+// each year
+type Calendar struct {
+	Name     string
+	Location *time.Location
+
+	// Opening and closing times as offsets from midnight in Location
+	Open       time.Duration
+	Close      time.Duration
+	EarlyClose time.Duration
+
+	// holidays and earlyCloses return the dates of a year the exchange is
+	// closed or closes early
+	holidays    func(year int) []time.Time
+	earlyCloses func(year int) []time.Time
+}
+
+// IsTradingDay reports whether the exchange trades on the local date of t
+func (c *Calendar) IsTradingDay(t time.Time) bool {
+	t = t.In(c.Location)
+	if t.Weekday() == time.Saturday || t.Weekday() == time.Sunday {
+		return false
+	}
+	return !containsDate(c.holidays(t.Year()), t)
+}
+
+// IsHoliday reports whether the local date of t is a weekday on which the
+// exchange is closed
+func (c *Calendar) IsHoliday(t time.Time) bool {
+	t = t.In(c.Location)
+	return containsDate(c.holidays(t.Year()), t)
+}
+
+// SessionOn returns the regular session on the local date of t, or false if
+// the exchange doesn't trade that day
+func (c *Calendar) SessionOn(t time.Time) (Session, bool) {
+	if !c.IsTradingDay(t) {
+		return Session{}, false
+	}
+
+	t = t.In(c.Location)
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, c.Location)
+	session := Session{Open: addClock(midnight, c.Open), Close: addClock(midnight, c.Close)}
+	if containsDate(c.earlyCloses(t.Year()), t) {
+		session.Close = addClock(midnight, c.EarlyClose)
+		session.EarlyClose = true
+	}
+	return session, true
+}
+
+// IsOpen reports whether t falls within a regular session
+func (c *Calendar) IsOpen(t time.Time) bool {
+	session, ok := c.SessionOn(t)
+	return ok && session.Contains(t)
+}
+
+// NextSession returns the first session that hasn't closed by t. It is the
+// current session while the exchange is open.
+func (c *Calendar) NextSession(t time.Time) Session {
+	day := t.In(c.Location)
+	for {
+		if session, ok := c.SessionOn(day); ok && t.Before(session.Close) {
+			return session
+		}
+		day = time.Date(day.Year(), day.Month(), day.Day()+1, 0, 0, 0, 0, c.Location)
+	}
+}
+
+// addClock adds a time of day to midnight, counting wall-clock hours so the
+// result is right on days when daylight saving time starts or ends
+func addClock(midnight time.Time, offset time.Duration) time.Time {
+	hours := int(offset / time.Hour)
+	minutes := int(offset % time.Hour / time.Minute)
+	return time.Date(midnight.Year(), midnight.Month(), midnight.Day(), hours, minutes, 0, 0, midnight.Location())
+}
+
+func containsDate(dates []time.Time, t time.Time) bool {
+	y, m, d := t.Date()
+	for _, date := range dates {
+		dy, dm, dd := date.Date()
+		if dy == y && dm == m && dd == d {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,219 @@
+// internal/calendar/cron.go
+package calendar
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidCron = errors.New("invalid cron expression")
+)
+
+// cronSearchYears bounds the search for the next run of an expression, such
+// as "0 0 30 2 *", that never matches
+const cronSearchYears = 5
+
+// Cron is a parsed five-field cron expression: minute, hour, day of month,
+// month and day of week. Fields accept "*", values, ranges "a-b", steps
+// "*/n" and "a-b/n", and comma-separated lists of these. Months and days of
+// the week may be given by their three-letter names, and Sunday is 0 or 7.
+// As in standard cron, when both the day of month and the day of week are
+// restricted, a day matching either one matches.
+type Cron struct {
+	expr    string
+	minutes uint64
+	hours   uint64
+	days    uint64
+	months  uint64
+	weekday uint64
+
+	// Whether the day fields were given as "*"
+	anyDay     bool
+	anyWeekday bool
+}
+
+type cronField struct {
+	name     string
+	min, max int
+	names    []string // names of the values from min, if any
+}
+
+var cronFields = []cronField{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12, names: []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}},
+	{name: "day of week", min: 0, max: 7, names: []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}},
+}
+
+// ParseCron parses a five-field cron expression
+func ParseCron(expr string) (*Cron, error) {
+	parts := strings.Fields(expr)
+	if len(parts) != len(cronFields) {
+		return nil, fmt.Errorf("%w: expected %d fields, found %d", ErrInvalidCron, len(cronFields), len(parts))
+	}
+
+	sets := make([]uint64, len(parts))
+	for i, part := range parts {
+		set, err := cronFields[i].parse(part)
+		if err != nil {
+			return nil, err
+		}
+		sets[i] = set
+	}
+
+	// Sunday may be written as 7
+	weekday := sets[4]
+	if weekday&(1<<7) != 0 {
+		weekday = weekday&^(1<<7) | 1
+	}
+
+	return &Cron{
+		expr:       strings.Join(parts, " "),
+		minutes:    sets[0],
+		hours:      sets[1],
+		days:       sets[2],
+		months:     sets[3],
+		weekday:    weekday,
+		anyDay:     parts[2] == "*",
+		anyWeekday: parts[4] == "*",
+	}, nil
+}
+
+func (c *Cron) String() string {
+	return c.expr
+}
+
+// Next returns the first time after t that the expression matches, in t's
+// location, or false if it matches none in the next few years. Wall-clock
+// times skipped when clocks go forward never match, and those repeated when
+// they go back match twice.
+func (c *Cron) Next(t time.Time) (time.Time, bool) {
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(cronSearchYears, 0, 0)
+
+	for t.Before(limit) {
+		if !has(c.months, int(t.Month())) {
+			t = forward(t, time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc))
+			continue
+		}
+		if !c.matchesDay(t) {
+			t = forward(t, time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc))
+			continue
+		}
+		if !has(c.hours, t.Hour()) {
+			t = forward(t, time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc))
+			continue
+		}
+		if !has(c.minutes, t.Minute()) {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t, true
+	}
+	return time.Time{}, false
+}
+
+// forward returns next, unless it isn't after t because a wall-clock time
+// skipped by a daylight saving change normalized backwards, in which case it
+// returns the start of the following hour
+func forward(t, next time.Time) time.Time {
+	if next.After(t) {
+		return next
+	}
+	return t.Add(time.Duration(60-t.Minute()) * time.Minute)
+}
+
+func (c *Cron) matchesDay(t time.Time) bool {
+	day := has(c.days, t.Day())
+	weekday := has(c.weekday, int(t.Weekday()))
+	switch {
+	case c.anyDay && c.anyWeekday:
+		return true
+	case c.anyDay:
+		return weekday
+	case c.anyWeekday:
+		return day
+	default:
+		return day || weekday
+	}
+}
+
+func has(set uint64, value int) bool {
+	return set&(1<<uint(value)) != 0
+}
+
+// parse returns the set of values a field matches as a bit set
+func (f cronField) parse(field string) (uint64, error) {
+	var set uint64
+	for _, item := range strings.Split(field, ",") {
+		rangePart, step := item, 1
+		if i := strings.IndexByte(item, '/'); i >= 0 {
+			rangePart = item[:i]
+			n, err := strconv.Atoi(item[i+1:])
+			if err != nil || n <= 0 {
+				return 0, f.errorf("invalid step %q", item[i+1:])
+			}
+			step = n
+		}
+
+		low, high := f.min, f.max
+		switch {
+		case rangePart == "*":
+			if f.name == "day of week" {
+				high = 6
+			}
+		case strings.Contains(rangePart, "-"):
+			bounds := strings.SplitN(rangePart, "-", 2)
+			var err error
+			if low, err = f.value(bounds[0]); err != nil {
+				return 0, err
+			}
+			if high, err = f.value(bounds[1]); err != nil {
+				return 0, err
+			}
+			if low > high {
+				return 0, f.errorf("range %q is backwards", rangePart)
+			}
+		default:
+			value, err := f.value(rangePart)
+			if err != nil {
+				return 0, err
+			}
+			low = value
+			if step == 1 {
+				high = value
+			}
+		}
+
+		for v := low; v <= high; v += step {
+			set |= 1 << uint(v)
+		}
+	}
+	return set, nil
+}
+
+func (f cronField) value(text string) (int, error) {
+	for i, name := range f.names {
+		if strings.EqualFold(text, name) {
+			return f.min + i, nil
+		}
+	}
+	value, err := strconv.Atoi(text)
+	if err != nil {
+		return 0, f.errorf("invalid value %q", text)
+	}
+	if value < f.min || value > f.max {
+		return 0, f.errorf("%d is outside %d-%d", value, f.min, f.max)
+	}
+	return value, nil
+}
+
+func (f cronField) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s: %s", ErrInvalidCron, f.name, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,123 @@
+// internal/calendar/nyse.go
+package calendar
+
+import (
+	"sync"
+	"time"
+)
+
+var (
+	nyseOnce sync.Once
+	nyse     *Calendar
+)
+
+// NYSE returns the calendar of the New York Stock Exchange: 9:30 to 16:00
+// Eastern time on weekdays, closing at 13:00 on the day before Independence
+// Day, the day after Thanksgiving and Christmas Eve
+func NYSE() *Calendar {
+	nyseOnce.Do(func() {
+		location, err := time.LoadLocation("America/New_York")
+		if err != nil {
+			// The time zone database is embedded, so this cannot happen
+			panic(err)
+		}
+		nyse = &Calendar{
+			Name:        "NYSE",
+			Location:    location,
+			Open:        9*time.Hour + 30*time.Minute,
+			Close:       16 * time.Hour,
+			EarlyClose:  13 * time.Hour,
+			holidays:    nyseHolidays,
+			earlyCloses: nyseEarlyCloses,
+		}
+	})
+	return nyse
+}
+
+// nyseHolidays returns the weekdays of a year on which the NYSE is closed
+func nyseHolidays(year int) []time.Time {
+	holidays := []time.Time{
+		nthWeekday(year, time.January, time.Monday, 3),    // Martin Luther King Jr. Day
+		nthWeekday(year, time.February, time.Monday, 3),   // Washington's Birthday
+		easter(year).AddDate(0, 0, -2),                    // Good Friday
+		lastWeekday(year, time.May, time.Monday),          // Memorial Day
+		observed(date(year, time.July, 4)),                // Independence Day
+		nthWeekday(year, time.September, time.Monday, 1),  // Labor Day
+		nthWeekday(year, time.November, time.Thursday, 4), // Thanksgiving Day
+		observed(date(year, time.December, 25)),           // Christmas Day
+	}
+
+	// New Year's Day falling on a Saturday is not observed on the Friday
+	// before, which would close the market on the last day of the year
+	if newYear := date(year, time.January, 1); newYear.Weekday() != time.Saturday {
+		holidays = append(holidays, observed(newYear))
+	}
+	if year >= 2022 {
+		holidays = append(holidays, observed(date(year, time.June, 19))) // Juneteenth
+	}
+	return holidays
+}
+
+// nyseEarlyCloses returns the trading days of a year on which the NYSE
+// closes early
+func nyseEarlyCloses(year int) []time.Time {
+	var closes []time.Time
+	for _, day := range []time.Time{
+		date(year, time.July, 3),
+		nthWeekday(year, time.November, time.Thursday, 4).AddDate(0, 0, 1),
+		date(year, time.December, 24),
+	} {
+		// The day before a holiday is only shortened when it's a regular
+		// trading day itself, not the observed holiday
+		if day.Weekday() != time.Saturday && day.Weekday() != time.Sunday && !containsDate(nyseHolidays(year), day) {
+			closes = append(closes, day)
+		}
+	}
+	return closes
+}
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// observed moves a holiday that falls on a weekend to the nearest weekday
+func observed(day time.Time) time.Time {
+	switch day.Weekday() {
+	case time.Saturday:
+		return day.AddDate(0, 0, -1)
+	case time.Sunday:
+		return day.AddDate(0, 0, 1)
+	default:
+		return day
+	}
+}
+
+// nthWeekday returns the nth given weekday of a month
+func nthWeekday(year int, month time.Month, weekday time.Weekday, n int) time.Time {
+	first := date(year, month, 1)
+	offset := (int(weekday) - int(first.Weekday()) + 7) % 7
+	return first.AddDate(0, 0, offset+7*(n-1))
+}
+
+// lastWeekday returns the last given weekday of a month
+func lastWeekday(year int, month time.Month, weekday time.Weekday) time.Time {
+	last := date(year, month+1, 0)
+	offset := (int(last.Weekday()) - int(weekday) + 7) % 7
+	return last.AddDate(0, 0, -offset)
+}
+
+// easter returns Easter Sunday of a year in the Gregorian calendar
+func easter(year int) time.Time {
+	a := year % 19
+	b, c := year/100, year%100
+	d, e := b/4, b%4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i, k := c/4, c%4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	month := (h + l - 7*m + 114) / 31
+	day := (h+l-7*m+114)%31 + 1
+	return date(year, time.Month(month), day)
+}
@@ -4,6 +4,7 @@ package config
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 )
@@ -36,6 +37,31 @@ type Config struct {
 		APIKey    string `mapstructure:"api_key"`
 		APISecret string `mapstructure:"api_secret"`
 		WSPort    string `mapstructure:"ws_port"`
+
+		// Provider stream and historical data endpoints; the provider's
+		// defaults if empty
+		StreamURL string `mapstructure:"stream_url"`
+		DataURL   string `mapstructure:"data_url"`
+
+		// Symbols the market data service ingests
+		Symbols []string `mapstructure:"symbols"`
+
+		// How long the API caches latest prices and quotes, and symbol
+		// master lookups
+		CacheTTL       time.Duration `mapstructure:"cache_ttl"`
+		SymbolCacheTTL time.Duration `mapstructure:"symbol_cache_ttl"`
+
+		// Bounds on the wait before reconnecting to a failed provider stream
+		MinBackoff time.Duration `mapstructure:"min_backoff"`
+		MaxBackoff time.Duration `mapstructure:"max_backoff"`
+
+		// Files the replay provider plays back, how many times faster than
+		// real time, and whether it starts over once they run out
+		Replay struct {
+			Path  string  `mapstructure:"path"`
+			Speed float64 `mapstructure:"speed"`
+			Loop  bool    `mapstructure:"loop"`
+		} `mapstructure:"replay"`
 	} `mapstructure:"market_data"`
 
 	Broker struct {
@@ -44,6 +70,30 @@ type Config struct {
 		APISecret string `mapstructure:"api_secret"`
 		IsPaper   bool   `mapstructure:"is_paper"`
 	} `mapstructure:"broker"`
+
+	RuleEngine struct {
+		Workers         int           `mapstructure:"workers"`
+		QueueSize       int           `mapstructure:"queue_size"`
+		RefreshInterval time.Duration `mapstructure:"refresh_interval"`
+
+		// How often scheduled rules are checked for due runs
+		SchedulePollInterval time.Duration `mapstructure:"schedule_poll_interval"`
+
+		// Instances running against the same database share its rules. Each
+		// renews a lease every heartbeat interval and is treated as failed
+		// once its lease goes unrenewed for the lease TTL.
+		InstanceName      string        `mapstructure:"instance_name"`
+		HeartbeatInterval time.Duration `mapstructure:"heartbeat_interval"`
+		LeaseTTL          time.Duration `mapstructure:"lease_ttl"`
+
+		// How often per-rule metrics are written, and how many consecutive
+		// errors set a rule to errored (0 never does)
+		MetricsFlushInterval  time.Duration `mapstructure:"metrics_flush_interval"`
+		QuarantineAfterErrors int           `mapstructure:"quarantine_after_errors"`
+
+		// How often AI-managed rules are reviewed by the strategy advisor
+		AdvisorInterval time.Duration `mapstructure:"advisor_interval"`
+	} `mapstructure:"rule_engine"`
 }
 
 func Load() (*Config, error) {
@@ -40,12 +40,56 @@ func Connect(cfg *config.Config) (*gorm.DB, error) {
 	if err := db.AutoMigrate(
 		&models.User{},
 		&models.TradingRule{},
+		&models.RuleVersion{},
 		&models.Execution{},
 		&models.Portfolio{},
 		&models.PortfolioHolding{},
+		&models.MarketData{},
+		&models.Quote{},
+		&models.EngineInstance{},
+		&models.RuleMetrics{},
+		&models.Notification{},
+		&models.RuleProposal{},
+		&models.Symbol{},
 	); err != nil {
 		return nil, fmt.Errorf("failed to migrate database: %w", err)
 	}
 
+	if err := installMarketEventTriggers(db); err != nil {
+		return nil, fmt.Errorf("failed to install market event triggers: %w", err)
+	}
+
 	return db, nil
 }
+
+// installMarketEventTriggers makes every insert into market_data and quotes
+// publish a notification, so consumers react to new data without polling
+func installMarketEventTriggers(db *gorm.DB) error {
+	statements := []string{
+		`CREATE OR REPLACE FUNCTION notify_market_event() RETURNS trigger AS $$
+		BEGIN
+			PERFORM pg_notify('` + models.MarketEventChannel + `', json_build_object(
+				'symbol', NEW.symbol,
+				'kind', TG_ARGV[0],
+				'timestamp', NEW.timestamp
+			)::text);
+			RETURN NEW;
+		END;
+		$$ LANGUAGE plpgsql`,
+		`DROP TRIGGER IF EXISTS market_data_notify ON market_data`,
+		`CREATE TRIGGER market_data_notify AFTER INSERT ON market_data
+			FOR EACH ROW EXECUTE FUNCTION notify_market_event('bar')`,
+		`DROP TRIGGER IF EXISTS quotes_notify ON quotes`,
+		`CREATE TRIGGER quotes_notify AFTER INSERT ON quotes
+			FOR EACH ROW EXECUTE FUNCTION notify_market_event('quote')`,
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, statement := range statements {
+			if err := tx.Exec(statement).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
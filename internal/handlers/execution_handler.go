@@ -0,0 +1,136 @@
+// internal/handlers/execution_handler.go
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/aquibsayyed9/sentinel/internal/models"
+	"github.com/aquibsayyed9/sentinel/internal/repository"
+	"github.com/aquibsayyed9/sentinel/internal/services"
+)
+
+type ExecutionHandler struct {
+	executionService services.ExecutionService
+}
+
+func NewExecutionHandler(executionService services.ExecutionService) *ExecutionHandler {
+	return &ExecutionHandler{
+		executionService: executionService,
+	}
+}
+
+type executionResponse struct {
+	ID            string  `json:"id"`
+	RuleID        *string `json:"rule_id"`
+	RuleVersion   *int    `json:"rule_version"`
+	TargetRuleID  *string `json:"target_rule_id,omitempty"`
+	Symbol        string  `json:"symbol"`
+	ExecutionType string  `json:"execution_type"`
+	Quantity      float64 `json:"quantity"`
+	Price         float64 `json:"price"`
+	TotalAmount   float64 `json:"total_amount"`
+	Status        string  `json:"status"`
+	ExecutionTime string  `json:"execution_time"`
+}
+
+func newExecutionResponse(execution *models.Execution) executionResponse {
+	var ruleID *string
+	if execution.RuleID != nil {
+		id := execution.RuleID.String()
+		ruleID = &id
+	}
+	// The rule a rule action activated, deactivated or created
+	var targetRuleID *string
+	if execution.TargetRuleID != nil {
+		id := execution.TargetRuleID.String()
+		targetRuleID = &id
+	}
+
+	return executionResponse{
+		ID:            execution.ID.String(),
+		RuleID:        ruleID,
+		RuleVersion:   execution.RuleVersion,
+		TargetRuleID:  targetRuleID,
+		Symbol:        execution.Symbol,
+		ExecutionType: execution.ExecutionType,
+		Quantity:      execution.Quantity,
+		Price:         execution.Price,
+		TotalAmount:   execution.TotalAmount,
+		Status:        execution.Status,
+		ExecutionTime: execution.ExecutionTime.Format(time.RFC3339),
+	}
+}
+
+// GetExecutions lists the user's executions, newest first. With a rule_id
+// query parameter it lists the executions of that rule instead.
+func (h *ExecutionHandler) GetExecutions(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var executions []models.Execution
+	var err error
+	if ruleIDParam := c.Query("rule_id"); ruleIDParam != "" {
+		ruleID, parseErr := uuid.Parse(ruleIDParam)
+		if parseErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid rule ID"})
+			return
+		}
+		executions, err = h.executionService.GetRuleExecutions(c.Request.Context(), userID.(uuid.UUID), ruleID)
+	} else {
+		page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+		pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+		executions, err = h.executionService.GetUserExecutions(c.Request.Context(), userID.(uuid.UUID), page, pageSize)
+	}
+	if err != nil {
+		respondExecutionError(c, err)
+		return
+	}
+
+	response := make([]executionResponse, len(executions))
+	for i := range executions {
+		response[i] = newExecutionResponse(&executions[i])
+	}
+
+	c.JSON(http.StatusOK, gin.H{"executions": response})
+}
+
+func (h *ExecutionHandler) GetExecution(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid execution ID"})
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	execution, err := h.executionService.GetExecutionByID(c.Request.Context(), userID.(uuid.UUID), id)
+	if err != nil {
+		respondExecutionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"execution": newExecutionResponse(execution)})
+}
+
+// respondExecutionError writes the response for an error from the execution
+// service, reporting other users' executions and rules as not found
+func respondExecutionError(c *gin.Context, err error) {
+	if errors.Is(err, repository.ErrExecutionNotFound) || errors.Is(err, repository.ErrRuleNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
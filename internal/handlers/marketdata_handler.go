@@ -2,49 +2,64 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/aquibsayyed9/sentinel/internal/models"
+	"github.com/aquibsayyed9/sentinel/internal/repository"
 	"github.com/aquibsayyed9/sentinel/internal/services"
 	"github.com/gin-gonic/gin"
 )
 
+// Bounds on the bars returned by one request
+const (
+	defaultBarLimit = 1000
+	maxBarLimit     = 10000
+)
+
 type MarketDataHandler struct {
 	marketDataService services.MarketDataService
+	symbolMaster      services.SymbolMaster
 }
 
-func NewMarketDataHandler(marketDataService services.MarketDataService) *MarketDataHandler {
+func NewMarketDataHandler(marketDataService services.MarketDataService, symbolMaster services.SymbolMaster) *MarketDataHandler {
 	return &MarketDataHandler{
 		marketDataService: marketDataService,
+		symbolMaster:      symbolMaster,
 	}
 }
 
 func (h *MarketDataHandler) GetLatestPrice(c *gin.Context) {
-	symbol := c.Param("symbol")
-	if symbol == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "symbol is required"})
+	symbol, ok := h.symbol(c)
+	if !ok {
 		return
 	}
 
 	price, err := h.marketDataService.GetPrice(c.Request.Context(), symbol)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondMarketDataError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"price": price})
 }
 
+// GetHistoricalData lists the symbol's bars of one time frame, oldest first,
+// aggregated from minute bars if the time frame is not stored. At most limit
+// bars are returned; truncated reports whether there were more in the range.
 func (h *MarketDataHandler) GetHistoricalData(c *gin.Context) {
-	symbol := c.Param("symbol")
-	if symbol == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "symbol is required"})
+	symbol, ok := h.symbol(c)
+	if !ok {
 		return
 	}
 
 	startStr := c.Query("start")
 	endStr := c.Query("end")
-	timeframe := c.Query("timeframe")
+	timeframe := c.DefaultQuery("timeframe", "1m")
 
 	var start, end time.Time
 	var err error
@@ -71,27 +86,72 @@ func (h *MarketDataHandler) GetHistoricalData(c *gin.Context) {
 		end = time.Now()
 	}
 
-	data, err := h.marketDataService.GetHistoricalData(c.Request.Context(), symbol, start, end, timeframe)
+	if _, err := models.TimeFrameDuration(timeframe); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultBarLimit)))
+	if err != nil || limit < 1 || limit > maxBarLimit {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("limit must be between 1 and %d", maxBarLimit)})
+		return
+	}
+
+	// One bar past the limit tells whether the range holds more
+	data, err := h.marketDataService.GetBars(c.Request.Context(), symbol, timeframe, start, end, limit+1)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondMarketDataError(c, err)
 		return
 	}
+	truncated := len(data) > limit
+	if truncated {
+		data = data[:limit]
+	}
 
-	c.JSON(http.StatusOK, gin.H{"data": data})
+	c.JSON(http.StatusOK, gin.H{"data": data, "truncated": truncated})
 }
 
 func (h *MarketDataHandler) GetQuote(c *gin.Context) {
-	symbol := c.Param("symbol")
-	if symbol == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "symbol is required"})
+	symbol, ok := h.symbol(c)
+	if !ok {
 		return
 	}
 
 	quote, err := h.marketDataService.GetQuote(c.Request.Context(), symbol)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondMarketDataError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"quote": quote})
 }
+
+// symbol returns the request's symbol, writing the response and returning
+// false if it is not in the symbol master
+func (h *MarketDataHandler) symbol(c *gin.Context) (string, bool) {
+	symbol := strings.ToUpper(strings.TrimSpace(c.Param("symbol")))
+	if symbol == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "symbol is required"})
+		return "", false
+	}
+
+	if _, err := h.symbolMaster.Lookup(c.Request.Context(), symbol); err != nil {
+		respondMarketDataError(c, err)
+		return "", false
+	}
+	return symbol, true
+}
+
+// respondMarketDataError writes the response for an error from the market
+// data service or symbol master
+func respondMarketDataError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrUnknownSymbol), errors.Is(err, repository.ErrMarketDataNotFound),
+		errors.Is(err, repository.ErrQuoteNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInvalidDateRange), errors.Is(err, models.ErrInvalidTimeFrame):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,104 @@
+// internal/handlers/notification_handler.go
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/aquibsayyed9/sentinel/internal/models"
+	"github.com/aquibsayyed9/sentinel/internal/repository"
+	"github.com/aquibsayyed9/sentinel/internal/services"
+)
+
+type NotificationHandler struct {
+	notificationService services.NotificationService
+}
+
+func NewNotificationHandler(notificationService services.NotificationService) *NotificationHandler {
+	return &NotificationHandler{
+		notificationService: notificationService,
+	}
+}
+
+type notificationResponse struct {
+	ID        string  `json:"id"`
+	Kind      string  `json:"kind"`
+	RuleID    *string `json:"rule_id"`
+	Message   string  `json:"message"`
+	ReadAt    *string `json:"read_at"`
+	CreatedAt string  `json:"created_at"`
+}
+
+func newNotificationResponse(notification *models.Notification) notificationResponse {
+	var ruleID *string
+	if notification.RuleID != nil {
+		id := notification.RuleID.String()
+		ruleID = &id
+	}
+
+	return notificationResponse{
+		ID:        notification.ID.String(),
+		Kind:      notification.Kind,
+		RuleID:    ruleID,
+		Message:   notification.Message,
+		ReadAt:    formatOptionalTime(notification.ReadAt),
+		CreatedAt: notification.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+// GetNotifications lists the user's notifications, newest first. With
+// unread=true it lists only those not yet marked read.
+func (h *NotificationHandler) GetNotifications(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	unreadOnly := c.Query("unread") == "true"
+
+	notifications, err := h.notificationService.GetNotifications(c.Request.Context(), userID.(uuid.UUID), unreadOnly, page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := make([]notificationResponse, len(notifications))
+	for i := range notifications {
+		response[i] = newNotificationResponse(&notifications[i])
+	}
+
+	c.JSON(http.StatusOK, gin.H{"notifications": response})
+}
+
+func (h *NotificationHandler) MarkRead(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid notification ID"})
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	if err := h.notificationService.MarkRead(c.Request.Context(), userID.(uuid.UUID), id); err != nil {
+		if errors.Is(err, repository.ErrNotificationNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "notification marked as read"})
+}
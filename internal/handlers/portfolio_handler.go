@@ -2,12 +2,14 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 
+	"github.com/aquibsayyed9/sentinel/internal/repository"
 	"github.com/aquibsayyed9/sentinel/internal/services"
 )
 
@@ -96,7 +98,7 @@ func (h *PortfolioHandler) GetPortfolio(c *gin.Context) {
 
 	portfolio, err := h.portfolioService.GetPortfolioByUserID(c.Request.Context(), userID.(uuid.UUID))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondPortfolioError(c, err)
 		return
 	}
 
@@ -121,7 +123,7 @@ func (h *PortfolioHandler) GetHoldings(c *gin.Context) {
 
 	holdings, err := h.portfolioService.GetHoldings(c.Request.Context(), userID.(uuid.UUID))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondPortfolioError(c, err)
 		return
 	}
 
@@ -164,7 +166,7 @@ func (h *PortfolioHandler) AddHolding(c *gin.Context) {
 	}
 
 	if err := h.portfolioService.AddOrUpdateHolding(c.Request.Context(), userID.(uuid.UUID), req.Symbol, req.Quantity, req.Price); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondPortfolioError(c, err)
 		return
 	}
 
@@ -185,9 +187,20 @@ func (h *PortfolioHandler) RemoveHolding(c *gin.Context) {
 	}
 
 	if err := h.portfolioService.RemoveHolding(c.Request.Context(), userID.(uuid.UUID), symbol); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondPortfolioError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "holding removed successfully"})
 }
+
+// respondPortfolioError writes the response for an error from the portfolio
+// service. Portfolios are looked up by the authenticated user, so a missing
+// portfolio or holding is always the caller's own.
+func respondPortfolioError(c *gin.Context, err error) {
+	if errors.Is(err, repository.ErrPortfolioNotFound) || errors.Is(err, repository.ErrHoldingNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
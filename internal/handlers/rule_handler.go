@@ -3,22 +3,33 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 
+	"github.com/aquibsayyed9/sentinel/internal/models"
+	"github.com/aquibsayyed9/sentinel/internal/repository"
 	"github.com/aquibsayyed9/sentinel/internal/services"
 )
 
 type RuleHandler struct {
-	ruleService services.RuleService
+	ruleService     services.RuleService
+	ruleEngine      services.RuleEngineService
+	proposalService services.RuleProposalService
 }
 
-func NewRuleHandler(ruleService services.RuleService) *RuleHandler {
+func NewRuleHandler(ruleService services.RuleService, ruleEngine services.RuleEngineService,
+	proposalService services.RuleProposalService) *RuleHandler {
+
 	return &RuleHandler{
-		ruleService: ruleService,
+		ruleService:     ruleService,
+		ruleEngine:      ruleEngine,
+		proposalService: proposalService,
 	}
 }
 
@@ -27,8 +38,26 @@ type createRuleRequest struct {
 	Description string                   `json:"description"`
 	Symbol      string                   `json:"symbol" binding:"required"`
 	RuleType    string                   `json:"rule_type" binding:"required"`
-	Conditions  []services.RuleCondition `json:"conditions" binding:"required"`
-	Actions     []services.RuleAction    `json:"actions" binding:"required"`
+	Conditions  []services.RuleCondition `json:"conditions"`
+	Actions     []services.RuleAction    `json:"actions"`
+
+	// Conditions and actions as a rule expression, instead of the JSON fields
+	Expression string `json:"expression"`
+
+	TriggerPolicy   services.TriggerPolicy    `json:"trigger_policy"`
+	Stop            *services.StopSpec        `json:"stop"`
+	TimeConstraints *services.TimeConstraints `json:"time_constraints"`
+	Schedule        *services.ScheduleSpec    `json:"schedule"`
+	AIManagement    *services.AIManagement    `json:"ai_management"`
+}
+
+type evaluateRuleRequest struct {
+	MarketData map[string]services.MarketOverride `json:"market_data"`
+}
+
+type evaluateUnsavedRuleRequest struct {
+	createRuleRequest
+	MarketData map[string]services.MarketOverride `json:"market_data"`
 }
 
 type ruleResponse struct {
@@ -43,6 +72,229 @@ type ruleResponse struct {
 	IsAIManaged bool                     `json:"is_ai_managed"`
 	CreatedAt   string                   `json:"created_at"`
 	UpdatedAt   string                   `json:"updated_at"`
+
+	TriggerPolicy   services.TriggerPolicy `json:"trigger_policy"`
+	LastTriggeredAt *string                `json:"last_triggered_at"`
+	TriggerCount    int                    `json:"trigger_count"`
+	LastExecutedAt  *string                `json:"last_executed_at"`
+	Stop            *services.StopSpec     `json:"stop,omitempty"`
+	ReferencePrice  *float64               `json:"reference_price,omitempty"`
+
+	TimeConstraints *services.TimeConstraints `json:"time_constraints,omitempty"`
+	Schedule        *services.ScheduleSpec    `json:"schedule,omitempty"`
+	NextRunAt       *string                   `json:"next_run_at,omitempty"`
+	Version         int                       `json:"version"`
+	AIManagement    *services.AIManagement    `json:"ai_management,omitempty"`
+
+	// Conditions and actions as a rule expression
+	Expression string `json:"expression,omitempty"`
+
+	// How the rule has fared in the rule engine, once it has been evaluated
+	Metrics *ruleMetricsResponse `json:"metrics,omitempty"`
+}
+
+type ruleMetricsResponse struct {
+	Evaluations       int64   `json:"evaluations"`
+	Triggers          int64   `json:"triggers"`
+	Errors            int64   `json:"errors"`
+	ConsecutiveErrors int     `json:"consecutive_errors"`
+	AvgLatencyMs      float64 `json:"avg_latency_ms"`
+	MaxLatencyMs      float64 `json:"max_latency_ms"`
+	LastEvaluatedAt   *string `json:"last_evaluated_at"`
+	LastError         *string `json:"last_error"`
+	LastErrorAt       *string `json:"last_error_at"`
+}
+
+type createRuleFromTemplateRequest struct {
+	TemplateID string                 `json:"template_id" binding:"required"`
+	Params     map[string]interface{} `json:"params"`
+}
+
+type rollbackRuleRequest struct {
+	Version int `json:"version" binding:"required,min=1"`
+}
+
+type ruleProposalResponse struct {
+	ID             string                         `json:"id"`
+	RuleVersion    int                            `json:"rule_version"`
+	Advisor        string                         `json:"advisor"`
+	Adjustments    []services.ParameterAdjustment `json:"adjustments"`
+	Status         string                         `json:"status"`
+	AutoApplied    bool                           `json:"auto_applied"`
+	AppliedVersion *int                           `json:"applied_version"`
+	CreatedAt      string                         `json:"created_at"`
+	DecidedAt      *string                        `json:"decided_at"`
+}
+
+type ruleVersionResponse struct {
+	Version    int                    `json:"version"`
+	Definition services.RuleInput     `json:"definition"`
+	Changes    []services.FieldChange `json:"changes"`
+	CreatedBy  string                 `json:"created_by"`
+	CreatedAt  string                 `json:"created_at"`
+}
+
+func (r createRuleRequest) input() services.RuleInput {
+	return services.RuleInput{
+		Name:            r.Name,
+		Description:     r.Description,
+		Symbol:          r.Symbol,
+		RuleType:        r.RuleType,
+		Conditions:      r.Conditions,
+		Actions:         r.Actions,
+		TriggerPolicy:   r.TriggerPolicy,
+		Stop:            r.Stop,
+		TimeConstraints: r.TimeConstraints,
+		Schedule:        r.Schedule,
+		AIManagement:    r.AIManagement,
+		Expression:      r.Expression,
+	}
+}
+
+// ruleValidationError returns the structured 400 body for an invalid rule
+// definition, or false if err was caused by something else
+func ruleValidationError(err error) (gin.H, bool) {
+	var validationErr *services.ValidationError
+	if !errors.As(err, &validationErr) {
+		return nil, false
+	}
+	return gin.H{"error": validationErr.Err.Error(), "details": validationErr.Fields}, true
+}
+
+// newRuleResponse decodes a stored rule into its API representation
+func newRuleResponse(rule *models.TradingRule) (ruleResponse, error) {
+	var conditions []services.RuleCondition
+	var actions []services.RuleAction
+
+	if err := json.Unmarshal(rule.Conditions, &conditions); err != nil {
+		return ruleResponse{}, errors.New("failed to parse rule conditions")
+	}
+
+	if err := json.Unmarshal(rule.Actions, &actions); err != nil {
+		return ruleResponse{}, errors.New("failed to parse rule actions")
+	}
+
+	var stop *services.StopSpec
+	if len(rule.Stop) > 0 {
+		if err := json.Unmarshal(rule.Stop, &stop); err != nil {
+			return ruleResponse{}, errors.New("failed to parse rule stop")
+		}
+	}
+
+	var timeConstraints *services.TimeConstraints
+	if len(rule.TimeConstraints) > 0 {
+		if err := json.Unmarshal(rule.TimeConstraints, &timeConstraints); err != nil {
+			return ruleResponse{}, errors.New("failed to parse rule time constraints")
+		}
+	}
+
+	var schedule *services.ScheduleSpec
+	if len(rule.Schedule) > 0 {
+		if err := json.Unmarshal(rule.Schedule, &schedule); err != nil {
+			return ruleResponse{}, errors.New("failed to parse rule schedule")
+		}
+	}
+
+	var aiManagement *services.AIManagement
+	if len(rule.AIManagement) > 0 {
+		if err := json.Unmarshal(rule.AIManagement, &aiManagement); err != nil {
+			return ruleResponse{}, errors.New("failed to parse rule AI management")
+		}
+	}
+
+	// Rules whose conditions the expression language cannot print are
+	// returned without an expression
+	expression, err := services.FormatRuleExpression(conditions, actions)
+	if err != nil {
+		expression = ""
+	}
+
+	return ruleResponse{
+		ID:              rule.ID.String(),
+		Name:            rule.Name,
+		Description:     rule.Description,
+		Symbol:          rule.Symbol,
+		RuleType:        rule.RuleType,
+		Conditions:      conditions,
+		Actions:         actions,
+		Status:          rule.Status,
+		IsAIManaged:     rule.IsAIManaged,
+		CreatedAt:       rule.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:       rule.UpdatedAt.Format(time.RFC3339),
+		TriggerPolicy:   services.TriggerPolicyOf(rule),
+		LastTriggeredAt: formatOptionalTime(rule.LastTriggeredAt),
+		TriggerCount:    rule.TriggerCount,
+		LastExecutedAt:  formatOptionalTime(rule.LastExecutedAt),
+		Stop:            stop,
+		ReferencePrice:  rule.ReferencePrice,
+		TimeConstraints: timeConstraints,
+		Schedule:        schedule,
+		NextRunAt:       formatOptionalTime(rule.NextRunAt),
+		Version:         rule.Version,
+		AIManagement:    aiManagement,
+		Expression:      expression,
+	}, nil
+}
+
+func newRuleMetricsResponse(metrics *models.RuleMetrics) *ruleMetricsResponse {
+	response := &ruleMetricsResponse{
+		Evaluations:       metrics.Evaluations,
+		Triggers:          metrics.Triggers,
+		Errors:            metrics.Errors,
+		ConsecutiveErrors: metrics.ConsecutiveErrors,
+		MaxLatencyMs:      float64(metrics.MaxLatencyMicros) / 1000,
+		LastEvaluatedAt:   formatOptionalTime(metrics.LastEvaluatedAt),
+		LastError:         metrics.LastError,
+		LastErrorAt:       formatOptionalTime(metrics.LastErrorAt),
+	}
+	if metrics.Evaluations > 0 {
+		response.AvgLatencyMs = float64(metrics.TotalLatencyMicros) / float64(metrics.Evaluations) / 1000
+	}
+	return response
+}
+
+// newRuleVersionResponse decodes a stored rule version into its API representation
+func newRuleVersionResponse(version *models.RuleVersion) (ruleVersionResponse, error) {
+	definition, changes, err := services.DecodeRuleVersion(version)
+	if err != nil {
+		return ruleVersionResponse{}, errors.New("failed to parse rule version")
+	}
+
+	return ruleVersionResponse{
+		Version:    version.Version,
+		Definition: definition,
+		Changes:    changes,
+		CreatedBy:  version.CreatedBy.String(),
+		CreatedAt:  version.CreatedAt.Format(time.RFC3339),
+	}, nil
+}
+
+// newRuleProposalResponse decodes a stored rule proposal into its API representation
+func newRuleProposalResponse(proposal *models.RuleProposal) (ruleProposalResponse, error) {
+	adjustments, err := services.DecodeProposalAdjustments(proposal)
+	if err != nil {
+		return ruleProposalResponse{}, errors.New("failed to parse rule proposal")
+	}
+
+	return ruleProposalResponse{
+		ID:             proposal.ID.String(),
+		RuleVersion:    proposal.RuleVersion,
+		Advisor:        proposal.Advisor,
+		Adjustments:    adjustments,
+		Status:         proposal.Status,
+		AutoApplied:    proposal.AutoApplied,
+		AppliedVersion: proposal.AppliedVersion,
+		CreatedAt:      proposal.CreatedAt.Format(time.RFC3339),
+		DecidedAt:      formatOptionalTime(proposal.DecidedAt),
+	}, nil
+}
+
+func formatOptionalTime(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+	formatted := t.Format(time.RFC3339)
+	return &formatted
 }
 
 func (h *RuleHandler) CreateRule(c *gin.Context) {
@@ -58,50 +310,57 @@ func (h *RuleHandler) CreateRule(c *gin.Context) {
 		return
 	}
 
-	rule, err := h.ruleService.CreateRule(
-		c.Request.Context(),
-		userID.(uuid.UUID),
-		req.Name,
-		req.Description,
-		req.Symbol,
-		req.RuleType,
-		req.Conditions,
-		req.Actions,
-	)
+	rule, err := h.ruleService.CreateRule(c.Request.Context(), userID.(uuid.UUID), req.input())
 	if err != nil {
+		if body, ok := ruleValidationError(err); ok {
+			c.JSON(http.StatusBadRequest, body)
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Parse the conditions and actions from JSON
-	var conditions []services.RuleCondition
-	var actions []services.RuleAction
+	response, err := newRuleResponse(rule)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
 
-	if err := json.Unmarshal(rule.Conditions, &conditions); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to parse rule conditions"})
+	c.JSON(http.StatusCreated, gin.H{"rule": response})
+}
+
+// GetRuleTemplates lists the built-in rule templates and their parameters
+func (h *RuleHandler) GetRuleTemplates(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"templates": h.ruleService.GetRuleTemplates()})
+}
+
+// CreateRuleFromTemplate creates a rule from a built-in template
+func (h *RuleHandler) CreateRuleFromTemplate(c *gin.Context) {
+	var req createRuleFromTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	if err := json.Unmarshal(rule.Actions, &actions); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to parse rule actions"})
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	rule, err := h.ruleService.CreateRuleFromTemplate(c.Request.Context(), userID.(uuid.UUID), req.TemplateID, req.Params)
+	if err != nil {
+		respondRuleError(c, err)
+		return
+	}
+
+	response, err := newRuleResponse(rule)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{
-		"rule": ruleResponse{
-			ID:          rule.ID.String(),
-			Name:        rule.Name,
-			Description: rule.Description,
-			Symbol:      rule.Symbol,
-			RuleType:    rule.RuleType,
-			Conditions:  conditions,
-			Actions:     actions,
-			Status:      rule.Status,
-			IsAIManaged: rule.IsAIManaged,
-			CreatedAt:   rule.CreatedAt.Format(time.RFC3339),
-			UpdatedAt:   rule.UpdatedAt.Format(time.RFC3339),
-		},
-	})
+	c.JSON(http.StatusCreated, gin.H{"rule": response})
 }
 
 func (h *RuleHandler) GetRules(c *gin.Context) {
@@ -117,33 +376,25 @@ func (h *RuleHandler) GetRules(c *gin.Context) {
 		return
 	}
 
-	response := make([]ruleResponse, len(rules))
-	for i, rule := range rules {
-		var conditions []services.RuleCondition
-		var actions []services.RuleAction
-
-		if err := json.Unmarshal(rule.Conditions, &conditions); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to parse rule conditions"})
-			return
-		}
+	ruleIDs := make([]uuid.UUID, len(rules))
+	for i := range rules {
+		ruleIDs[i] = rules[i].ID
+	}
+	metrics, err := h.ruleService.GetRuleMetrics(c.Request.Context(), userID.(uuid.UUID), ruleIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
 
-		if err := json.Unmarshal(rule.Actions, &actions); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to parse rule actions"})
+	response := make([]ruleResponse, len(rules))
+	for i := range rules {
+		response[i], err = newRuleResponse(&rules[i])
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
-
-		response[i] = ruleResponse{
-			ID:          rule.ID.String(),
-			Name:        rule.Name,
-			Description: rule.Description,
-			Symbol:      rule.Symbol,
-			RuleType:    rule.RuleType,
-			Conditions:  conditions,
-			Actions:     actions,
-			Status:      rule.Status,
-			IsAIManaged: rule.IsAIManaged,
-			CreatedAt:   rule.CreatedAt.Format(time.RFC3339),
-			UpdatedAt:   rule.UpdatedAt.Format(time.RFC3339),
+		if ruleMetrics, ok := metrics[rules[i].ID]; ok {
+			response[i].Metrics = newRuleMetricsResponse(ruleMetrics)
 		}
 	}
 
@@ -151,10 +402,93 @@ func (h *RuleHandler) GetRules(c *gin.Context) {
 }
 
 func (h *RuleHandler) GetRule(c *gin.Context) {
-	idParam := c.Param("id")
-	id, err := uuid.Parse(idParam)
+	rule, ok := h.userRule(c)
+	if !ok {
+		return
+	}
+
+	response, err := newRuleResponse(rule)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid rule ID"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	metrics, err := h.ruleService.GetRuleMetrics(c.Request.Context(), rule.UserID, []uuid.UUID{rule.ID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if ruleMetrics, ok := metrics[rule.ID]; ok {
+		response.Metrics = newRuleMetricsResponse(ruleMetrics)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"rule": response})
+}
+
+func (h *RuleHandler) ActivateRule(c *gin.Context) {
+	id, userID, ok := ruleRequestIDs(c)
+	if !ok {
+		return
+	}
+
+	if err := h.ruleService.ActivateRule(c.Request.Context(), userID, id); err != nil {
+		respondRuleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "rule activated successfully"})
+}
+
+func (h *RuleHandler) DeactivateRule(c *gin.Context) {
+	id, userID, ok := ruleRequestIDs(c)
+	if !ok {
+		return
+	}
+
+	if err := h.ruleService.DeactivateRule(c.Request.Context(), userID, id); err != nil {
+		respondRuleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "rule deactivated successfully"})
+}
+
+func (h *RuleHandler) DeleteRule(c *gin.Context) {
+	id, userID, ok := ruleRequestIDs(c)
+	if !ok {
+		return
+	}
+
+	if err := h.ruleService.DeleteRule(c.Request.Context(), userID, id); err != nil {
+		respondRuleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "rule deleted successfully"})
+}
+
+// EvaluateRule dry-runs a saved rule and returns a trace of the evaluation.
+// Market data in the request body replaces the latest data for its symbols.
+func (h *RuleHandler) EvaluateRule(c *gin.Context) {
+	var req evaluateRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rule, ok := h.userRule(c)
+	if !ok {
+		return
+	}
+
+	h.explainRule(c, rule, req.MarketData)
+}
+
+// EvaluateUnsavedRule dry-runs a rule definition without saving it
+func (h *RuleHandler) EvaluateUnsavedRule(c *gin.Context) {
+	var req evaluateUnsavedRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
@@ -164,92 +498,255 @@ func (h *RuleHandler) GetRule(c *gin.Context) {
 		return
 	}
 
-	rule, err := h.ruleService.GetRuleByID(c.Request.Context(), id)
+	rule, err := h.ruleService.BuildRule(userID.(uuid.UUID), req.input())
 	if err != nil {
+		if body, ok := ruleValidationError(err); ok {
+			c.JSON(http.StatusBadRequest, body)
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Check if rule belongs to user
-	if rule.UserID != userID.(uuid.UUID) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "you do not have access to this rule"})
+	h.explainRule(c, rule, req.MarketData)
+}
+
+func (h *RuleHandler) explainRule(c *gin.Context, rule *models.TradingRule, overrides map[string]services.MarketOverride) {
+	explanation, err := h.ruleEngine.ExplainRule(c.Request.Context(), rule, overrides)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrNoRuleConditions):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, repository.ErrMarketDataNotFound):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "no market data available for " + rule.Symbol})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
 		return
 	}
 
-	var conditions []services.RuleCondition
-	var actions []services.RuleAction
+	c.JSON(http.StatusOK, gin.H{"evaluation": explanation})
+}
 
-	if err := json.Unmarshal(rule.Conditions, &conditions); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to parse rule conditions"})
+// PatchRule updates part of a rule's definition, recording the result as a
+// new version of the rule
+func (h *RuleHandler) PatchRule(c *gin.Context) {
+	var patch services.RulePatch
+	if err := c.ShouldBindJSON(&patch); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	if err := json.Unmarshal(rule.Actions, &actions); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to parse rule actions"})
+	id, userID, ok := ruleRequestIDs(c)
+	if !ok {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"rule": ruleResponse{
-			ID:          rule.ID.String(),
-			Name:        rule.Name,
-			Description: rule.Description,
-			Symbol:      rule.Symbol,
-			RuleType:    rule.RuleType,
-			Conditions:  conditions,
-			Actions:     actions,
-			Status:      rule.Status,
-			IsAIManaged: rule.IsAIManaged,
-			CreatedAt:   rule.CreatedAt.Format(time.RFC3339),
-			UpdatedAt:   rule.UpdatedAt.Format(time.RFC3339),
-		},
-	})
+	rule, err := h.ruleService.PatchRule(c.Request.Context(), userID, id, patch)
+	h.respondRuleUpdate(c, rule, err)
 }
 
-func (h *RuleHandler) ActivateRule(c *gin.Context) {
-	idParam := c.Param("id")
-	id, err := uuid.Parse(idParam)
+// GetRuleVersions lists every version of a rule, newest first
+func (h *RuleHandler) GetRuleVersions(c *gin.Context) {
+	id, userID, ok := ruleRequestIDs(c)
+	if !ok {
+		return
+	}
+
+	versions, err := h.ruleService.GetRuleVersions(c.Request.Context(), userID, id)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid rule ID"})
+		respondRuleError(c, err)
+		return
+	}
+
+	response := make([]ruleVersionResponse, len(versions))
+	for i := range versions {
+		response[i], err = newRuleVersionResponse(&versions[i])
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"versions": response})
+}
+
+func (h *RuleHandler) GetRuleVersion(c *gin.Context) {
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil || version < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid rule version"})
+		return
+	}
+
+	id, userID, ok := ruleRequestIDs(c)
+	if !ok {
 		return
 	}
 
-	if err := h.ruleService.ActivateRule(c.Request.Context(), id); err != nil {
+	ruleVersion, err := h.ruleService.GetRuleVersion(c.Request.Context(), userID, id, version)
+	if err != nil {
+		respondRuleError(c, err)
+		return
+	}
+
+	response, err := newRuleVersionResponse(ruleVersion)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "rule activated successfully"})
+	c.JSON(http.StatusOK, gin.H{"version": response})
 }
 
-func (h *RuleHandler) DeactivateRule(c *gin.Context) {
-	idParam := c.Param("id")
-	id, err := uuid.Parse(idParam)
+// RollbackRule restores the definition of an earlier version. The rollback is
+// itself recorded as a new version, so history is never rewritten.
+func (h *RuleHandler) RollbackRule(c *gin.Context) {
+	var req rollbackRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	id, userID, ok := ruleRequestIDs(c)
+	if !ok {
+		return
+	}
+
+	rule, err := h.ruleService.RollbackRule(c.Request.Context(), userID, id, req.Version)
+	h.respondRuleUpdate(c, rule, err)
+}
+
+// GetRuleProposals lists the adjustments strategy advisors have proposed for
+// an AI-managed rule, newest first
+func (h *RuleHandler) GetRuleProposals(c *gin.Context) {
+	id, userID, ok := ruleRequestIDs(c)
+	if !ok {
+		return
+	}
+
+	proposals, err := h.proposalService.GetProposals(c.Request.Context(), userID, id)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid rule ID"})
+		respondRuleError(c, err)
 		return
 	}
 
-	if err := h.ruleService.DeactivateRule(c.Request.Context(), id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	response := make([]ruleProposalResponse, len(proposals))
+	for i := range proposals {
+		response[i], err = newRuleProposalResponse(&proposals[i])
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"proposals": response})
+}
+
+// ApproveRuleProposal applies a pending proposal as a new version of the rule
+func (h *RuleHandler) ApproveRuleProposal(c *gin.Context) {
+	id, userID, proposalID, ok := ruleProposalRequestIDs(c)
+	if !ok {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "rule deactivated successfully"})
+	rule, err := h.proposalService.ApproveProposal(c.Request.Context(), userID, id, proposalID)
+	h.respondRuleUpdate(c, rule, err)
 }
 
-func (h *RuleHandler) DeleteRule(c *gin.Context) {
-	idParam := c.Param("id")
-	id, err := uuid.Parse(idParam)
+func (h *RuleHandler) RejectRuleProposal(c *gin.Context) {
+	id, userID, proposalID, ok := ruleProposalRequestIDs(c)
+	if !ok {
+		return
+	}
+
+	if err := h.proposalService.RejectProposal(c.Request.Context(), userID, id, proposalID); err != nil {
+		respondRuleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "rule proposal rejected"})
+}
+
+// ruleProposalRequestIDs returns the rule and proposal IDs in the path and
+// the authenticated user, writing the error response if any is missing
+func ruleProposalRequestIDs(c *gin.Context) (uuid.UUID, uuid.UUID, uuid.UUID, bool) {
+	proposalID, err := uuid.Parse(c.Param("proposal_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid rule proposal ID"})
+		return uuid.Nil, uuid.Nil, uuid.Nil, false
+	}
+
+	id, userID, ok := ruleRequestIDs(c)
+	return id, userID, proposalID, ok
+}
+
+// ruleRequestIDs returns the rule ID in the path and the authenticated user,
+// writing the error response if either is missing
+func ruleRequestIDs(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid rule ID"})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return id, userID.(uuid.UUID), true
+}
+
+// userRule loads the rule named in the path if it belongs to the
+// authenticated user, writing the error response if it cannot
+func (h *RuleHandler) userRule(c *gin.Context) (*models.TradingRule, bool) {
+	id, userID, ok := ruleRequestIDs(c)
+	if !ok {
+		return nil, false
+	}
+
+	rule, err := h.ruleService.GetRuleByID(c.Request.Context(), userID, id)
+	if err != nil {
+		respondRuleError(c, err)
+		return nil, false
+	}
+
+	return rule, true
+}
+
+// respondRuleError writes the response for an error from the rule service.
+// Rules owned by other users are reported as not found, so their IDs cannot
+// be probed.
+func respondRuleError(c *gin.Context, err error) {
+	if body, ok := ruleValidationError(err); ok {
+		c.JSON(http.StatusBadRequest, body)
+		return
+	}
+
+	switch {
+	case errors.Is(err, repository.ErrRuleNotFound), errors.Is(err, repository.ErrRuleVersionNotFound),
+		errors.Is(err, services.ErrRuleTemplateNotFound), errors.Is(err, repository.ErrRuleProposalNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, repository.ErrRuleVersionConflict), errors.Is(err, repository.ErrRuleProposalDecided),
+		errors.Is(err, services.ErrRuleProposalStale):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+func (h *RuleHandler) respondRuleUpdate(c *gin.Context, rule *models.TradingRule, err error) {
+	if err != nil {
+		respondRuleError(c, err)
 		return
 	}
 
-	if err := h.ruleService.DeleteRule(c.Request.Context(), id); err != nil {
+	response, err := newRuleResponse(rule)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "rule deleted successfully"})
+	c.JSON(http.StatusOK, gin.H{"rule": response})
 }
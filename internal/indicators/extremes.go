@@ -0,0 +1,69 @@
+// internal/indicators/extremes.go
+package indicators
+
+import "github.com/aquibsayyed9/sentinel/internal/models"
+
+// rollingExtreme tracks the maximum (or minimum) of a sliding window using a
+// monotonic queue, so each update is amortised O(1)
+type rollingExtreme struct {
+	period  int
+	source  Source
+	greater func(a, b float64) bool
+	indexes []int
+	values  []float64
+	count   int
+}
+
+func (r *rollingExtreme) Update(bar models.MarketData) {
+	value := r.source.value(bar)
+	index := r.count
+	r.count++
+
+	for len(r.values) > 0 && !r.greater(r.values[len(r.values)-1], value) {
+		r.values = r.values[:len(r.values)-1]
+		r.indexes = r.indexes[:len(r.indexes)-1]
+	}
+	r.values = append(r.values, value)
+	r.indexes = append(r.indexes, index)
+
+	for r.indexes[0] <= index-r.period {
+		r.values = r.values[1:]
+		r.indexes = r.indexes[1:]
+	}
+}
+
+func (r *rollingExtreme) Value() float64 {
+	if len(r.values) == 0 {
+		return 0
+	}
+	return r.values[0]
+}
+
+func (r *rollingExtreme) Ready() bool       { return r.count >= r.period }
+func (r *rollingExtreme) WarmupPeriod() int { return r.period }
+
+// Highest is the rolling maximum over the last period bars
+type Highest struct {
+	rollingExtreme
+}
+
+func NewHighest(period int, source Source) *Highest {
+	return &Highest{rollingExtreme{
+		period:  period,
+		source:  source,
+		greater: func(a, b float64) bool { return a > b },
+	}}
+}
+
+// Lowest is the rolling minimum over the last period bars
+type Lowest struct {
+	rollingExtreme
+}
+
+func NewLowest(period int, source Source) *Lowest {
+	return &Lowest{rollingExtreme{
+		period:  period,
+		source:  source,
+		greater: func(a, b float64) bool { return a < b },
+	}}
+}
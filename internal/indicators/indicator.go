@@ -0,0 +1,139 @@
+// internal/indicators/indicator.go
+package indicators
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/aquibsayyed9/sentinel/internal/models"
+)
+
+var (
+	ErrUnknownIndicator = errors.New("unknown indicator")
+	ErrInvalidParams    = errors.New("invalid indicator params")
+)
+
+// Indicator kinds supported by New
+const (
+	KindPrice     = "price"
+	KindSMA       = "sma"
+	KindEMA       = "ema"
+	KindRSI       = "rsi"
+	KindMACD      = "macd"
+	KindBollinger = "bollinger"
+	KindATR       = "atr"
+	KindVWAP      = "vwap"
+	KindHighest   = "highest"
+	KindLowest    = "lowest"
+)
+
+// Indicator is a streaming technical indicator. Bars are fed one at a time in
+// chronological order, so values never have to be recomputed from scratch.
+type Indicator interface {
+	// Update feeds the next bar into the indicator
+	Update(bar models.MarketData)
+
+	// Value returns the indicator value as of the last bar
+	Value() float64
+
+	// Ready reports whether enough bars have been seen for Value to be meaningful
+	Ready() bool
+
+	// WarmupPeriod returns the number of bars needed before the indicator is ready
+	WarmupPeriod() int
+}
+
+// Params configures an indicator. Fields that do not apply to an indicator are ignored.
+type Params struct {
+	Period       int     `json:"period,omitempty"`
+	FastPeriod   int     `json:"fast_period,omitempty"`
+	SlowPeriod   int     `json:"slow_period,omitempty"`
+	SignalPeriod int     `json:"signal_period,omitempty"`
+	StdDev       float64 `json:"std_dev,omitempty"`
+	Source       Source  `json:"source,omitempty"`
+	Output       string  `json:"output,omitempty"`
+}
+
+// Kinds lists every indicator kind supported by New
+func Kinds() []string {
+	return []string{KindPrice, KindSMA, KindEMA, KindRSI, KindMACD, KindBollinger, KindATR, KindVWAP, KindHighest, KindLowest}
+}
+
+// IsKind reports whether name is a supported indicator kind
+func IsKind(name string) bool {
+	for _, kind := range Kinds() {
+		if kind == name {
+			return true
+		}
+	}
+	return false
+}
+
+// New creates an indicator of the given kind, applying the conventional
+// defaults for any parameters that are not set
+func New(kind string, params Params) (Indicator, error) {
+	if params.Source != "" && !params.Source.valid() {
+		return nil, fmt.Errorf("%w: unknown source %q", ErrInvalidParams, params.Source)
+	}
+
+	switch kind {
+	case KindPrice:
+		return NewPrice(params.Source), nil
+	case KindSMA:
+		if params.Period <= 0 {
+			return nil, fmt.Errorf("%w: %s requires a positive period", ErrInvalidParams, kind)
+		}
+		return NewSMA(params.Period, params.Source), nil
+	case KindEMA:
+		if params.Period <= 0 {
+			return nil, fmt.Errorf("%w: %s requires a positive period", ErrInvalidParams, kind)
+		}
+		return NewEMA(params.Period, params.Source), nil
+	case KindRSI:
+		return NewRSI(withDefault(params.Period, 14), params.Source), nil
+	case KindMACD:
+		fast := withDefault(params.FastPeriod, 12)
+		slow := withDefault(params.SlowPeriod, 26)
+		if fast >= slow {
+			return nil, fmt.Errorf("%w: macd fast_period must be less than slow_period", ErrInvalidParams)
+		}
+		return NewMACD(fast, slow, withDefault(params.SignalPeriod, 9), params.Source, params.Output)
+	case KindBollinger:
+		stdDev := params.StdDev
+		if stdDev <= 0 {
+			stdDev = 2
+		}
+		return NewBollinger(withDefault(params.Period, 20), stdDev, params.Source, params.Output)
+	case KindATR:
+		return NewATR(withDefault(params.Period, 14)), nil
+	case KindVWAP:
+		return NewVWAP(), nil
+	case KindHighest:
+		if params.Period <= 0 {
+			return nil, fmt.Errorf("%w: %s requires a positive period", ErrInvalidParams, kind)
+		}
+		source := params.Source
+		if source == "" {
+			source = SourceHigh
+		}
+		return NewHighest(params.Period, source), nil
+	case KindLowest:
+		if params.Period <= 0 {
+			return nil, fmt.Errorf("%w: %s requires a positive period", ErrInvalidParams, kind)
+		}
+		source := params.Source
+		if source == "" {
+			source = SourceLow
+		}
+		return NewLowest(params.Period, source), nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownIndicator, kind)
+	}
+}
+
+func withDefault(value, fallback int) int {
+	if value <= 0 {
+		return fallback
+	}
+	return value
+}
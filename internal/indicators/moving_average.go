@@ -0,0 +1,91 @@
+// internal/indicators/moving_average.go
+package indicators
+
+import "github.com/aquibsayyed9/sentinel/internal/models"
+
+// Price passes the selected bar value through unchanged, which lets raw prices
+// take part in crossovers alongside other indicators
+type Price struct {
+	source Source
+	value  float64
+	seen   bool
+}
+
+func NewPrice(source Source) *Price {
+	return &Price{source: source}
+}
+
+func (p *Price) Update(bar models.MarketData) {
+	p.value = p.source.value(bar)
+	p.seen = true
+}
+
+func (p *Price) Value() float64    { return p.value }
+func (p *Price) Ready() bool       { return p.seen }
+func (p *Price) WarmupPeriod() int { return 1 }
+
+// SMA is a simple moving average
+type SMA struct {
+	period int
+	source Source
+	window *ringBuffer
+	sum    float64
+}
+
+func NewSMA(period int, source Source) *SMA {
+	return &SMA{period: period, source: source, window: newRingBuffer(period)}
+}
+
+func (s *SMA) Update(bar models.MarketData) {
+	s.add(s.source.value(bar))
+}
+
+func (s *SMA) add(value float64) {
+	if evicted, full := s.window.push(value); full {
+		s.sum -= evicted
+	}
+	s.sum += value
+}
+
+func (s *SMA) Value() float64 {
+	if s.window.count == 0 {
+		return 0
+	}
+	return s.sum / float64(s.window.count)
+}
+
+func (s *SMA) Ready() bool       { return s.window.full() }
+func (s *SMA) WarmupPeriod() int { return s.period }
+
+// EMA is an exponential moving average seeded with the simple average of its
+// first period values
+type EMA struct {
+	period int
+	source Source
+	alpha  float64
+	value  float64
+	count  int
+	seed   float64
+}
+
+func NewEMA(period int, source Source) *EMA {
+	return &EMA{period: period, source: source, alpha: 2 / float64(period+1)}
+}
+
+func (e *EMA) Update(bar models.MarketData) {
+	e.add(e.source.value(bar))
+}
+
+func (e *EMA) add(value float64) {
+	e.count++
+	if e.count <= e.period {
+		e.seed += value
+		e.value = e.seed / float64(e.count)
+		return
+	}
+	e.value += e.alpha * (value - e.value)
+}
+
+func (e *EMA) Value() float64    { return e.value }
+func (e *EMA) Ready() bool       { return e.count >= e.period }
+func (e *EMA) WarmupPeriod() int { return e.period }
@@ -0,0 +1,138 @@
+// internal/indicators/oscillators.go
+package indicators
+
+import (
+	"fmt"
+
+	"github.com/aquibsayyed9/sentinel/internal/models"
+)
+
+// MACD outputs
+const (
+	OutputMACD      = "macd"
+	OutputSignal    = "signal"
+	OutputHistogram = "histogram"
+)
+
+// RSI is the relative strength index using Wilder's smoothing
+type RSI struct {
+	period    int
+	source    Source
+	prev      float64
+	changes   int
+	avgGain   float64
+	avgLoss   float64
+	seenFirst bool
+}
+
+func NewRSI(period int, source Source) *RSI {
+	return &RSI{period: period, source: source}
+}
+
+func (r *RSI) Update(bar models.MarketData) {
+	value := r.source.value(bar)
+	if !r.seenFirst {
+		r.prev = value
+		r.seenFirst = true
+		return
+	}
+
+	change := value - r.prev
+	r.prev = value
+
+	gain, loss := 0.0, 0.0
+	if change > 0 {
+		gain = change
+	} else {
+		loss = -change
+	}
+
+	r.changes++
+	if r.changes <= r.period {
+		// Seed with the simple average of the first period changes
+		r.avgGain += (gain - r.avgGain) / float64(r.changes)
+		r.avgLoss += (loss - r.avgLoss) / float64(r.changes)
+		return
+	}
+
+	n := float64(r.period)
+	r.avgGain = (r.avgGain*(n-1) + gain) / n
+	r.avgLoss = (r.avgLoss*(n-1) + loss) / n
+}
+
+func (r *RSI) Value() float64 {
+	if r.avgLoss == 0 {
+		if r.avgGain == 0 {
+			return 50
+		}
+		return 100
+	}
+	rs := r.avgGain / r.avgLoss
+	return 100 - 100/(1+rs)
+}
+
+func (r *RSI) Ready() bool       { return r.changes >= r.period }
+func (r *RSI) WarmupPeriod() int { return r.period + 1 }
+
+// MACD is the moving average convergence/divergence indicator. Output selects
+// which of the MACD line, signal line or histogram Value reports.
+type MACD struct {
+	fast   *EMA
+	slow   *EMA
+	signal *EMA
+	source Source
+	output string
+}
+
+func NewMACD(fastPeriod, slowPeriod, signalPeriod int, source Source, output string) (*MACD, error) {
+	switch output {
+	case "":
+		output = OutputMACD
+	case OutputMACD, OutputSignal, OutputHistogram:
+	default:
+		return nil, fmt.Errorf("%w: unknown macd output %q", ErrInvalidParams, output)
+	}
+
+	return &MACD{
+		fast:   NewEMA(fastPeriod, source),
+		slow:   NewEMA(slowPeriod, source),
+		signal: NewEMA(signalPeriod, source),
+		source: source,
+		output: output,
+	}, nil
+}
+
+func (m *MACD) Update(bar models.MarketData) {
+	value := m.source.value(bar)
+	m.fast.add(value)
+	m.slow.add(value)
+	if m.slow.Ready() {
+		m.signal.add(m.line())
+	}
+}
+
+func (m *MACD) line() float64 {
+	return m.fast.Value() - m.slow.Value()
+}
+
+func (m *MACD) Value() float64 {
+	switch m.output {
+	case OutputSignal:
+		return m.signal.Value()
+	case OutputHistogram:
+		return m.line() - m.signal.Value()
+	default:
+		return m.line()
+	}
+}
+
+func (m *MACD) Ready() bool {
+	if m.output == OutputMACD {
+		return m.slow.Ready()
+	}
+	return m.slow.Ready() && m.signal.Ready()
+}
+
+func (m *MACD) WarmupPeriod() int {
+	return m.slow.period + m.signal.period - 1
+}
@@ -0,0 +1,71 @@
+// internal/indicators/source.go
+package indicators
+
+import "github.com/aquibsayyed9/sentinel/internal/models"
+
+// Source selects which value of a bar an indicator is computed from
+type Source string
+
+const (
+	SourceClose  Source = "close"
+	SourceOpen   Source = "open"
+	SourceHigh   Source = "high"
+	SourceLow    Source = "low"
+	SourceHL2    Source = "hl2"
+	SourceHLC3   Source = "hlc3"
+	SourceVolume Source = "volume"
+)
+
+func (s Source) valid() bool {
+	switch s {
+	case SourceClose, SourceOpen, SourceHigh, SourceLow, SourceHL2, SourceHLC3, SourceVolume:
+		return true
+	}
+	return false
+}
+
+// value extracts the source value from a bar, defaulting to the close
+func (s Source) value(bar models.MarketData) float64 {
+	switch s {
+	case SourceOpen:
+		return bar.Open
+	case SourceHigh:
+		return bar.High
+	case SourceLow:
+		return bar.Low
+	case SourceHL2:
+		return (bar.High + bar.Low) / 2
+	case SourceHLC3:
+		return (bar.High + bar.Low + bar.Close) / 3
+	case SourceVolume:
+		return float64(bar.Volume)
+	default:
+		return bar.Close
+	}
+}
+
+// ringBuffer holds the most recent values of a fixed-size window
+type ringBuffer struct {
+	values []float64
+	next   int
+	count  int
+}
+
+func newRingBuffer(size int) *ringBuffer {
+	return &ringBuffer{values: make([]float64, size)}
+}
+
+// push adds a value and returns the value it evicted, if the buffer was full
+func (r *ringBuffer) push(value float64) (float64, bool) {
+	evicted, full := r.values[r.next], r.count == len(r.values)
+	r.values[r.next] = value
+	r.next = (r.next + 1) % len(r.values)
+	if !full {
+		r.count++
+	}
+	return evicted, full
+}
+
+func (r *ringBuffer) full() bool {
+	return r.count == len(r.values)
+}
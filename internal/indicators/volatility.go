@@ -0,0 +1,133 @@
+// internal/indicators/volatility.go
+package indicators
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/aquibsayyed9/sentinel/internal/models"
+)
+
+// Bollinger Band outputs
+const (
+	OutputMiddle    = "middle"
+	OutputUpper     = "upper"
+	OutputLower     = "lower"
+	OutputBandwidth = "bandwidth"
+	OutputPercentB  = "percent_b"
+)
+
+// Bollinger computes Bollinger Bands: a simple moving average with bands a
+// number of standard deviations above and below it
+type Bollinger struct {
+	period     int
+	multiplier float64
+	source     Source
+	output     string
+	window     *ringBuffer
+	sum        float64
+	sumSquares float64
+	last       float64
+}
+
+func NewBollinger(period int, multiplier float64, source Source, output string) (*Bollinger, error) {
+	switch output {
+	case "":
+		output = OutputMiddle
+	case OutputMiddle, OutputUpper, OutputLower, OutputBandwidth, OutputPercentB:
+	default:
+		return nil, fmt.Errorf("%w: unknown bollinger output %q", ErrInvalidParams, output)
+	}
+
+	return &Bollinger{
+		period:     period,
+		multiplier: multiplier,
+		source:     source,
+		output:     output,
+		window:     newRingBuffer(period),
+	}, nil
+}
+
+func (b *Bollinger) Update(bar models.MarketData) {
+	value := b.source.value(bar)
+	if evicted, full := b.window.push(value); full {
+		b.sum -= evicted
+		b.sumSquares -= evicted * evicted
+	}
+	b.sum += value
+	b.sumSquares += value * value
+	b.last = value
+}
+
+// Bands returns the lower, middle and upper bands
+func (b *Bollinger) Bands() (lower, middle, upper float64) {
+	if b.window.count == 0 {
+		return 0, 0, 0
+	}
+	n := float64(b.window.count)
+	middle = b.sum / n
+	variance := b.sumSquares/n - middle*middle
+	if variance < 0 {
+		// Guard against floating point drift on flat series
+		variance = 0
+	}
+	width := b.multiplier * math.Sqrt(variance)
+	return middle - width, middle, middle + width
+}
+
+func (b *Bollinger) Value() float64 {
+	lower, middle, upper := b.Bands()
+	switch b.output {
+	case OutputUpper:
+		return upper
+	case OutputLower:
+		return lower
+	case OutputBandwidth:
+		if middle == 0 {
+			return 0
+		}
+		return (upper - lower) / middle
+	case OutputPercentB:
+		if upper == lower {
+			return 0.5
+		}
+		return (b.last - lower) / (upper - lower)
+	default:
+		return middle
+	}
+}
+
+func (b *Bollinger) Ready() bool       { return b.window.full() }
+func (b *Bollinger) WarmupPeriod() int { return b.period }
+
+// ATR is the average true range using Wilder's smoothing
+type ATR struct {
+	period    int
+	prevClose float64
+	count     int
+	value     float64
+}
+
+func NewATR(period int) *ATR {
+	return &ATR{period: period}
+}
+
+func (a *ATR) Update(bar models.MarketData) {
+	trueRange := bar.High - bar.Low
+	if a.count > 0 {
+		trueRange = math.Max(trueRange, math.Max(math.Abs(bar.High-a.prevClose), math.Abs(bar.Low-a.prevClose)))
+	}
+	a.prevClose = bar.Close
+	a.count++
+
+	if a.count <= a.period {
+		a.value += (trueRange - a.value) / float64(a.count)
+		return
+	}
+	n := float64(a.period)
+	a.value = (a.value*(n-1) + trueRange) / n
+}
+
+func (a *ATR) Value() float64    { return a.value }
+func (a *ATR) Ready() bool       { return a.count >= a.period }
+func (a *ATR) WarmupPeriod() int { return a.period }
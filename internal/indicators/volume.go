@@ -0,0 +1,52 @@
+// internal/indicators/volume.go
+package indicators
+
+import (
+	"time"
+
+	"github.com/aquibsayyed9/sentinel/internal/models"
+)
+
+// VWAP is the volume weighted average price of the current session. It resets
+// at the first bar of each new day.
+type VWAP struct {
+	session     time.Time
+	priceVolume float64
+	volume      float64
+	lastPrice   float64
+	seen        bool
+}
+
+func NewVWAP() *VWAP {
+	return &VWAP{}
+}
+
+func (v *VWAP) Update(bar models.MarketData) {
+	session := sessionDate(bar.Timestamp)
+	if !v.seen || !session.Equal(v.session) {
+		v.session = session
+		v.priceVolume = 0
+		v.volume = 0
+	}
+	v.seen = true
+
+	typical := SourceHLC3.value(bar)
+	v.lastPrice = typical
+	v.priceVolume += typical * float64(bar.Volume)
+	v.volume += float64(bar.Volume)
+}
+
+func (v *VWAP) Value() float64 {
+	if v.volume == 0 {
+		return v.lastPrice
+	}
+	return v.priceVolume / v.volume
+}
+
+func (v *VWAP) Ready() bool       { return v.seen }
+func (v *VWAP) WarmupPeriod() int { return 1 }
+
+func sessionDate(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,139 @@
+// internal/marketdata/aggregate.go
+package marketdata
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/aquibsayyed9/sentinel/internal/calendar"
+	"github.com/aquibsayyed9/sentinel/internal/models"
+)
+
+// Aggregator builds bars of longer time frames from shorter ones, on the
+// boundaries of an exchange's sessions in its time zone.
+//
+// Minute and hour bars are aligned to the session: a 1h bar starts at the open
+// and the last one ends at the close, however early. Outside regular hours
+// they are aligned to midnight before the open and to the close after it, so
+// no bar mixes regular and extended hours. A 1d bar covers the regular
+// session of one trading day and a 1w bar those of one week, starting on
+// Monday; both are timestamped at local midnight and leave out extended hours.
+type Aggregator struct {
+	calendar *calendar.Calendar
+}
+
+func NewAggregator(cal *calendar.Calendar) *Aggregator {
+	return &Aggregator{calendar: cal}
+}
+
+// Aggregate builds bars of the time frame from bars of one symbol in a
+// shorter time frame that divides it. Bars whose span has not ended by asOf
+// are still forming and left out.
+func (a *Aggregator) Aggregate(bars []models.MarketData, timeFrame string, asOf time.Time) ([]models.MarketData, error) {
+	frame, err := models.TimeFrameDuration(timeFrame)
+	if err != nil {
+		return nil, err
+	}
+	unit := timeFrame[len(timeFrame)-1]
+	intraday := unit == 'm' || unit == 'h'
+	if !intraday && timeFrame[:len(timeFrame)-1] != "1" {
+		return nil, fmt.Errorf("%w: %q spans more than one session or week", models.ErrInvalidTimeFrame, timeFrame)
+	}
+
+	sorted := make([]models.MarketData, len(bars))
+	copy(sorted, bars)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Timestamp.Before(sorted[j].Timestamp) })
+
+	var aggregated []models.MarketData
+	var current *models.MarketData
+	var currentEnd time.Time
+	flush := func() {
+		if current != nil && !currentEnd.After(asOf) {
+			aggregated = append(aggregated, *current)
+		}
+		current = nil
+	}
+
+	for _, bar := range sorted {
+		source, err := models.TimeFrameDuration(bar.TimeFrame)
+		// Daily and weekly bars are built from the bars within sessions
+		if err != nil || (intraday && (source >= frame || frame%source != 0)) || (!intraday && source >= 24*time.Hour) {
+			return nil, fmt.Errorf("%w: cannot build %q bars from %q bars", models.ErrInvalidTimeFrame, timeFrame, bar.TimeFrame)
+		}
+
+		start, end, ok := a.span(bar.Timestamp, unit, frame)
+		if !ok {
+			continue
+		}
+
+		if current != nil && start.Equal(current.Timestamp) {
+			current.High = max(current.High, bar.High)
+			current.Low = min(current.Low, bar.Low)
+			current.Close = bar.Close
+			current.Volume += bar.Volume
+			continue
+		}
+
+		flush()
+		current = &models.MarketData{
+			Symbol:    bar.Symbol,
+			Timestamp: start,
+			Open:      bar.Open,
+			High:      bar.High,
+			Low:       bar.Low,
+			Close:     bar.Close,
+			Volume:    bar.Volume,
+			Source:    bar.Source,
+			TimeFrame: timeFrame,
+		}
+		currentEnd = end
+	}
+	flush()
+
+	return aggregated, nil
+}
+
+// span returns the start and end of the bar of a time frame holding t, or
+// false if t is outside the hours such bars cover
+func (a *Aggregator) span(t time.Time, unit byte, frame time.Duration) (time.Time, time.Time, bool) {
+	local := t.In(a.calendar.Location)
+	midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, a.calendar.Location)
+	nextMidnight := time.Date(local.Year(), local.Month(), local.Day()+1, 0, 0, 0, 0, a.calendar.Location)
+	session, trading := a.calendar.SessionOn(local)
+
+	switch unit {
+	case 'd':
+		if !trading || !session.Contains(t) {
+			return time.Time{}, time.Time{}, false
+		}
+		return midnight.UTC(), session.Close.UTC(), true
+
+	case 'w':
+		if !trading || !session.Contains(t) {
+			return time.Time{}, time.Time{}, false
+		}
+		monday := time.Date(local.Year(), local.Month(), local.Day()-(int(local.Weekday())+6)%7, 0, 0, 0, 0, a.calendar.Location)
+		return monday.UTC(), monday.AddDate(0, 0, 7).UTC(), true
+	}
+
+	// Bars within the day are aligned to the start of the part of it holding
+	// t, and cut short at its end
+	from, to := midnight, nextMidnight
+	switch {
+	case !trading:
+	case t.Before(session.Open):
+		to = session.Open
+	case session.Contains(t):
+		from, to = session.Open, session.Close
+	default:
+		from = session.Close
+	}
+
+	start := from.Add(t.Sub(from) / frame * frame)
+	end := start.Add(frame)
+	if end.After(to) {
+		end = to
+	}
+	return start.UTC(), end.UTC(), true
+}
@@ -0,0 +1,385 @@
+// internal/marketdata/alpaca.go
+package marketdata
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/aquibsayyed9/sentinel/internal/models"
+)
+
+// Default Alpaca endpoints: the real-time stream of the free IEX feed, the
+// historical data API, and the trading API that lists tradable assets
+const (
+	DefaultAlpacaStreamURL = "wss://stream.data.alpaca.markets/v2/iex"
+	DefaultAlpacaDataURL   = "https://data.alpaca.markets"
+	DefaultAlpacaAssetsURL = "https://paper-api.alpaca.markets"
+)
+
+// alpacaHandshakeTimeout bounds connecting, authenticating and subscribing
+const alpacaHandshakeTimeout = 10 * time.Second
+
+// alpacaRequestTimeout bounds each request to Alpaca's REST APIs
+const alpacaRequestTimeout = 30 * time.Second
+
+// AlpacaConfig configures the Alpaca provider
+type AlpacaConfig struct {
+	// Endpoints; the defaults above if empty
+	StreamURL string
+	DataURL   string
+	AssetsURL string
+
+	APIKey    string
+	APISecret string
+
+	// How often the connection is pinged. A connection that has been silent
+	// for two intervals is treated as lost.
+	PingInterval time.Duration
+}
+
+// alpacaProvider streams minute bars, quotes and trades from Alpaca's market
+// data API, and fetches historical bars and the symbols Alpaca trades
+type alpacaProvider struct {
+	config AlpacaConfig
+	client *http.Client
+}
+
+// NewAlpacaProvider creates an Alpaca provider, applying defaults to unset
+// config values
+func NewAlpacaProvider(config AlpacaConfig) Provider {
+	if config.StreamURL == "" {
+		config.StreamURL = DefaultAlpacaStreamURL
+	}
+	if config.DataURL == "" {
+		config.DataURL = DefaultAlpacaDataURL
+	}
+	if config.AssetsURL == "" {
+		config.AssetsURL = DefaultAlpacaAssetsURL
+	}
+	if config.PingInterval <= 0 {
+		config.PingInterval = 20 * time.Second
+	}
+	return &alpacaProvider{config: config, client: &http.Client{Timeout: alpacaRequestTimeout}}
+}
+
+func (p *alpacaProvider) Name() string {
+	return "alpaca"
+}
+
+// alpacaMessage is any message on an Alpaca stream; T says which
+type alpacaMessage struct {
+	Type    string `json:"T"`
+	Message string `json:"msg"`
+	Code    int    `json:"code"`
+
+	Symbol    string    `json:"S"`
+	Timestamp time.Time `json:"t"`
+
+	// Bars
+	Open   float64 `json:"o"`
+	High   float64 `json:"h"`
+	Low    float64 `json:"l"`
+	Close  float64 `json:"c"`
+	Volume float64 `json:"v"`
+
+	// Quotes
+	BidPrice float64 `json:"bp"`
+	BidSize  float64 `json:"bs"`
+	AskPrice float64 `json:"ap"`
+	AskSize  float64 `json:"as"`
+
+	// Trades
+	Price float64 `json:"p"`
+	Size  float64 `json:"s"`
+}
+
+func (p *alpacaProvider) Stream(ctx context.Context, symbols []string, handler func(Update)) error {
+	dialCtx, cancel := context.WithTimeout(ctx, alpacaHandshakeTimeout)
+	defer cancel()
+	conn, _, err := websocket.DefaultDialer.DialContext(dialCtx, p.config.StreamURL, nil)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		return err
+	}
+	defer conn.Close()
+
+	// Closing the connection unblocks the read loop when ctx is cancelled
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	if err := p.handshake(conn, symbols); err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		return err
+	}
+
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(2 * p.config.PingInterval))
+	})
+	done := make(chan struct{})
+	defer close(done)
+	go p.ping(conn, done)
+
+	for {
+		if err := conn.SetReadDeadline(time.Now().Add(2 * p.config.PingInterval)); err != nil {
+			return err
+		}
+		messages, err := readAlpacaMessages(conn)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+
+		for _, message := range messages {
+			switch message.Type {
+			case "b":
+				handler(Update{Kind: KindBar, Bar: &models.MarketData{
+					Symbol:    message.Symbol,
+					Timestamp: message.Timestamp,
+					Open:      message.Open,
+					High:      message.High,
+					Low:       message.Low,
+					Close:     message.Close,
+					Volume:    int64(message.Volume),
+					TimeFrame: "1m",
+				}})
+			case "q":
+				handler(Update{Kind: KindQuote, Quote: &models.Quote{
+					Symbol:    message.Symbol,
+					Timestamp: message.Timestamp,
+					Bid:       message.BidPrice,
+					Ask:       message.AskPrice,
+					BidSize:   int(message.BidSize),
+					AskSize:   int(message.AskSize),
+				}})
+			case "t":
+				handler(Update{Kind: KindTrade, Trade: &Trade{
+					Symbol:    message.Symbol,
+					Timestamp: message.Timestamp,
+					Price:     message.Price,
+					Size:      int64(message.Size),
+				}})
+			case "error":
+				return alpacaError(message)
+			}
+		}
+	}
+}
+
+// handshake authenticates and subscribes to the symbols' bars, quotes and
+// trades
+func (p *alpacaProvider) handshake(conn *websocket.Conn, symbols []string) error {
+	deadline := time.Now().Add(alpacaHandshakeTimeout)
+	if err := conn.SetReadDeadline(deadline); err != nil {
+		return err
+	}
+	if err := conn.SetWriteDeadline(deadline); err != nil {
+		return err
+	}
+
+	if err := expectAlpacaMessage(conn, "success", "connected"); err != nil {
+		return err
+	}
+
+	auth := map[string]string{"action": "auth", "key": p.config.APIKey, "secret": p.config.APISecret}
+	if err := conn.WriteJSON(auth); err != nil {
+		return err
+	}
+	if err := expectAlpacaMessage(conn, "success", "authenticated"); err != nil {
+		return err
+	}
+
+	subscribe := map[string]interface{}{"action": "subscribe", "bars": symbols, "quotes": symbols, "trades": symbols}
+	if err := conn.WriteJSON(subscribe); err != nil {
+		return err
+	}
+	if err := expectAlpacaMessage(conn, "subscription", ""); err != nil {
+		return err
+	}
+
+	return conn.SetWriteDeadline(time.Time{})
+}
+
+// ping pings the connection until done is closed
+func (p *alpacaProvider) ping(conn *websocket.Conn, done <-chan struct{}) {
+	ticker := time.NewTicker(p.config.PingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			// A failed ping surfaces as a read error once the deadline passes
+			conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(p.config.PingInterval))
+		}
+	}
+}
+
+// expectAlpacaMessage reads the next messages and checks they include one of
+// the given type, and message unless message is empty
+func expectAlpacaMessage(conn *websocket.Conn, typ, message string) error {
+	messages, err := readAlpacaMessages(conn)
+	if err != nil {
+		return err
+	}
+	for _, m := range messages {
+		if m.Type == "error" {
+			return alpacaError(m)
+		}
+		if m.Type == typ && (message == "" || m.Message == message) {
+			return nil
+		}
+	}
+	return fmt.Errorf("alpaca: expected %s %q", typ, message)
+}
+
+func readAlpacaMessages(conn *websocket.Conn) ([]alpacaMessage, error) {
+	_, data, err := conn.ReadMessage()
+	if err != nil {
+		return nil, err
+	}
+	var messages []alpacaMessage
+	if err := json.Unmarshal(data, &messages); err != nil {
+		return nil, fmt.Errorf("alpaca: invalid message: %w", err)
+	}
+	return messages, nil
+}
+
+func alpacaError(message alpacaMessage) error {
+	return fmt.Errorf("alpaca: %s (%d)", message.Message, message.Code)
+}
+
+// alpacaBar is a bar from the historical data API
+type alpacaBar struct {
+	Timestamp time.Time `json:"t"`
+	Open      float64   `json:"o"`
+	High      float64   `json:"h"`
+	Low       float64   `json:"l"`
+	Close     float64   `json:"c"`
+	Volume    float64   `json:"v"`
+}
+
+func (p *alpacaProvider) History(ctx context.Context, symbol, timeFrame string, start, end time.Time) ([]models.MarketData, error) {
+	alpacaTimeFrame, err := alpacaTimeFrame(timeFrame)
+	if err != nil {
+		return nil, err
+	}
+	symbol = normalizeSymbol(symbol)
+
+	query := url.Values{
+		"timeframe": {alpacaTimeFrame},
+		"start":     {start.UTC().Format(time.RFC3339)},
+		"end":       {end.UTC().Format(time.RFC3339)},
+		"limit":     {"10000"},
+	}
+
+	var bars []models.MarketData
+	for {
+		var page struct {
+			Bars          []alpacaBar `json:"bars"`
+			NextPageToken *string     `json:"next_page_token"`
+		}
+		endpoint := fmt.Sprintf("%s/v2/stocks/%s/bars?%s", p.config.DataURL, url.PathEscape(symbol), query.Encode())
+		if err := p.get(ctx, endpoint, &page); err != nil {
+			return nil, err
+		}
+
+		for _, b := range page.Bars {
+			// The API's end is inclusive
+			if !b.Timestamp.Before(end) {
+				continue
+			}
+			bar, err := normalizeBar(models.MarketData{
+				Symbol:    symbol,
+				Timestamp: b.Timestamp,
+				Open:      b.Open,
+				High:      b.High,
+				Low:       b.Low,
+				Close:     b.Close,
+				Volume:    int64(b.Volume),
+				TimeFrame: timeFrame,
+			}, p.Name())
+			if err != nil {
+				return nil, err
+			}
+			bars = append(bars, bar)
+		}
+
+		if page.NextPageToken == nil || *page.NextPageToken == "" {
+			return bars, nil
+		}
+		query.Set("page_token", *page.NextPageToken)
+	}
+}
+
+func (p *alpacaProvider) Symbols(ctx context.Context) ([]string, error) {
+	var assets []struct {
+		Symbol   string `json:"symbol"`
+		Tradable bool   `json:"tradable"`
+	}
+	endpoint := p.config.AssetsURL + "/v2/assets?status=active&asset_class=us_equity"
+	if err := p.get(ctx, endpoint, &assets); err != nil {
+		return nil, err
+	}
+
+	symbols := make([]string, 0, len(assets))
+	for _, asset := range assets {
+		if asset.Tradable {
+			symbols = append(symbols, normalizeSymbol(asset.Symbol))
+		}
+	}
+	sort.Strings(symbols)
+	return symbols, nil
+}
+
+// get requests endpoint and decodes its JSON response into v
+func (p *alpacaProvider) get(ctx context.Context, endpoint string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("APCA-API-KEY-ID", p.config.APIKey)
+	req.Header.Set("APCA-API-SECRET-KEY", p.config.APISecret)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var body struct {
+			Message string `json:"message"`
+		}
+		json.NewDecoder(resp.Body).Decode(&body)
+		return fmt.Errorf("alpaca: %s: %s", resp.Status, body.Message)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("alpaca: invalid response: %w", err)
+	}
+	return nil
+}
+
+// alpacaTimeFrame converts a time frame such as "15m" into Alpaca's form,
+// "15Min"
+func alpacaTimeFrame(timeFrame string) (string, error) {
+	if _, err := models.TimeFrameDuration(timeFrame); err != nil {
+		return "", err
+	}
+	units := map[byte]string{'m': "Min", 'h': "Hour", 'd': "Day", 'w': "Week"}
+	last := len(timeFrame) - 1
+	return timeFrame[:last] + units[timeFrame[last]], nil
+}
@@ -0,0 +1,84 @@
+// internal/marketdata/broadcaster.go
+package marketdata
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Publisher delivers stored updates to the consumers of the market data service
+type Publisher interface {
+	Publish(update Update)
+}
+
+// Broadcaster fans updates out to in-process subscribers. Each subscriber
+// reads from a buffered channel; one that falls behind misses updates rather
+// than holding up ingestion.
+type Broadcaster struct {
+	mu          sync.RWMutex
+	subscribers map[*Subscription]struct{}
+}
+
+// Subscription receives the updates published after it was created
+type Subscription struct {
+	// C delivers updates, and is closed when the subscription is
+	C <-chan Update
+
+	ch          chan Update
+	filter      func(Update) bool
+	dropped     atomic.Int64
+	broadcaster *Broadcaster
+	closeOnce   sync.Once
+}
+
+func NewBroadcaster() *Broadcaster {
+	return &Broadcaster{subscribers: make(map[*Subscription]struct{})}
+}
+
+// Subscribe returns a subscription buffering up to buffer updates. If filter
+// is not nil, only the updates it accepts are delivered; it is called as
+// updates are published, so must be quick.
+func (b *Broadcaster) Subscribe(buffer int, filter func(Update) bool) *Subscription {
+	ch := make(chan Update, buffer)
+	sub := &Subscription{C: ch, ch: ch, filter: filter, broadcaster: b}
+
+	b.mu.Lock()
+	b.subscribers[sub] = struct{}{}
+	b.mu.Unlock()
+	return sub
+}
+
+// Publish delivers update to every subscriber that wants it and has room in
+// its buffer
+func (b *Broadcaster) Publish(update Update) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for sub := range b.subscribers {
+		if sub.filter != nil && !sub.filter(update) {
+			continue
+		}
+		select {
+		case sub.ch <- update:
+		default:
+			sub.dropped.Add(1)
+		}
+	}
+}
+
+// Dropped returns how many updates the subscription has missed because its
+// buffer was full
+func (s *Subscription) Dropped() int64 {
+	return s.dropped.Load()
+}
+
+// Close stops the subscription and closes C
+func (s *Subscription) Close() {
+	s.closeOnce.Do(func() {
+		b := s.broadcaster
+		b.mu.Lock()
+		delete(b.subscribers, s)
+		b.mu.Unlock()
+		close(s.ch)
+	})
+}
@@ -0,0 +1,141 @@
+// internal/marketdata/ingestor.go
+package marketdata
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aquibsayyed9/sentinel/internal/repository"
+)
+
+var (
+	ErrNoSymbols = errors.New("no symbols to ingest")
+)
+
+// saveTimeout bounds how long storing one update may take. An update being
+// stored when the service shuts down is still stored, within this time.
+const saveTimeout = 5 * time.Second
+
+// IngestorConfig tunes how market data is ingested
+type IngestorConfig struct {
+	// Symbols streamed from the provider
+	Symbols []string
+
+	// Bounds on the wait before reconnecting to a provider whose stream
+	// failed. The wait doubles with each failure in a row.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+
+	// Called when the provider's stream fails, or an update cannot be
+	// normalized or stored
+	OnError func(err error)
+}
+
+// Ingestor streams bars, quotes and trades from a provider, normalizes them,
+// stores bars and quotes, and publishes each update once stored. Storing an
+// update also notifies the rule engine, through the market_data and quotes
+// triggers. Trades are only published. A failed stream is reconnected with
+// backoff until the ingestor is stopped.
+type Ingestor struct {
+	provider  Provider
+	repo      repository.MarketDataRepository
+	publisher Publisher
+	config    IngestorConfig
+}
+
+// NewIngestor creates an ingestor, applying defaults to unset config values
+func NewIngestor(provider Provider, repo repository.MarketDataRepository, publisher Publisher,
+	config IngestorConfig) *Ingestor {
+
+	if config.MinBackoff <= 0 {
+		config.MinBackoff = 500 * time.Millisecond
+	}
+	if config.MaxBackoff < config.MinBackoff {
+		config.MaxBackoff = 30 * time.Second
+	}
+	if config.OnError == nil {
+		config.OnError = func(error) {}
+	}
+
+	symbols := make([]string, 0, len(config.Symbols))
+	seen := make(map[string]bool)
+	for _, symbol := range config.Symbols {
+		if symbol = normalizeSymbol(symbol); symbol != "" && !seen[symbol] {
+			seen[symbol] = true
+			symbols = append(symbols, symbol)
+		}
+	}
+	config.Symbols = symbols
+
+	return &Ingestor{
+		provider:  provider,
+		repo:      repo,
+		publisher: publisher,
+		config:    config,
+	}
+}
+
+// Run ingests market data until ctx is cancelled
+func (i *Ingestor) Run(ctx context.Context) error {
+	if len(i.config.Symbols) == 0 {
+		return ErrNoSymbols
+	}
+
+	backoff := i.config.MinBackoff
+	for {
+		started := time.Now()
+		err := i.provider.Stream(ctx, i.config.Symbols, func(update Update) {
+			if err := i.Ingest(ctx, update); err != nil {
+				i.config.OnError(err)
+			}
+		})
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err == nil {
+			err = errors.New("stream ended")
+		}
+		i.config.OnError(fmt.Errorf("%s stream failed: %w", i.provider.Name(), err))
+
+		// A stream that stayed healthy for a while resets the backoff
+		if time.Since(started) > i.config.MaxBackoff {
+			backoff = i.config.MinBackoff
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(backoff):
+		}
+
+		if backoff *= 2; backoff > i.config.MaxBackoff {
+			backoff = i.config.MaxBackoff
+		}
+	}
+}
+
+// Ingest normalizes, stores if it is a bar or quote, and publishes one update
+func (i *Ingestor) Ingest(ctx context.Context, update Update) error {
+	update, err := Normalize(update, i.provider.Name())
+	if err != nil {
+		return err
+	}
+
+	saveCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), saveTimeout)
+	defer cancel()
+
+	switch update.Kind {
+	case KindBar:
+		err = i.repo.SaveMarketData(saveCtx, update.Bar)
+	case KindQuote:
+		err = i.repo.SaveQuote(saveCtx, update.Quote)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to store %s %s: %w", update.Symbol(), update.Kind, err)
+	}
+
+	i.publisher.Publish(update)
+	return nil
+}
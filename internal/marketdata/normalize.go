@@ -0,0 +1,141 @@
+// internal/marketdata/normalize.go
+package marketdata
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/aquibsayyed9/sentinel/internal/models"
+)
+
+var (
+	ErrInvalidUpdate = errors.New("invalid market data update")
+)
+
+// DefaultBarTimeFrame is the time frame of streamed bars that do not state one
+const DefaultBarTimeFrame = "1m"
+
+// Normalize validates an update from a provider and puts it in the form
+// stored in the database: upper-case symbols, UTC timestamps, a time frame
+// on every bar, and the provider recorded as the source. The update is
+// copied, so the provider's values are left as they are.
+func Normalize(update Update, source string) (Update, error) {
+	switch update.Kind {
+	case KindBar:
+		if update.Bar == nil {
+			return Update{}, fmt.Errorf("%w: bar update without a bar", ErrInvalidUpdate)
+		}
+		bar, err := normalizeBar(*update.Bar, source)
+		if err != nil {
+			return Update{}, err
+		}
+		return Update{Kind: KindBar, Bar: &bar}, nil
+
+	case KindQuote:
+		if update.Quote == nil {
+			return Update{}, fmt.Errorf("%w: quote update without a quote", ErrInvalidUpdate)
+		}
+		quote, err := normalizeQuote(*update.Quote, source)
+		if err != nil {
+			return Update{}, err
+		}
+		return Update{Kind: KindQuote, Quote: &quote}, nil
+
+	case KindTrade:
+		if update.Trade == nil {
+			return Update{}, fmt.Errorf("%w: trade update without a trade", ErrInvalidUpdate)
+		}
+		trade, err := normalizeTrade(*update.Trade, source)
+		if err != nil {
+			return Update{}, err
+		}
+		return Update{Kind: KindTrade, Trade: &trade}, nil
+
+	default:
+		return Update{}, fmt.Errorf("%w: unknown kind %q", ErrInvalidUpdate, update.Kind)
+	}
+}
+
+func normalizeBar(bar models.MarketData, source string) (models.MarketData, error) {
+	bar.Symbol = normalizeSymbol(bar.Symbol)
+	if bar.Symbol == "" {
+		return bar, fmt.Errorf("%w: bar without a symbol", ErrInvalidUpdate)
+	}
+	if bar.Timestamp.IsZero() {
+		return bar, fmt.Errorf("%w: %s bar without a timestamp", ErrInvalidUpdate, bar.Symbol)
+	}
+	for _, price := range []float64{bar.Open, bar.High, bar.Low, bar.Close} {
+		if !validPrice(price) {
+			return bar, fmt.Errorf("%w: %s bar has a non-positive price", ErrInvalidUpdate, bar.Symbol)
+		}
+	}
+	if bar.High < bar.Low || bar.Open > bar.High || bar.Open < bar.Low || bar.Close > bar.High || bar.Close < bar.Low {
+		return bar, fmt.Errorf("%w: %s bar prices are outside its range", ErrInvalidUpdate, bar.Symbol)
+	}
+	if bar.Volume < 0 {
+		return bar, fmt.Errorf("%w: %s bar has negative volume", ErrInvalidUpdate, bar.Symbol)
+	}
+
+	bar.Timestamp = bar.Timestamp.UTC()
+	if bar.TimeFrame == "" {
+		bar.TimeFrame = DefaultBarTimeFrame
+	}
+	if _, err := models.TimeFrameDuration(bar.TimeFrame); err != nil {
+		return bar, fmt.Errorf("%w: %s bar: %v", ErrInvalidUpdate, bar.Symbol, err)
+	}
+	bar.Source = source
+	return bar, nil
+}
+
+func normalizeQuote(quote models.Quote, source string) (models.Quote, error) {
+	quote.Symbol = normalizeSymbol(quote.Symbol)
+	if quote.Symbol == "" {
+		return quote, fmt.Errorf("%w: quote without a symbol", ErrInvalidUpdate)
+	}
+	if quote.Timestamp.IsZero() {
+		return quote, fmt.Errorf("%w: %s quote without a timestamp", ErrInvalidUpdate, quote.Symbol)
+	}
+	if !validPrice(quote.Bid) || !validPrice(quote.Ask) {
+		return quote, fmt.Errorf("%w: %s quote has a non-positive price", ErrInvalidUpdate, quote.Symbol)
+	}
+	if quote.Ask < quote.Bid {
+		return quote, fmt.Errorf("%w: %s quote is crossed", ErrInvalidUpdate, quote.Symbol)
+	}
+	if quote.BidSize < 0 || quote.AskSize < 0 {
+		return quote, fmt.Errorf("%w: %s quote has a negative size", ErrInvalidUpdate, quote.Symbol)
+	}
+
+	quote.Timestamp = quote.Timestamp.UTC()
+	quote.Source = source
+	return quote, nil
+}
+
+func normalizeTrade(trade Trade, source string) (Trade, error) {
+	trade.Symbol = normalizeSymbol(trade.Symbol)
+	if trade.Symbol == "" {
+		return trade, fmt.Errorf("%w: trade without a symbol", ErrInvalidUpdate)
+	}
+	if trade.Timestamp.IsZero() {
+		return trade, fmt.Errorf("%w: %s trade without a timestamp", ErrInvalidUpdate, trade.Symbol)
+	}
+	if !validPrice(trade.Price) {
+		return trade, fmt.Errorf("%w: %s trade has a non-positive price", ErrInvalidUpdate, trade.Symbol)
+	}
+	if trade.Size < 0 {
+		return trade, fmt.Errorf("%w: %s trade has a negative size", ErrInvalidUpdate, trade.Symbol)
+	}
+
+	trade.Timestamp = trade.Timestamp.UTC()
+	trade.Source = source
+	return trade, nil
+}
+
+func normalizeSymbol(symbol string) string {
+	return strings.ToUpper(strings.TrimSpace(symbol))
+}
+
+func validPrice(price float64) bool {
+	return price > 0 && !math.IsInf(price, 0) && !math.IsNaN(price)
+}
@@ -0,0 +1,82 @@
+// internal/marketdata/provider.go
+package marketdata
+
+import (
+	"context"
+	"time"
+
+	"github.com/aquibsayyed9/sentinel/internal/models"
+)
+
+// Kinds of update a provider streams
+const (
+	KindBar   = "bar"
+	KindQuote = "quote"
+	KindTrade = "trade"
+)
+
+// Update is a bar, quote or trade received from a provider. Exactly one of
+// Bar, Quote and Trade is set, according to Kind.
+type Update struct {
+	Kind  string
+	Bar   *models.MarketData
+	Quote *models.Quote
+	Trade *Trade
+}
+
+// Trade is a single trade reported by a provider. Trades are streamed to
+// clients as they happen but not stored.
+type Trade struct {
+	Symbol    string
+	Timestamp time.Time
+	Price     float64
+	Size      int64
+	Source    string
+}
+
+// Symbol returns the symbol the update is for
+func (u Update) Symbol() string {
+	if u.Bar != nil {
+		return u.Bar.Symbol
+	}
+	if u.Quote != nil {
+		return u.Quote.Symbol
+	}
+	if u.Trade != nil {
+		return u.Trade.Symbol
+	}
+	return ""
+}
+
+// Time returns when the update happened
+func (u Update) Time() time.Time {
+	if u.Bar != nil {
+		return u.Bar.Timestamp
+	}
+	if u.Quote != nil {
+		return u.Quote.Timestamp
+	}
+	if u.Trade != nil {
+		return u.Trade.Timestamp
+	}
+	return time.Time{}
+}
+
+// Provider is a source of live and historical market data
+type Provider interface {
+	// Name identifies the provider, and is recorded as the source of the
+	// data it delivers
+	Name() string
+
+	// Stream delivers bars, quotes and trades for the symbols to handler until ctx is
+	// cancelled, returning nil, or the connection fails, returning the
+	// error. Updates are delivered one at a time, in the order received.
+	Stream(ctx context.Context, symbols []string, handler func(Update)) error
+
+	// History returns the symbol's bars of the time frame that start within
+	// [start, end), oldest first and normalized
+	History(ctx context.Context, symbol, timeFrame string, start, end time.Time) ([]models.MarketData, error)
+
+	// Symbols lists the symbols the provider has data for, in order
+	Symbols(ctx context.Context) ([]string, error)
+}
@@ -0,0 +1,91 @@
+// internal/marketdata/registry.go
+package marketdata
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var (
+	ErrUnknownProvider = errors.New("unknown market data provider")
+)
+
+// ProviderConfig configures a provider created through a registry. Each
+// provider reads the fields that apply to it.
+type ProviderConfig struct {
+	// Endpoints of a remote provider; the provider's defaults if empty
+	StreamURL string
+	DataURL   string
+
+	APIKey    string
+	APISecret string
+
+	Replay ReplayConfig
+}
+
+// ProviderFactory creates a provider from its config
+type ProviderFactory func(config ProviderConfig) (Provider, error)
+
+// Registry creates providers by name
+type Registry struct {
+	mu        sync.RWMutex
+	factories map[string]ProviderFactory
+}
+
+// NewRegistry creates a registry holding the built-in providers, "alpaca"
+// and "replay"
+func NewRegistry() *Registry {
+	r := &Registry{factories: make(map[string]ProviderFactory)}
+	r.Register("alpaca", func(config ProviderConfig) (Provider, error) {
+		return NewAlpacaProvider(AlpacaConfig{
+			StreamURL: config.StreamURL,
+			DataURL:   config.DataURL,
+			APIKey:    config.APIKey,
+			APISecret: config.APISecret,
+		}), nil
+	})
+	r.Register("replay", func(config ProviderConfig) (Provider, error) {
+		return NewReplayProvider(config.Replay)
+	})
+	return r
+}
+
+// Register adds a provider under name, replacing any registered before it.
+// Names are case-insensitive.
+func (r *Registry) Register(name string, factory ProviderFactory) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.factories[strings.ToLower(name)] = factory
+}
+
+// New creates the provider registered under name
+func (r *Registry) New(name string, config ProviderConfig) (Provider, error) {
+	r.mu.RLock()
+	factory, ok := r.factories[strings.ToLower(name)]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w %q (have %s)", ErrUnknownProvider, name, strings.Join(r.Names(), ", "))
+	}
+
+	provider, err := factory(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s provider: %w", name, err)
+	}
+	return provider, nil
+}
+
+// Names lists the registered providers in order
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.factories))
+	for name := range r.factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,461 @@
+// internal/marketdata/replay.go
+package marketdata
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aquibsayyed9/sentinel/internal/models"
+)
+
+var (
+	ErrNoReplayData = errors.New("no replay data")
+)
+
+// replayLoopGap separates the passes of a looping replay
+const replayLoopGap = time.Minute
+
+// ReplayConfig configures the replay provider
+type ReplayConfig struct {
+	// A .csv or .json file, or a directory whose .csv and .json files are
+	// replayed together
+	Path string
+
+	// How many times faster than real time updates are replayed; 1 if unset
+	Speed float64
+
+	// Replays the data again once it runs out, shifted forward in time so
+	// timestamps keep increasing. Otherwise the stream goes quiet at the end.
+	Loop bool
+}
+
+// replayProvider replays recorded bars, quotes and trades from files, pacing
+// them by the time between their timestamps, so the stack can run without a
+// market data subscription. Files hold one update per row (CSV, with a header) or
+// object (JSON, either an array or one object per line), with the fields
+//
+//	kind, symbol, timestamp, open, high, low, close, volume, time_frame,
+//	bid, ask, bid_size, ask_size, price, size
+//
+// Timestamps are RFC 3339 or dates. kind is "bar", "quote" or "trade"; if it
+// is missing, updates with a bid or ask are quotes, those with a price are
+// trades and the rest are bars.
+type replayProvider struct {
+	config  ReplayConfig
+	updates []Update
+}
+
+// NewReplayProvider creates a replay provider, reading and validating all of
+// its data up front
+func NewReplayProvider(config ReplayConfig) (Provider, error) {
+	if config.Speed <= 0 {
+		config.Speed = 1
+	}
+
+	files, err := replayFiles(config.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &replayProvider{config: config}
+	for _, file := range files {
+		updates, err := p.readFile(file)
+		if err != nil {
+			return nil, err
+		}
+		p.updates = append(p.updates, updates...)
+	}
+	if len(p.updates) == 0 {
+		return nil, fmt.Errorf("%w in %s", ErrNoReplayData, config.Path)
+	}
+
+	sort.SliceStable(p.updates, func(i, j int) bool {
+		return p.updates[i].Time().Before(p.updates[j].Time())
+	})
+	return p, nil
+}
+
+func (p *replayProvider) Name() string {
+	return "replay"
+}
+
+func (p *replayProvider) Stream(ctx context.Context, symbols []string, handler func(Update)) error {
+	wanted := make(map[string]bool, len(symbols))
+	for _, symbol := range symbols {
+		wanted[normalizeSymbol(symbol)] = true
+	}
+	var updates []Update
+	for _, update := range p.updates {
+		if wanted[update.Symbol()] {
+			updates = append(updates, update)
+		}
+	}
+
+	if len(updates) > 0 {
+		first := updates[0].Time()
+		span := updates[len(updates)-1].Time().Sub(first) + replayLoopGap
+
+		for pass := 0; ; pass++ {
+			shift := time.Duration(pass) * span
+			last := first
+			if pass > 0 {
+				last = first.Add(-replayLoopGap)
+			}
+
+			for _, update := range updates {
+				at := update.Time()
+				if !p.wait(ctx, at.Sub(last)) {
+					return nil
+				}
+				last = at
+				handler(shiftUpdate(update, shift))
+			}
+
+			if !p.config.Loop {
+				break
+			}
+		}
+	}
+
+	<-ctx.Done()
+	return nil
+}
+
+// wait sleeps for the replay time d, reporting false if ctx is cancelled
+// first
+func (p *replayProvider) wait(ctx context.Context, d time.Duration) bool {
+	d = time.Duration(float64(d) / p.config.Speed)
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+func (p *replayProvider) History(ctx context.Context, symbol, timeFrame string, start, end time.Time) ([]models.MarketData, error) {
+	if _, err := models.TimeFrameDuration(timeFrame); err != nil {
+		return nil, err
+	}
+	symbol = normalizeSymbol(symbol)
+
+	var bars []models.MarketData
+	for _, update := range p.updates {
+		if update.Kind != KindBar || update.Bar.Symbol != symbol || update.Bar.TimeFrame != timeFrame {
+			continue
+		}
+		if update.Bar.Timestamp.Before(start) || !update.Bar.Timestamp.Before(end) {
+			continue
+		}
+		bars = append(bars, *update.Bar)
+	}
+	return bars, nil
+}
+
+func (p *replayProvider) Symbols(ctx context.Context) ([]string, error) {
+	seen := make(map[string]bool)
+	var symbols []string
+	for _, update := range p.updates {
+		if symbol := update.Symbol(); !seen[symbol] {
+			seen[symbol] = true
+			symbols = append(symbols, symbol)
+		}
+	}
+	sort.Strings(symbols)
+	return symbols, nil
+}
+
+// replayFiles returns path if it is a file, or the .csv and .json files in it
+// if it is a directory
+func replayFiles(path string) ([]string, error) {
+	if path == "" {
+		return nil, fmt.Errorf("%w: no replay path set", ErrNoReplayData)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, entry := range entries {
+		ext := strings.ToLower(filepath.Ext(entry.Name()))
+		if !entry.IsDir() && (ext == ".csv" || ext == ".json") {
+			files = append(files, filepath.Join(path, entry.Name()))
+		}
+	}
+	return files, nil
+}
+
+// replayRecord is one update as written in a replay file
+type replayRecord struct {
+	Kind      string  `json:"kind"`
+	Symbol    string  `json:"symbol"`
+	Timestamp string  `json:"timestamp"`
+	Open      float64 `json:"open"`
+	High      float64 `json:"high"`
+	Low       float64 `json:"low"`
+	Close     float64 `json:"close"`
+	Volume    int64   `json:"volume"`
+	TimeFrame string  `json:"time_frame"`
+	Bid       float64 `json:"bid"`
+	Ask       float64 `json:"ask"`
+	BidSize   int     `json:"bid_size"`
+	AskSize   int     `json:"ask_size"`
+	Price     float64 `json:"price"`
+	Size      int64   `json:"size"`
+}
+
+// readFile reads and normalizes the updates in a replay file
+func (p *replayProvider) readFile(file string) ([]Update, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var records []replayRecord
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".csv":
+		records, err = readReplayCSV(f)
+	case ".json":
+		records, err = readReplayJSON(f)
+	default:
+		err = errors.New("not a .csv or .json file")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+
+	updates := make([]Update, 0, len(records))
+	for i, record := range records {
+		update, err := record.update(p.Name())
+		if err != nil {
+			return nil, fmt.Errorf("%s: record %d: %w", file, i+1, err)
+		}
+		updates = append(updates, update)
+	}
+	return updates, nil
+}
+
+func readReplayCSV(r io.Reader) ([]replayRecord, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	header := make([]string, len(rows[0]))
+	for i, column := range rows[0] {
+		header[i] = strings.ToLower(strings.TrimSpace(column))
+	}
+
+	records := make([]replayRecord, 0, len(rows)-1)
+	for line, row := range rows[1:] {
+		var record replayRecord
+		for i, value := range row {
+			if err := record.set(header[i], strings.TrimSpace(value)); err != nil {
+				return nil, fmt.Errorf("line %d: %w", line+2, err)
+			}
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// set assigns a CSV column to the record
+func (r *replayRecord) set(column, value string) error {
+	var err error
+	switch column {
+	case "kind":
+		r.Kind = value
+	case "symbol":
+		r.Symbol = value
+	case "timestamp":
+		r.Timestamp = value
+	case "time_frame":
+		r.TimeFrame = value
+	case "open":
+		r.Open, err = parseReplayFloat(value)
+	case "high":
+		r.High, err = parseReplayFloat(value)
+	case "low":
+		r.Low, err = parseReplayFloat(value)
+	case "close":
+		r.Close, err = parseReplayFloat(value)
+	case "bid":
+		r.Bid, err = parseReplayFloat(value)
+	case "ask":
+		r.Ask, err = parseReplayFloat(value)
+	case "volume":
+		var volume float64
+		volume, err = parseReplayFloat(value)
+		r.Volume = int64(volume)
+	case "bid_size":
+		var size float64
+		size, err = parseReplayFloat(value)
+		r.BidSize = int(size)
+	case "ask_size":
+		var size float64
+		size, err = parseReplayFloat(value)
+		r.AskSize = int(size)
+	case "price":
+		r.Price, err = parseReplayFloat(value)
+	case "size":
+		var size float64
+		size, err = parseReplayFloat(value)
+		r.Size = int64(size)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid %s %q", column, value)
+	}
+	return nil
+}
+
+func parseReplayFloat(value string) (float64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.ParseFloat(value, 64)
+}
+
+// readReplayJSON reads a JSON array of records, or one record per line
+func readReplayJSON(r io.Reader) ([]replayRecord, error) {
+	reader := bufio.NewReader(r)
+	start, err := reader.Peek(1)
+	for err == nil && len(bytes.TrimSpace(start)) == 0 {
+		reader.ReadByte()
+		start, err = reader.Peek(1)
+	}
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(reader)
+	var records []replayRecord
+	if start[0] == '[' {
+		err := decoder.Decode(&records)
+		return records, err
+	}
+	for {
+		var record replayRecord
+		if err := decoder.Decode(&record); err == io.EOF {
+			return records, nil
+		} else if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+}
+
+// update converts the record into a normalized update
+func (r replayRecord) update(source string) (Update, error) {
+	timestamp, err := parseReplayTime(r.Timestamp)
+	if err != nil {
+		return Update{}, err
+	}
+
+	kind := strings.ToLower(r.Kind)
+	if kind == "" {
+		switch {
+		case r.Bid != 0 || r.Ask != 0:
+			kind = KindQuote
+		case r.Price != 0:
+			kind = KindTrade
+		default:
+			kind = KindBar
+		}
+	}
+
+	var update Update
+	switch kind {
+	case KindBar:
+		update = Update{Kind: KindBar, Bar: &models.MarketData{
+			Symbol:    r.Symbol,
+			Timestamp: timestamp,
+			Open:      r.Open,
+			High:      r.High,
+			Low:       r.Low,
+			Close:     r.Close,
+			Volume:    r.Volume,
+			TimeFrame: r.TimeFrame,
+		}}
+	case KindQuote:
+		update = Update{Kind: KindQuote, Quote: &models.Quote{
+			Symbol:    r.Symbol,
+			Timestamp: timestamp,
+			Bid:       r.Bid,
+			Ask:       r.Ask,
+			BidSize:   r.BidSize,
+			AskSize:   r.AskSize,
+		}}
+	case KindTrade:
+		update = Update{Kind: KindTrade, Trade: &Trade{
+			Symbol:    r.Symbol,
+			Timestamp: timestamp,
+			Price:     r.Price,
+			Size:      r.Size,
+		}}
+	default:
+		return Update{}, fmt.Errorf("%w: unknown kind %q", ErrInvalidUpdate, r.Kind)
+	}
+	return Normalize(update, source)
+}
+
+func parseReplayTime(value string) (time.Time, error) {
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%w: invalid timestamp %q", ErrInvalidUpdate, value)
+}
+
+// shiftUpdate returns a copy of update moved forward in time by shift
+func shiftUpdate(update Update, shift time.Duration) Update {
+	switch {
+	case update.Bar != nil:
+		bar := *update.Bar
+		bar.Timestamp = bar.Timestamp.Add(shift)
+		return Update{Kind: update.Kind, Bar: &bar}
+	case update.Quote != nil:
+		quote := *update.Quote
+		quote.Timestamp = quote.Timestamp.Add(shift)
+		return Update{Kind: update.Kind, Quote: &quote}
+	default:
+		trade := *update.Trade
+		trade.Timestamp = trade.Timestamp.Add(shift)
+		return Update{Kind: update.Kind, Trade: &trade}
+	}
+}
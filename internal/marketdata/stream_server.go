@@ -0,0 +1,476 @@
+// internal/marketdata/stream_server.go
+package marketdata
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/aquibsayyed9/sentinel/internal/auth"
+)
+
+// Channels a stream client subscribes to
+const (
+	ChannelBars   = "bars"
+	ChannelQuotes = "quotes"
+	ChannelTrades = "trades"
+)
+
+// channelKinds maps each channel to the kind of update it carries
+var channelKinds = map[string]string{
+	ChannelBars:   KindBar,
+	ChannelQuotes: KindQuote,
+	ChannelTrades: KindTrade,
+}
+
+// maxClientMessageSize bounds the messages a stream client may send
+const maxClientMessageSize = 16 * 1024
+
+// StreamServerConfig tunes the WebSocket stream
+type StreamServerConfig struct {
+	// Updates buffered for each connection. A connection that falls further
+	// behind misses updates, and is told how many in its next batch.
+	Buffer int
+
+	// Updates are sent in batches, at most this long after they arrive and
+	// at most this many at a time
+	BatchInterval time.Duration
+	MaxBatch      int
+
+	// How often connections are pinged. A connection that has been silent
+	// for two intervals is closed.
+	PingInterval time.Duration
+
+	// How long a write may take before the connection is treated as stalled
+	// and closed
+	WriteTimeout time.Duration
+
+	// Most symbols a connection may subscribe to, across all channels
+	MaxSymbols int
+}
+
+// StreamServer streams published updates to WebSocket clients. Clients
+// authenticate with an API token and subscribe to channels of updates for
+// the symbols they name; docs/api/websocket.md describes the protocol.
+type StreamServer struct {
+	broadcaster  *Broadcaster
+	tokenService auth.TokenService
+	config       StreamServerConfig
+	upgrader     websocket.Upgrader
+
+	mu     sync.Mutex
+	closed bool
+	done   chan struct{}
+	conns  sync.WaitGroup
+}
+
+// NewStreamServer creates a stream server, applying defaults to unset config
+// values
+func NewStreamServer(broadcaster *Broadcaster, tokenService auth.TokenService, config StreamServerConfig) *StreamServer {
+	if config.Buffer <= 0 {
+		config.Buffer = 1024
+	}
+	if config.BatchInterval <= 0 {
+		config.BatchInterval = 100 * time.Millisecond
+	}
+	if config.MaxBatch <= 0 {
+		config.MaxBatch = 500
+	}
+	if config.PingInterval <= 0 {
+		config.PingInterval = 30 * time.Second
+	}
+	if config.WriteTimeout <= 0 {
+		config.WriteTimeout = 10 * time.Second
+	}
+	if config.MaxSymbols <= 0 {
+		config.MaxSymbols = 100
+	}
+
+	return &StreamServer{
+		broadcaster:  broadcaster,
+		tokenService: tokenService,
+		config:       config,
+		upgrader: websocket.Upgrader{
+			// Clients authenticate with a token rather than a cookie, so a
+			// page on another origin cannot connect on a user's behalf
+			CheckOrigin: func(*http.Request) bool { return true },
+		},
+		done: make(chan struct{}),
+	}
+}
+
+// ServeHTTP authenticates the request and streams to it until the client
+// disconnects or the server is closed. The token is read from the
+// Authorization header or, for browsers, which cannot set headers on a
+// WebSocket, the token query parameter.
+func (s *StreamServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := s.authenticate(r); err != nil {
+		writeStreamError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		writeStreamError(w, http.StatusServiceUnavailable, "server is shutting down")
+		return
+	}
+	s.conns.Add(1)
+	s.mu.Unlock()
+	defer s.conns.Done()
+
+	ws, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// The upgrader has already responded
+		return
+	}
+	newStreamConn(s, ws).run()
+}
+
+// Close disconnects all clients and refuses new ones
+func (s *StreamServer) Close() {
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.done)
+	}
+	s.mu.Unlock()
+	s.conns.Wait()
+}
+
+func (s *StreamServer) authenticate(r *http.Request) error {
+	token := r.URL.Query().Get("token")
+	if header := r.Header.Get("Authorization"); header != "" {
+		parts := strings.Split(header, " ")
+		if len(parts) != 2 || parts[0] != "Bearer" {
+			return errors.New("authorization header format must be Bearer {token}")
+		}
+		token = parts[1]
+	}
+	if token == "" {
+		return errors.New("authorization token is required")
+	}
+
+	if _, err := s.tokenService.ValidateToken(token); err != nil {
+		return errors.New("invalid or expired token")
+	}
+	return nil
+}
+
+func writeStreamError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
+
+// clientMessage is a request from a stream client
+type clientMessage struct {
+	Action   string   `json:"action"`
+	Channels []string `json:"channels"`
+	Symbols  []string `json:"symbols"`
+}
+
+// serverMessage is a message to a stream client; Type says which
+type serverMessage struct {
+	Type          string              `json:"type"`
+	Message       string              `json:"message,omitempty"`
+	Subscriptions map[string][]string `json:"subscriptions,omitempty"`
+	Updates       []interface{}       `json:"updates,omitempty"`
+	Dropped       int64               `json:"dropped,omitempty"`
+}
+
+// The updates carried in data messages
+type (
+	barMessage struct {
+		Channel   string    `json:"channel"`
+		Symbol    string    `json:"symbol"`
+		Timestamp time.Time `json:"timestamp"`
+		TimeFrame string    `json:"time_frame"`
+		Open      float64   `json:"open"`
+		High      float64   `json:"high"`
+		Low       float64   `json:"low"`
+		Close     float64   `json:"close"`
+		Volume    int64     `json:"volume"`
+	}
+
+	quoteMessage struct {
+		Channel   string    `json:"channel"`
+		Symbol    string    `json:"symbol"`
+		Timestamp time.Time `json:"timestamp"`
+		Bid       float64   `json:"bid"`
+		Ask       float64   `json:"ask"`
+		BidSize   int       `json:"bid_size"`
+		AskSize   int       `json:"ask_size"`
+	}
+
+	tradeMessage struct {
+		Channel   string    `json:"channel"`
+		Symbol    string    `json:"symbol"`
+		Timestamp time.Time `json:"timestamp"`
+		Price     float64   `json:"price"`
+		Size      int64     `json:"size"`
+	}
+)
+
+func newUpdateMessage(update Update) interface{} {
+	switch {
+	case update.Bar != nil:
+		bar := update.Bar
+		return barMessage{ChannelBars, bar.Symbol, bar.Timestamp, bar.TimeFrame,
+			bar.Open, bar.High, bar.Low, bar.Close, bar.Volume}
+	case update.Quote != nil:
+		quote := update.Quote
+		return quoteMessage{ChannelQuotes, quote.Symbol, quote.Timestamp,
+			quote.Bid, quote.Ask, quote.BidSize, quote.AskSize}
+	default:
+		trade := update.Trade
+		return tradeMessage{ChannelTrades, trade.Symbol, trade.Timestamp, trade.Price, trade.Size}
+	}
+}
+
+// streamConn is one client's connection. Its reader handles the client's
+// requests; its writer alone writes to the socket, sending batches of
+// updates, replies to requests, and pings.
+type streamConn struct {
+	server *StreamServer
+	ws     *websocket.Conn
+	sub    *Subscription
+
+	replies   chan serverMessage
+	readDone  chan struct{}
+	writeDone chan struct{}
+
+	// The symbols subscribed to on each channel
+	mu            sync.RWMutex
+	subscriptions map[string]map[string]bool
+}
+
+func newStreamConn(server *StreamServer, ws *websocket.Conn) *streamConn {
+	c := &streamConn{
+		server:        server,
+		ws:            ws,
+		replies:       make(chan serverMessage, 16),
+		readDone:      make(chan struct{}),
+		writeDone:     make(chan struct{}),
+		subscriptions: make(map[string]map[string]bool),
+	}
+	for channel := range channelKinds {
+		c.subscriptions[channel] = make(map[string]bool)
+	}
+	return c
+}
+
+func (c *streamConn) run() {
+	c.sub = c.server.broadcaster.Subscribe(c.server.config.Buffer, c.wants)
+	defer c.sub.Close()
+
+	go c.read()
+	c.write()
+
+	// Closing the socket stops the reader if the writer stopped first
+	c.ws.Close()
+	<-c.readDone
+}
+
+// wants reports whether the client is subscribed to the update. Only minute
+// bars are streamed on the bars channel.
+func (c *streamConn) wants(update Update) bool {
+	if update.Kind == KindBar && update.Bar.TimeFrame != DefaultBarTimeFrame {
+		return false
+	}
+	for channel, kind := range channelKinds {
+		if kind == update.Kind {
+			c.mu.RLock()
+			defer c.mu.RUnlock()
+			return c.subscriptions[channel][update.Symbol()]
+		}
+	}
+	return false
+}
+
+func (c *streamConn) read() {
+	defer close(c.readDone)
+
+	timeout := 2 * c.server.config.PingInterval
+	c.ws.SetReadLimit(maxClientMessageSize)
+	c.ws.SetReadDeadline(time.Now().Add(timeout))
+	c.ws.SetPongHandler(func(string) error {
+		return c.ws.SetReadDeadline(time.Now().Add(timeout))
+	})
+
+	for {
+		_, data, err := c.ws.ReadMessage()
+		if err != nil {
+			return
+		}
+		c.ws.SetReadDeadline(time.Now().Add(timeout))
+
+		var message clientMessage
+		if err := json.Unmarshal(data, &message); err != nil {
+			c.reply(serverMessage{Type: "error", Message: "invalid message: " + err.Error()})
+			continue
+		}
+		c.reply(c.handle(message))
+	}
+}
+
+// handle carries out a client's request and returns the reply
+func (c *streamConn) handle(message clientMessage) serverMessage {
+	switch message.Action {
+	case "subscribe", "unsubscribe":
+		if err := c.subscribe(message); err != nil {
+			return serverMessage{Type: "error", Message: err.Error()}
+		}
+		return serverMessage{Type: "subscribed", Subscriptions: c.listSubscriptions()}
+	case "ping":
+		return serverMessage{Type: "pong"}
+	default:
+		return serverMessage{Type: "error", Message: fmt.Sprintf("unknown action %q", message.Action)}
+	}
+}
+
+// subscribe adds or removes the symbols on the channels, or on every channel
+// if none are named
+func (c *streamConn) subscribe(message clientMessage) error {
+	channels := message.Channels
+	if len(channels) == 0 {
+		channels = []string{ChannelBars, ChannelQuotes, ChannelTrades}
+	}
+	for _, channel := range channels {
+		if _, ok := channelKinds[channel]; !ok {
+			return fmt.Errorf("unknown channel %q", channel)
+		}
+	}
+
+	symbols := make([]string, 0, len(message.Symbols))
+	for _, symbol := range message.Symbols {
+		if symbol = normalizeSymbol(symbol); symbol != "" {
+			symbols = append(symbols, symbol)
+		}
+	}
+	if len(symbols) == 0 {
+		return errors.New("no symbols given")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	subscribing := message.Action == "subscribe"
+	if subscribing {
+		total := make(map[string]bool)
+		for _, subscribed := range c.subscriptions {
+			for symbol := range subscribed {
+				total[symbol] = true
+			}
+		}
+		for _, symbol := range symbols {
+			total[symbol] = true
+		}
+		if len(total) > c.server.config.MaxSymbols {
+			return fmt.Errorf("cannot subscribe to more than %d symbols", c.server.config.MaxSymbols)
+		}
+	}
+
+	for _, channel := range channels {
+		for _, symbol := range symbols {
+			if subscribing {
+				c.subscriptions[channel][symbol] = true
+			} else {
+				delete(c.subscriptions[channel], symbol)
+			}
+		}
+	}
+	return nil
+}
+
+func (c *streamConn) listSubscriptions() map[string][]string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	list := make(map[string][]string, len(c.subscriptions))
+	for channel, subscribed := range c.subscriptions {
+		symbols := make([]string, 0, len(subscribed))
+		for symbol := range subscribed {
+			symbols = append(symbols, symbol)
+		}
+		sort.Strings(symbols)
+		list[channel] = symbols
+	}
+	return list
+}
+
+// reply queues a message for the writer, unless the writer has stopped
+func (c *streamConn) reply(message serverMessage) {
+	select {
+	case c.replies <- message:
+	case <-c.writeDone:
+	}
+}
+
+func (c *streamConn) write() {
+	defer close(c.writeDone)
+
+	config := c.server.config
+	batchTicker := time.NewTicker(config.BatchInterval)
+	defer batchTicker.Stop()
+	pingTicker := time.NewTicker(config.PingInterval)
+	defer pingTicker.Stop()
+
+	var batch []interface{}
+	var reported int64
+	flush := func() error {
+		dropped := c.sub.Dropped() - reported
+		if len(batch) == 0 && dropped == 0 {
+			return nil
+		}
+		reported += dropped
+		message := serverMessage{Type: "data", Updates: batch, Dropped: dropped}
+		batch = nil
+		return c.send(message)
+	}
+
+	if err := c.send(serverMessage{Type: "connected"}); err != nil {
+		return
+	}
+
+	for {
+		var err error
+		select {
+		case <-c.readDone:
+			return
+		case <-c.server.done:
+			c.ws.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down"),
+				time.Now().Add(config.WriteTimeout))
+			return
+		case update := <-c.sub.C:
+			if batch = append(batch, newUpdateMessage(update)); len(batch) >= config.MaxBatch {
+				err = flush()
+			}
+		case <-batchTicker.C:
+			err = flush()
+		case message := <-c.replies:
+			err = c.send(message)
+		case <-pingTicker.C:
+			err = c.ws.WriteControl(websocket.PingMessage, nil, time.Now().Add(config.WriteTimeout))
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// send writes a message, failing if the client does not take it in time
+func (c *streamConn) send(message serverMessage) error {
+	if err := c.ws.SetWriteDeadline(time.Now().Add(c.server.config.WriteTimeout)); err != nil {
+		return err
+	}
+	return c.ws.WriteJSON(message)
+}
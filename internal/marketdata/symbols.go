@@ -0,0 +1,34 @@
+// internal/marketdata/symbols.go
+package marketdata
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aquibsayyed9/sentinel/internal/models"
+	"github.com/aquibsayyed9/sentinel/internal/repository"
+)
+
+// SyncSymbols lists the symbols the provider carries in the symbol master,
+// along with the given symbols, which are listed even if the provider's
+// cannot be fetched. It returns how many symbols were listed.
+func SyncSymbols(ctx context.Context, provider Provider, repo repository.SymbolRepository, symbols []string) (int, error) {
+	listed, fetchErr := provider.Symbols(ctx)
+	if fetchErr != nil {
+		fetchErr = fmt.Errorf("failed to list %s symbols: %w", provider.Name(), fetchErr)
+	}
+
+	var entries []models.Symbol
+	seen := make(map[string]bool)
+	for _, symbol := range append(listed, symbols...) {
+		if symbol = normalizeSymbol(symbol); symbol != "" && !seen[symbol] {
+			seen[symbol] = true
+			entries = append(entries, models.Symbol{Symbol: symbol, Source: provider.Name()})
+		}
+	}
+
+	if err := repo.Upsert(ctx, entries); err != nil {
+		return 0, fmt.Errorf("failed to store symbols: %w", err)
+	}
+	return len(entries), fetchErr
+}
@@ -0,0 +1,23 @@
+// internal/models/engine_instance.go
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EngineInstance is a running rule engine process. Each instance renews its
+// lease by heartbeating; an instance whose lease has expired is treated as
+// failed and its rules are reassigned to the live instances.
+type EngineInstance struct {
+	ID          uuid.UUID `gorm:"type:uuid;primary_key"`
+	Name        string    `gorm:"not null"` // host and process, for logs
+	StartedAt   time.Time `gorm:"not null"`
+	HeartbeatAt time.Time `gorm:"not null;index"`
+}
+
+// TableName specifies the table name for EngineInstance model
+func (EngineInstance) TableName() string {
+	return "engine_instances"
+}
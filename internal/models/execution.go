@@ -12,9 +12,10 @@ import (
 type Execution struct {
 	ID              uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
 	RuleID          *uuid.UUID `gorm:"type:uuid"`
+	RuleVersion     *int       // version of the rule that produced the execution
 	UserID          uuid.UUID  `gorm:"type:uuid;not null"`
 	Symbol          string     `gorm:"not null"`
-	ExecutionType   string     `gorm:"not null"` // buy, sell
+	ExecutionType   string     `gorm:"not null"` // buy, sell, or a rule action such as activate_rule
 	Quantity        float64    `gorm:"not null"`
 	Price           float64    `gorm:"not null"`
 	TotalAmount     float64    `gorm:"not null"`
@@ -22,6 +23,7 @@ type Execution struct {
 	ExecutionTime   time.Time  `gorm:"not null"`
 	Exchange        string
 	ExternalOrderID string
+	TargetRuleID    *uuid.UUID     `gorm:"type:uuid"` // rule activated, deactivated or created by a rule action
 	CreatedAt       time.Time      `gorm:"autoCreateTime"`
 	UpdatedAt       time.Time      `gorm:"autoUpdateTime"`
 	DeletedAt       gorm.DeletedAt `gorm:"index"`
@@ -31,3 +31,14 @@ type Quote struct {
 	AskSize   int       `json:"ask_size"`
 	Source    string    `json:"source"`
 }
+
+// MarketEventChannel is the Postgres NOTIFY channel that announces newly
+// stored bars and quotes
+const MarketEventChannel = "market_events"
+
+// MarketEvent signals that new market data has been stored for a symbol
+type MarketEvent struct {
+	Symbol    string    `json:"symbol"`
+	Kind      string    `json:"kind"` // "bar" or "quote"
+	Timestamp time.Time `json:"timestamp"`
+}
@@ -0,0 +1,34 @@
+// internal/models/notification.go
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Notification is a message to a user about something that happened to their
+// account or rules without their involvement
+type Notification struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID    uuid.UUID  `gorm:"type:uuid;not null;index"`
+	RuleID    *uuid.UUID `gorm:"type:uuid"` // rule the notification is about, if any
+	Kind      string     `gorm:"not null"`  // e.g., rule_quarantined
+	Message   string     `gorm:"not null"`
+	ReadAt    *time.Time
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
+
+// TableName specifies the table name for Notification model
+func (Notification) TableName() string {
+	return "notifications"
+}
+
+// BeforeCreate will set ID if not provided
+func (n *Notification) BeforeCreate(tx *gorm.DB) error {
+	if n.ID == uuid.Nil {
+		n.ID = uuid.New()
+	}
+	return nil
+}
@@ -14,15 +14,42 @@ type TradingRule struct {
 	UserID      uuid.UUID `gorm:"type:uuid;not null"`
 	Name        string    `gorm:"not null"`
 	Description string
-	Symbol      string         `gorm:"not null"`
-	RuleType    string         `gorm:"not null"` // stop_loss, take_profit, etc.
-	Conditions  []byte         `gorm:"type:jsonb"`
-	Actions     []byte         `gorm:"type:jsonb"`
-	Status      string         `gorm:"default:active"`
-	IsAIManaged bool           `gorm:"default:false"`
-	CreatedAt   time.Time      `gorm:"autoCreateTime"`
-	UpdatedAt   time.Time      `gorm:"autoUpdateTime"`
-	DeletedAt   gorm.DeletedAt `gorm:"index"`
+	Symbol      string `gorm:"not null"`
+	RuleType    string `gorm:"not null"` // stop_loss, take_profit, trailing_stop, etc.
+	Conditions  []byte `gorm:"type:jsonb"`
+	Actions     []byte `gorm:"type:jsonb"`
+	Stop        []byte `gorm:"type:jsonb"` // stop level for stop rule types
+	Status      string `gorm:"default:active"`
+	IsAIManaged bool   `gorm:"default:false"`
+	Version     int    `gorm:"not null;default:1"` // current RuleVersion
+
+	// Trigger policy
+	OneShot           bool `gorm:"default:false"` // deactivate after the first trigger
+	CooldownSeconds   int  `gorm:"default:0"`     // minimum time between triggers
+	MaxTriggersPerDay int  `gorm:"default:0"`     // 0 means unlimited
+
+	// Days, intraday windows and sessions the rule may trigger in
+	TimeConstraints []byte `gorm:"type:jsonb"`
+
+	// When a scheduled rule triggers
+	Schedule []byte `gorm:"type:jsonb"`
+
+	// How a strategy advisor may tune an AI-managed rule, and the parameter
+	// values the owner last set, which auto-applied changes are bounded by
+	AIManagement []byte `gorm:"type:jsonb"`
+	AIBaseline   []byte `gorm:"type:jsonb"`
+
+	// Trigger state, maintained by the rule engine
+	LastTriggeredAt *time.Time
+	TriggerCount    int `gorm:"default:0"`
+	TriggersToday   int `gorm:"default:0"` // triggers on the UTC day of LastTriggeredAt
+	LastExecutedAt  *time.Time
+	ReferencePrice  *float64   // entry price or trailing high-water mark of a stop
+	NextRunAt       *time.Time `gorm:"index"` // next run of a scheduled rule
+
+	CreatedAt time.Time      `gorm:"autoCreateTime"`
+	UpdatedAt time.Time      `gorm:"autoUpdateTime"`
+	DeletedAt gorm.DeletedAt `gorm:"index"`
 
 	// Relationships
 	User User `gorm:"foreignKey:UserID"`
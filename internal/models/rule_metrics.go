@@ -0,0 +1,29 @@
+// internal/models/rule_metrics.go
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RuleMetrics are the rule engine's running totals for a trading rule,
+// accumulated across every engine instance that has evaluated it
+type RuleMetrics struct {
+	RuleID             uuid.UUID `gorm:"type:uuid;primary_key"`
+	Evaluations        int64     `gorm:"not null;default:0"`
+	Triggers           int64     `gorm:"not null;default:0"`
+	Errors             int64     `gorm:"not null;default:0"`
+	ConsecutiveErrors  int       `gorm:"not null;default:0"` // errors since the last successful evaluation
+	TotalLatencyMicros int64     `gorm:"not null;default:0"`
+	MaxLatencyMicros   int64     `gorm:"not null;default:0"`
+	LastEvaluatedAt    *time.Time
+	LastError          *string
+	LastErrorAt        *time.Time
+	UpdatedAt          time.Time `gorm:"autoUpdateTime"`
+}
+
+// TableName specifies the table name for RuleMetrics model
+func (RuleMetrics) TableName() string {
+	return "rule_metrics"
+}
@@ -0,0 +1,39 @@
+// internal/models/rule_proposal.go
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// RuleProposal is a set of parameter adjustments a strategy advisor proposed
+// for an AI-managed rule. It applies to the rule version it was made for, and
+// is either applied, automatically or on the owner's approval, or rejected.
+type RuleProposal struct {
+	ID             uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	RuleID         uuid.UUID `gorm:"type:uuid;not null;index"`
+	UserID         uuid.UUID `gorm:"type:uuid;not null"`
+	RuleVersion    int       `gorm:"not null"` // version the adjustments were proposed against
+	Advisor        string    `gorm:"not null"`
+	Adjustments    []byte    `gorm:"type:jsonb;not null"`
+	Status         string    `gorm:"not null;index"` // pending, applied, rejected or superseded
+	AutoApplied    bool      `gorm:"default:false"`
+	AppliedVersion *int      // version created by applying the proposal
+	CreatedAt      time.Time `gorm:"autoCreateTime"`
+	DecidedAt      *time.Time
+}
+
+// TableName specifies the table name for RuleProposal model
+func (RuleProposal) TableName() string {
+	return "rule_proposals"
+}
+
+// BeforeCreate will set ID if not provided
+func (p *RuleProposal) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+// internal/models/rule_version.go
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// RuleVersion is an immutable snapshot of a trading rule's definition. A new
+// version is stored every time the rule's logic changes.
+type RuleVersion struct {
+	ID         uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	RuleID     uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_rule_version"`
+	Version    int       `gorm:"not null;uniqueIndex:idx_rule_version"`
+	Definition []byte    `gorm:"type:jsonb;not null"` // the complete rule definition
+	Changes    []byte    `gorm:"type:jsonb"`          // fields changed from the previous version
+	CreatedBy  uuid.UUID `gorm:"type:uuid;not null"`
+	CreatedAt  time.Time `gorm:"autoCreateTime"`
+}
+
+// TableName specifies the table name for RuleVersion model
+func (RuleVersion) TableName() string {
+	return "rule_versions"
+}
+
+// BeforeCreate will set ID if not provided
+func (v *RuleVersion) BeforeCreate(tx *gorm.DB) error {
+	if v.ID == uuid.Nil {
+		v.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,19 @@
+// internal/models/symbol.go
+package models
+
+import "time"
+
+// Symbol is an entry in the symbol master: a symbol market data is available
+// for. The market data service lists the symbols its provider carries here
+// when it starts.
+type Symbol struct {
+	Symbol    string    `gorm:"primary_key"`
+	Source    string    `gorm:"not null"` // the provider that listed it
+	CreatedAt time.Time `gorm:"not null"`
+	UpdatedAt time.Time `gorm:"not null"`
+}
+
+// TableName specifies the table name for Symbol model
+func (Symbol) TableName() string {
+	return "symbols"
+}
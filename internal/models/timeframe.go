@@ -0,0 +1,42 @@
+// internal/models/timeframe.go
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+var (
+	ErrInvalidTimeFrame = errors.New("invalid time frame")
+)
+
+// TimeFrameDuration parses a bar time frame such as "1m", "15m", "1h", "1d" or
+// "1w" into the duration it spans
+func TimeFrameDuration(timeFrame string) (time.Duration, error) {
+	if len(timeFrame) < 2 {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidTimeFrame, timeFrame)
+	}
+
+	count, err := strconv.Atoi(timeFrame[:len(timeFrame)-1])
+	if err != nil || count <= 0 {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidTimeFrame, timeFrame)
+	}
+
+	var unit time.Duration
+	switch timeFrame[len(timeFrame)-1] {
+	case 'm':
+		unit = time.Minute
+	case 'h':
+		unit = time.Hour
+	case 'd':
+		unit = 24 * time.Hour
+	case 'w':
+		unit = 7 * 24 * time.Hour
+	default:
+		return 0, fmt.Errorf("%w: %q", ErrInvalidTimeFrame, timeFrame)
+	}
+
+	return time.Duration(count) * unit, nil
+}
@@ -0,0 +1,111 @@
+// internal/repository/cluster_repo.go
+package repository
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/binary"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/aquibsayyed9/sentinel/internal/models"
+)
+
+// ClusterRepository coordinates rule engine instances running against the
+// same database. Lease times are taken from the database clock, so instances
+// on hosts with skewed clocks agree on which leases have expired.
+type ClusterRepository interface {
+	// Renews the instance's lease, registering the instance on first call
+	Heartbeat(ctx context.Context, id uuid.UUID, name string) error
+
+	// Lists the instances whose lease was renewed within ttl, oldest first
+	GetLiveInstances(ctx context.Context, ttl time.Duration) ([]models.EngineInstance, error)
+
+	// Removes the instances whose lease has not been renewed within ttl
+	DeleteExpiredInstances(ctx context.Context, ttl time.Duration) error
+
+	// Removes an instance that is shutting down, releasing its rules at once
+	Deregister(ctx context.Context, id uuid.UUID) error
+
+	// Runs fn while holding an advisory lock on the rule. Returns false
+	// without running fn if another session holds the lock. The lock belongs
+	// to a dedicated connection, so it is released if the process dies.
+	WithRuleLock(ctx context.Context, ruleID uuid.UUID, fn func() error) (bool, error)
+}
+
+type clusterRepository struct {
+	db *gorm.DB
+}
+
+func NewClusterRepository(db *gorm.DB) ClusterRepository {
+	return &clusterRepository{db: db}
+}
+
+func (r *clusterRepository) Heartbeat(ctx context.Context, id uuid.UUID, name string) error {
+	return conn(ctx, r.db).Exec(`
+		INSERT INTO engine_instances (id, name, started_at, heartbeat_at)
+		VALUES (?, ?, now(), now())
+		ON CONFLICT (id) DO UPDATE SET heartbeat_at = now()`,
+		id, name,
+	).Error
+}
+
+func (r *clusterRepository) GetLiveInstances(ctx context.Context, ttl time.Duration) ([]models.EngineInstance, error) {
+	var instances []models.EngineInstance
+	if err := conn(ctx, r.db).
+		Where("heartbeat_at > now() - ? * interval '1 millisecond'", ttl.Milliseconds()).
+		Order("started_at, id").
+		Find(&instances).Error; err != nil {
+		return nil, err
+	}
+	return instances, nil
+}
+
+func (r *clusterRepository) DeleteExpiredInstances(ctx context.Context, ttl time.Duration) error {
+	return conn(ctx, r.db).
+		Where("heartbeat_at <= now() - ? * interval '1 millisecond'", ttl.Milliseconds()).
+		Delete(&models.EngineInstance{}).Error
+}
+
+func (r *clusterRepository) Deregister(ctx context.Context, id uuid.UUID) error {
+	return conn(ctx, r.db).Delete(&models.EngineInstance{}, "id = ?", id).Error
+}
+
+func (r *clusterRepository) WithRuleLock(ctx context.Context, ruleID uuid.UUID, fn func() error) (bool, error) {
+	sqlDB, err := r.db.DB()
+	if err != nil {
+		return false, err
+	}
+
+	c, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer c.Close()
+
+	key := ruleLockKey(ruleID)
+	var locked bool
+	if err := c.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", key).Scan(&locked); err != nil {
+		return false, err
+	}
+	if !locked {
+		return false, nil
+	}
+
+	defer func() {
+		// Unlock even if ctx was cancelled during fn. A connection that fails
+		// to unlock is discarded rather than returned to the pool holding the
+		// lock, since closing it releases the lock.
+		if _, err := c.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", key); err != nil {
+			c.Raw(func(interface{}) error { return driver.ErrBadConn })
+		}
+	}()
+	return true, fn()
+}
+
+// ruleLockKey derives a rule's advisory lock key from its ID
+func ruleLockKey(ruleID uuid.UUID) int64 {
+	return int64(binary.BigEndian.Uint64(ruleID[:8]) ^ binary.BigEndian.Uint64(ruleID[8:]))
+}
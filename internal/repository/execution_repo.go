@@ -18,6 +18,8 @@ var (
 type ExecutionRepository interface {
 	Create(ctx context.Context, execution *models.Execution) error
 	GetByID(ctx context.Context, id uuid.UUID) (*models.Execution, error)
+	// Returns ErrExecutionNotFound unless the execution belongs to userID
+	GetByIDAndUserID(ctx context.Context, id, userID uuid.UUID) (*models.Execution, error)
 	GetByUserID(ctx context.Context, userID uuid.UUID, limit, offset int) ([]models.Execution, error)
 	GetByRuleID(ctx context.Context, ruleID uuid.UUID) ([]models.Execution, error)
 	GetRecentExecutions(ctx context.Context, limit int) ([]models.Execution, error)
@@ -34,12 +36,23 @@ func NewExecutionRepository(db *gorm.DB) ExecutionRepository {
 }
 
 func (r *executionRepository) Create(ctx context.Context, execution *models.Execution) error {
-	return r.db.WithContext(ctx).Create(execution).Error
+	return conn(ctx, r.db).Create(execution).Error
 }
 
 func (r *executionRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Execution, error) {
 	var execution models.Execution
-	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&execution).Error; err != nil {
+	if err := conn(ctx, r.db).Where("id = ?", id).First(&execution).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrExecutionNotFound
+		}
+		return nil, err
+	}
+	return &execution, nil
+}
+
+func (r *executionRepository) GetByIDAndUserID(ctx context.Context, id, userID uuid.UUID) (*models.Execution, error) {
+	var execution models.Execution
+	if err := conn(ctx, r.db).Where("id = ? AND user_id = ?", id, userID).First(&execution).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, ErrExecutionNotFound
 		}
@@ -50,7 +63,7 @@ func (r *executionRepository) GetByID(ctx context.Context, id uuid.UUID) (*model
 
 func (r *executionRepository) GetByUserID(ctx context.Context, userID uuid.UUID, limit, offset int) ([]models.Execution, error) {
 	var executions []models.Execution
-	query := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("execution_time DESC")
+	query := conn(ctx, r.db).Where("user_id = ?", userID).Order("execution_time DESC")
 
 	if limit > 0 {
 		query = query.Limit(limit)
@@ -69,7 +82,7 @@ func (r *executionRepository) GetByUserID(ctx context.Context, userID uuid.UUID,
 
 func (r *executionRepository) GetByRuleID(ctx context.Context, ruleID uuid.UUID) ([]models.Execution, error) {
 	var executions []models.Execution
-	if err := r.db.WithContext(ctx).Where("rule_id = ?", ruleID).Order("execution_time DESC").Find(&executions).Error; err != nil {
+	if err := conn(ctx, r.db).Where("rule_id = ?", ruleID).Order("execution_time DESC").Find(&executions).Error; err != nil {
 		return nil, err
 	}
 	return executions, nil
@@ -77,14 +90,14 @@ func (r *executionRepository) GetByRuleID(ctx context.Context, ruleID uuid.UUID)
 
 func (r *executionRepository) GetRecentExecutions(ctx context.Context, limit int) ([]models.Execution, error) {
 	var executions []models.Execution
-	if err := r.db.WithContext(ctx).Order("execution_time DESC").Limit(limit).Find(&executions).Error; err != nil {
+	if err := conn(ctx, r.db).Order("execution_time DESC").Limit(limit).Find(&executions).Error; err != nil {
 		return nil, err
 	}
 	return executions, nil
 }
 
 func (r *executionRepository) Update(ctx context.Context, execution *models.Execution) error {
-	result := r.db.WithContext(ctx).Save(execution)
+	result := conn(ctx, r.db).Save(execution)
 	if result.Error != nil {
 		return result.Error
 	}
@@ -96,7 +109,7 @@ func (r *executionRepository) Update(ctx context.Context, execution *models.Exec
 
 func (r *executionRepository) CountByUserID(ctx context.Context, userID uuid.UUID) (int64, error) {
 	var count int64
-	if err := r.db.WithContext(ctx).Model(&models.Execution{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+	if err := conn(ctx, r.db).Model(&models.Execution{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
 		return 0, err
 	}
 	return count, nil
@@ -0,0 +1,103 @@
+// internal/repository/market_event_listener.go
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/stdlib"
+	"gorm.io/gorm"
+
+	"github.com/aquibsayyed9/sentinel/internal/models"
+)
+
+const (
+	listenerMinBackoff = 500 * time.Millisecond
+	listenerMaxBackoff = 30 * time.Second
+)
+
+// MarketEventListener receives notifications for newly stored market data
+type MarketEventListener interface {
+	// Listen delivers market events to handler until ctx is cancelled,
+	// reconnecting with backoff whenever the underlying connection is lost.
+	// Connection and payload errors are reported to onError.
+	Listen(ctx context.Context, handler func(event models.MarketEvent), onError func(err error)) error
+}
+
+type marketEventListener struct {
+	db *gorm.DB
+}
+
+func NewMarketEventListener(db *gorm.DB) MarketEventListener {
+	return &marketEventListener{db: db}
+}
+
+func (l *marketEventListener) Listen(ctx context.Context, handler func(event models.MarketEvent), onError func(err error)) error {
+	backoff := listenerMinBackoff
+	for {
+		started := time.Now()
+		err := l.listen(ctx, handler, onError)
+		if ctx.Err() != nil {
+			return nil
+		}
+		onError(fmt.Errorf("market event listener disconnected: %w", err))
+
+		// A connection that stayed healthy for a while resets the backoff
+		if time.Since(started) > listenerMaxBackoff {
+			backoff = listenerMinBackoff
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(backoff):
+		}
+
+		if backoff *= 2; backoff > listenerMaxBackoff {
+			backoff = listenerMaxBackoff
+		}
+	}
+}
+
+// listen holds a dedicated connection open and blocks on notifications
+func (l *marketEventListener) listen(ctx context.Context, handler func(event models.MarketEvent), onError func(err error)) error {
+	sqlDB, err := l.db.DB()
+	if err != nil {
+		return err
+	}
+
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	return conn.Raw(func(driverConn interface{}) error {
+		stdlibConn, ok := driverConn.(*stdlib.Conn)
+		if !ok {
+			return errors.New("market event listener requires the pgx driver")
+		}
+		pgxConn := stdlibConn.Conn()
+
+		if _, err := pgxConn.Exec(ctx, "LISTEN "+models.MarketEventChannel); err != nil {
+			return err
+		}
+
+		for {
+			notification, err := pgxConn.WaitForNotification(ctx)
+			if err != nil {
+				return err
+			}
+
+			var event models.MarketEvent
+			if err := json.Unmarshal([]byte(notification.Payload), &event); err != nil {
+				onError(fmt.Errorf("invalid market event payload: %w", err))
+				continue
+			}
+			handler(event)
+		}
+	})
+}
@@ -3,18 +3,29 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/aquibsayyed9/sentinel/internal/models"
 	"gorm.io/gorm"
 )
 
+var (
+	ErrMarketDataNotFound = errors.New("market data not found")
+	ErrQuoteNotFound      = errors.New("quote not found")
+)
+
 type MarketDataRepository interface {
 	SaveMarketData(ctx context.Context, data *models.MarketData) error
 	GetLatestPrice(ctx context.Context, symbol string) (*models.MarketData, error)
+	GetPriceAt(ctx context.Context, symbol string, at time.Time) (*models.MarketData, error)
 	GetHistoricalData(ctx context.Context, symbol string, start, end time.Time, timeframe string) ([]models.MarketData, error)
+	// Returns up to limit of the symbol's bars of the time frame within
+	// [start, end], oldest first
+	GetBars(ctx context.Context, symbol, timeFrame string, start, end time.Time, limit int) ([]models.MarketData, error)
 	SaveQuote(ctx context.Context, quote *models.Quote) error
 	GetLatestQuote(ctx context.Context, symbol string) (*models.Quote, error)
+	GetQuoteAt(ctx context.Context, symbol string, at time.Time) (*models.Quote, error)
 }
 
 type marketDataRepository struct {
@@ -26,16 +37,35 @@ func NewMarketDataRepository(db *gorm.DB) MarketDataRepository {
 }
 
 func (r *marketDataRepository) SaveMarketData(ctx context.Context, data *models.MarketData) error {
-	return r.db.WithContext(ctx).Create(data).Error
+	return conn(ctx, r.db).Create(data).Error
 }
 
 func (r *marketDataRepository) GetLatestPrice(ctx context.Context, symbol string) (*models.MarketData, error) {
 	var data models.MarketData
-	err := r.db.WithContext(ctx).
+	err := conn(ctx, r.db).
 		Where("symbol = ?", symbol).
 		Order("timestamp desc").
 		First(&data).Error
 	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrMarketDataNotFound
+		}
+		return nil, err
+	}
+	return &data, nil
+}
+
+// GetPriceAt returns the latest bar for a symbol at or before a time
+func (r *marketDataRepository) GetPriceAt(ctx context.Context, symbol string, at time.Time) (*models.MarketData, error) {
+	var data models.MarketData
+	err := conn(ctx, r.db).
+		Where("symbol = ? AND timestamp <= ?", symbol, at).
+		Order("timestamp desc").
+		First(&data).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrMarketDataNotFound
+		}
 		return nil, err
 	}
 	return &data, nil
@@ -43,7 +73,7 @@ func (r *marketDataRepository) GetLatestPrice(ctx context.Context, symbol string
 
 func (r *marketDataRepository) GetHistoricalData(ctx context.Context, symbol string, start, end time.Time, timeframe string) ([]models.MarketData, error) {
 	var data []models.MarketData
-	query := r.db.WithContext(ctx).
+	query := conn(ctx, r.db).
 		Where("symbol = ?", symbol).
 		Where("timestamp BETWEEN ? AND ?", start, end)
 
@@ -58,17 +88,50 @@ func (r *marketDataRepository) GetHistoricalData(ctx context.Context, symbol str
 	return data, nil
 }
 
+func (r *marketDataRepository) GetBars(ctx context.Context, symbol, timeFrame string, start, end time.Time, limit int) ([]models.MarketData, error) {
+	var data []models.MarketData
+	err := conn(ctx, r.db).
+		Where("symbol = ? AND time_frame = ?", symbol, timeFrame).
+		Where("timestamp BETWEEN ? AND ?", start, end).
+		Order("timestamp asc").
+		Limit(limit).
+		Find(&data).Error
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
 func (r *marketDataRepository) SaveQuote(ctx context.Context, quote *models.Quote) error {
-	return r.db.WithContext(ctx).Create(quote).Error
+	return conn(ctx, r.db).Create(quote).Error
 }
 
 func (r *marketDataRepository) GetLatestQuote(ctx context.Context, symbol string) (*models.Quote, error) {
 	var quote models.Quote
-	err := r.db.WithContext(ctx).
+	err := conn(ctx, r.db).
 		Where("symbol = ?", symbol).
 		Order("timestamp desc").
 		First(&quote).Error
 	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrQuoteNotFound
+		}
+		return nil, err
+	}
+	return &quote, nil
+}
+
+// GetQuoteAt returns the latest quote for a symbol at or before a time
+func (r *marketDataRepository) GetQuoteAt(ctx context.Context, symbol string, at time.Time) (*models.Quote, error) {
+	var quote models.Quote
+	err := conn(ctx, r.db).
+		Where("symbol = ? AND timestamp <= ?", symbol, at).
+		Order("timestamp desc").
+		First(&quote).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrQuoteNotFound
+		}
 		return nil, err
 	}
 	return &quote, nil
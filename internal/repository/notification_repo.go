@@ -0,0 +1,63 @@
+// internal/repository/notification_repo.go
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/aquibsayyed9/sentinel/internal/models"
+)
+
+var (
+	ErrNotificationNotFound = errors.New("notification not found")
+)
+
+type NotificationRepository interface {
+	Create(ctx context.Context, notification *models.Notification) error
+	// Lists the user's notifications, newest first
+	GetByUserID(ctx context.Context, userID uuid.UUID, unreadOnly bool, limit, offset int) ([]models.Notification, error)
+	// Returns ErrNotificationNotFound unless the notification belongs to userID
+	MarkRead(ctx context.Context, id, userID uuid.UUID, readAt time.Time) error
+}
+
+type notificationRepository struct {
+	db *gorm.DB
+}
+
+func NewNotificationRepository(db *gorm.DB) NotificationRepository {
+	return &notificationRepository{db: db}
+}
+
+func (r *notificationRepository) Create(ctx context.Context, notification *models.Notification) error {
+	return conn(ctx, r.db).Create(notification).Error
+}
+
+func (r *notificationRepository) GetByUserID(ctx context.Context, userID uuid.UUID, unreadOnly bool, limit, offset int) ([]models.Notification, error) {
+	query := conn(ctx, r.db).Where("user_id = ?", userID)
+	if unreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+
+	var notifications []models.Notification
+	if err := query.Order("created_at DESC").Limit(limit).Offset(offset).Find(&notifications).Error; err != nil {
+		return nil, err
+	}
+	return notifications, nil
+}
+
+func (r *notificationRepository) MarkRead(ctx context.Context, id, userID uuid.UUID, readAt time.Time) error {
+	result := conn(ctx, r.db).Model(&models.Notification{}).
+		Where("id = ? AND user_id = ?", id, userID).
+		Update("read_at", gorm.Expr("COALESCE(read_at, ?)", readAt))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotificationNotFound
+	}
+	return nil
+}
@@ -40,12 +40,12 @@ func NewPortfolioRepository(db *gorm.DB) PortfolioRepository {
 
 // Portfolio methods
 func (r *portfolioRepository) CreatePortfolio(ctx context.Context, portfolio *models.Portfolio) error {
-	return r.db.WithContext(ctx).Create(portfolio).Error
+	return conn(ctx, r.db).Create(portfolio).Error
 }
 
 func (r *portfolioRepository) GetPortfolioByUserID(ctx context.Context, userID uuid.UUID) (*models.Portfolio, error) {
 	var portfolio models.Portfolio
-	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&portfolio).Error; err != nil {
+	if err := conn(ctx, r.db).Where("user_id = ?", userID).First(&portfolio).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, ErrPortfolioNotFound
 		}
@@ -55,7 +55,7 @@ func (r *portfolioRepository) GetPortfolioByUserID(ctx context.Context, userID u
 }
 
 func (r *portfolioRepository) UpdatePortfolio(ctx context.Context, portfolio *models.Portfolio) error {
-	result := r.db.WithContext(ctx).Save(portfolio)
+	result := conn(ctx, r.db).Save(portfolio)
 	if result.Error != nil {
 		return result.Error
 	}
@@ -67,12 +67,12 @@ func (r *portfolioRepository) UpdatePortfolio(ctx context.Context, portfolio *mo
 
 // Holdings methods
 func (r *portfolioRepository) CreateHolding(ctx context.Context, holding *models.PortfolioHolding) error {
-	return r.db.WithContext(ctx).Create(holding).Error
+	return conn(ctx, r.db).Create(holding).Error
 }
 
 func (r *portfolioRepository) GetHolding(ctx context.Context, portfolioID uuid.UUID, symbol string) (*models.PortfolioHolding, error) {
 	var holding models.PortfolioHolding
-	if err := r.db.WithContext(ctx).Where("portfolio_id = ? AND symbol = ?", portfolioID, symbol).First(&holding).Error; err != nil {
+	if err := conn(ctx, r.db).Where("portfolio_id = ? AND symbol = ?", portfolioID, symbol).First(&holding).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, ErrHoldingNotFound
 		}
@@ -83,14 +83,14 @@ func (r *portfolioRepository) GetHolding(ctx context.Context, portfolioID uuid.U
 
 func (r *portfolioRepository) GetAllHoldings(ctx context.Context, portfolioID uuid.UUID) ([]models.PortfolioHolding, error) {
 	var holdings []models.PortfolioHolding
-	if err := r.db.WithContext(ctx).Where("portfolio_id = ?", portfolioID).Find(&holdings).Error; err != nil {
+	if err := conn(ctx, r.db).Where("portfolio_id = ?", portfolioID).Find(&holdings).Error; err != nil {
 		return nil, err
 	}
 	return holdings, nil
 }
 
 func (r *portfolioRepository) UpdateHolding(ctx context.Context, holding *models.PortfolioHolding) error {
-	result := r.db.WithContext(ctx).Save(holding)
+	result := conn(ctx, r.db).Save(holding)
 	if result.Error != nil {
 		return result.Error
 	}
@@ -101,7 +101,7 @@ func (r *portfolioRepository) UpdateHolding(ctx context.Context, holding *models
 }
 
 func (r *portfolioRepository) DeleteHolding(ctx context.Context, id uuid.UUID) error {
-	result := r.db.WithContext(ctx).Delete(&models.PortfolioHolding{}, "id = ?", id)
+	result := conn(ctx, r.db).Delete(&models.PortfolioHolding{}, "id = ?", id)
 	if result.Error != nil {
 		return result.Error
 	}
@@ -0,0 +1,85 @@
+// internal/repository/rule_proposal_repo.go
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/aquibsayyed9/sentinel/internal/models"
+)
+
+var (
+	ErrRuleProposalNotFound = errors.New("rule proposal not found")
+	ErrRuleProposalDecided  = errors.New("rule proposal has already been decided")
+)
+
+type RuleProposalRepository interface {
+	Create(ctx context.Context, proposal *models.RuleProposal) error
+	// Returns ErrRuleProposalNotFound unless the proposal belongs to userID
+	GetByIDAndUserID(ctx context.Context, id, userID uuid.UUID) (*models.RuleProposal, error)
+	// Lists a rule's proposals, newest first
+	GetByRuleID(ctx context.Context, ruleID uuid.UUID) ([]models.RuleProposal, error)
+
+	// Records the decision on a pending proposal: its status, decision time,
+	// and the version applying it created. Fails with ErrRuleProposalDecided
+	// if the proposal is no longer pending.
+	Decide(ctx context.Context, proposal *models.RuleProposal) error
+
+	// Marks the rule's pending proposals superseded
+	SupersedePending(ctx context.Context, ruleID uuid.UUID, decidedAt time.Time) error
+}
+
+type ruleProposalRepository struct {
+	db *gorm.DB
+}
+
+func NewRuleProposalRepository(db *gorm.DB) RuleProposalRepository {
+	return &ruleProposalRepository{db: db}
+}
+
+func (r *ruleProposalRepository) Create(ctx context.Context, proposal *models.RuleProposal) error {
+	return conn(ctx, r.db).Create(proposal).Error
+}
+
+func (r *ruleProposalRepository) GetByIDAndUserID(ctx context.Context, id, userID uuid.UUID) (*models.RuleProposal, error) {
+	var proposal models.RuleProposal
+	if err := conn(ctx, r.db).Where("id = ? AND user_id = ?", id, userID).First(&proposal).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRuleProposalNotFound
+		}
+		return nil, err
+	}
+	return &proposal, nil
+}
+
+func (r *ruleProposalRepository) GetByRuleID(ctx context.Context, ruleID uuid.UUID) ([]models.RuleProposal, error) {
+	var proposals []models.RuleProposal
+	if err := conn(ctx, r.db).Where("rule_id = ?", ruleID).Order("created_at DESC").Find(&proposals).Error; err != nil {
+		return nil, err
+	}
+	return proposals, nil
+}
+
+func (r *ruleProposalRepository) Decide(ctx context.Context, proposal *models.RuleProposal) error {
+	result := conn(ctx, r.db).Model(proposal).
+		Where("status = ?", "pending").
+		Select("status", "auto_applied", "applied_version", "decided_at").
+		Updates(proposal)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrRuleProposalDecided
+	}
+	return nil
+}
+
+func (r *ruleProposalRepository) SupersedePending(ctx context.Context, ruleID uuid.UUID, decidedAt time.Time) error {
+	return conn(ctx, r.db).Model(&models.RuleProposal{}).
+		Where("rule_id = ? AND status = ?", ruleID, "pending").
+		Updates(map[string]interface{}{"status": "superseded", "decided_at": decidedAt}).Error
+}
@@ -4,24 +4,106 @@ package repository
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"github.com/aquibsayyed9/sentinel/internal/models"
 )
 
 var (
-	ErrRuleNotFound = errors.New("trading rule not found")
+	ErrRuleNotFound        = errors.New("trading rule not found")
+	ErrRuleVersionNotFound = errors.New("rule version not found")
+	ErrRuleVersionConflict = errors.New("rule was modified concurrently")
 )
 
+// ruleDefinitionColumns are the columns replaced when a rule's definition changes
+var ruleDefinitionColumns = []string{
+	"name", "description", "symbol", "rule_type", "conditions", "actions", "stop", "time_constraints",
+	"schedule", "one_shot", "cooldown_seconds", "max_triggers_per_day", "reference_price", "next_run_at", "version",
+	"is_ai_managed", "ai_management", "ai_baseline",
+}
+
 type RuleRepository interface {
 	Create(ctx context.Context, rule *models.TradingRule) error
+	// Creates the rule together with its first version
+	CreateWithVersion(ctx context.Context, rule *models.TradingRule, version *models.RuleVersion) error
 	GetByID(ctx context.Context, id uuid.UUID) (*models.TradingRule, error)
+	// Returns ErrRuleNotFound unless the rule belongs to userID
+	GetByIDAndUserID(ctx context.Context, id, userID uuid.UUID) (*models.TradingRule, error)
 	GetByUserID(ctx context.Context, userID uuid.UUID) ([]models.TradingRule, error)
 	GetActiveRules(ctx context.Context) ([]models.TradingRule, error)
+	// Lists the active scheduled rules whose next run is due at now, or not yet set
+	GetDueScheduledRules(ctx context.Context, now time.Time) ([]models.TradingRule, error)
 	Update(ctx context.Context, rule *models.TradingRule) error
 	Delete(ctx context.Context, id uuid.UUID) error
+
+	// Adds triggers at a time to the rule's trigger counts, and deactivates it
+	// if it is one-shot. The counts are updated in the database rather than
+	// copied from the rule, which may be stale, and a rule no longer active is
+	// left as it is.
+	RecordTrigger(ctx context.Context, rule *models.TradingRule, at time.Time, triggers int) error
+
+	// Sets the rule's status, and the time of its next run if it is being
+	// activated, leaving the rest of the rule as it is
+	UpdateStatus(ctx context.Context, rule *models.TradingRule) error
+
+	// Sets the time the rule last produced an execution
+	UpdateLastExecutedAt(ctx context.Context, id uuid.UUID, executedAt time.Time) error
+
+	// Sets the reference price a stop rule measures its level from
+	UpdateReferencePrice(ctx context.Context, id uuid.UUID, price float64) error
+
+	// Moves a scheduled rule's next run from previous to nextRunAt, reporting
+	// false if its next run is no longer previous because another instance
+	// took the run
+	UpdateNextRunAt(ctx context.Context, id uuid.UUID, previous *time.Time, nextRunAt time.Time) (bool, error)
+
+	// Replaces the rule's definition and records it as a new version. Fails
+	// with ErrRuleVersionConflict if the rule is no longer at the version
+	// preceding the new one.
+	UpdateDefinition(ctx context.Context, rule *models.TradingRule, version *models.RuleVersion) error
+
+	// Lists a rule's versions, newest first
+	GetVersions(ctx context.Context, ruleID uuid.UUID) ([]models.RuleVersion, error)
+	GetVersion(ctx context.Context, ruleID uuid.UUID, version int) (*models.RuleVersion, error)
+
+	// Adds a batch of evaluations to the rule's metrics, returning the rule's
+	// consecutive errors after the batch
+	RecordMetrics(ctx context.Context, delta RuleMetricsDelta) (int, error)
+
+	// Returns the metrics of those of the rules that belong to userID and
+	// have been evaluated
+	GetMetrics(ctx context.Context, userID uuid.UUID, ruleIDs []uuid.UUID) ([]models.RuleMetrics, error)
+
+	// Sets an active rule's status to errored and restarts its count of
+	// consecutive errors, reporting whether the rule was active
+	QuarantineRule(ctx context.Context, id uuid.UUID) (bool, error)
+}
+
+// RuleMetricsDelta is a batch of evaluations of one rule, to be added to its
+// metrics
+type RuleMetricsDelta struct {
+	RuleID      uuid.UUID
+	Evaluations int64
+	Triggers    int64
+	Errors      int64
+
+	// Errors since the last successful evaluation in the batch. If the batch
+	// had a successful evaluation, the rule's consecutive errors restart from
+	// this count; otherwise they are added to it.
+	TrailingErrors int
+	Recovered      bool
+
+	TotalLatency    time.Duration
+	MaxLatency      time.Duration
+	LastEvaluatedAt time.Time
+
+	// The batch's last error, if it had one
+	LastError   *string
+	LastErrorAt *time.Time
 }
 
 type ruleRepository struct {
@@ -33,12 +115,33 @@ func NewRuleRepository(db *gorm.DB) RuleRepository {
 }
 
 func (r *ruleRepository) Create(ctx context.Context, rule *models.TradingRule) error {
-	return r.db.WithContext(ctx).Create(rule).Error
+	return conn(ctx, r.db).Create(rule).Error
+}
+
+func (r *ruleRepository) CreateWithVersion(ctx context.Context, rule *models.TradingRule, version *models.RuleVersion) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(rule).Error; err != nil {
+			return err
+		}
+		version.RuleID = rule.ID
+		return tx.Create(version).Error
+	})
 }
 
 func (r *ruleRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.TradingRule, error) {
 	var rule models.TradingRule
-	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&rule).Error; err != nil {
+	if err := conn(ctx, r.db).Where("id = ?", id).First(&rule).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRuleNotFound
+		}
+		return nil, err
+	}
+	return &rule, nil
+}
+
+func (r *ruleRepository) GetByIDAndUserID(ctx context.Context, id, userID uuid.UUID) (*models.TradingRule, error) {
+	var rule models.TradingRule
+	if err := conn(ctx, r.db).Where("id = ? AND user_id = ?", id, userID).First(&rule).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, ErrRuleNotFound
 		}
@@ -49,7 +152,7 @@ func (r *ruleRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Tra
 
 func (r *ruleRepository) GetByUserID(ctx context.Context, userID uuid.UUID) ([]models.TradingRule, error) {
 	var rules []models.TradingRule
-	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).Find(&rules).Error; err != nil {
+	if err := conn(ctx, r.db).Where("user_id = ?", userID).Find(&rules).Error; err != nil {
 		return nil, err
 	}
 	return rules, nil
@@ -57,14 +160,26 @@ func (r *ruleRepository) GetByUserID(ctx context.Context, userID uuid.UUID) ([]m
 
 func (r *ruleRepository) GetActiveRules(ctx context.Context) ([]models.TradingRule, error) {
 	var rules []models.TradingRule
-	if err := r.db.WithContext(ctx).Where("status = ?", "active").Find(&rules).Error; err != nil {
+	if err := conn(ctx, r.db).Where("status = ?", "active").Find(&rules).Error; err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+func (r *ruleRepository) GetDueScheduledRules(ctx context.Context, now time.Time) ([]models.TradingRule, error) {
+	var rules []models.TradingRule
+	if err := conn(ctx, r.db).
+		Where("status = ? AND rule_type = ?", "active", "scheduled").
+		Where("next_run_at IS NULL OR next_run_at <= ?", now).
+		Order("next_run_at").
+		Find(&rules).Error; err != nil {
 		return nil, err
 	}
 	return rules, nil
 }
 
 func (r *ruleRepository) Update(ctx context.Context, rule *models.TradingRule) error {
-	result := r.db.WithContext(ctx).Save(rule)
+	result := conn(ctx, r.db).Save(rule)
 	if result.Error != nil {
 		return result.Error
 	}
@@ -75,7 +190,53 @@ func (r *ruleRepository) Update(ctx context.Context, rule *models.TradingRule) e
 }
 
 func (r *ruleRepository) Delete(ctx context.Context, id uuid.UUID) error {
-	result := r.db.WithContext(ctx).Delete(&models.TradingRule{}, "id = ?", id)
+	result := conn(ctx, r.db).Delete(&models.TradingRule{}, "id = ?", id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrRuleNotFound
+	}
+	return nil
+}
+
+func (r *ruleRepository) RecordTrigger(ctx context.Context, rule *models.TradingRule, at time.Time, triggers int) error {
+	// Daily trigger counts start over at midnight UTC
+	day := at.UTC().Truncate(24 * time.Hour)
+
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.TradingRule{}).
+			Where("id = ?", rule.ID).
+			Updates(map[string]interface{}{
+				"last_triggered_at": at,
+				"trigger_count":     gorm.Expr("trigger_count + ?", triggers),
+				"triggers_today":    gorm.Expr("CASE WHEN last_triggered_at >= ? THEN triggers_today + ? ELSE ? END", day, triggers, triggers),
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrRuleNotFound
+		}
+
+		if !rule.OneShot {
+			return nil
+		}
+		return tx.Model(&models.TradingRule{}).
+			Where("id = ? AND status = ?", rule.ID, "active").
+			Update("status", "inactive").Error
+	})
+}
+
+func (r *ruleRepository) UpdateStatus(ctx context.Context, rule *models.TradingRule) error {
+	updates := map[string]interface{}{"status": rule.Status}
+	if rule.Status == "active" {
+		updates["next_run_at"] = rule.NextRunAt
+	}
+
+	result := conn(ctx, r.db).Model(&models.TradingRule{}).
+		Where("id = ?", rule.ID).
+		Updates(updates)
 	if result.Error != nil {
 		return result.Error
 	}
@@ -84,3 +245,152 @@ func (r *ruleRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	}
 	return nil
 }
+
+func (r *ruleRepository) UpdateLastExecutedAt(ctx context.Context, id uuid.UUID, executedAt time.Time) error {
+	result := conn(ctx, r.db).Model(&models.TradingRule{}).
+		Where("id = ?", id).
+		Update("last_executed_at", executedAt)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrRuleNotFound
+	}
+	return nil
+}
+
+func (r *ruleRepository) UpdateReferencePrice(ctx context.Context, id uuid.UUID, price float64) error {
+	result := conn(ctx, r.db).Model(&models.TradingRule{}).
+		Where("id = ?", id).
+		Update("reference_price", price)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrRuleNotFound
+	}
+	return nil
+}
+
+func (r *ruleRepository) UpdateNextRunAt(ctx context.Context, id uuid.UUID, previous *time.Time, nextRunAt time.Time) (bool, error) {
+	result := conn(ctx, r.db).Model(&models.TradingRule{}).
+		Where("id = ? AND next_run_at IS NOT DISTINCT FROM ?", id, previous).
+		Update("next_run_at", nextRunAt)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *ruleRepository) UpdateDefinition(ctx context.Context, rule *models.TradingRule, version *models.RuleVersion) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(rule).
+			Where("version = ?", version.Version-1).
+			Select(ruleDefinitionColumns).
+			Updates(rule)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrRuleVersionConflict
+		}
+
+		version.RuleID = rule.ID
+		return tx.Create(version).Error
+	})
+}
+
+func (r *ruleRepository) GetVersions(ctx context.Context, ruleID uuid.UUID) ([]models.RuleVersion, error) {
+	var versions []models.RuleVersion
+	if err := conn(ctx, r.db).Where("rule_id = ?", ruleID).Order("version DESC").Find(&versions).Error; err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+func (r *ruleRepository) GetVersion(ctx context.Context, ruleID uuid.UUID, version int) (*models.RuleVersion, error) {
+	var ruleVersion models.RuleVersion
+	if err := conn(ctx, r.db).Where("rule_id = ? AND version = ?", ruleID, version).First(&ruleVersion).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRuleVersionNotFound
+		}
+		return nil, err
+	}
+	return &ruleVersion, nil
+}
+
+func (r *ruleRepository) RecordMetrics(ctx context.Context, delta RuleMetricsDelta) (int, error) {
+	metrics := models.RuleMetrics{
+		RuleID:             delta.RuleID,
+		Evaluations:        delta.Evaluations,
+		Triggers:           delta.Triggers,
+		Errors:             delta.Errors,
+		ConsecutiveErrors:  delta.TrailingErrors,
+		TotalLatencyMicros: delta.TotalLatency.Microseconds(),
+		MaxLatencyMicros:   delta.MaxLatency.Microseconds(),
+		LastEvaluatedAt:    &delta.LastEvaluatedAt,
+		LastError:          delta.LastError,
+		LastErrorAt:        delta.LastErrorAt,
+	}
+
+	consecutiveErrors := gorm.Expr("rule_metrics.consecutive_errors + excluded.consecutive_errors")
+	if delta.Recovered {
+		consecutiveErrors = gorm.Expr("excluded.consecutive_errors")
+	}
+
+	// Counters from every engine instance accumulate in the same row
+	err := conn(ctx, r.db).
+		Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "rule_id"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"evaluations":          gorm.Expr("rule_metrics.evaluations + excluded.evaluations"),
+				"triggers":             gorm.Expr("rule_metrics.triggers + excluded.triggers"),
+				"errors":               gorm.Expr("rule_metrics.errors + excluded.errors"),
+				"consecutive_errors":   consecutiveErrors,
+				"total_latency_micros": gorm.Expr("rule_metrics.total_latency_micros + excluded.total_latency_micros"),
+				"max_latency_micros":   gorm.Expr("GREATEST(rule_metrics.max_latency_micros, excluded.max_latency_micros)"),
+				"last_evaluated_at":    gorm.Expr("GREATEST(rule_metrics.last_evaluated_at, excluded.last_evaluated_at)"),
+				"last_error":           gorm.Expr("COALESCE(excluded.last_error, rule_metrics.last_error)"),
+				"last_error_at":        gorm.Expr("COALESCE(excluded.last_error_at, rule_metrics.last_error_at)"),
+				"updated_at":           gorm.Expr("now()"),
+			}),
+		}, clause.Returning{Columns: []clause.Column{{Name: "consecutive_errors"}}}).
+		Create(&metrics).Error
+	if err != nil {
+		return 0, err
+	}
+	return metrics.ConsecutiveErrors, nil
+}
+
+func (r *ruleRepository) GetMetrics(ctx context.Context, userID uuid.UUID, ruleIDs []uuid.UUID) ([]models.RuleMetrics, error) {
+	var metrics []models.RuleMetrics
+	if len(ruleIDs) == 0 {
+		return metrics, nil
+	}
+	if err := conn(ctx, r.db).
+		Joins("JOIN trading_rules ON trading_rules.id = rule_metrics.rule_id").
+		Where("rule_metrics.rule_id IN ? AND trading_rules.user_id = ?", ruleIDs, userID).
+		Find(&metrics).Error; err != nil {
+		return nil, err
+	}
+	return metrics, nil
+}
+
+func (r *ruleRepository) QuarantineRule(ctx context.Context, id uuid.UUID) (bool, error) {
+	quarantined := false
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.TradingRule{}).
+			Where("id = ? AND status = ?", id, "active").
+			Update("status", "errored")
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		quarantined = true
+
+		// A reactivated rule starts a new run of errors
+		return tx.Model(&models.RuleMetrics{}).
+			Where("rule_id = ?", id).
+			Update("consecutive_errors", 0).Error
+	})
+	return quarantined, err
+}
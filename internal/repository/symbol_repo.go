@@ -0,0 +1,59 @@
+// internal/repository/symbol_repo.go
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/aquibsayyed9/sentinel/internal/models"
+)
+
+var (
+	ErrSymbolNotFound = errors.New("symbol not found")
+)
+
+// symbolBatchSize bounds the rows written by one insert, as a provider may
+// list thousands of symbols
+const symbolBatchSize = 1000
+
+// SymbolRepository stores the symbol master
+type SymbolRepository interface {
+	GetBySymbol(ctx context.Context, symbol string) (*models.Symbol, error)
+
+	// Adds the symbols, updating the source of any already listed
+	Upsert(ctx context.Context, symbols []models.Symbol) error
+}
+
+type symbolRepository struct {
+	db *gorm.DB
+}
+
+func NewSymbolRepository(db *gorm.DB) SymbolRepository {
+	return &symbolRepository{db: db}
+}
+
+func (r *symbolRepository) GetBySymbol(ctx context.Context, symbol string) (*models.Symbol, error) {
+	var s models.Symbol
+	if err := conn(ctx, r.db).Where("symbol = ?", symbol).First(&s).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSymbolNotFound
+		}
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *symbolRepository) Upsert(ctx context.Context, symbols []models.Symbol) error {
+	if len(symbols) == 0 {
+		return nil
+	}
+	return conn(ctx, r.db).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "symbol"}},
+			DoUpdates: clause.AssignmentColumns([]string{"source", "updated_at"}),
+		}).
+		CreateInBatches(symbols, symbolBatchSize).Error
+}
@@ -0,0 +1,41 @@
+// internal/repository/transaction.go
+package repository
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// Transactor runs units of work that span several repositories in one
+// database transaction
+type Transactor interface {
+	// Runs fn in a transaction, committing if it returns nil and rolling back
+	// otherwise. Repository calls made with the context fn receives take part
+	// in the transaction; nested calls run in a savepoint of the outer one.
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type txKey struct{}
+
+type transactor struct {
+	db *gorm.DB
+}
+
+func NewTransactor(db *gorm.DB) Transactor {
+	return &transactor{db: db}
+}
+
+func (t *transactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return conn(ctx, t.db).Transaction(func(tx *gorm.DB) error {
+		return fn(context.WithValue(ctx, txKey{}, tx))
+	})
+}
+
+// conn returns the transaction carried by ctx, or db outside a transaction
+func conn(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return tx.WithContext(ctx)
+	}
+	return db.WithContext(ctx)
+}
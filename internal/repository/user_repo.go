@@ -42,12 +42,12 @@ func (r *userRepository) Create(ctx context.Context, user *models.User) error {
 		return ErrEmailTaken
 	}
 
-	return r.db.WithContext(ctx).Create(user).Error
+	return conn(ctx, r.db).Create(user).Error
 }
 
 func (r *userRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
 	var user models.User
-	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&user).Error; err != nil {
+	if err := conn(ctx, r.db).Where("id = ?", id).First(&user).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, ErrUserNotFound
 		}
@@ -58,7 +58,7 @@ func (r *userRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Use
 
 func (r *userRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
 	var user models.User
-	if err := r.db.WithContext(ctx).Where("email = ?", email).First(&user).Error; err != nil {
+	if err := conn(ctx, r.db).Where("email = ?", email).First(&user).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, ErrUserNotFound
 		}
@@ -68,7 +68,7 @@ func (r *userRepository) GetByEmail(ctx context.Context, email string) (*models.
 }
 
 func (r *userRepository) Update(ctx context.Context, user *models.User) error {
-	result := r.db.WithContext(ctx).Save(user)
+	result := conn(ctx, r.db).Save(user)
 	if result.Error != nil {
 		return result.Error
 	}
@@ -79,7 +79,7 @@ func (r *userRepository) Update(ctx context.Context, user *models.User) error {
 }
 
 func (r *userRepository) Delete(ctx context.Context, id uuid.UUID) error {
-	result := r.db.WithContext(ctx).Delete(&models.User{}, "id = ?", id)
+	result := conn(ctx, r.db).Delete(&models.User{}, "id = ?", id)
 	if result.Error != nil {
 		return result.Error
 	}
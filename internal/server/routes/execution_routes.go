@@ -0,0 +1,17 @@
+// internal/server/routes/execution_routes.go
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/aquibsayyed9/sentinel/internal/handlers"
+)
+
+// SetupExecutionRoutes sets up all execution-related routes
+func SetupExecutionRoutes(router *gin.RouterGroup, executionHandler *handlers.ExecutionHandler) {
+	executions := router.Group("/executions")
+	{
+		executions.GET("", executionHandler.GetExecutions)
+		executions.GET("/:id", executionHandler.GetExecution)
+	}
+}
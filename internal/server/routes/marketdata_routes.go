@@ -0,0 +1,18 @@
+// internal/server/routes/marketdata_routes.go
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/aquibsayyed9/sentinel/internal/handlers"
+)
+
+// SetupMarketDataRoutes sets up all market data routes
+func SetupMarketDataRoutes(router *gin.RouterGroup, marketDataHandler *handlers.MarketDataHandler) {
+	market := router.Group("/market/:symbol")
+	{
+		market.GET("/price", marketDataHandler.GetLatestPrice)
+		market.GET("/quote", marketDataHandler.GetQuote)
+		market.GET("/bars", marketDataHandler.GetHistoricalData)
+	}
+}
@@ -0,0 +1,17 @@
+// internal/server/routes/notification_routes.go
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/aquibsayyed9/sentinel/internal/handlers"
+)
+
+// SetupNotificationRoutes sets up all notification-related routes
+func SetupNotificationRoutes(router *gin.RouterGroup, notificationHandler *handlers.NotificationHandler) {
+	notifications := router.Group("/notifications")
+	{
+		notifications.GET("", notificationHandler.GetNotifications)
+		notifications.PUT("/:id/read", notificationHandler.MarkRead)
+	}
+}
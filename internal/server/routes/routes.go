@@ -10,7 +10,9 @@ import (
 
 // Setup sets up all routes for the API
 func Setup(router *gin.Engine, authHandler *handlers.AuthHandler, ruleHandler *handlers.RuleHandler,
-	portfolioHandler *handlers.PortfolioHandler, tokenService auth.TokenService) {
+	portfolioHandler *handlers.PortfolioHandler, executionHandler *handlers.ExecutionHandler,
+	notificationHandler *handlers.NotificationHandler, marketDataHandler *handlers.MarketDataHandler,
+	tokenService auth.TokenService) {
 
 	// Health check route
 	router.GET("/health", func(c *gin.Context) {
@@ -35,6 +37,15 @@ func Setup(router *gin.Engine, authHandler *handlers.AuthHandler, ruleHandler *h
 		// Portfolio routes
 		SetupPortfolioRoutes(protected, portfolioHandler)
 
+		// Execution routes
+		SetupExecutionRoutes(protected, executionHandler)
+
+		// Notification routes
+		SetupNotificationRoutes(protected, notificationHandler)
+
+		// Market data routes
+		SetupMarketDataRoutes(protected, marketDataHandler)
+
 		// User profile
 		protected.GET("/profile", authHandler.GetProfile)
 	}
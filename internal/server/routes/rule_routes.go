@@ -13,9 +13,21 @@ func SetupRuleRoutes(router *gin.RouterGroup, ruleHandler *handlers.RuleHandler)
 	{
 		rules.POST("", ruleHandler.CreateRule)
 		rules.GET("", ruleHandler.GetRules)
+		rules.POST("/evaluate", ruleHandler.EvaluateUnsavedRule)
+		rules.POST("/from-template", ruleHandler.CreateRuleFromTemplate)
 		rules.GET("/:id", ruleHandler.GetRule)
+		rules.PATCH("/:id", ruleHandler.PatchRule)
+		rules.GET("/:id/versions", ruleHandler.GetRuleVersions)
+		rules.GET("/:id/versions/:version", ruleHandler.GetRuleVersion)
+		rules.POST("/:id/rollback", ruleHandler.RollbackRule)
 		rules.PUT("/:id/activate", ruleHandler.ActivateRule)
 		rules.PUT("/:id/deactivate", ruleHandler.DeactivateRule)
 		rules.DELETE("/:id", ruleHandler.DeleteRule)
+		rules.POST("/:id/evaluate", ruleHandler.EvaluateRule)
+		rules.GET("/:id/proposals", ruleHandler.GetRuleProposals)
+		rules.POST("/:id/proposals/:proposal_id/approve", ruleHandler.ApproveRuleProposal)
+		rules.POST("/:id/proposals/:proposal_id/reject", ruleHandler.RejectRuleProposal)
 	}
+
+	router.GET("/rule-templates", ruleHandler.GetRuleTemplates)
 }
@@ -23,7 +23,9 @@ type Server struct {
 
 // NewServer creates a new HTTP server
 func NewServer(cfg *config.Config, authHandler *handlers.AuthHandler, ruleHandler *handlers.RuleHandler,
-	portfolioHandler *handlers.PortfolioHandler, tokenService auth.TokenService) *Server {
+	portfolioHandler *handlers.PortfolioHandler, executionHandler *handlers.ExecutionHandler,
+	notificationHandler *handlers.NotificationHandler, marketDataHandler *handlers.MarketDataHandler,
+	tokenService auth.TokenService) *Server {
 
 	// Set Gin mode based on environment
 	if cfg.Environment == "production" {
@@ -33,7 +35,8 @@ func NewServer(cfg *config.Config, authHandler *handlers.AuthHandler, ruleHandle
 	router := gin.Default()
 
 	// Setup routes
-	routes.Setup(router, authHandler, ruleHandler, portfolioHandler, tokenService)
+	routes.Setup(router, authHandler, ruleHandler, portfolioHandler, executionHandler, notificationHandler,
+		marketDataHandler, tokenService)
 
 	// Create HTTP server
 	httpServer := &http.Server{
@@ -21,14 +21,14 @@ type ExecutionService interface {
 	// Create a new execution record
 	CreateExecution(ctx context.Context, execution *models.Execution) error
 
-	// Get an execution by its ID
-	GetExecutionByID(ctx context.Context, id uuid.UUID) (*models.Execution, error)
+	// Get one of the user's executions by its ID
+	GetExecutionByID(ctx context.Context, userID, id uuid.UUID) (*models.Execution, error)
 
 	// Get executions for a specific user with pagination
 	GetUserExecutions(ctx context.Context, userID uuid.UUID, page, pageSize int) ([]models.Execution, error)
 
-	// Get executions for a specific trading rule
-	GetRuleExecutions(ctx context.Context, ruleID uuid.UUID) ([]models.Execution, error)
+	// Get executions for one of the user's trading rules
+	GetRuleExecutions(ctx context.Context, userID, ruleID uuid.UUID) ([]models.Execution, error)
 
 	// Get recent executions across all users (for admin/monitoring purposes)
 	GetRecentExecutions(ctx context.Context, limit int) ([]models.Execution, error)
@@ -76,7 +76,7 @@ type SymbolStat struct {
 
 type executionService struct {
 	executionRepo repository.ExecutionRepository
-	ruleRepo      repository.RuleRepository // You'll need to implement this
+	ruleRepo      repository.RuleRepository
 }
 
 // NewExecutionService creates a new instance of execution service
@@ -112,8 +112,8 @@ func (s *executionService) CreateExecution(ctx context.Context, execution *model
 	return s.executionRepo.Create(ctx, execution)
 }
 
-func (s *executionService) GetExecutionByID(ctx context.Context, id uuid.UUID) (*models.Execution, error) {
-	return s.executionRepo.GetByID(ctx, id)
+func (s *executionService) GetExecutionByID(ctx context.Context, userID, id uuid.UUID) (*models.Execution, error) {
+	return s.executionRepo.GetByIDAndUserID(ctx, id, userID)
 }
 
 func (s *executionService) GetUserExecutions(ctx context.Context, userID uuid.UUID, page, pageSize int) ([]models.Execution, error) {
@@ -129,7 +129,10 @@ func (s *executionService) GetUserExecutions(ctx context.Context, userID uuid.UU
 	return s.executionRepo.GetByUserID(ctx, userID, pageSize, offset)
 }
 
-func (s *executionService) GetRuleExecutions(ctx context.Context, ruleID uuid.UUID) ([]models.Execution, error) {
+func (s *executionService) GetRuleExecutions(ctx context.Context, userID, ruleID uuid.UUID) ([]models.Execution, error) {
+	if _, err := s.ruleRepo.GetByIDAndUserID(ctx, ruleID, userID); err != nil {
+		return nil, err
+	}
 	return s.executionRepo.GetByRuleID(ctx, ruleID)
 }
 
@@ -257,9 +260,16 @@ func (s *executionService) GetUserExecutionStats(ctx context.Context, userID uui
 }
 
 func (s *executionService) ProcessExecution(ctx context.Context, execution *models.Execution) error {
-	// Validate the execution
-	if execution.UserID == uuid.Nil || execution.Symbol == "" ||
-		execution.Quantity <= 0 || execution.Price <= 0 {
+	// Validate the execution. Rule actions record the rule they acted on in
+	// place of a trade.
+	if execution.UserID == uuid.Nil || execution.Symbol == "" {
+		return ErrInvalidExecution
+	}
+	if IsRuleActionType(execution.ExecutionType) {
+		if execution.TargetRuleID == nil {
+			return ErrInvalidExecution
+		}
+	} else if execution.Quantity <= 0 || execution.Price <= 0 {
 		return ErrInvalidExecution
 	}
 
@@ -286,9 +296,6 @@ func (s *executionService) ProcessExecution(ctx context.Context, execution *mode
 			return err
 		}
 
-		// Update the rule's last execution time
-		// Note: This assumes you have a method to update last execution time in your rule repository
-		// You'll need to implement or adjust this based on your actual rule model
 		err = s.updateRuleExecutionTime(ctx, rule, execution.ExecutionTime)
 		if err != nil {
 			return err
@@ -299,11 +306,7 @@ func (s *executionService) ProcessExecution(ctx context.Context, execution *mode
 }
 
 // Helper method to update a rule's last execution time
-// Note: Implement this method based on your actual rule model and repository interface
-func (s *executionService) updateRuleExecutionTime(ctx context.Context, rule interface{}, executionTime time.Time) error {
-	// This is a placeholder - implement based on your actual rule model and repository
-	// For example:
-	// rule.LastExecutionTime = executionTime
-	// return s.ruleRepo.Update(ctx, rule)
-	return nil
+func (s *executionService) updateRuleExecutionTime(ctx context.Context, rule *models.TradingRule, executionTime time.Time) error {
+	rule.LastExecutedAt = &executionTime
+	return s.ruleRepo.UpdateLastExecutedAt(ctx, rule.ID, executionTime)
 }
@@ -0,0 +1,157 @@
+// internal/services/marketdata_cache.go
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/aquibsayyed9/sentinel/internal/models"
+	"github.com/aquibsayyed9/sentinel/internal/repository"
+)
+
+var (
+	ErrUnknownSymbol = errors.New("unknown symbol")
+)
+
+// defaultCacheEntries bounds each cache, so a client cycling through symbols
+// cannot grow it without limit
+const defaultCacheEntries = 10000
+
+// ttlCache holds values for a fixed time. Once full, expired entries are
+// evicted to make room, then arbitrary ones.
+type ttlCache[K comparable, V any] struct {
+	ttl        time.Duration
+	maxEntries int
+
+	mu      sync.Mutex
+	entries map[K]ttlEntry[V]
+}
+
+type ttlEntry[V any] struct {
+	value   V
+	expires time.Time
+}
+
+func newTTLCache[K comparable, V any](ttl time.Duration, maxEntries int) *ttlCache[K, V] {
+	return &ttlCache[K, V]{ttl: ttl, maxEntries: maxEntries, entries: make(map[K]ttlEntry[V])}
+}
+
+func (c *ttlCache[K, V]) get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok || time.Now().After(entry.expires) {
+		var zero V
+		return zero, false
+	}
+	return entry.value, true
+}
+
+func (c *ttlCache[K, V]) set(key K, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if _, ok := c.entries[key]; !ok && len(c.entries) >= c.maxEntries {
+		for k, entry := range c.entries {
+			if now.After(entry.expires) {
+				delete(c.entries, k)
+			}
+		}
+		for k := range c.entries {
+			if len(c.entries) < c.maxEntries {
+				break
+			}
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = ttlEntry[V]{value: value, expires: now.Add(c.ttl)}
+}
+
+// cached returns the value cached under key, or loads and caches it. Errors
+// are not cached.
+func cached[K comparable, V any](c *ttlCache[K, V], key K, load func() (V, error)) (V, error) {
+	if value, ok := c.get(key); ok {
+		return value, nil
+	}
+	value, err := load()
+	if err != nil {
+		return value, err
+	}
+	c.set(key, value)
+	return value, nil
+}
+
+// SymbolMaster looks up symbols in the symbol master
+type SymbolMaster interface {
+	// Returns ErrUnknownSymbol if the symbol is not listed
+	Lookup(ctx context.Context, symbol string) (*models.Symbol, error)
+}
+
+type symbolMaster struct {
+	symbolRepo repository.SymbolRepository
+	// Symbols looked up, with nil for those not listed
+	symbols *ttlCache[string, *models.Symbol]
+}
+
+// NewSymbolMaster creates a symbol master that caches lookups, including of
+// unknown symbols, for ttl
+func NewSymbolMaster(symbolRepo repository.SymbolRepository, ttl time.Duration) SymbolMaster {
+	return &symbolMaster{
+		symbolRepo: symbolRepo,
+		symbols:    newTTLCache[string, *models.Symbol](ttl, defaultCacheEntries),
+	}
+}
+
+func (m *symbolMaster) Lookup(ctx context.Context, symbol string) (*models.Symbol, error) {
+	s, err := cached(m.symbols, symbol, func() (*models.Symbol, error) {
+		s, err := m.symbolRepo.GetBySymbol(ctx, symbol)
+		if errors.Is(err, repository.ErrSymbolNotFound) {
+			return nil, nil
+		}
+		return s, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if s == nil {
+		return nil, ErrUnknownSymbol
+	}
+	return s, nil
+}
+
+// cachedMarketDataService serves the latest price and quote of each symbol
+// from a cache, so clients polling hot symbols do not each reach the
+// database. Everything else passes through.
+type cachedMarketDataService struct {
+	MarketDataService
+
+	prices *ttlCache[string, *models.MarketData]
+	quotes *ttlCache[string, *models.Quote]
+}
+
+// NewCachedMarketDataService wraps a market data service, caching latest
+// prices and quotes for ttl. Served prices may be up to ttl old, so it is for
+// API clients rather than rule evaluation.
+func NewCachedMarketDataService(marketDataService MarketDataService, ttl time.Duration) MarketDataService {
+	return &cachedMarketDataService{
+		MarketDataService: marketDataService,
+		prices:            newTTLCache[string, *models.MarketData](ttl, defaultCacheEntries),
+		quotes:            newTTLCache[string, *models.Quote](ttl, defaultCacheEntries),
+	}
+}
+
+func (s *cachedMarketDataService) GetPrice(ctx context.Context, symbol string) (*models.MarketData, error) {
+	return cached(s.prices, symbol, func() (*models.MarketData, error) {
+		return s.MarketDataService.GetPrice(ctx, symbol)
+	})
+}
+
+func (s *cachedMarketDataService) GetQuote(ctx context.Context, symbol string) (*models.Quote, error) {
+	return cached(s.quotes, symbol, func() (*models.Quote, error) {
+		return s.MarketDataService.GetQuote(ctx, symbol)
+	})
+}
@@ -3,28 +3,50 @@ package services
 
 import (
 	"context"
-	"fmt"
+	"errors"
 	"time"
 
+	"github.com/aquibsayyed9/sentinel/internal/calendar"
+	"github.com/aquibsayyed9/sentinel/internal/marketdata"
 	"github.com/aquibsayyed9/sentinel/internal/models"
 	"github.com/aquibsayyed9/sentinel/internal/repository"
 )
 
+var (
+	ErrInvalidDateRange = errors.New("end date must be after start date")
+)
+
+// aggregatePage is the longest span of minute bars loaded at once to build
+// bars of a longer time frame
+const aggregatePage = 7 * 24 * time.Hour
+
+// MarketDataService serves stored market data. Bars of a time frame that is
+// not stored are aggregated on demand from the stored minute bars, along
+// NYSE sessions; those still forming are left out.
 type MarketDataService interface {
 	GetPrice(ctx context.Context, symbol string) (*models.MarketData, error)
 	GetHistoricalData(ctx context.Context, symbol string, start, end time.Time, timeframe string) ([]models.MarketData, error)
 	GetQuote(ctx context.Context, symbol string) (*models.Quote, error)
+
+	// Up to limit bars of the time frame within [start, end], oldest first
+	GetBars(ctx context.Context, symbol, timeFrame string, start, end time.Time, limit int) ([]models.MarketData, error)
+
+	// Latest bar and quote at or before a time
+	GetPriceAt(ctx context.Context, symbol string, at time.Time) (*models.MarketData, error)
+	GetQuoteAt(ctx context.Context, symbol string, at time.Time) (*models.Quote, error)
 	// Additional methods for external data fetching would be added here
 }
 
 type marketDataService struct {
 	marketDataRepo repository.MarketDataRepository
+	aggregator     *marketdata.Aggregator
 	// You might add API clients for external data providers here
 }
 
 func NewMarketDataService(marketDataRepo repository.MarketDataRepository) MarketDataService {
 	return &marketDataService{
 		marketDataRepo: marketDataRepo,
+		aggregator:     marketdata.NewAggregator(calendar.NYSE()),
 	}
 }
 
@@ -34,11 +56,114 @@ func (s *marketDataService) GetPrice(ctx context.Context, symbol string) (*model
 
 func (s *marketDataService) GetHistoricalData(ctx context.Context, symbol string, start, end time.Time, timeframe string) ([]models.MarketData, error) {
 	if end.Before(start) {
-		return nil, fmt.Errorf("end date must be after start date")
+		return nil, ErrInvalidDateRange
+	}
+	data, err := s.marketDataRepo.GetHistoricalData(ctx, symbol, start, end, timeframe)
+	if err != nil || len(data) > 0 || timeframe == "" || timeframe == marketdata.DefaultBarTimeFrame {
+		return data, err
+	}
+	return s.aggregate(ctx, symbol, timeframe, start, end, 0)
+}
+
+func (s *marketDataService) GetBars(ctx context.Context, symbol, timeFrame string, start, end time.Time, limit int) ([]models.MarketData, error) {
+	if end.Before(start) {
+		return nil, ErrInvalidDateRange
 	}
-	return s.marketDataRepo.GetHistoricalData(ctx, symbol, start, end, timeframe)
+	data, err := s.marketDataRepo.GetBars(ctx, symbol, timeFrame, start, end, limit)
+	if err != nil || len(data) > 0 || timeFrame == marketdata.DefaultBarTimeFrame {
+		return data, err
+	}
+
+	return s.aggregate(ctx, symbol, timeFrame, start, end, limit)
+}
+
+// aggregate builds up to limit of the symbol's bars of a time frame within
+// [start, end] from its minute bars, or all of them if limit is 0. The minute
+// bars are loaded a page at a time, and no further once limit bars are built.
+func (s *marketDataService) aggregate(ctx context.Context, symbol, timeFrame string, start, end time.Time, limit int) ([]models.MarketData, error) {
+	frame, err := models.TimeFrameDuration(timeFrame)
+	if err != nil {
+		return nil, err
+	}
+	page := max(aggregatePage, frame)
+
+	asOf := time.Now()
+	data := make([]models.MarketData, 0)
+	for from, skip := start, true; !from.After(end); from = from.Add(page) {
+		// Stretches without minute bars, before the symbol was ingested or
+		// while ingestion was down, are skipped in one query rather than
+		// paged through
+		if skip {
+			next, err := s.nextBarStart(ctx, symbol, timeFrame, frame, from, end)
+			if err != nil || next.IsZero() {
+				return data, err
+			}
+			if next.After(from) {
+				from = next
+			}
+		}
+
+		to := from.Add(page)
+		// No bar spans more than its time frame, so the minute bars up to one
+		// frame past the page complete the last bar starting in it
+		minutes, err := s.marketDataRepo.GetHistoricalData(ctx, symbol, from, minTime(to, end).Add(frame), marketdata.DefaultBarTimeFrame)
+		if err != nil {
+			return nil, err
+		}
+		skip = len(minutes) == 0
+		bars, err := s.aggregator.Aggregate(minutes, timeFrame, asOf)
+		if err != nil {
+			return nil, err
+		}
+
+		// Bars starting before the page would miss the minute bars before it;
+		// the previous page built them
+		for _, bar := range bars {
+			if bar.Timestamp.Before(from) || !bar.Timestamp.Before(to) || bar.Timestamp.After(end) {
+				continue
+			}
+			data = append(data, bar)
+			if len(data) == limit {
+				return data, nil
+			}
+		}
+	}
+	return data, nil
+}
+
+// nextBarStart returns the start of the bar of a time frame holding the first
+// minute bar stored within [from, end], or the zero time if there is none
+func (s *marketDataService) nextBarStart(ctx context.Context, symbol, timeFrame string, frame time.Duration,
+	from, end time.Time) (time.Time, error) {
+
+	first, err := s.marketDataRepo.GetBars(ctx, symbol, marketdata.DefaultBarTimeFrame, from, end, 1)
+	if err != nil || len(first) == 0 {
+		return time.Time{}, err
+	}
+	// A minute bar outside the hours bars of the time frame cover is in no
+	// bar, but no later bar starts a frame or more before it
+	held, err := s.aggregator.Aggregate(first, timeFrame, first[0].Timestamp.Add(frame))
+	if err != nil || len(held) == 0 {
+		return first[0].Timestamp.Add(-frame), err
+	}
+	return held[0].Timestamp, nil
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
 }
 
 func (s *marketDataService) GetQuote(ctx context.Context, symbol string) (*models.Quote, error) {
 	return s.marketDataRepo.GetLatestQuote(ctx, symbol)
 }
+
+func (s *marketDataService) GetPriceAt(ctx context.Context, symbol string, at time.Time) (*models.MarketData, error) {
+	return s.marketDataRepo.GetPriceAt(ctx, symbol, at)
+}
+
+func (s *marketDataService) GetQuoteAt(ctx context.Context, symbol string, at time.Time) (*models.Quote, error) {
+	return s.marketDataRepo.GetQuoteAt(ctx, symbol, at)
+}
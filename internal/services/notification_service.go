@@ -0,0 +1,65 @@
+// internal/services/notification_service.go
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/aquibsayyed9/sentinel/internal/models"
+	"github.com/aquibsayyed9/sentinel/internal/repository"
+)
+
+// Kinds of notification sent to users
+const (
+	// A rule was set to errored after failing repeatedly
+	NotificationKindRuleQuarantined = "rule_quarantined"
+
+	// A strategy advisor proposed adjustments to an AI-managed rule, or
+	// applied them within the rule's bounds
+	NotificationKindRuleProposal = "rule_proposal"
+	NotificationKindRuleAdjusted = "rule_adjusted"
+)
+
+// Notifier delivers notifications to users
+type Notifier interface {
+	Notify(ctx context.Context, notification *models.Notification) error
+}
+
+// NotificationService stores notifications for users to read through the API
+type NotificationService interface {
+	Notifier
+
+	// Lists the user's notifications, newest first
+	GetNotifications(ctx context.Context, userID uuid.UUID, unreadOnly bool, page, pageSize int) ([]models.Notification, error)
+
+	// Marks one of the user's notifications as read
+	MarkRead(ctx context.Context, userID, id uuid.UUID) error
+}
+
+type notificationService struct {
+	notificationRepo repository.NotificationRepository
+}
+
+func NewNotificationService(notificationRepo repository.NotificationRepository) NotificationService {
+	return &notificationService{notificationRepo: notificationRepo}
+}
+
+func (s *notificationService) Notify(ctx context.Context, notification *models.Notification) error {
+	return s.notificationRepo.Create(ctx, notification)
+}
+
+func (s *notificationService) GetNotifications(ctx context.Context, userID uuid.UUID, unreadOnly bool, page, pageSize int) ([]models.Notification, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+	return s.notificationRepo.GetByUserID(ctx, userID, unreadOnly, pageSize, (page-1)*pageSize)
+}
+
+func (s *notificationService) MarkRead(ctx context.Context, userID, id uuid.UUID) error {
+	return s.notificationRepo.MarkRead(ctx, id, userID, time.Now())
+}
@@ -0,0 +1,551 @@
+// internal/services/rule_advisor.go
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/aquibsayyed9/sentinel/internal/models"
+	"github.com/aquibsayyed9/sentinel/internal/repository"
+)
+
+// DefaultAIMaxChangePercent bounds auto-applied adjustments of rules whose AI
+// management does not set its own bound
+const DefaultAIMaxChangePercent = 10
+
+// AIManagement lets a strategy advisor tune a rule's thresholds and
+// quantities. Rules with AI management are AI-managed.
+type AIManagement struct {
+	// Apply proposals without the owner's approval when every adjustment
+	// stays within MaxChangePercent of the value the owner set. Proposals
+	// that go further, or all proposals without AutoApply, wait for approval.
+	AutoApply bool `json:"auto_apply"`
+
+	// How far an auto-applied adjustment may move a parameter from the value
+	// the owner set, in percent; DefaultAIMaxChangePercent if 0
+	MaxChangePercent float64 `json:"max_change_percent,omitempty"`
+}
+
+// maxChange returns the bound on auto-applied adjustments as a fraction
+func (m AIManagement) maxChange() float64 {
+	if m.MaxChangePercent == 0 {
+		return DefaultAIMaxChangePercent / 100
+	}
+	return m.MaxChangePercent / 100
+}
+
+// Kinds of rule parameter an advisor may tune
+const (
+	// The threshold a leaf condition compares a market value against
+	ParameterKindThreshold = "threshold"
+
+	// The quantity or cash amount of a trade action
+	ParameterKindQuantity = "quantity"
+	ParameterKindNotional = "notional"
+)
+
+// RuleParameter is a number in a rule definition that an advisor may tune
+type RuleParameter struct {
+	// Path to the number in the rule definition, such as
+	// "conditions[0].any[1].value" or "actions[0].quantity"
+	Path string `json:"path"`
+	Kind string `json:"kind"`
+
+	// The symbol the parameter applies to, and for thresholds the condition
+	// type and operator they are compared with
+	Symbol   string `json:"symbol"`
+	Type     string `json:"type,omitempty"`
+	Operator string `json:"operator,omitempty"`
+
+	// The current value, and the value the owner set, which auto-applied
+	// adjustments are bounded by
+	Value    float64 `json:"value"`
+	Baseline float64 `json:"baseline"`
+}
+
+// ParameterAdjustment is a proposed change to one rule parameter
+type ParameterAdjustment struct {
+	Path   string  `json:"path"`
+	From   float64 `json:"from"`
+	To     float64 `json:"to"`
+	Reason string  `json:"reason"`
+}
+
+// AdvisorInput is what an advisor is told about an AI-managed rule
+type AdvisorInput struct {
+	Rule       RuleInput
+	Parameters []RuleParameter
+
+	// Recent bars of every symbol a parameter applies to, oldest first
+	Bars map[string][]models.MarketData
+
+	// How the rule has fared: its engine metrics, nil before its first
+	// evaluation, and its recent executions, newest first
+	Metrics    *models.RuleMetrics
+	Executions []models.Execution
+}
+
+// StrategyAdvisor proposes adjustments to the parameters of AI-managed rules.
+// Advisors are consulted periodically; proposing nothing leaves a rule as it is.
+type StrategyAdvisor interface {
+	// Name identifies the advisor on its proposals
+	Name() string
+
+	// Advise proposes adjustments to some of input.Parameters
+	Advise(ctx context.Context, input AdvisorInput) ([]ParameterAdjustment, error)
+}
+
+// ruleParameter is a tunable number in a rule definition and where it lives
+type ruleParameter struct {
+	RuleParameter
+	value *float64
+}
+
+// ruleParameters lists the numbers in a rule definition an advisor may tune:
+// the thresholds of leaf conditions compared against a constant, and the
+// quantities and amounts of trade actions. The parameters point into input,
+// so setting one changes the definition.
+func ruleParameters(input *RuleInput) []ruleParameter {
+	var params []ruleParameter
+	for i := range input.Conditions {
+		params = conditionParameters(params, &input.Conditions[i], fmt.Sprintf("conditions[%d]", i), input.Symbol)
+	}
+
+	for i := range input.Actions {
+		action := &input.Actions[i]
+		if action.Type != ActionTypeBuy && action.Type != ActionTypeSell {
+			continue
+		}
+		symbol := action.Symbol
+		if symbol == "" {
+			symbol = input.Symbol
+		}
+		path := fmt.Sprintf("actions[%d]", i)
+		if action.Quantity > 0 {
+			params = append(params, ruleParameter{
+				RuleParameter: RuleParameter{Path: path + ".quantity", Kind: ParameterKindQuantity, Symbol: symbol},
+				value:         &action.Quantity,
+			})
+		}
+		if action.Notional > 0 {
+			params = append(params, ruleParameter{
+				RuleParameter: RuleParameter{Path: path + ".notional", Kind: ParameterKindNotional, Symbol: symbol},
+				value:         &action.Notional,
+			})
+		}
+	}
+
+	for i := range params {
+		params[i].Value = *params[i].value
+		params[i].Baseline = params[i].Value
+	}
+	return params
+}
+
+func conditionParameters(params []ruleParameter, node *RuleCondition, path, ruleSymbol string) []ruleParameter {
+	switch {
+	case node.All != nil:
+		for i := range node.All {
+			params = conditionParameters(params, &node.All[i], fmt.Sprintf("%s.all[%d]", path, i), ruleSymbol)
+		}
+	case node.Any != nil:
+		for i := range node.Any {
+			params = conditionParameters(params, &node.Any[i], fmt.Sprintf("%s.any[%d]", path, i), ruleSymbol)
+		}
+	case node.Not != nil:
+		params = conditionParameters(params, node.Not, path+".not", ruleSymbol)
+	case node.CompareTo == nil && len(node.Legs) == 0 &&
+		node.Operator != OperatorCrossesAbove && node.Operator != OperatorCrossesBelow:
+
+		symbol := node.Symbol
+		if symbol == "" {
+			symbol = ruleSymbol
+		}
+		params = append(params, ruleParameter{
+			RuleParameter: RuleParameter{
+				Path:     path + ".value",
+				Kind:     ParameterKindThreshold,
+				Symbol:   symbol,
+				Type:     node.Type,
+				Operator: node.Operator,
+			},
+			value: &node.Value,
+		})
+	}
+	return params
+}
+
+// ruleParametersOf lists the tunable parameters of a stored rule, whose
+// definition is input, with the baselines the owner set
+func ruleParametersOf(rule *models.TradingRule, input *RuleInput) ([]ruleParameter, error) {
+	params := ruleParameters(input)
+	if len(rule.AIBaseline) == 0 {
+		return params, nil
+	}
+
+	var baseline map[string]float64
+	if err := json.Unmarshal(rule.AIBaseline, &baseline); err != nil {
+		return nil, err
+	}
+	for i := range params {
+		if value, ok := baseline[params[i].Path]; ok {
+			params[i].Baseline = value
+		}
+	}
+	return params, nil
+}
+
+// aiBaseline records the parameter values of a definition, keyed by path, as
+// the values auto-applied adjustments are bounded by
+func aiBaseline(input RuleInput) ([]byte, error) {
+	baseline := make(map[string]float64)
+	for _, param := range ruleParameters(&input) {
+		baseline[param.Path] = param.Value
+	}
+	return json.Marshal(baseline)
+}
+
+// decodeAIManagement returns a rule's AI management, or nil if the rule is
+// not AI-managed
+func decodeAIManagement(rule *models.TradingRule) (*AIManagement, error) {
+	if len(rule.AIManagement) == 0 {
+		return nil, nil
+	}
+	var management AIManagement
+	if err := json.Unmarshal(rule.AIManagement, &management); err != nil {
+		return nil, err
+	}
+	return &management, nil
+}
+
+// withinBounds reports whether every adjustment stays within the bound of
+// the rule's AI management, measured from the parameter's baseline
+func withinBounds(management AIManagement, params []ruleParameter, adjustments []ParameterAdjustment) bool {
+	for _, adjustment := range adjustments {
+		param := findParameter(params, adjustment.Path)
+		if param == nil {
+			return false
+		}
+		if param.Baseline == 0 {
+			if adjustment.To != 0 {
+				return false
+			}
+			continue
+		}
+		if math.Abs(adjustment.To-param.Baseline)/math.Abs(param.Baseline) > management.maxChange()+floatTolerance {
+			return false
+		}
+	}
+	return true
+}
+
+func findParameter(params []ruleParameter, path string) *ruleParameter {
+	for i := range params {
+		if params[i].Path == path {
+			return &params[i]
+		}
+	}
+	return nil
+}
+
+// aiManagement checks a rule's AI management
+func (v *ruleValidator) aiManagement(management *AIManagement) {
+	if management == nil {
+		return
+	}
+	if management.MaxChangePercent < 0 || management.MaxChangePercent > 100 {
+		v.add("ai_management.max_change_percent", "must be between 0 and 100")
+	}
+}
+
+// validAdjustments keeps the adjustments that change a parameter of the rule
+// to a usable value, recording the value each changes from. Trade sizes
+// must stay positive.
+func validAdjustments(params []ruleParameter, adjustments []ParameterAdjustment) []ParameterAdjustment {
+	var valid []ParameterAdjustment
+	seen := make(map[string]bool)
+	for _, adjustment := range adjustments {
+		param := findParameter(params, adjustment.Path)
+		if param == nil || seen[adjustment.Path] || math.IsNaN(adjustment.To) || math.IsInf(adjustment.To, 0) {
+			continue
+		}
+		if param.Kind != ParameterKindThreshold && adjustment.To <= 0 {
+			continue
+		}
+		if math.Abs(adjustment.To-param.Value) < floatTolerance {
+			continue
+		}
+
+		seen[adjustment.Path] = true
+		adjustment.From = param.Value
+		valid = append(valid, adjustment)
+	}
+	return valid
+}
+
+// RuleAdvisorConfig tunes how AI-managed rules are reviewed
+type RuleAdvisorConfig struct {
+	// How often every AI-managed rule is reviewed
+	Interval time.Duration
+
+	// The time frame and number of the bars advisors are given
+	TimeFrame string
+	Bars      int
+
+	// Decides which rules this instance reviews; nil reviews every rule
+	Coordinator RuleCoordinator
+
+	// Called when an advisor proposes adjustments to a rule, after the
+	// proposal has been applied if it could be
+	OnProposal func(rule *models.TradingRule, proposal *models.RuleProposal)
+
+	// Called when a rule fails to be reviewed, with a nil rule if the rules
+	// could not be loaded
+	OnError func(rule *models.TradingRule, err error)
+}
+
+// RuleAdvisor periodically asks a strategy advisor to review the active
+// AI-managed rules. Each set of adjustments it proposes is stored for the
+// owner to approve, replacing any proposal still pending, or applied
+// straight away if the rule allows it and every adjustment is within the
+// rule's bounds. The owner is notified either way.
+type RuleAdvisor struct {
+	advisor       StrategyAdvisor
+	ruleRepo      repository.RuleRepository
+	proposals     *ruleProposalService
+	marketData    MarketDataService
+	executionRepo repository.ExecutionRepository
+	notifier      Notifier
+	transactor    repository.Transactor
+	config        RuleAdvisorConfig
+}
+
+// advisorExecutionLimit caps how many of a rule's executions advisors are given
+const advisorExecutionLimit = 50
+
+// NewRuleAdvisor creates a rule advisor, applying defaults to unset config values
+func NewRuleAdvisor(advisor StrategyAdvisor, ruleRepo repository.RuleRepository,
+	proposalRepo repository.RuleProposalRepository, marketData MarketDataService,
+	executionRepo repository.ExecutionRepository, notifier Notifier, transactor repository.Transactor,
+	config RuleAdvisorConfig) *RuleAdvisor {
+
+	if config.Interval <= 0 {
+		config.Interval = time.Hour
+	}
+	if config.TimeFrame == "" {
+		config.TimeFrame = DefaultIndicatorTimeFrame
+	}
+	if config.Bars <= 0 {
+		config.Bars = 60
+	}
+	if config.Coordinator == nil {
+		config.Coordinator = standaloneCoordinator{}
+	}
+	if config.OnProposal == nil {
+		config.OnProposal = func(*models.TradingRule, *models.RuleProposal) {}
+	}
+	if config.OnError == nil {
+		config.OnError = func(*models.TradingRule, error) {}
+	}
+
+	return &RuleAdvisor{
+		advisor:       advisor,
+		ruleRepo:      ruleRepo,
+		proposals:     newRuleProposalService(ruleRepo, proposalRepo, transactor),
+		marketData:    marketData,
+		executionRepo: executionRepo,
+		notifier:      notifier,
+		transactor:    transactor,
+		config:        config,
+	}
+}
+
+// Run reviews the AI-managed rules periodically until ctx is cancelled
+func (a *RuleAdvisor) Run(ctx context.Context) error {
+	ticker := time.NewTicker(a.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := a.AdviseRules(ctx); err != nil && ctx.Err() == nil {
+				a.config.OnError(nil, err)
+			}
+		}
+	}
+}
+
+// AdviseRules reviews the active AI-managed rules this instance owns
+func (a *RuleAdvisor) AdviseRules(ctx context.Context) error {
+	rules, err := a.ruleRepo.GetActiveRules(ctx)
+	if err != nil {
+		return err
+	}
+
+	for i := range rules {
+		rule := &rules[i]
+		if !rule.IsAIManaged || !a.config.Coordinator.Owns(rule.ID) {
+			continue
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		proposal, err := a.AdviseRule(ctx, rule)
+		if err != nil {
+			a.config.OnError(rule, err)
+			continue
+		}
+		if proposal != nil {
+			a.config.OnProposal(rule, proposal)
+		}
+	}
+	return nil
+}
+
+// AdviseRule asks the advisor to review one rule, returning the proposal it
+// made, or nil if it proposed nothing or the rule is not AI-managed
+func (a *RuleAdvisor) AdviseRule(ctx context.Context, rule *models.TradingRule) (*models.RuleProposal, error) {
+	management, err := decodeAIManagement(rule)
+	if err != nil || management == nil {
+		return nil, err
+	}
+
+	input, err := RuleInputOf(rule)
+	if err != nil {
+		return nil, err
+	}
+	params, err := ruleParametersOf(rule, &input)
+	if err != nil || len(params) == 0 {
+		return nil, err
+	}
+
+	advisorInput, err := a.advisorInput(ctx, rule, input, params)
+	if err != nil {
+		return nil, err
+	}
+	adjustments, err := a.advisor.Advise(ctx, advisorInput)
+	if err != nil {
+		return nil, fmt.Errorf("advisor %s failed: %w", a.advisor.Name(), err)
+	}
+	if adjustments = validAdjustments(params, adjustments); len(adjustments) == 0 {
+		return nil, nil
+	}
+
+	adjustmentsJSON, err := json.Marshal(adjustments)
+	if err != nil {
+		return nil, err
+	}
+	proposal := &models.RuleProposal{
+		RuleID:      rule.ID,
+		UserID:      rule.UserID,
+		RuleVersion: rule.Version,
+		Advisor:     a.advisor.Name(),
+		Adjustments: adjustmentsJSON,
+		Status:      RuleProposalStatusPending,
+	}
+	autoApply := management.AutoApply && withinBounds(*management, params, adjustments)
+
+	err = a.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := a.proposals.proposalRepo.SupersedePending(ctx, rule.ID, time.Now()); err != nil {
+			return err
+		}
+		if err := a.proposals.proposalRepo.Create(ctx, proposal); err != nil {
+			return err
+		}
+		if autoApply {
+			if _, err := a.proposals.apply(ctx, rule, proposal, true); err != nil {
+				return err
+			}
+		}
+		return a.notifier.Notify(ctx, proposalNotification(rule, proposal, adjustments))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return proposal, nil
+}
+
+// advisorInput gathers what the advisor is told about a rule
+func (a *RuleAdvisor) advisorInput(ctx context.Context, rule *models.TradingRule, input RuleInput,
+	params []ruleParameter) (AdvisorInput, error) {
+
+	frame, err := models.TimeFrameDuration(a.config.TimeFrame)
+	if err != nil {
+		return AdvisorInput{}, err
+	}
+	end := time.Now()
+	start := end.Add(-warmupWindow(frame, a.config.Bars))
+
+	advisorInput := AdvisorInput{
+		Rule:       input,
+		Parameters: make([]RuleParameter, len(params)),
+		Bars:       make(map[string][]models.MarketData),
+	}
+	for i, param := range params {
+		advisorInput.Parameters[i] = param.RuleParameter
+		if _, ok := advisorInput.Bars[param.Symbol]; ok {
+			continue
+		}
+
+		bars, err := a.marketData.GetHistoricalData(ctx, param.Symbol, start, end, a.config.TimeFrame)
+		if err != nil {
+			return AdvisorInput{}, err
+		}
+		if len(bars) > a.config.Bars {
+			bars = bars[len(bars)-a.config.Bars:]
+		}
+		advisorInput.Bars[param.Symbol] = bars
+	}
+
+	metrics, err := a.ruleRepo.GetMetrics(ctx, rule.UserID, []uuid.UUID{rule.ID})
+	if err != nil {
+		return AdvisorInput{}, err
+	}
+	if len(metrics) > 0 {
+		advisorInput.Metrics = &metrics[0]
+	}
+
+	executions, err := a.executionRepo.GetByRuleID(ctx, rule.ID)
+	if err != nil {
+		return AdvisorInput{}, err
+	}
+	if len(executions) > advisorExecutionLimit {
+		executions = executions[:advisorExecutionLimit]
+	}
+	advisorInput.Executions = executions
+
+	return advisorInput, nil
+}
+
+// proposalNotification tells a rule's owner about a proposal for it
+func proposalNotification(rule *models.TradingRule, proposal *models.RuleProposal,
+	adjustments []ParameterAdjustment) *models.Notification {
+
+	changes := make([]string, len(adjustments))
+	for i, adjustment := range adjustments {
+		changes[i] = fmt.Sprintf("%s from %g to %g", adjustment.Path, adjustment.From, adjustment.To)
+	}
+
+	notification := &models.Notification{
+		UserID: rule.UserID,
+		RuleID: &rule.ID,
+		Kind:   NotificationKindRuleProposal,
+		Message: fmt.Sprintf("The %s advisor proposed changing %s on rule %q. Approve or reject the proposal.",
+			proposal.Advisor, strings.Join(changes, ", "), rule.Name),
+	}
+	if proposal.Status == RuleProposalStatusApplied {
+		notification.Kind = NotificationKindRuleAdjusted
+		notification.Message = fmt.Sprintf("The %s advisor changed %s on rule %q, within the bounds you set.",
+			proposal.Advisor, strings.Join(changes, ", "), rule.Name)
+	}
+	return notification
+}
@@ -0,0 +1,147 @@
+// internal/services/rule_advisor_volatility.go
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/aquibsayyed9/sentinel/internal/models"
+)
+
+// VolatilityAdvisorConfig tunes the volatility advisor
+type VolatilityAdvisorConfig struct {
+	// Number of returns recent and long-run volatility are measured over
+	ShortWindow int
+	LongWindow  int
+
+	// Bounds on how far parameters are scaled from the values the owner set
+	MinScale float64
+	MaxScale float64
+
+	// Changes smaller than this percent of the current value are not proposed
+	MinChangePercent float64
+}
+
+// volatilityAdvisor scales rule parameters with the market's volatility.
+// When recent volatility runs above its long-run level, price thresholds
+// move further from the last close and trade sizes shrink; when the market
+// is calmer than usual, thresholds move closer and sizes grow. Parameters
+// are scaled from the values the owner set, so repeated reviews do not
+// compound. It is deterministic and needs only locally stored bars.
+type volatilityAdvisor struct {
+	config VolatilityAdvisorConfig
+}
+
+// NewVolatilityAdvisor creates a volatility advisor, applying defaults to
+// unset config values
+func NewVolatilityAdvisor(config VolatilityAdvisorConfig) StrategyAdvisor {
+	if config.ShortWindow <= 0 {
+		config.ShortWindow = 10
+	}
+	if config.LongWindow <= config.ShortWindow {
+		config.LongWindow = 5 * config.ShortWindow
+	}
+	if config.MinScale <= 0 {
+		config.MinScale = 0.5
+	}
+	if config.MaxScale < config.MinScale {
+		config.MaxScale = 2
+	}
+	if config.MinChangePercent <= 0 {
+		config.MinChangePercent = 1
+	}
+	return &volatilityAdvisor{config: config}
+}
+
+func (a *volatilityAdvisor) Name() string {
+	return "volatility"
+}
+
+func (a *volatilityAdvisor) Advise(ctx context.Context, input AdvisorInput) ([]ParameterAdjustment, error) {
+	var adjustments []ParameterAdjustment
+	for _, param := range input.Parameters {
+		scale, last, ok := a.scale(input.Bars[param.Symbol])
+		if !ok {
+			continue
+		}
+
+		var to float64
+		switch param.Kind {
+		case ParameterKindThreshold:
+			// Only price levels sit at a distance from the price
+			if param.Type != ConditionTypePrice && param.Type != ConditionTypeBid && param.Type != ConditionTypeAsk {
+				continue
+			}
+			to = roundTo(last+(param.Baseline-last)*scale, 2)
+		case ParameterKindQuantity:
+			to = param.Baseline / scale
+			if param.Baseline == math.Trunc(param.Baseline) {
+				to = math.Max(1, math.Round(to))
+			}
+		case ParameterKindNotional:
+			to = roundTo(param.Baseline/scale, 2)
+		default:
+			continue
+		}
+
+		if param.Value == 0 || math.Abs(to-param.Value)/math.Abs(param.Value)*100 < a.config.MinChangePercent {
+			continue
+		}
+		adjustments = append(adjustments, ParameterAdjustment{
+			Path: param.Path,
+			From: param.Value,
+			To:   to,
+			Reason: fmt.Sprintf("%s volatility over the last %d bars is %.2fx its %d-bar level",
+				param.Symbol, a.config.ShortWindow, scale, a.config.LongWindow),
+		})
+	}
+	return adjustments, nil
+}
+
+// scale returns the ratio of recent to long-run volatility of the bars'
+// closes, clamped to the configured bounds, and the last close. It reports
+// false if there are too few bars to measure.
+func (a *volatilityAdvisor) scale(bars []models.MarketData) (float64, float64, bool) {
+	if len(bars) < a.config.LongWindow+1 {
+		return 0, 0, false
+	}
+	bars = bars[len(bars)-a.config.LongWindow-1:]
+
+	returns := make([]float64, 0, a.config.LongWindow)
+	for i := 1; i < len(bars); i++ {
+		if bars[i-1].Close <= 0 || bars[i].Close <= 0 {
+			return 0, 0, false
+		}
+		returns = append(returns, math.Log(bars[i].Close/bars[i-1].Close))
+	}
+
+	long := stddev(returns)
+	if long == 0 {
+		return 0, 0, false
+	}
+	short := stddev(returns[len(returns)-a.config.ShortWindow:])
+
+	scale := math.Min(math.Max(short/long, a.config.MinScale), a.config.MaxScale)
+	return scale, bars[len(bars)-1].Close, true
+}
+
+// stddev returns the population standard deviation of values
+func stddev(values []float64) float64 {
+	var mean float64
+	for _, v := range values {
+		mean += v
+	}
+	mean /= float64(len(values))
+
+	var variance float64
+	for _, v := range values {
+		variance += (v - mean) * (v - mean)
+	}
+	return math.Sqrt(variance / float64(len(values)))
+}
+
+func roundTo(value float64, decimals int) float64 {
+	factor := math.Pow(10, float64(decimals))
+	return math.Round(value*factor) / factor
+}
@@ -0,0 +1,197 @@
+// internal/services/rule_chaining.go
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aquibsayyed9/sentinel/internal/models"
+)
+
+// Action types that act on the user's rules rather than trading. They run in
+// the same transaction as the rule's trades and are recorded in the execution
+// history with the rule they acted on.
+const (
+	ActionTypeActivateRule   = "activate_rule"
+	ActionTypeDeactivateRule = "deactivate_rule"
+
+	// Creates a follow-up rule, such as a stop-loss after an entry. A stop on
+	// the new rule without a reference price is measured from the fill price
+	// of the last trade the action's rule made in the symbol the stop guards.
+	ActionTypeCreateRule = "create_rule"
+)
+
+// MaxRuleChainDepth is how deeply create_rule actions may nest follow-up rules
+const MaxRuleChainDepth = 3
+
+// IsRuleActionType reports whether an action or execution type acts on a rule
+// rather than trading
+func IsRuleActionType(actionType string) bool {
+	switch actionType {
+	case ActionTypeActivateRule, ActionTypeDeactivateRule, ActionTypeCreateRule:
+		return true
+	}
+	return false
+}
+
+// executeRuleAction activates, deactivates or creates a rule owned by the
+// rule's user and records it as an execution. fills holds the fill of the
+// last executed trade in each symbol so far in this execution of the rule.
+func (s *ruleEngineService) executeRuleAction(ctx context.Context, rule *models.TradingRule,
+	action RuleAction, fills map[string]*models.Execution) error {
+
+	var target *models.TradingRule
+	switch action.Type {
+	case ActionTypeActivateRule, ActionTypeDeactivateRule:
+		if action.RuleID == nil {
+			return fmt.Errorf("%w: %s requires a rule_id", ErrInvalidRuleAction, action.Type)
+		}
+		var err error
+		target, err = s.ruleRepo.GetByIDAndUserID(ctx, *action.RuleID, rule.UserID)
+		if err != nil {
+			return err
+		}
+
+		status := "inactive"
+		if action.Type == ActionTypeActivateRule {
+			status = "active"
+		}
+		if target.Status != status {
+			if status == "active" {
+				if err := activateRule(target, time.Now()); err != nil {
+					return err
+				}
+			} else {
+				target.Status = status
+			}
+			if err := s.ruleRepo.UpdateStatus(ctx, target); err != nil {
+				return err
+			}
+		}
+
+	case ActionTypeCreateRule:
+		if action.Rule == nil {
+			return fmt.Errorf("%w: %s requires a rule", ErrInvalidRuleAction, action.Type)
+		}
+		input := followUpRule(*action.Rule, fills)
+
+		var err error
+		target, err = buildRule(rule.UserID, input)
+		if err != nil {
+			return err
+		}
+		version, err := newRuleVersion(target, rule.UserID, nil)
+		if err != nil {
+			return err
+		}
+		if err := s.ruleRepo.CreateWithVersion(ctx, target, version); err != nil {
+			return err
+		}
+
+	default:
+		return fmt.Errorf("%w: %q", ErrUnsupportedAction, action.Type)
+	}
+
+	ruleVersion := rule.Version
+	execution := &models.Execution{
+		RuleID:        &rule.ID,
+		RuleVersion:   &ruleVersion,
+		UserID:        rule.UserID,
+		Symbol:        target.Symbol,
+		ExecutionType: action.Type,
+		Status:        ExecutionStatusExecuted,
+		ExecutionTime: time.Now(),
+		TargetRuleID:  &target.ID,
+	}
+	return s.executionService.ProcessExecution(ctx, execution)
+}
+
+// previewRuleAction describes the rule an action would act on, checking that
+// a rule it activates or deactivates exists and belongs to the rule's user
+func (s *ruleEngineService) previewRuleAction(ctx context.Context, rule *models.TradingRule, action RuleAction) ActionPreview {
+	preview := ActionPreview{Type: action.Type, RuleID: action.RuleID}
+
+	switch {
+	case action.Type == ActionTypeCreateRule && action.Rule != nil:
+		preview.Symbol = action.Rule.Symbol
+	case action.RuleID != nil:
+		target, err := s.ruleRepo.GetByIDAndUserID(ctx, *action.RuleID, rule.UserID)
+		if err != nil {
+			preview.Error = err.Error()
+			return preview
+		}
+		preview.Symbol = target.Symbol
+	default:
+		preview.Error = fmt.Sprintf("%v: %s is missing the rule it acts on", ErrInvalidRuleAction, action.Type)
+	}
+	return preview
+}
+
+// followUpRule returns the rule a create_rule action creates, measuring its
+// stop from the fill price of the trade that preceded it
+func followUpRule(input RuleInput, fills map[string]*models.Execution) RuleInput {
+	if input.Stop == nil || input.Stop.Price > 0 || input.Stop.ReferencePrice > 0 {
+		return input
+	}
+	fill, ok := fills[input.Symbol]
+	if !ok {
+		return input
+	}
+
+	stop := *input.Stop
+	stop.ReferencePrice = fill.Price
+	input.Stop = &stop
+	return input
+}
+
+// ruleAction checks an action that acts on a rule
+func (v *ruleValidator) ruleAction(action RuleAction, path string) {
+	if action.Symbol != "" || action.Quantity != 0 || action.Notional != 0 || action.OrderType != "" ||
+		action.Limit != 0 || action.Stop != 0 || isRelativeAction(action) {
+		v.add(path, "%s actions do not trade, so take no symbol, quantity or order", action.Type)
+	}
+
+	if action.Type != ActionTypeCreateRule {
+		if action.RuleID == nil {
+			v.add(path+".rule_id", "is required")
+		}
+		if action.Rule != nil {
+			v.add(path+".rule", "only applies to %s actions", ActionTypeCreateRule)
+		}
+		return
+	}
+
+	if action.RuleID != nil {
+		v.add(path+".rule_id", "does not apply to %s actions", ActionTypeCreateRule)
+	}
+	if action.Rule == nil {
+		v.add(path+".rule", "is required")
+		return
+	}
+	if v.depth+1 > MaxRuleChainDepth {
+		v.add(path+".rule", "follow-up rules may be nested at most %d levels deep", MaxRuleChainDepth)
+		return
+	}
+
+	// The follow-up rule is checked as a rule of its own, with its errors
+	// reported under this action
+	nested := &ruleValidator{depth: v.depth + 1}
+	input := *action.Rule
+	if input.Expression != "" {
+		compiled, err := compileExpression(input)
+		var validationErr *ValidationError
+		if errors.As(err, &validationErr) {
+			nested.fields = append(nested.fields, validationErr.Fields...)
+		}
+		input = compiled
+	}
+	if input.Expression == "" {
+		nested.rule(input)
+	}
+	for _, field := range nested.fields {
+		field.Field = path + ".rule." + field.Field
+		v.fields = append(v.fields, field)
+	}
+}
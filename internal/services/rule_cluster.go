@@ -0,0 +1,296 @@
+// internal/services/rule_cluster.go
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/aquibsayyed9/sentinel/internal/models"
+	"github.com/aquibsayyed9/sentinel/internal/repository"
+)
+
+// RuleCoordinator decides which rules an engine instance evaluates, so that
+// several instances can share the rules of one database
+type RuleCoordinator interface {
+	// Owns reports whether this instance is responsible for the rule
+	Owns(ruleID uuid.UUID) bool
+
+	// Exclusive runs fn unless the rule is already being evaluated elsewhere,
+	// reporting whether fn ran
+	Exclusive(ctx context.Context, ruleID uuid.UUID, fn func() error) (bool, error)
+}
+
+// standaloneCoordinator is the coordinator of an engine running on its own,
+// which owns every rule
+type standaloneCoordinator struct{}
+
+func (standaloneCoordinator) Owns(uuid.UUID) bool { return true }
+
+func (standaloneCoordinator) Exclusive(ctx context.Context, ruleID uuid.UUID, fn func() error) (bool, error) {
+	return true, fn()
+}
+
+// processExclusive evaluates a rule through the coordinator, recording the
+// evaluation in metrics unless metrics is nil. The rule is reloaded once its
+// lock is held, since the copy passed in may predate a trigger, edit or
+// deactivation by another worker. A rule another worker is evaluating, or that
+// is no longer active, is skipped, and reported as not triggered. So is a rule
+// still waiting for the market data it reads.
+func processExclusive(ctx context.Context, coordinator RuleCoordinator, metrics *RuleMetrics,
+	ruleRepo repository.RuleRepository, engine RuleEngineService, rule *models.TradingRule) (bool, error) {
+
+	var triggered bool
+	_, err := coordinator.Exclusive(ctx, rule.ID, func() error {
+		current, err := ruleRepo.GetByID(ctx, rule.ID)
+		if errors.Is(err, repository.ErrRuleNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if current.Status != "active" {
+			return nil
+		}
+
+		started := time.Now()
+		triggered, err = engine.ProcessRule(ctx, current)
+		if metrics != nil && ctx.Err() == nil {
+			metrics.Observe(current, triggered, err, time.Since(started))
+		}
+		if !triggered && awaitingMarketData(err) {
+			return nil
+		}
+		return err
+	})
+	return triggered, err
+}
+
+// RuleClusterConfig tunes how an engine instance coordinates with the others
+type RuleClusterConfig struct {
+	// Identifies the instance in logs. Defaults to the host name and process ID.
+	Name string
+
+	// How often the instance renews its lease and reloads the live instances
+	HeartbeatInterval time.Duration
+
+	// How long a lease lasts without renewal before the instance is treated
+	// as failed and its rules are reassigned. Should span several heartbeats.
+	LeaseTTL time.Duration
+
+	// Called when the set of live instances changes, with whether this
+	// instance is now the leader
+	OnMembershipChange func(instances []models.EngineInstance, leader bool)
+
+	// Called when a heartbeat or lease cleanup fails
+	OnError func(err error)
+}
+
+// RuleCluster shards rules across the engine instances running against one
+// database. Instances hold leases renewed by heartbeat, and each rule is
+// owned by one live instance chosen by rendezvous hashing, so when an
+// instance joins or fails only the rules it owns change hands. The oldest
+// live instance leads and removes expired leases.
+//
+// Ownership only changes as instances renew their leases, so two instances
+// may briefly both own a rule; Exclusive holds a database lock on the rule
+// for the duration of an evaluation, so it is never evaluated concurrently.
+type RuleCluster struct {
+	repo   repository.ClusterRepository
+	config RuleClusterConfig
+	id     uuid.UUID
+
+	mu        sync.RWMutex
+	members   []uuid.UUID
+	leader    bool
+	renewedAt time.Time
+}
+
+// NewRuleCluster creates an instance's view of the cluster, applying defaults
+// to unset config values. The instance joins the cluster on its first heartbeat.
+func NewRuleCluster(repo repository.ClusterRepository, config RuleClusterConfig) *RuleCluster {
+	if config.Name == "" {
+		host, _ := os.Hostname()
+		config.Name = fmt.Sprintf("%s:%d", host, os.Getpid())
+	}
+	if config.HeartbeatInterval <= 0 {
+		config.HeartbeatInterval = 5 * time.Second
+	}
+	if config.LeaseTTL <= 0 {
+		config.LeaseTTL = 4 * config.HeartbeatInterval
+	}
+	if config.OnMembershipChange == nil {
+		config.OnMembershipChange = func([]models.EngineInstance, bool) {}
+	}
+	if config.OnError == nil {
+		config.OnError = func(error) {}
+	}
+
+	return &RuleCluster{
+		repo:   repo,
+		config: config,
+		id:     uuid.New(),
+	}
+}
+
+// ID returns the instance's ID in the cluster
+func (c *RuleCluster) ID() uuid.UUID {
+	return c.id
+}
+
+// Name returns the name identifying the instance in logs
+func (c *RuleCluster) Name() string {
+	return c.config.Name
+}
+
+// Join registers the instance and loads the live instances, so that it owns
+// its share of the rules before it starts evaluating them
+func (c *RuleCluster) Join(ctx context.Context) error {
+	return c.Heartbeat(ctx)
+}
+
+// Run renews the instance's lease until ctx is cancelled, then leaves the
+// cluster so that its rules are reassigned without waiting for the lease
+// to expire
+func (c *RuleCluster) Run(ctx context.Context) error {
+	ticker := time.NewTicker(c.config.HeartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return c.leave()
+		case <-ticker.C:
+			if err := c.Heartbeat(ctx); err != nil && ctx.Err() == nil {
+				c.config.OnError(fmt.Errorf("heartbeat failed: %w", err))
+			}
+		}
+	}
+}
+
+// Heartbeat renews the instance's lease and reloads the live instances
+func (c *RuleCluster) Heartbeat(ctx context.Context) error {
+	// The lease is renewed no later than now, so the instance gives up its
+	// rules no later than the other instances take them over
+	renewedAt := time.Now()
+	if err := c.repo.Heartbeat(ctx, c.id, c.config.Name); err != nil {
+		return err
+	}
+
+	instances, err := c.repo.GetLiveInstances(ctx, c.config.LeaseTTL)
+	if err != nil {
+		return err
+	}
+
+	members := make([]uuid.UUID, 0, len(instances)+1)
+	for _, instance := range instances {
+		members = append(members, instance.ID)
+	}
+	if !containsID(members, c.id) {
+		members = append(members, c.id)
+	}
+	leader := members[0] == c.id
+
+	c.mu.Lock()
+	changed := leader != c.leader || !equalIDs(members, c.members)
+	c.members = members
+	c.leader = leader
+	c.renewedAt = renewedAt
+	c.mu.Unlock()
+
+	if changed {
+		c.config.OnMembershipChange(instances, leader)
+	}
+
+	if leader {
+		if err := c.repo.DeleteExpiredInstances(ctx, c.config.LeaseTTL); err != nil {
+			c.config.OnError(fmt.Errorf("failed to remove expired instances: %w", err))
+		}
+	}
+	return nil
+}
+
+// leave deregisters the instance. It runs after the instance's context is
+// cancelled, so it is given a context of its own.
+func (c *RuleCluster) leave() error {
+	c.mu.Lock()
+	c.members = nil
+	c.leader = false
+	c.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.config.HeartbeatInterval)
+	defer cancel()
+	return c.repo.Deregister(ctx, c.id)
+}
+
+// IsLeader reports whether the instance currently leads the cluster
+func (c *RuleCluster) IsLeader() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.leader && c.leaseHeld()
+}
+
+// Owns reports whether the instance is responsible for the rule. An instance
+// whose lease has lapsed, because it cannot reach the database, owns nothing.
+func (c *RuleCluster) Owns(ruleID uuid.UUID) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if !c.leaseHeld() {
+		return false
+	}
+
+	var owner uuid.UUID
+	var best uint64
+	for _, member := range c.members {
+		if score := rendezvousScore(member, ruleID); owner == uuid.Nil || score > best {
+			owner, best = member, score
+		}
+	}
+	return owner == c.id
+}
+
+// Exclusive runs fn while holding the rule's lock in the database
+func (c *RuleCluster) Exclusive(ctx context.Context, ruleID uuid.UUID, fn func() error) (bool, error) {
+	return c.repo.WithRuleLock(ctx, ruleID, fn)
+}
+
+// leaseHeld reports whether the lease is still current. c.mu must be held.
+func (c *RuleCluster) leaseHeld() bool {
+	return len(c.members) > 0 && time.Since(c.renewedAt) < c.config.LeaseTTL
+}
+
+// rendezvousScore ranks an instance for a rule; the highest ranked live
+// instance owns the rule
+func rendezvousScore(instanceID, ruleID uuid.UUID) uint64 {
+	h := fnv.New64a()
+	h.Write(instanceID[:])
+	h.Write(ruleID[:])
+	return h.Sum64()
+}
+
+func containsID(ids []uuid.UUID, id uuid.UUID) bool {
+	for _, candidate := range ids {
+		if candidate == id {
+			return true
+		}
+	}
+	return false
+}
+
+func equalIDs(a, b []uuid.UUID) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,56 @@
+// internal/services/rule_conditions.go
+package services
+
+import (
+	"errors"
+)
+
+var (
+	ErrInvalidRuleConditions = errors.New("invalid rule conditions")
+)
+
+// MaxConditionDepth limits how deeply condition groups may be nested
+const MaxConditionDepth = 8
+
+// ValidateConditions checks a rule's condition tree against the schema of
+// the rule engine
+func ValidateConditions(conditions []RuleCondition) error {
+	v := &ruleValidator{}
+	v.conditions(conditions)
+	return v.result(ErrInvalidRuleConditions)
+}
+
+// conditionSymbols returns every symbol a condition tree reads market data
+// for. Leaves without a symbol refer to defaultSymbol.
+func conditionSymbols(conditions []RuleCondition, defaultSymbol string) []string {
+	seen := map[string]bool{defaultSymbol: true}
+	symbols := []string{defaultSymbol}
+
+	var walk func(node RuleCondition)
+	walk = func(node RuleCondition) {
+		for _, child := range node.All {
+			walk(child)
+		}
+		for _, child := range node.Any {
+			walk(child)
+		}
+		if node.Not != nil {
+			walk(*node.Not)
+		}
+		if node.CompareTo != nil {
+			walk(*node.CompareTo)
+		}
+		for _, leg := range node.Legs {
+			walk(leg)
+		}
+		if node.Symbol != "" && !seen[node.Symbol] {
+			seen[node.Symbol] = true
+			symbols = append(symbols, node.Symbol)
+		}
+	}
+
+	for _, condition := range conditions {
+		walk(condition)
+	}
+	return symbols
+}
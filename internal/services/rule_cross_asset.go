@@ -0,0 +1,148 @@
+// internal/services/rule_cross_asset.go
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aquibsayyed9/sentinel/internal/calendar"
+	"github.com/aquibsayyed9/sentinel/internal/models"
+)
+
+var (
+	ErrUndefinedRatio  = errors.New("ratio is undefined")
+	ErrNoPreviousClose = errors.New("no previous close")
+)
+
+// Condition types that relate one instrument to another or to its own recent
+// trading. Their symbols may differ from the rule's, such as a rule on XLE
+// that buys when the USO/XLE ratio rises above 1.2.
+const (
+	// The first leg divided by the second
+	ConditionTypeRatio = "ratio"
+
+	// The first leg minus the second, the spread between two instruments
+	ConditionTypeDifference = "difference"
+
+	// Percent change of the price since the previous session's close
+	ConditionTypeChangePercent = "change_percent"
+)
+
+// previousCloseLookback is how far back the previous session's daily bar is
+// looked for, enough to span long weekends and exchange closures
+const previousCloseLookback = 10 * 24 * time.Hour
+
+// isLeggedCondition reports whether a condition type combines two legs
+func isLeggedCondition(conditionType string) bool {
+	return conditionType == ConditionTypeRatio || conditionType == ConditionTypeDifference
+}
+
+// resolveLegs resolves both legs of a ratio or difference and combines them.
+// Legs without a symbol use the condition's, then the rule's. The result has
+// a previous value when both legs do, so it can be tested for crossovers.
+func (s *ruleEngineService) resolveLegs(ctx context.Context, snapshot *marketSnapshot,
+	rule *models.TradingRule, condition RuleCondition) (operand, error) {
+
+	if len(condition.Legs) != 2 {
+		return operand{}, fmt.Errorf("%w: %s requires exactly two legs", ErrUnsupportedCondition, condition.Type)
+	}
+
+	var legs [2]operand
+	for i, leg := range condition.Legs {
+		if leg.Symbol == "" {
+			leg.Symbol = condition.Symbol
+		}
+		value, err := s.resolveOperand(ctx, snapshot, rule, leg)
+		if err != nil {
+			return operand{}, fmt.Errorf("legs[%d]: %w", i, err)
+		}
+		legs[i] = value
+	}
+
+	combine := func(a, b float64) (float64, error) {
+		if condition.Type == ConditionTypeDifference {
+			return a - b, nil
+		}
+		if b == 0 {
+			return 0, fmt.Errorf("%w: the second leg is zero", ErrUndefinedRatio)
+		}
+		return a / b, nil
+	}
+
+	current, err := combine(legs[0].current, legs[1].current)
+	if err != nil {
+		return operand{}, err
+	}
+	result := operand{current: current, bar: legs[0].bar}
+	if legs[1].bar.After(result.bar) {
+		result.bar = legs[1].bar
+	}
+	if legs[0].hasPrevious && legs[1].hasPrevious {
+		if previous, err := combine(legs[0].previous, legs[1].previous); err == nil {
+			result.previous, result.hasPrevious = previous, true
+		}
+	}
+	return result, nil
+}
+
+// changePercent returns the percent change of a symbol's price since the
+// previous session's close
+func (s *ruleEngineService) changePercent(ctx context.Context, snapshot *marketSnapshot, symbol string) (float64, error) {
+	bar, err := snapshot.bar(ctx, symbol)
+	if err != nil {
+		return 0, err
+	}
+	previous, err := snapshot.previousClose(ctx, symbol, s.calendar)
+	if err != nil {
+		return 0, err
+	}
+	return (bar.Close - previous) / previous * 100, nil
+}
+
+// previousClose returns the close of the last daily bar before the snapshot's
+// trading day. Daily bars are expected to be timestamped within the day they
+// cover, in the exchange's time zone.
+func (m *marketSnapshot) previousClose(ctx context.Context, symbol string, cal *calendar.Calendar) (float64, error) {
+	if close, ok := m.previousCloses[symbol]; ok {
+		return close, nil
+	}
+
+	local := m.at.In(cal.Location)
+	dayStart := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, cal.Location)
+	bars, err := m.marketDataService.GetHistoricalData(ctx, symbol,
+		dayStart.Add(-previousCloseLookback), dayStart.Add(-time.Nanosecond), "1d")
+	if err != nil {
+		return 0, err
+	}
+	if len(bars) == 0 || bars[len(bars)-1].Close <= 0 {
+		return 0, fmt.Errorf("%w: no daily bar for %s before %s", ErrNoPreviousClose, symbol, dayStart.Format("2006-01-02"))
+	}
+
+	close := bars[len(bars)-1].Close
+	m.previousCloses[symbol] = close
+	return close, nil
+}
+
+// legs checks the two market values a ratio or difference combines
+func (v *ruleValidator) legs(node RuleCondition, path string) {
+	if node.Params != nil {
+		v.add(path+".params", "are not supported by %s conditions", node.Type)
+	}
+	if len(node.Legs) != 2 {
+		v.add(path+".legs", "%s conditions require exactly two legs", node.Type)
+		return
+	}
+	for i, leg := range node.Legs {
+		legPath := fmt.Sprintf("%s.legs[%d]", path, i)
+		if leg.IsGroup() || leg.Operator != "" || leg.CompareTo != nil || leg.Legs != nil {
+			v.add(legPath, "must describe a market value or indicator")
+			continue
+		}
+		if leg.Symbol == "" {
+			leg.Symbol = node.Symbol
+		}
+		v.operand(leg, legPath)
+	}
+}
@@ -0,0 +1,252 @@
+// internal/services/rule_dispatcher.go
+package services
+
+import (
+	"context"
+	"hash/fnv"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/aquibsayyed9/sentinel/internal/models"
+	"github.com/aquibsayyed9/sentinel/internal/repository"
+)
+
+// RuleDispatcherConfig tunes the event-driven rule dispatcher
+type RuleDispatcherConfig struct {
+	// Number of workers evaluating rules concurrently
+	Workers int
+
+	// Capacity of each worker's event queue
+	QueueSize int
+
+	// How often the symbol index is rebuilt from the active rules
+	RefreshInterval time.Duration
+
+	// Decides which rules this instance evaluates when several instances
+	// share the database. Nil evaluates every rule.
+	Coordinator RuleCoordinator
+
+	// Records how each rule fares and quarantines failing rules. Nil records
+	// nothing.
+	Metrics *RuleMetrics
+
+	// Called when a rule triggers
+	OnTrigger func(rule *models.TradingRule)
+
+	// Called when a rule fails to evaluate or execute. The rule is nil for
+	// errors that are not specific to a rule, such as a failed index refresh.
+	OnError func(rule *models.TradingRule, err error)
+}
+
+// RuleDispatcher evaluates rules in response to market events. Active rules
+// are indexed by every symbol they read, so an event only evaluates the rules
+// it can affect. Events are sharded across a fixed pool of workers by symbol,
+// which bounds concurrency and keeps the events for any one symbol in order.
+type RuleDispatcher struct {
+	ruleRepo repository.RuleRepository
+	engine   RuleEngineService
+	config   RuleDispatcherConfig
+	queues   []chan models.MarketEvent
+
+	indexMu sync.RWMutex
+	index   map[string][]*models.TradingRule
+
+	// Symbols with an event already queued. Further events for a pending
+	// symbol are coalesced, since the queued evaluation reads the latest data.
+	pendingMu sync.Mutex
+	pending   map[string]bool
+
+	// Per-rule locks, so a rule that reads several symbols is never evaluated
+	// by two workers at once
+	ruleLocks sync.Map
+}
+
+// NewRuleDispatcher creates a dispatcher, applying defaults to unset config values
+func NewRuleDispatcher(ruleRepo repository.RuleRepository, engine RuleEngineService, config RuleDispatcherConfig) *RuleDispatcher {
+	if config.Workers <= 0 {
+		config.Workers = runtime.NumCPU()
+	}
+	if config.QueueSize <= 0 {
+		config.QueueSize = 1024
+	}
+	if config.RefreshInterval <= 0 {
+		config.RefreshInterval = 15 * time.Second
+	}
+	if config.Coordinator == nil {
+		config.Coordinator = standaloneCoordinator{}
+	}
+	if config.OnTrigger == nil {
+		config.OnTrigger = func(*models.TradingRule) {}
+	}
+	if config.OnError == nil {
+		config.OnError = func(*models.TradingRule, error) {}
+	}
+
+	queues := make([]chan models.MarketEvent, config.Workers)
+	for i := range queues {
+		queues[i] = make(chan models.MarketEvent, config.QueueSize)
+	}
+
+	return &RuleDispatcher{
+		ruleRepo: ruleRepo,
+		engine:   engine,
+		config:   config,
+		queues:   queues,
+		index:    make(map[string][]*models.TradingRule),
+		pending:  make(map[string]bool),
+	}
+}
+
+// Refresh rebuilds the symbol index from the currently active rules
+func (d *RuleDispatcher) Refresh(ctx context.Context) error {
+	rules, err := d.ruleRepo.GetActiveRules(ctx)
+	if err != nil {
+		return err
+	}
+
+	index := make(map[string][]*models.TradingRule)
+	for i := range rules {
+		rule := &rules[i]
+
+		// Scheduled rules are run by the RuleScheduler when they are due
+		if rule.RuleType == RuleTypeScheduled {
+			continue
+		}
+
+		conditions, err := decodeRuleConditions(rule)
+		if err != nil {
+			d.config.OnError(rule, err)
+			continue
+		}
+		for _, symbol := range conditionSymbols(conditions, rule.Symbol) {
+			index[symbol] = append(index[symbol], rule)
+		}
+	}
+
+	d.indexMu.Lock()
+	d.index = index
+	d.indexMu.Unlock()
+	return nil
+}
+
+// Dispatch queues a market event for evaluation. It blocks while the
+// symbol's worker queue is full, applying backpressure to the event source.
+func (d *RuleDispatcher) Dispatch(ctx context.Context, event models.MarketEvent) error {
+	d.indexMu.RLock()
+	_, indexed := d.index[event.Symbol]
+	d.indexMu.RUnlock()
+	if !indexed {
+		return nil
+	}
+
+	d.pendingMu.Lock()
+	if d.pending[event.Symbol] {
+		d.pendingMu.Unlock()
+		return nil
+	}
+	d.pending[event.Symbol] = true
+	d.pendingMu.Unlock()
+
+	select {
+	case d.queues[d.shard(event.Symbol)] <- event:
+		return nil
+	case <-ctx.Done():
+		d.clearPending(event.Symbol)
+		return ctx.Err()
+	}
+}
+
+// Run loads the rule index, then evaluates dispatched events until ctx is
+// cancelled, refreshing the index periodically
+func (d *RuleDispatcher) Run(ctx context.Context) error {
+	if err := d.Refresh(ctx); err != nil {
+		return err
+	}
+
+	var wg sync.WaitGroup
+	for _, queue := range d.queues {
+		wg.Add(1)
+		go func(queue chan models.MarketEvent) {
+			defer wg.Done()
+			d.work(ctx, queue)
+		}(queue)
+	}
+
+	ticker := time.NewTicker(d.config.RefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return nil
+		case <-ticker.C:
+			if err := d.Refresh(ctx); err != nil {
+				d.config.OnError(nil, err)
+			}
+		}
+	}
+}
+
+func (d *RuleDispatcher) work(ctx context.Context, queue chan models.MarketEvent) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-queue:
+			d.clearPending(event.Symbol)
+			d.evaluateSymbol(ctx, event.Symbol)
+		}
+	}
+}
+
+func (d *RuleDispatcher) evaluateSymbol(ctx context.Context, symbol string) {
+	d.indexMu.RLock()
+	rules := d.index[symbol]
+	d.indexMu.RUnlock()
+
+	for _, rule := range rules {
+		if ctx.Err() != nil {
+			return
+		}
+
+		// Ownership is checked as each rule is evaluated rather than when the
+		// index is built, so rules move between instances as soon as the
+		// cluster changes
+		if !d.config.Coordinator.Owns(rule.ID) {
+			continue
+		}
+
+		lock := d.ruleLock(rule.ID)
+		lock.Lock()
+		triggered, err := processExclusive(ctx, d.config.Coordinator, d.config.Metrics, d.ruleRepo, d.engine, rule)
+		lock.Unlock()
+
+		if err != nil {
+			d.config.OnError(rule, err)
+		} else if triggered {
+			d.config.OnTrigger(rule)
+		}
+	}
+}
+
+func (d *RuleDispatcher) clearPending(symbol string) {
+	d.pendingMu.Lock()
+	delete(d.pending, symbol)
+	d.pendingMu.Unlock()
+}
+
+func (d *RuleDispatcher) ruleLock(id uuid.UUID) *sync.Mutex {
+	lock, _ := d.ruleLocks.LoadOrStore(id, &sync.Mutex{})
+	return lock.(*sync.Mutex)
+}
+
+// shard maps a symbol to the worker responsible for it
+func (d *RuleDispatcher) shard(symbol string) int {
+	h := fnv.New32a()
+	h.Write([]byte(symbol))
+	return int(h.Sum32() % uint32(len(d.queues)))
+}
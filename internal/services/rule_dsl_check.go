@@ -0,0 +1,343 @@
+// internal/services/rule_dsl_check.go
+package services
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/aquibsayyed9/sentinel/internal/indicators"
+)
+
+// dslNumericParams lists, in positional order, the numeric parameters each
+// indicator accepts. Market values take none.
+var dslNumericParams = map[string][]string{
+	indicators.KindSMA:       {"period"},
+	indicators.KindEMA:       {"period"},
+	indicators.KindRSI:       {"period"},
+	indicators.KindATR:       {"period"},
+	indicators.KindHighest:   {"period"},
+	indicators.KindLowest:    {"period"},
+	indicators.KindMACD:      {"fast_period", "slow_period", "signal_period"},
+	indicators.KindBollinger: {"period", "std_dev"},
+}
+
+// dslAliases maps alternative names of market values to condition types
+var dslAliases = map[string]string{
+	"close": ConditionTypePrice,
+}
+
+// ParseRuleExpression parses and type-checks a rule expression, returning the
+// conditions and actions it compiles to. Problems are reported as a
+// *ValidationError on the "expression" field, each prefixed with the
+// line:column it was found at.
+func ParseRuleExpression(src string) ([]RuleCondition, []RuleAction, error) {
+	tree, err := parseRuleExpression(src)
+	if err != nil {
+		return nil, nil, expressionError(err)
+	}
+
+	c := &dslChecker{}
+	conditions := c.conditions(tree.when)
+	actions := c.actions(tree.actions)
+	if len(c.errors) > 0 {
+		return nil, nil, expressionError(c.errors...)
+	}
+	return conditions, actions, nil
+}
+
+func expressionError(errs ...error) error {
+	fields := make([]FieldError, len(errs))
+	for i, err := range errs {
+		fields[i] = FieldError{Field: "expression", Message: err.Error()}
+	}
+	return &ValidationError{Err: ErrInvalidRuleExpression, Fields: fields}
+}
+
+// dslChecker type-checks a rule expression's syntax tree while compiling it
+// to conditions and actions, collecting every problem it finds
+type dslChecker struct {
+	errors []error
+}
+
+func (c *dslChecker) errorf(pos dslPos, format string, args ...interface{}) {
+	c.errors = append(c.errors, dslError{pos, fmt.Sprintf(format, args...)})
+}
+
+// conditions compiles the when clause. A top-level "and" becomes separate
+// top-level conditions.
+func (c *dslChecker) conditions(expr dslExpr) []RuleCondition {
+	if expr == nil {
+		return nil
+	}
+	if logical, ok := expr.(*dslLogical); ok && logical.operator == "and" {
+		conditions := make([]RuleCondition, len(logical.operands))
+		for i, operand := range logical.operands {
+			conditions[i] = c.condition(operand)
+		}
+		return conditions
+	}
+	return []RuleCondition{c.condition(expr)}
+}
+
+func (c *dslChecker) condition(expr dslExpr) RuleCondition {
+	switch node := expr.(type) {
+	case *dslLogical:
+		children := make([]RuleCondition, len(node.operands))
+		for i, operand := range node.operands {
+			children[i] = c.condition(operand)
+		}
+		if node.operator == "and" {
+			return RuleCondition{All: children}
+		}
+		return RuleCondition{Any: children}
+
+	case *dslNot:
+		child := c.condition(node.operand)
+		return RuleCondition{Not: &child}
+
+	default:
+		return c.comparison(expr.(*dslComparison))
+	}
+}
+
+func (c *dslChecker) comparison(node *dslComparison) RuleCondition {
+	left, operator, right := node.left, node.operator, node.right
+	if left.number != nil && right.number != nil {
+		c.errorf(node.pos, "a comparison needs a market value or indicator on at least one side")
+		return RuleCondition{}
+	}
+
+	// Conditions keep the market value on the left
+	if left.number != nil {
+		left, right = right, left
+		operator = flipOperator(operator)
+	}
+
+	// The schema is only checked for operands whose arguments compiled
+	// cleanly, so a bad argument isn't reported twice
+	errorCount := len(c.errors)
+	leaf := c.operand(left)
+	leaf.Operator = operator
+	if len(c.errors) == errorCount {
+		c.checkOperand(leaf, left.pos)
+	}
+	if right.number != nil {
+		leaf.Value = *right.number
+	} else {
+		errorCount = len(c.errors)
+		target := c.operand(right)
+		leaf.CompareTo = &target
+		if len(c.errors) == errorCount {
+			c.checkOperand(compareTarget(leaf), right.pos)
+		}
+	}
+
+	if operator == OperatorCrossesAbove || operator == OperatorCrossesBelow {
+		if leaf.Type != "" && !isSeriesCondition(leaf) {
+			c.errorf(left.pos, "%s requires a series such as an indicator or a price with a time frame", operator)
+		}
+		if leaf.CompareTo != nil && leaf.CompareTo.Type != "" && !isSeriesCondition(compareTarget(leaf)) {
+			c.errorf(right.pos, "%s requires a series to compare against", operator)
+		}
+	}
+	return leaf
+}
+
+// flipOperator returns the operator that compares the same two values with
+// their sides swapped
+func flipOperator(operator string) string {
+	switch operator {
+	case OperatorLessThan:
+		return OperatorGreaterThan
+	case OperatorLessThanOrEqual:
+		return OperatorGreaterThanOrEqual
+	case OperatorGreaterThan:
+		return OperatorLessThan
+	case OperatorGreaterThanOrEqual:
+		return OperatorLessThanOrEqual
+	case OperatorCrossesAbove:
+		return OperatorCrossesBelow
+	case OperatorCrossesBelow:
+		return OperatorCrossesAbove
+	default:
+		return operator
+	}
+}
+
+// operand compiles a market value or indicator call to the condition fields
+// that describe it
+func (c *dslChecker) operand(node dslOperand) RuleCondition {
+	kind := node.name
+	if alias, ok := dslAliases[kind]; ok {
+		kind = alias
+	}
+	schema, ok := conditionSchemas[kind]
+	if !ok {
+		c.errorf(node.pos, "unknown market value or indicator %q", node.name)
+		return RuleCondition{}
+	}
+	if schema.legs {
+		c.errorf(node.pos, "%s conditions cannot be written as an expression", node.name)
+		return RuleCondition{}
+	}
+
+	condition := RuleCondition{Type: kind}
+	var params indicators.Params
+	hasParams := false
+	numeric := dslNumericParams[kind]
+	positionalNumbers, positionalStrings := 0, 0
+	seen := map[string]bool{}
+
+	set := func(arg dslArg, name string) bool {
+		if seen[name] {
+			c.errorf(arg.pos, "%s is given more than once", name)
+			return false
+		}
+		seen[name] = true
+		return true
+	}
+
+	for _, arg := range node.args {
+		name := arg.name
+		switch {
+		case name == "" && arg.tok.kind == dslNumber:
+			if positionalNumbers >= len(numeric) {
+				c.errorf(arg.pos, "%s takes at most %d numeric arguments", node.name, len(numeric))
+				continue
+			}
+			name = numeric[positionalNumbers]
+			positionalNumbers++
+		case name == "":
+			positionalStrings++
+			switch positionalStrings {
+			case 1:
+				name = "symbol"
+			case 2:
+				name = "tf"
+			default:
+				c.errorf(arg.pos, "%s takes at most a symbol and a time frame", node.name)
+				continue
+			}
+		case name == "time_frame":
+			name = "tf"
+		}
+
+		if !set(arg, name) {
+			continue
+		}
+
+		switch name {
+		case "symbol", "tf", "source", "output":
+			if arg.tok.kind != dslString {
+				c.errorf(arg.pos, "%s must be a string", name)
+				continue
+			}
+			switch name {
+			case "symbol":
+				condition.Symbol = arg.tok.text
+			case "tf":
+				condition.TimeFrame = arg.tok.text
+			case "source":
+				params.Source = indicators.Source(arg.tok.text)
+				hasParams = true
+			case "output":
+				params.Output = arg.tok.text
+				hasParams = true
+			}
+
+		case "period", "fast_period", "slow_period", "signal_period", "std_dev":
+			if !containsString(numeric, name) {
+				c.errorf(arg.pos, "%s does not take %s", node.name, name)
+				continue
+			}
+			if arg.tok.kind != dslNumber {
+				c.errorf(arg.pos, "%s must be a number", name)
+				continue
+			}
+			value := arg.tok.value
+			if name == "std_dev" {
+				params.StdDev = value
+			} else {
+				if value != math.Trunc(value) {
+					c.errorf(arg.pos, "%s must be a whole number", name)
+					continue
+				}
+				setIntParam(&params, name, int(value))
+			}
+			hasParams = true
+
+		default:
+			c.errorf(arg.pos, "%s has no argument %q", node.name, arg.name)
+		}
+	}
+	if hasParams {
+		condition.Params = params
+	}
+	return condition
+}
+
+// checkOperand reports what the rule schema rejects about a compiled operand
+// at its position in the expression
+func (c *dslChecker) checkOperand(condition RuleCondition, pos dslPos) {
+	if condition.Type == "" {
+		return
+	}
+	v := &ruleValidator{}
+	v.operand(condition, "")
+	for _, field := range v.fields {
+		c.errorf(pos, "%s: %s", strings.TrimPrefix(field.Field, "."), field.Message)
+	}
+}
+
+func setIntParam(params *indicators.Params, name string, value int) {
+	switch name {
+	case "period":
+		params.Period = value
+	case "fast_period":
+		params.FastPeriod = value
+	case "slow_period":
+		params.SlowPeriod = value
+	case "signal_period":
+		params.SignalPeriod = value
+	}
+}
+
+func (c *dslChecker) actions(nodes []dslAction) []RuleAction {
+	actions := make([]RuleAction, len(nodes))
+	for i, node := range nodes {
+		if node.quantity <= 0 && node.notional <= 0 && node.percent <= 0 {
+			c.errorf(node.pos, "quantity must be positive")
+		}
+
+		action := RuleAction{Type: node.actionType, Quantity: node.quantity, Notional: node.notional, OrderType: node.orderType}
+		switch node.percentOf {
+		case "position":
+			action.PositionPercent = node.percent
+		case "cash":
+			action.CashPercent = node.percent
+		case "weight":
+			action.TargetWeight = node.percent
+		}
+		switch node.orderType {
+		case OrderTypeLimit:
+			action.Limit = node.price
+		case OrderTypeStop:
+			action.Stop = node.price
+		}
+		if node.orderType != OrderTypeMarket && node.price <= 0 {
+			c.errorf(node.pos, "%s price must be positive", node.orderType)
+		}
+		actions[i] = action
+	}
+	return actions
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
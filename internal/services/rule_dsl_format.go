@@ -0,0 +1,223 @@
+// internal/services/rule_dsl_format.go
+package services
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/aquibsayyed9/sentinel/internal/indicators"
+)
+
+// Binding strength of the parts of a condition expression, loosest first
+const (
+	dslPrecOr = iota + 1
+	dslPrecAnd
+	dslPrecUnary
+)
+
+// dslOperatorText maps stored operators back to the text of the language
+var dslOperatorText = map[string]string{
+	OperatorLessThan:           "<",
+	OperatorLessThanOrEqual:    "<=",
+	OperatorGreaterThan:        ">",
+	OperatorGreaterThanOrEqual: ">=",
+	OperatorEqual:              "==",
+	OperatorNotEqual:           "!=",
+	OperatorCrossesAbove:       "crosses_above",
+	OperatorCrossesBelow:       "crosses_below",
+}
+
+// FormatRuleExpression prints conditions and actions as a rule expression in
+// canonical form. Parsing the result yields equivalent conditions and actions.
+func FormatRuleExpression(conditions []RuleCondition, actions []RuleAction) (string, error) {
+	var b strings.Builder
+
+	if len(conditions) > 0 {
+		// A lone condition needs no parentheses; several are joined by "and"
+		context := dslPrecOr
+		if len(conditions) > 1 {
+			context = dslPrecAnd
+		}
+
+		b.WriteString("when ")
+		for i, condition := range conditions {
+			if i > 0 {
+				b.WriteString(" and ")
+			}
+			text, err := formatDSLCondition(condition, context)
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(text)
+		}
+		b.WriteString(" ")
+	}
+
+	b.WriteString("then ")
+	for i, action := range actions {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		text, err := formatDSLAction(action)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(text)
+	}
+	return b.String(), nil
+}
+
+// formatDSLCondition prints a condition, parenthesised if it binds more
+// loosely than its context requires
+func formatDSLCondition(condition RuleCondition, context int) (string, error) {
+	var text string
+	prec := dslPrecUnary
+
+	switch {
+	case condition.All != nil || condition.Any != nil:
+		children, keyword := condition.All, " and "
+		prec = dslPrecAnd
+		if condition.Any != nil {
+			children, keyword = condition.Any, " or "
+			prec = dslPrecOr
+		}
+
+		parts := make([]string, len(children))
+		for i, child := range children {
+			part, err := formatDSLCondition(child, prec)
+			if err != nil {
+				return "", err
+			}
+			parts[i] = part
+		}
+		text = strings.Join(parts, keyword)
+
+	case condition.Not != nil:
+		child, err := formatDSLCondition(*condition.Not, dslPrecUnary)
+		if err != nil {
+			return "", err
+		}
+		text = "not " + child
+
+	default:
+		left, err := formatDSLOperand(condition)
+		if err != nil {
+			return "", err
+		}
+		right := formatNumber(condition.Value)
+		if condition.CompareTo != nil {
+			if right, err = formatDSLOperand(*condition.CompareTo); err != nil {
+				return "", err
+			}
+		}
+		operator, ok := dslOperatorText[condition.Operator]
+		if !ok {
+			operator = condition.Operator
+		}
+		text = left + " " + operator + " " + right
+	}
+
+	if prec < context {
+		return "(" + text + ")", nil
+	}
+	return text, nil
+}
+
+// formatDSLOperand prints the market value or indicator a condition describes
+func formatDSLOperand(condition RuleCondition) (string, error) {
+	if isLeggedCondition(condition.Type) {
+		return "", fmt.Errorf("%w: %s conditions cannot be written as an expression", ErrInvalidRuleExpression, condition.Type)
+	}
+
+	params, err := indicatorParams(condition)
+	if err != nil {
+		return "", err
+	}
+
+	var args []string
+
+	// Numeric parameters are positional while they are set in order, and
+	// named once one is skipped
+	named := false
+	for _, name := range dslNumericParams[condition.Type] {
+		value := dslParamValue(params, name)
+		if value == 0 {
+			named = true
+			continue
+		}
+		if named {
+			args = append(args, name+"="+formatNumber(value))
+		} else {
+			args = append(args, formatNumber(value))
+		}
+	}
+
+	if condition.Symbol != "" {
+		args = append(args, strconv.Quote(condition.Symbol))
+		if condition.TimeFrame != "" {
+			args = append(args, strconv.Quote(condition.TimeFrame))
+		}
+	} else if condition.TimeFrame != "" {
+		args = append(args, "tf="+strconv.Quote(condition.TimeFrame))
+	}
+	if params.Source != "" {
+		args = append(args, "source="+strconv.Quote(string(params.Source)))
+	}
+	if params.Output != "" {
+		args = append(args, "output="+strconv.Quote(params.Output))
+	}
+
+	// Market values read naturally without parentheses
+	if len(args) == 0 && (condition.Type == ConditionTypePrice || !indicators.IsKind(condition.Type)) {
+		return condition.Type, nil
+	}
+	return condition.Type + "(" + strings.Join(args, ", ") + ")", nil
+}
+
+func dslParamValue(params indicators.Params, name string) float64 {
+	switch name {
+	case "period":
+		return float64(params.Period)
+	case "fast_period":
+		return float64(params.FastPeriod)
+	case "slow_period":
+		return float64(params.SlowPeriod)
+	case "signal_period":
+		return float64(params.SignalPeriod)
+	case "std_dev":
+		return params.StdDev
+	}
+	return 0
+}
+
+func formatDSLAction(action RuleAction) (string, error) {
+	if IsRuleActionType(action.Type) {
+		return "", fmt.Errorf("%w: %s actions cannot be written as an expression", ErrInvalidRuleExpression, action.Type)
+	}
+
+	amount := formatNumber(action.Quantity)
+	switch {
+	case action.Notional > 0:
+		amount = "$" + formatNumber(action.Notional)
+	case action.PositionPercent > 0:
+		amount = formatNumber(action.PositionPercent) + "% position"
+	case action.CashPercent > 0:
+		amount = formatNumber(action.CashPercent) + "% cash"
+	case action.TargetWeight > 0:
+		amount = formatNumber(action.TargetWeight) + "% weight"
+	}
+	text := action.Type + " " + amount
+	switch action.OrderType {
+	case OrderTypeLimit:
+		return text + " limit " + formatNumber(action.Limit), nil
+	case OrderTypeStop:
+		return text + " stop " + formatNumber(action.Stop), nil
+	default:
+		return text + " market", nil
+	}
+}
+
+func formatNumber(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
@@ -0,0 +1,537 @@
+// internal/services/rule_dsl_parser.go
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+var (
+	ErrInvalidRuleExpression = errors.New("invalid rule expression")
+)
+
+// A rule expression describes a rule's conditions and actions as text:
+//
+//	when close("AAPL", "1h") < sma(50) and rsi(14) < 30 then buy 10 market
+//
+// The grammar is
+//
+//	rule       = [ "when" expr ] "then" action { "," action }
+//	expr       = and { "or" and }
+//	and        = unary { "and" unary }
+//	unary      = "not" unary | "(" expr ")" | comparison
+//	comparison = operand op operand
+//	op         = "<" | "<=" | ">" | ">=" | "==" | "!=" | "crosses_above" | "crosses_below"
+//	operand    = number | name [ "(" [ arg { "," arg } ] ")" ]
+//	arg        = number | string | name "=" ( string | number )
+//	action     = ( "buy" | "sell" ) amount [ "market" | "limit" number | "stop" number ]
+//	amount     = number | "$" number | number "%" ( "position" | "cash" | "weight" )
+//
+// An action's quantity is a number of shares, a cash amount after "$", or a
+// percent of the position held, of the cash balance, or of the portfolio's
+// value to bring the position to.
+//
+// Keywords are case-insensitive. Conditions joined by a top-level "and" are
+// stored as separate top-level conditions, which the engine ANDs.
+
+// dslPos is a position in the source of a rule expression
+type dslPos struct {
+	line, col int
+}
+
+func (p dslPos) String() string {
+	return fmt.Sprintf("%d:%d", p.line, p.col)
+}
+
+// dslError is a problem at a position in a rule expression
+type dslError struct {
+	pos     dslPos
+	message string
+}
+
+func (e dslError) Error() string {
+	return e.pos.String() + ": " + e.message
+}
+
+type dslTokenKind int
+
+const (
+	dslEOF dslTokenKind = iota
+	dslIdent
+	dslNumber
+	dslString
+	dslOperator
+	dslLParen
+	dslRParen
+	dslComma
+	dslAssign
+	dslDollar
+	dslPercent
+)
+
+type dslToken struct {
+	kind  dslTokenKind
+	text  string
+	pos   dslPos
+	value float64
+}
+
+func (t dslToken) describe() string {
+	switch t.kind {
+	case dslEOF:
+		return "end of expression"
+	case dslString:
+		return strconv.Quote(t.text)
+	default:
+		return fmt.Sprintf("%q", t.text)
+	}
+}
+
+// keyword reports whether the token is the given keyword
+func (t dslToken) keyword(word string) bool {
+	return t.kind == dslIdent && strings.EqualFold(t.text, word)
+}
+
+// lexRuleExpression splits a rule expression into tokens
+func lexRuleExpression(src string) ([]dslToken, error) {
+	var tokens []dslToken
+	runes := []rune(src)
+	line, col := 1, 1
+
+	advance := func(n int) {
+		for i := 0; i < n; i++ {
+			if runes[0] == '\n' {
+				line++
+				col = 1
+			} else {
+				col++
+			}
+			runes = runes[1:]
+		}
+	}
+
+	for len(runes) > 0 {
+		r := runes[0]
+		pos := dslPos{line, col}
+
+		switch {
+		case unicode.IsSpace(r):
+			advance(1)
+
+		case unicode.IsLetter(r) || r == '_':
+			n := 1
+			for n < len(runes) && (unicode.IsLetter(runes[n]) || unicode.IsDigit(runes[n]) || runes[n] == '_') {
+				n++
+			}
+			tokens = append(tokens, dslToken{kind: dslIdent, text: string(runes[:n]), pos: pos})
+			advance(n)
+
+		case unicode.IsDigit(r) || r == '.' || (r == '-' && len(runes) > 1 && (unicode.IsDigit(runes[1]) || runes[1] == '.')):
+			n := 1
+			for n < len(runes) && (unicode.IsDigit(runes[n]) || runes[n] == '.') {
+				n++
+			}
+			text := string(runes[:n])
+			value, err := strconv.ParseFloat(text, 64)
+			if err != nil {
+				return nil, dslError{pos, fmt.Sprintf("invalid number %q", text)}
+			}
+			tokens = append(tokens, dslToken{kind: dslNumber, text: text, pos: pos, value: value})
+			advance(n)
+
+		case r == '"':
+			n := 1
+			var text strings.Builder
+			for n < len(runes) && runes[n] != '"' && runes[n] != '\n' {
+				text.WriteRune(runes[n])
+				n++
+			}
+			if n == len(runes) || runes[n] != '"' {
+				return nil, dslError{pos, "unterminated string"}
+			}
+			tokens = append(tokens, dslToken{kind: dslString, text: text.String(), pos: pos})
+			advance(n + 1)
+
+		case r == '(':
+			tokens = append(tokens, dslToken{kind: dslLParen, text: "(", pos: pos})
+			advance(1)
+		case r == ')':
+			tokens = append(tokens, dslToken{kind: dslRParen, text: ")", pos: pos})
+			advance(1)
+		case r == ',':
+			tokens = append(tokens, dslToken{kind: dslComma, text: ",", pos: pos})
+			advance(1)
+		case r == '$':
+			tokens = append(tokens, dslToken{kind: dslDollar, text: "$", pos: pos})
+			advance(1)
+		case r == '%':
+			tokens = append(tokens, dslToken{kind: dslPercent, text: "%", pos: pos})
+			advance(1)
+
+		case r == '<' || r == '>' || r == '=' || r == '!':
+			if len(runes) > 1 && runes[1] == '=' {
+				tokens = append(tokens, dslToken{kind: dslOperator, text: string(runes[:2]), pos: pos})
+				advance(2)
+			} else if r == '<' || r == '>' {
+				tokens = append(tokens, dslToken{kind: dslOperator, text: string(r), pos: pos})
+				advance(1)
+			} else if r == '=' {
+				tokens = append(tokens, dslToken{kind: dslAssign, text: "=", pos: pos})
+				advance(1)
+			} else {
+				return nil, dslError{pos, "unexpected \"!\""}
+			}
+
+		default:
+			return nil, dslError{pos, fmt.Sprintf("unexpected character %q", r)}
+		}
+	}
+
+	tokens = append(tokens, dslToken{kind: dslEOF, pos: dslPos{line, col}})
+	return tokens, nil
+}
+
+// dslRule is the syntax tree of a rule expression
+type dslRule struct {
+	when    dslExpr // nil if the expression has no conditions
+	actions []dslAction
+}
+
+// dslExpr is a node of a condition expression: *dslLogical, *dslNot or *dslComparison
+type dslExpr interface {
+	position() dslPos
+}
+
+type dslLogical struct {
+	pos      dslPos
+	operator string // "and" or "or"
+	operands []dslExpr
+}
+
+type dslNot struct {
+	pos     dslPos
+	operand dslExpr
+}
+
+type dslComparison struct {
+	pos      dslPos
+	left     dslOperand
+	operator string
+	right    dslOperand
+}
+
+func (e *dslLogical) position() dslPos    { return e.pos }
+func (e *dslNot) position() dslPos        { return e.pos }
+func (e *dslComparison) position() dslPos { return e.pos }
+
+// dslOperand is a number or a call of a market value or indicator
+type dslOperand struct {
+	pos    dslPos
+	number *float64
+	name   string
+	args   []dslArg
+}
+
+type dslArg struct {
+	pos  dslPos
+	name string // empty for positional arguments
+	tok  dslToken
+}
+
+type dslAction struct {
+	pos        dslPos
+	actionType string
+	quantity   float64
+	notional   float64
+	percent    float64
+	percentOf  string // "position", "cash" or "weight" for a percent amount
+	orderType  string
+	price      float64
+}
+
+// dslOperators maps the comparison operators of the language to the
+// operators stored in conditions
+var dslOperators = map[string]string{
+	"<":             OperatorLessThan,
+	"<=":            OperatorLessThanOrEqual,
+	">":             OperatorGreaterThan,
+	">=":            OperatorGreaterThanOrEqual,
+	"==":            OperatorEqual,
+	"!=":            OperatorNotEqual,
+	"crosses_above": OperatorCrossesAbove,
+	"crosses_below": OperatorCrossesBelow,
+}
+
+type dslParser struct {
+	tokens []dslToken
+	pos    int
+}
+
+// parseRuleExpression parses the source of a rule expression into its syntax tree
+func parseRuleExpression(src string) (*dslRule, error) {
+	tokens, err := lexRuleExpression(src)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &dslParser{tokens: tokens}
+	rule := &dslRule{}
+
+	if p.peek().keyword("when") {
+		p.next()
+		if rule.when, err = p.expr(); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := p.expectKeyword("then"); err != nil {
+		return nil, err
+	}
+	for {
+		action, err := p.action()
+		if err != nil {
+			return nil, err
+		}
+		rule.actions = append(rule.actions, action)
+
+		if p.peek().kind != dslComma {
+			break
+		}
+		p.next()
+	}
+
+	if tok := p.peek(); tok.kind != dslEOF {
+		return nil, p.unexpected(tok, "end of expression")
+	}
+	return rule, nil
+}
+
+func (p *dslParser) peek() dslToken {
+	return p.tokens[p.pos]
+}
+
+func (p *dslParser) next() dslToken {
+	tok := p.tokens[p.pos]
+	if tok.kind != dslEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *dslParser) unexpected(tok dslToken, expected string) error {
+	return dslError{tok.pos, fmt.Sprintf("expected %s, found %s", expected, tok.describe())}
+}
+
+func (p *dslParser) expectKeyword(word string) (dslToken, error) {
+	tok := p.next()
+	if !tok.keyword(word) {
+		return tok, p.unexpected(tok, strconv.Quote(word))
+	}
+	return tok, nil
+}
+
+func (p *dslParser) expect(kind dslTokenKind, expected string) (dslToken, error) {
+	tok := p.next()
+	if tok.kind != kind {
+		return tok, p.unexpected(tok, expected)
+	}
+	return tok, nil
+}
+
+func (p *dslParser) expr() (dslExpr, error) {
+	return p.logical("or", p.and)
+}
+
+func (p *dslParser) and() (dslExpr, error) {
+	return p.logical("and", p.unary)
+}
+
+// logical parses operands joined by the given keyword
+func (p *dslParser) logical(keyword string, operand func() (dslExpr, error)) (dslExpr, error) {
+	first, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	if !p.peek().keyword(keyword) {
+		return first, nil
+	}
+
+	node := &dslLogical{pos: first.position(), operator: keyword, operands: []dslExpr{first}}
+	for p.peek().keyword(keyword) {
+		p.next()
+		next, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		node.operands = append(node.operands, next)
+	}
+	return node, nil
+}
+
+func (p *dslParser) unary() (dslExpr, error) {
+	tok := p.peek()
+	switch {
+	case tok.keyword("not"):
+		p.next()
+		operand, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return &dslNot{pos: tok.pos, operand: operand}, nil
+
+	case tok.kind == dslLParen:
+		p.next()
+		expr, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(dslRParen, `")"`); err != nil {
+			return nil, err
+		}
+		return expr, nil
+
+	default:
+		return p.comparison()
+	}
+}
+
+func (p *dslParser) comparison() (dslExpr, error) {
+	left, err := p.operand()
+	if err != nil {
+		return nil, err
+	}
+
+	tok := p.next()
+	operator, ok := dslOperators[strings.ToLower(tok.text)]
+	if !ok || (tok.kind != dslOperator && tok.kind != dslIdent) {
+		return nil, p.unexpected(tok, "a comparison operator")
+	}
+
+	right, err := p.operand()
+	if err != nil {
+		return nil, err
+	}
+	return &dslComparison{pos: left.pos, left: left, operator: operator, right: right}, nil
+}
+
+func (p *dslParser) operand() (dslOperand, error) {
+	tok := p.next()
+	switch tok.kind {
+	case dslNumber:
+		value := tok.value
+		return dslOperand{pos: tok.pos, number: &value}, nil
+	case dslIdent:
+		if isDSLKeyword(tok.text) {
+			return dslOperand{}, p.unexpected(tok, "a market value, indicator or number")
+		}
+	default:
+		return dslOperand{}, p.unexpected(tok, "a market value, indicator or number")
+	}
+
+	operand := dslOperand{pos: tok.pos, name: strings.ToLower(tok.text)}
+	if p.peek().kind != dslLParen {
+		return operand, nil
+	}
+	p.next()
+
+	if p.peek().kind == dslRParen {
+		p.next()
+		return operand, nil
+	}
+	for {
+		arg, err := p.arg()
+		if err != nil {
+			return dslOperand{}, err
+		}
+		operand.args = append(operand.args, arg)
+
+		tok := p.next()
+		if tok.kind == dslRParen {
+			return operand, nil
+		}
+		if tok.kind != dslComma {
+			return dslOperand{}, p.unexpected(tok, `"," or ")"`)
+		}
+	}
+}
+
+func (p *dslParser) arg() (dslArg, error) {
+	tok := p.next()
+	switch tok.kind {
+	case dslNumber, dslString:
+		return dslArg{pos: tok.pos, tok: tok}, nil
+	case dslIdent:
+		if _, err := p.expect(dslAssign, `"="`); err != nil {
+			return dslArg{}, err
+		}
+		value := p.next()
+		if value.kind != dslString && value.kind != dslNumber {
+			return dslArg{}, p.unexpected(value, "a string or number")
+		}
+		return dslArg{pos: tok.pos, name: strings.ToLower(tok.text), tok: value}, nil
+	default:
+		return dslArg{}, p.unexpected(tok, "an argument")
+	}
+}
+
+func (p *dslParser) action() (dslAction, error) {
+	tok := p.next()
+	action := dslAction{pos: tok.pos, orderType: OrderTypeMarket}
+	switch {
+	case tok.keyword(ActionTypeBuy):
+		action.actionType = ActionTypeBuy
+	case tok.keyword(ActionTypeSell):
+		action.actionType = ActionTypeSell
+	default:
+		return dslAction{}, p.unexpected(tok, `"buy" or "sell"`)
+	}
+
+	notional := p.peek().kind == dslDollar
+	if notional {
+		p.next()
+	}
+	quantity, err := p.expect(dslNumber, "a quantity")
+	if err != nil {
+		return dslAction{}, err
+	}
+	switch {
+	case notional:
+		action.notional = quantity.value
+	case p.peek().kind == dslPercent:
+		p.next()
+		tok := p.next()
+		if !tok.keyword("position") && !tok.keyword("cash") && !tok.keyword("weight") {
+			return dslAction{}, p.unexpected(tok, `"position", "cash" or "weight"`)
+		}
+		action.percent = quantity.value
+		action.percentOf = strings.ToLower(tok.text)
+	default:
+		action.quantity = quantity.value
+	}
+
+	tok = p.peek()
+	switch {
+	case tok.keyword(OrderTypeMarket):
+		p.next()
+	case tok.keyword(OrderTypeLimit), tok.keyword(OrderTypeStop):
+		p.next()
+		action.orderType = strings.ToLower(tok.text)
+		price, err := p.expect(dslNumber, "a "+action.orderType+" price")
+		if err != nil {
+			return dslAction{}, err
+		}
+		action.price = price.value
+	}
+	return action, nil
+}
+
+func isDSLKeyword(word string) bool {
+	switch strings.ToLower(word) {
+	case "when", "then", "and", "or", "not", "crosses_above", "crosses_below":
+		return true
+	}
+	return false
+}
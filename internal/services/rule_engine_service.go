@@ -0,0 +1,730 @@
+// internal/services/rule_engine_service.go
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/aquibsayyed9/sentinel/internal/calendar"
+	"github.com/aquibsayyed9/sentinel/internal/models"
+	"github.com/aquibsayyed9/sentinel/internal/repository"
+)
+
+var (
+	ErrNoRuleConditions     = errors.New("rule has no conditions")
+	ErrUnsupportedCondition = errors.New("unsupported condition type")
+	ErrUnsupportedOperator  = errors.New("unsupported operator")
+	ErrUnsupportedAction    = errors.New("unsupported action type")
+	ErrUnsupportedOrderType = errors.New("unsupported order type")
+	ErrInvalidRuleAction    = errors.New("invalid rule action")
+	ErrCrossoverNeedsSeries = errors.New("crossover operators require a time series on both sides")
+)
+
+// Condition types understood by the rule engine
+const (
+	ConditionTypePrice  = "price"
+	ConditionTypeVolume = "volume"
+	ConditionTypeBid    = "bid"
+	ConditionTypeAsk    = "ask"
+	ConditionTypeSpread = "spread"
+)
+
+// Comparison operators understood by the rule engine
+const (
+	OperatorGreaterThan        = "greater_than"
+	OperatorGreaterThanOrEqual = "greater_than_or_equal"
+	OperatorLessThan           = "less_than"
+	OperatorLessThanOrEqual    = "less_than_or_equal"
+	OperatorEqual              = "equal"
+	OperatorNotEqual           = "not_equal"
+	OperatorCrossesAbove       = "crosses_above"
+	OperatorCrossesBelow       = "crosses_below"
+)
+
+// Action and order types understood by the rule engine
+const (
+	ActionTypeBuy  = "buy"
+	ActionTypeSell = "sell"
+
+	OrderTypeMarket = "market"
+	OrderTypeLimit  = "limit"
+	OrderTypeStop   = "stop"
+)
+
+// Execution statuses produced by the rule engine
+const (
+	ExecutionStatusExecuted = "executed"
+	ExecutionStatusPending  = "pending"
+)
+
+// floatTolerance is used when comparing prices for equality
+const floatTolerance = 1e-9
+
+// RuleEngineService evaluates trading rules against market data and turns
+// triggered rules into executions
+type RuleEngineService interface {
+	// Reports whether the rule's condition tree is currently satisfied
+	EvaluateRule(ctx context.Context, rule *models.TradingRule) (bool, error)
+
+	// Executes the rule's actions at the given market price for the rule's
+	// symbol, in one transaction
+	ExecuteRule(ctx context.Context, rule *models.TradingRule, price float64) error
+
+	// Evaluates the rule and executes it at the latest price if it triggers,
+	// subject to the rule's trigger policy, time constraints and, for scheduled
+	// rules, whether a run is due. Reports whether the rule triggered.
+	ProcessRule(ctx context.Context, rule *models.TradingRule) (bool, error)
+
+	// Evaluates the rule without side effects and traces every condition, using
+	// overrides in place of the latest market data for the given symbols
+	ExplainRule(ctx context.Context, rule *models.TradingRule, overrides map[string]MarketOverride) (*RuleExplanation, error)
+}
+
+type ruleEngineService struct {
+	ruleRepo          repository.RuleRepository
+	marketDataService MarketDataService
+	portfolioService  PortfolioService
+	executionService  ExecutionService
+	transactor        repository.Transactor
+	indicators        *indicatorCache
+	crosses           *crossTracker
+	calendar          *calendar.Calendar
+}
+
+// NewRuleEngineService creates a new instance of the rule engine service
+func NewRuleEngineService(ruleRepo repository.RuleRepository, marketDataService MarketDataService,
+	portfolioService PortfolioService, executionService ExecutionService, transactor repository.Transactor) RuleEngineService {
+	return &ruleEngineService{
+		ruleRepo:          ruleRepo,
+		marketDataService: marketDataService,
+		portfolioService:  portfolioService,
+		executionService:  executionService,
+		transactor:        transactor,
+		indicators:        newIndicatorCache(marketDataService),
+		crosses:           newCrossTracker(),
+		calendar:          calendar.NYSE(),
+	}
+}
+
+func (s *ruleEngineService) EvaluateRule(ctx context.Context, rule *models.TradingRule) (bool, error) {
+	if rule.Status != "active" {
+		return false, nil
+	}
+
+	conditions, err := decodeRuleConditions(rule)
+	if err != nil {
+		return false, err
+	}
+	stop, err := decodeStopSpec(rule)
+	if err != nil {
+		return false, err
+	}
+	if len(conditions) == 0 && stop == nil && len(rule.Schedule) == 0 {
+		return false, ErrNoRuleConditions
+	}
+
+	snapshot := newMarketSnapshot(s.marketDataService, time.Now())
+
+	// Stop rules trigger when their stop level is reached and any additional
+	// conditions hold
+	if stop != nil {
+		result, err := s.evaluateStop(ctx, snapshot, rule, *stop, true)
+		if err != nil || !result.Met {
+			return false, err
+		}
+	}
+
+	return s.evaluateAll(ctx, snapshot, rule, conditions, "conditions")
+}
+
+func (s *ruleEngineService) ExecuteRule(ctx context.Context, rule *models.TradingRule, price float64) error {
+	actions, err := decodeRuleActions(rule)
+	if err != nil {
+		return err
+	}
+
+	// Trades, portfolio updates and changes to other rules are committed
+	// together or not at all
+	return s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		snapshot := newMarketSnapshot(s.marketDataService, time.Now())
+		fills := make(map[string]*models.Execution)
+
+		for i, action := range actions {
+			if IsRuleActionType(action.Type) {
+				if err := s.executeRuleAction(ctx, rule, action, fills); err != nil {
+					return fmt.Errorf("action %d: %w", i, err)
+				}
+				continue
+			}
+
+			execution, err := s.executeAction(ctx, snapshot, rule, action, price)
+			if err != nil {
+				return fmt.Errorf("action %d: %w", i, err)
+			}
+			if execution != nil && execution.Status == ExecutionStatusExecuted {
+				fills[execution.Symbol] = execution
+			}
+		}
+		return nil
+	})
+}
+
+func (s *ruleEngineService) ProcessRule(ctx context.Context, rule *models.TradingRule) (bool, error) {
+	now := time.Now()
+	if !canTrigger(rule, now) {
+		return false, nil
+	}
+	allowed, err := withinTimeConstraints(rule, now, s.calendar)
+	if err != nil || !allowed {
+		return false, err
+	}
+
+	runs, err := s.takeScheduledRuns(ctx, rule, now)
+	if err != nil || runs == 0 {
+		return false, err
+	}
+
+	triggered, err := s.EvaluateRule(ctx, rule)
+	if err != nil || !triggered {
+		return false, err
+	}
+
+	// The trigger is persisted before executing, so a failed execution can
+	// never make the rule fire again ahead of its policy
+	if rule.OneShot {
+		runs = 1
+	}
+	for i := 0; i < runs; i++ {
+		recordTrigger(rule, now)
+	}
+	if err := s.ruleRepo.RecordTrigger(ctx, rule, now, runs); err != nil {
+		return true, err
+	}
+
+	marketData, err := s.marketDataService.GetPrice(ctx, rule.Symbol)
+	if err != nil {
+		return true, err
+	}
+
+	for i := 0; i < runs; i++ {
+		if err := s.ExecuteRule(ctx, rule, marketData.Close); err != nil {
+			return true, err
+		}
+	}
+	return true, nil
+}
+
+// takeScheduledRuns returns how many runs of a scheduled rule are due at now,
+// advancing and persisting its next run past them, so each run is taken only
+// once whether or not it triggers. Runs another instance has already taken
+// are not due. Rules without a schedule have one run, which is every
+// evaluation.
+func (s *ruleEngineService) takeScheduledRuns(ctx context.Context, rule *models.TradingRule, now time.Time) (int, error) {
+	schedule, err := decodeSchedule(rule)
+	if err != nil || schedule == nil {
+		return 1, err
+	}
+
+	runs, next, err := dueRuns(*schedule, rule.NextRunAt, now, s.calendar)
+	if err != nil {
+		return 0, err
+	}
+	if rule.NextRunAt != nil && next.Equal(*rule.NextRunAt) {
+		return 0, nil
+	}
+
+	taken, err := s.ruleRepo.UpdateNextRunAt(ctx, rule.ID, rule.NextRunAt, next)
+	if err != nil || !taken {
+		return 0, err
+	}
+	rule.NextRunAt = &next
+	return runs, nil
+}
+
+// evaluateNode evaluates a condition tree node, short-circuiting groups as soon
+// as their outcome is known
+func (s *ruleEngineService) evaluateNode(ctx context.Context, snapshot *marketSnapshot,
+	rule *models.TradingRule, node RuleCondition, path string) (bool, error) {
+
+	switch {
+	case node.All != nil:
+		return s.evaluateAll(ctx, snapshot, rule, node.All, path+".all")
+	case node.Any != nil:
+		for i, child := range node.Any {
+			met, err := s.evaluateNode(ctx, snapshot, rule, child, fmt.Sprintf("%s.any[%d]", path, i))
+			if err != nil {
+				return false, err
+			}
+			if met {
+				return true, nil
+			}
+		}
+		return false, nil
+	case node.Not != nil:
+		met, err := s.evaluateNode(ctx, snapshot, rule, *node.Not, path+".not")
+		if err != nil {
+			return false, err
+		}
+		return !met, nil
+	default:
+		met, err := s.evaluateCondition(ctx, snapshot, rule, node, path)
+		if err != nil {
+			return false, fmt.Errorf("%s: %w", path, err)
+		}
+		return met, nil
+	}
+}
+
+func (s *ruleEngineService) evaluateAll(ctx context.Context, snapshot *marketSnapshot,
+	rule *models.TradingRule, nodes []RuleCondition, path string) (bool, error) {
+
+	for i, child := range nodes {
+		met, err := s.evaluateNode(ctx, snapshot, rule, child, fmt.Sprintf("%s[%d]", path, i))
+		if err != nil {
+			return false, err
+		}
+		if !met {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// evaluateCondition evaluates a single leaf condition. The left-hand side is
+// the condition itself; the right-hand side is either the constant Value or
+// the operand described by CompareTo. A crossover is only met on the first
+// evaluation that sees the bar it happened on.
+func (s *ruleEngineService) evaluateCondition(ctx context.Context, snapshot *marketSnapshot,
+	rule *models.TradingRule, condition RuleCondition, path string) (bool, error) {
+
+	left, right, err := s.resolveOperands(ctx, snapshot, rule, condition)
+	if err != nil {
+		return false, err
+	}
+	met, err := compareOperands(left, condition.Operator, right)
+	if err != nil || !isCrossover(condition.Operator) {
+		return met, err
+	}
+
+	bar := left.bar
+	if right.bar.After(bar) {
+		bar = right.bar
+	}
+	fresh := s.crosses.consume(rule.ID.String()+"|"+path, bar)
+	return met && fresh, nil
+}
+
+// resolveOperands resolves both sides of a leaf condition's comparison
+func (s *ruleEngineService) resolveOperands(ctx context.Context, snapshot *marketSnapshot,
+	rule *models.TradingRule, condition RuleCondition) (operand, operand, error) {
+
+	left, err := s.resolveOperand(ctx, snapshot, rule, condition)
+	if err != nil {
+		return operand{}, operand{}, err
+	}
+
+	right := constantOperand(condition.Value)
+	if condition.CompareTo != nil {
+		right, err = s.resolveOperand(ctx, snapshot, rule, compareTarget(condition))
+		if err != nil {
+			return left, operand{}, fmt.Errorf("compare_to: %w", err)
+		}
+	}
+
+	return left, right, nil
+}
+
+// compareTarget returns a condition's compare_to operand, inheriting the
+// symbol and time frame of the condition where it does not set its own
+func compareTarget(condition RuleCondition) RuleCondition {
+	target := *condition.CompareTo
+	if target.Symbol == "" {
+		target.Symbol = condition.Symbol
+	}
+	if target.TimeFrame == "" {
+		target.TimeFrame = condition.TimeFrame
+	}
+	return target
+}
+
+// resolveOperand resolves the market value described by a leaf condition
+func (s *ruleEngineService) resolveOperand(ctx context.Context, snapshot *marketSnapshot,
+	rule *models.TradingRule, condition RuleCondition) (operand, error) {
+
+	if isLeggedCondition(condition.Type) {
+		return s.resolveLegs(ctx, snapshot, rule, condition)
+	}
+
+	symbol := condition.Symbol
+	if symbol == "" {
+		symbol = rule.Symbol
+	}
+
+	if isSeriesCondition(condition) {
+		params, err := indicatorParams(condition)
+		if err != nil {
+			return operand{}, err
+		}
+		timeFrame := condition.TimeFrame
+		if timeFrame == "" {
+			timeFrame = DefaultIndicatorTimeFrame
+		}
+		return s.indicators.value(ctx, symbol, timeFrame, condition.Type, params, snapshot.at)
+	}
+
+	if isPortfolioCondition(condition.Type) {
+		value, err := s.portfolioValue(ctx, snapshot, rule, symbol, condition.Type)
+		if err != nil {
+			return operand{}, err
+		}
+		return operand{current: value}, nil
+	}
+
+	value, err := s.conditionValue(ctx, snapshot, symbol, condition.Type)
+	if err != nil {
+		return operand{}, err
+	}
+	return operand{current: value}, nil
+}
+
+// conditionValue resolves the observed market value a condition compares against
+func (s *ruleEngineService) conditionValue(ctx context.Context, snapshot *marketSnapshot, symbol, conditionType string) (float64, error) {
+	switch conditionType {
+	case ConditionTypePrice, ConditionTypeVolume:
+		bar, err := snapshot.bar(ctx, symbol)
+		if err != nil {
+			return 0, err
+		}
+		if conditionType == ConditionTypeVolume {
+			return float64(bar.Volume), nil
+		}
+		return bar.Close, nil
+	case ConditionTypeChangePercent:
+		return s.changePercent(ctx, snapshot, symbol)
+	case ConditionTypeBid, ConditionTypeAsk, ConditionTypeSpread:
+		quote, err := snapshot.quote(ctx, symbol)
+		if err != nil {
+			return 0, err
+		}
+		switch conditionType {
+		case ConditionTypeBid:
+			return quote.Bid, nil
+		case ConditionTypeAsk:
+			return quote.Ask, nil
+		default:
+			return quote.Ask - quote.Bid, nil
+		}
+	default:
+		return 0, fmt.Errorf("%w: %q", ErrUnsupportedCondition, conditionType)
+	}
+}
+
+// executeAction places the order an action describes, returning its
+// execution, or nil if there was nothing to trade
+func (s *ruleEngineService) executeAction(ctx context.Context, snapshot *marketSnapshot,
+	rule *models.TradingRule, action RuleAction, price float64) (*models.Execution, error) {
+
+	if err := validateAction(action); err != nil {
+		return nil, err
+	}
+
+	symbol := action.Symbol
+	if symbol == "" {
+		symbol = rule.Symbol
+	}
+
+	// The caller supplies the price of the rule's own symbol; actions on other
+	// symbols are priced from the latest market data
+	if symbol != rule.Symbol {
+		data, err := s.marketDataService.GetPrice(ctx, symbol)
+		if err != nil {
+			return nil, err
+		}
+		price = data.Close
+	}
+
+	fillPrice, status, err := fillOrder(action, price)
+	if err != nil {
+		return nil, err
+	}
+	quantity, err := s.resolveActionQuantity(ctx, snapshot, rule, action, symbol, fillPrice)
+	if err != nil {
+		return nil, err
+	}
+	if quantity <= 0 {
+		return nil, nil
+	}
+
+	ruleVersion := rule.Version
+	execution := &models.Execution{
+		RuleID:        &rule.ID,
+		RuleVersion:   &ruleVersion,
+		UserID:        rule.UserID,
+		Symbol:        symbol,
+		ExecutionType: action.Type,
+		Quantity:      quantity,
+		Price:         fillPrice,
+		Status:        status,
+		ExecutionTime: time.Now(),
+	}
+
+	if err := s.executionService.ProcessExecution(ctx, execution); err != nil {
+		return nil, err
+	}
+
+	if status != ExecutionStatusExecuted {
+		return execution, nil
+	}
+
+	if err := s.applyToPortfolio(ctx, execution); err != nil {
+		return nil, err
+	}
+	return execution, nil
+}
+
+// applyToPortfolio reflects an executed trade in the user's paper portfolio.
+// Users without a portfolio are skipped.
+func (s *ruleEngineService) applyToPortfolio(ctx context.Context, execution *models.Execution) error {
+	portfolio, err := s.portfolioService.GetPortfolioByUserID(ctx, execution.UserID)
+	if err != nil {
+		if errors.Is(err, repository.ErrPortfolioNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	quantity := execution.Quantity
+	cashDelta := -execution.TotalAmount
+	if execution.ExecutionType == ActionTypeSell {
+		quantity = -quantity
+		cashDelta = execution.TotalAmount
+	}
+
+	if err := s.portfolioService.AddOrUpdateHolding(ctx, execution.UserID, execution.Symbol, quantity, execution.Price); err != nil {
+		return err
+	}
+
+	portfolio.CashBalance += cashDelta
+	return s.portfolioService.UpdatePortfolio(ctx, portfolio)
+}
+
+// validateAction checks an action's type and quantity
+func validateAction(action RuleAction) error {
+	if action.Type != ActionTypeBuy && action.Type != ActionTypeSell {
+		return fmt.Errorf("%w: %q", ErrUnsupportedAction, action.Type)
+	}
+	if action.Quantity <= 0 && action.Notional <= 0 && !isRelativeAction(action) {
+		return fmt.Errorf("%w: quantity must be positive", ErrInvalidRuleAction)
+	}
+	return nil
+}
+
+// actionQuantity returns the quantity an action trades at a fill price
+func actionQuantity(action RuleAction, fillPrice float64) float64 {
+	if action.Notional > 0 && fillPrice > 0 {
+		return action.Notional / fillPrice
+	}
+	return action.Quantity
+}
+
+// fillOrder simulates an order against the current market price and returns the
+// fill price along with the resulting execution status
+func fillOrder(action RuleAction, price float64) (float64, string, error) {
+	switch action.OrderType {
+	case "", OrderTypeMarket:
+		return price, ExecutionStatusExecuted, nil
+	case OrderTypeLimit:
+		if action.Limit <= 0 {
+			return 0, "", fmt.Errorf("%w: limit order requires a limit price", ErrInvalidRuleAction)
+		}
+		marketable := (action.Type == ActionTypeBuy && price <= action.Limit) ||
+			(action.Type == ActionTypeSell && price >= action.Limit)
+		if marketable {
+			return price, ExecutionStatusExecuted, nil
+		}
+		return action.Limit, ExecutionStatusPending, nil
+	case OrderTypeStop:
+		if action.Stop <= 0 {
+			return 0, "", fmt.Errorf("%w: stop order requires a stop price", ErrInvalidRuleAction)
+		}
+		triggered := (action.Type == ActionTypeBuy && price >= action.Stop) ||
+			(action.Type == ActionTypeSell && price <= action.Stop)
+		if triggered {
+			return price, ExecutionStatusExecuted, nil
+		}
+		return action.Stop, ExecutionStatusPending, nil
+	default:
+		return 0, "", fmt.Errorf("%w: %q", ErrUnsupportedOrderType, action.OrderType)
+	}
+}
+
+// compareOperands applies a comparison operator to two resolved operands.
+// Crossovers compare the previous and current values of both sides.
+func compareOperands(left operand, operator string, right operand) (bool, error) {
+	switch operator {
+	case OperatorCrossesAbove, OperatorCrossesBelow:
+		if !left.hasPrevious || !right.hasPrevious {
+			return false, ErrCrossoverNeedsSeries
+		}
+		if operator == OperatorCrossesAbove {
+			return left.previous <= right.previous && left.current > right.current, nil
+		}
+		return left.previous >= right.previous && left.current < right.current, nil
+	default:
+		return compare(left.current, operator, right.current)
+	}
+}
+
+func isCrossover(operator string) bool {
+	return operator == OperatorCrossesAbove || operator == OperatorCrossesBelow
+}
+
+// isSupportedOperator reports whether the engine understands an operator
+func isSupportedOperator(operator string) bool {
+	switch operator {
+	case OperatorGreaterThan, OperatorGreaterThanOrEqual, OperatorLessThan, OperatorLessThanOrEqual,
+		OperatorEqual, OperatorNotEqual, OperatorCrossesAbove, OperatorCrossesBelow:
+		return true
+	}
+	return false
+}
+
+// compare applies a comparison operator to an observed value and a threshold
+func compare(value float64, operator string, threshold float64) (bool, error) {
+	switch operator {
+	case OperatorGreaterThan:
+		return value > threshold, nil
+	case OperatorGreaterThanOrEqual:
+		return value >= threshold, nil
+	case OperatorLessThan:
+		return value < threshold, nil
+	case OperatorLessThanOrEqual:
+		return value <= threshold, nil
+	case OperatorEqual:
+		return math.Abs(value-threshold) < floatTolerance, nil
+	case OperatorNotEqual:
+		return math.Abs(value-threshold) >= floatTolerance, nil
+	default:
+		return false, fmt.Errorf("%w: %q", ErrUnsupportedOperator, operator)
+	}
+}
+
+func decodeRuleConditions(rule *models.TradingRule) ([]RuleCondition, error) {
+	var conditions []RuleCondition
+	if len(rule.Conditions) == 0 {
+		return conditions, nil
+	}
+	if err := json.Unmarshal(rule.Conditions, &conditions); err != nil {
+		return nil, fmt.Errorf("failed to parse rule conditions: %w", err)
+	}
+	return conditions, nil
+}
+
+func decodeRuleActions(rule *models.TradingRule) ([]RuleAction, error) {
+	var actions []RuleAction
+	if len(rule.Actions) == 0 {
+		return actions, nil
+	}
+	if err := json.Unmarshal(rule.Actions, &actions); err != nil {
+		return nil, fmt.Errorf("failed to parse rule actions: %w", err)
+	}
+	return actions, nil
+}
+
+// marketSnapshot caches market data for the duration of a single rule evaluation
+// so every condition referencing a symbol sees the same bar and quote. Every
+// symbol is read as of the same evaluation time, so conditions comparing
+// instruments never mix data from before and after an update.
+type marketSnapshot struct {
+	marketDataService MarketDataService
+	at                time.Time
+	overrides         map[string]MarketOverride
+	bars              map[string]*models.MarketData
+	quotes            map[string]*models.Quote
+	previousCloses    map[string]float64
+
+	// The rule owner's portfolio, loaded by the first portfolio condition
+	portfolio *portfolioView
+}
+
+func newMarketSnapshot(marketDataService MarketDataService, at time.Time) *marketSnapshot {
+	return &marketSnapshot{
+		marketDataService: marketDataService,
+		at:                at,
+		bars:              make(map[string]*models.MarketData),
+		quotes:            make(map[string]*models.Quote),
+		previousCloses:    make(map[string]float64),
+	}
+}
+
+func (m *marketSnapshot) bar(ctx context.Context, symbol string) (*models.MarketData, error) {
+	if bar, ok := m.bars[symbol]; ok {
+		return bar, nil
+	}
+
+	override, overridden := m.overrides[symbol]
+	overridden = overridden && (override.Price != nil || override.Volume != nil)
+
+	// Data stored after the evaluation started is passed over for the bar
+	// current at the evaluation time
+	bar, err := m.marketDataService.GetPrice(ctx, symbol)
+	if err == nil && bar.Timestamp.After(m.at) {
+		bar, err = m.marketDataService.GetPriceAt(ctx, symbol, m.at)
+	}
+	if err != nil {
+		if !overridden {
+			return nil, err
+		}
+		bar = &models.MarketData{Symbol: symbol}
+	}
+	if overridden {
+		patched := *bar
+		if override.Price != nil {
+			patched.Close = *override.Price
+		}
+		if override.Volume != nil {
+			patched.Volume = *override.Volume
+		}
+		bar = &patched
+	}
+
+	m.bars[symbol] = bar
+	return bar, nil
+}
+
+func (m *marketSnapshot) quote(ctx context.Context, symbol string) (*models.Quote, error) {
+	if quote, ok := m.quotes[symbol]; ok {
+		return quote, nil
+	}
+
+	override, overridden := m.overrides[symbol]
+	overridden = overridden && (override.Bid != nil || override.Ask != nil)
+
+	quote, err := m.marketDataService.GetQuote(ctx, symbol)
+	if err == nil && quote.Timestamp.After(m.at) {
+		quote, err = m.marketDataService.GetQuoteAt(ctx, symbol, m.at)
+	}
+	if err != nil {
+		if !overridden {
+			return nil, err
+		}
+		quote = &models.Quote{Symbol: symbol}
+	}
+	if overridden {
+		patched := *quote
+		if override.Bid != nil {
+			patched.Bid = *override.Bid
+		}
+		if override.Ask != nil {
+			patched.Ask = *override.Ask
+		}
+		quote = &patched
+	}
+
+	m.quotes[symbol] = quote
+	return quote, nil
+}
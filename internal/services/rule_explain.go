@@ -0,0 +1,268 @@
+// internal/services/rule_explain.go
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/aquibsayyed9/sentinel/internal/models"
+)
+
+// MarketOverride replaces parts of the latest bar and quote of a symbol when
+// explaining a rule. Indicators are always computed from stored bars.
+type MarketOverride struct {
+	Price  *float64 `json:"price,omitempty"`
+	Volume *int64   `json:"volume,omitempty"`
+	Bid    *float64 `json:"bid,omitempty"`
+	Ask    *float64 `json:"ask,omitempty"`
+}
+
+// RuleExplanation is a full trace of one evaluation of a rule
+type RuleExplanation struct {
+	// Whether the rule would trigger now
+	Triggered bool `json:"triggered"`
+
+	// Whether the rule is active, its trigger policy allows a trigger now and
+	// now is within its time constraints
+	Active                bool `json:"active"`
+	TriggerAllowed        bool `json:"trigger_allowed"`
+	WithinTimeConstraints bool `json:"within_time_constraints"`
+
+	// For scheduled rules, the next run and whether it is due now
+	NextRunAt   *time.Time `json:"next_run_at,omitempty"`
+	ScheduleDue bool       `json:"schedule_due"`
+
+	// Whether the stop level, if any, and the condition tree are satisfied
+	StopMet       bool             `json:"stop_met"`
+	ConditionsMet bool             `json:"conditions_met"`
+	Stop          *StopEvaluation  `json:"stop,omitempty"`
+	Conditions    []ConditionTrace `json:"conditions"`
+
+	// The orders the rule would place, when it triggers
+	Actions []ActionPreview `json:"actions"`
+
+	EvaluatedAt time.Time `json:"evaluated_at"`
+}
+
+// ConditionTrace records how one node of a condition tree was evaluated
+type ConditionTrace struct {
+	Path     string           `json:"path"`
+	Group    string           `json:"group,omitempty"` // all, any or not
+	Children []ConditionTrace `json:"children,omitempty"`
+
+	Type      string        `json:"type,omitempty"`
+	Symbol    string        `json:"symbol,omitempty"`
+	TimeFrame string        `json:"time_frame,omitempty"`
+	Operator  string        `json:"operator,omitempty"`
+	Left      *OperandTrace `json:"left,omitempty"`
+	Right     *OperandTrace `json:"right,omitempty"`
+
+	Met   bool   `json:"met"`
+	Error string `json:"error,omitempty"`
+}
+
+// OperandTrace is the resolved value of one side of a comparison. Previous is
+// set for series values, which is what crossovers compare.
+type OperandTrace struct {
+	Source   string   `json:"source"` // condition type, or "value" for a threshold
+	Current  float64  `json:"current"`
+	Previous *float64 `json:"previous,omitempty"`
+}
+
+// ActionPreview describes the order an action would place, or for a rule
+// action the rule it would act on
+type ActionPreview struct {
+	Type      string     `json:"type"`
+	Symbol    string     `json:"symbol"`
+	Quantity  float64    `json:"quantity"`
+	OrderType string     `json:"order_type"`
+	Price     float64    `json:"price"`
+	Status    string     `json:"status"`
+	RuleID    *uuid.UUID `json:"rule_id,omitempty"`
+	Error     string     `json:"error,omitempty"`
+}
+
+func (s *ruleEngineService) ExplainRule(ctx context.Context, rule *models.TradingRule,
+	overrides map[string]MarketOverride) (*RuleExplanation, error) {
+
+	conditions, err := decodeRuleConditions(rule)
+	if err != nil {
+		return nil, err
+	}
+	stop, err := decodeStopSpec(rule)
+	if err != nil {
+		return nil, err
+	}
+	if len(conditions) == 0 && stop == nil && len(rule.Schedule) == 0 {
+		return nil, ErrNoRuleConditions
+	}
+
+	now := time.Now()
+	inTime, err := withinTimeConstraints(rule, now, s.calendar)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := newMarketSnapshot(s.marketDataService, now)
+	snapshot.overrides = overrides
+
+	explanation := &RuleExplanation{
+		Active:                rule.Status == "active",
+		TriggerAllowed:        canTrigger(rule, now),
+		WithinTimeConstraints: inTime,
+		NextRunAt:             rule.NextRunAt,
+		ScheduleDue:           len(rule.Schedule) == 0 || (rule.NextRunAt != nil && !now.Before(*rule.NextRunAt)),
+		StopMet:               true,
+		ConditionsMet:         true,
+		Conditions:            make([]ConditionTrace, len(conditions)),
+		Actions:               []ActionPreview{},
+		EvaluatedAt:           now,
+	}
+
+	// A dry run never persists a stop's reference price, and works on a copy
+	// so the caller's rule is left untouched
+	if stop != nil {
+		ruleCopy := *rule
+		result, err := s.evaluateStop(ctx, snapshot, &ruleCopy, *stop, false)
+		if err != nil {
+			return nil, err
+		}
+		explanation.Stop = &result
+		explanation.StopMet = result.Met
+	}
+
+	for i, condition := range conditions {
+		explanation.Conditions[i] = s.explainNode(ctx, snapshot, rule, condition, fmt.Sprintf("conditions[%d]", i))
+		if !explanation.Conditions[i].Met {
+			explanation.ConditionsMet = false
+		}
+	}
+
+	explanation.Triggered = explanation.Active && explanation.TriggerAllowed && explanation.WithinTimeConstraints &&
+		explanation.ScheduleDue && explanation.StopMet && explanation.ConditionsMet
+	if explanation.Triggered {
+		explanation.Actions, err = s.previewActions(ctx, snapshot, rule)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return explanation, nil
+}
+
+// explainNode evaluates a condition tree node like evaluateNode, but without
+// short-circuiting so every leaf appears in the trace. Errors are recorded on
+// the leaf that produced them and count as not met.
+func (s *ruleEngineService) explainNode(ctx context.Context, snapshot *marketSnapshot,
+	rule *models.TradingRule, node RuleCondition, path string) ConditionTrace {
+
+	trace := ConditionTrace{Path: path}
+
+	switch {
+	case node.All != nil:
+		trace.Group = "all"
+		trace.Met = true
+		for i, child := range node.All {
+			childTrace := s.explainNode(ctx, snapshot, rule, child, fmt.Sprintf("%s.all[%d]", path, i))
+			trace.Met = trace.Met && childTrace.Met
+			trace.Children = append(trace.Children, childTrace)
+		}
+	case node.Any != nil:
+		trace.Group = "any"
+		for i, child := range node.Any {
+			childTrace := s.explainNode(ctx, snapshot, rule, child, fmt.Sprintf("%s.any[%d]", path, i))
+			trace.Met = trace.Met || childTrace.Met
+			trace.Children = append(trace.Children, childTrace)
+		}
+	case node.Not != nil:
+		trace.Group = "not"
+		childTrace := s.explainNode(ctx, snapshot, rule, *node.Not, path+".not")
+		trace.Met = !childTrace.Met && childTrace.Error == ""
+		trace.Children = []ConditionTrace{childTrace}
+	default:
+		trace.Type = node.Type
+		trace.Symbol = node.Symbol
+		if trace.Symbol == "" {
+			trace.Symbol = rule.Symbol
+		}
+		trace.TimeFrame = node.TimeFrame
+		trace.Operator = node.Operator
+
+		left, right, err := s.resolveOperands(ctx, snapshot, rule, node)
+		if err == nil {
+			trace.Left = newOperandTrace(node.Type, left)
+			rightSource := "value"
+			if node.CompareTo != nil {
+				rightSource = node.CompareTo.Type
+			}
+			trace.Right = newOperandTrace(rightSource, right)
+			trace.Met, err = compareOperands(left, node.Operator, right)
+		}
+		if err != nil {
+			trace.Met = false
+			trace.Error = err.Error()
+		}
+	}
+
+	return trace
+}
+
+func newOperandTrace(source string, value operand) *OperandTrace {
+	trace := &OperandTrace{Source: source, Current: value.current}
+	if value.hasPrevious && source != "value" {
+		previous := value.previous
+		trace.Previous = &previous
+	}
+	return trace
+}
+
+// previewActions prices the rule's actions without placing any orders
+func (s *ruleEngineService) previewActions(ctx context.Context, snapshot *marketSnapshot,
+	rule *models.TradingRule) ([]ActionPreview, error) {
+
+	actions, err := decodeRuleActions(rule)
+	if err != nil {
+		return nil, err
+	}
+
+	previews := make([]ActionPreview, len(actions))
+	for i, action := range actions {
+		if IsRuleActionType(action.Type) {
+			previews[i] = s.previewRuleAction(ctx, rule, action)
+			continue
+		}
+
+		preview := ActionPreview{
+			Type:      action.Type,
+			Symbol:    action.Symbol,
+			Quantity:  action.Quantity,
+			OrderType: action.OrderType,
+		}
+		if preview.Symbol == "" {
+			preview.Symbol = rule.Symbol
+		}
+		if preview.OrderType == "" {
+			preview.OrderType = OrderTypeMarket
+		}
+
+		bar, err := snapshot.bar(ctx, preview.Symbol)
+		if err == nil {
+			err = validateAction(action)
+		}
+		if err == nil {
+			preview.Price, preview.Status, err = fillOrder(action, bar.Close)
+		}
+		if err == nil {
+			preview.Quantity, err = s.resolveActionQuantity(ctx, snapshot, rule, action, preview.Symbol, preview.Price)
+		}
+		if err != nil {
+			preview.Error = err.Error()
+		}
+		previews[i] = preview
+	}
+
+	return previews, nil
+}
@@ -0,0 +1,248 @@
+// internal/services/rule_indicators.go
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aquibsayyed9/sentinel/internal/indicators"
+	"github.com/aquibsayyed9/sentinel/internal/models"
+)
+
+var (
+	ErrIndicatorNotReady = errors.New("not enough market data for indicator")
+)
+
+// DefaultIndicatorTimeFrame is used for indicator conditions without a time frame
+const DefaultIndicatorTimeFrame = "1d"
+
+const (
+	// indicatorIdleTTL is how long an indicator series is kept without being used
+	indicatorIdleTTL = time.Hour
+
+	// indicatorSweepInterval is how often idle indicator series are evicted
+	indicatorSweepInterval = 10 * time.Minute
+)
+
+// operand is a resolved side of a comparison. Series operands also carry the
+// value as of the previous bar so crossovers can be detected, and the time of
+// the bar their current value was computed from.
+type operand struct {
+	current     float64
+	previous    float64
+	hasPrevious bool
+	bar         time.Time
+}
+
+func constantOperand(value float64) operand {
+	return operand{current: value, previous: value, hasPrevious: true}
+}
+
+// indicatorCache keeps streaming indicator state between evaluations so each
+// evaluation only feeds the bars that arrived since the previous one
+type indicatorCache struct {
+	marketDataService MarketDataService
+
+	mu        sync.Mutex
+	series    map[string]*indicatorSeries
+	lastSweep time.Time
+}
+
+type indicatorSeries struct {
+	mu         sync.Mutex
+	indicator  indicators.Indicator
+	lastBar    time.Time
+	current    float64
+	currentBar time.Time
+	previous   float64
+	values     int
+	lastUsed   time.Time
+}
+
+func newIndicatorCache(marketDataService MarketDataService) *indicatorCache {
+	return &indicatorCache{
+		marketDataService: marketDataService,
+		series:            make(map[string]*indicatorSeries),
+		lastSweep:         time.Now(),
+	}
+}
+
+// value brings the indicator series up to date with the bars up to at and
+// returns its latest values
+func (c *indicatorCache) value(ctx context.Context, symbol, timeFrame, kind string, params indicators.Params, at time.Time) (operand, error) {
+	frame, err := models.TimeFrameDuration(timeFrame)
+	if err != nil {
+		return operand{}, err
+	}
+
+	series, err := c.get(fmt.Sprintf("%s|%s|%s|%+v", symbol, timeFrame, kind, params), kind, params)
+	if err != nil {
+		return operand{}, err
+	}
+
+	series.mu.Lock()
+	defer series.mu.Unlock()
+
+	series.lastUsed = time.Now()
+
+	start := series.lastBar
+	if start.IsZero() {
+		start = at.Add(-warmupWindow(frame, series.indicator.WarmupPeriod()))
+	}
+
+	// A concurrent evaluation may already have fed bars past at
+	end := at
+	if end.Before(start) {
+		end = start
+	}
+
+	bars, err := c.marketDataService.GetHistoricalData(ctx, symbol, start, end, timeFrame)
+	if err != nil {
+		return operand{}, err
+	}
+
+	for _, bar := range bars {
+		if !bar.Timestamp.After(series.lastBar) {
+			continue
+		}
+		series.indicator.Update(bar)
+		series.lastBar = bar.Timestamp
+		if series.indicator.Ready() {
+			series.previous = series.current
+			series.current = series.indicator.Value()
+			series.currentBar = bar.Timestamp
+			series.values++
+		}
+	}
+
+	if !series.indicator.Ready() {
+		return operand{}, fmt.Errorf("%w: %s on %s %s", ErrIndicatorNotReady, kind, symbol, timeFrame)
+	}
+
+	return operand{
+		current:     series.current,
+		previous:    series.previous,
+		hasPrevious: series.values > 1,
+		bar:         series.currentBar,
+	}, nil
+}
+
+func (c *indicatorCache) get(key, kind string, params indicators.Params) (*indicatorSeries, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(c.lastSweep) > indicatorSweepInterval {
+		for k, series := range c.series {
+			series.mu.Lock()
+			idle := now.Sub(series.lastUsed) > indicatorIdleTTL
+			series.mu.Unlock()
+			if idle {
+				delete(c.series, k)
+			}
+		}
+		c.lastSweep = now
+	}
+
+	if series, ok := c.series[key]; ok {
+		return series, nil
+	}
+
+	indicator, err := indicators.New(kind, params)
+	if err != nil {
+		return nil, err
+	}
+
+	series := &indicatorSeries{indicator: indicator, lastUsed: now}
+	c.series[key] = series
+	return series, nil
+}
+
+// crossTracker remembers, for each crossover condition of each rule, the
+// latest bar it was evaluated on. A crossover happens on one bar, but rules
+// are evaluated on every quote, so it is only reported the first time the
+// bar it happened on is seen.
+type crossTracker struct {
+	mu        sync.Mutex
+	seen      map[string]crossSeen
+	lastSweep time.Time
+}
+
+type crossSeen struct {
+	bar      time.Time
+	lastUsed time.Time
+}
+
+func newCrossTracker() *crossTracker {
+	return &crossTracker{seen: make(map[string]crossSeen), lastSweep: time.Now()}
+}
+
+// consume records that a crossover condition was evaluated on a bar and
+// reports whether it is a newer bar than the condition was last evaluated on
+func (t *crossTracker) consume(key string, bar time.Time) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(t.lastSweep) > indicatorSweepInterval {
+		for k, seen := range t.seen {
+			if now.Sub(seen.lastUsed) > indicatorIdleTTL {
+				delete(t.seen, k)
+			}
+		}
+		t.lastSweep = now
+	}
+
+	previous, ok := t.seen[key]
+	fresh := !ok || bar.After(previous.bar)
+	if fresh {
+		previous.bar = bar
+	}
+	previous.lastUsed = now
+	t.seen[key] = previous
+	return fresh
+}
+
+// warmupWindow returns how far back to load bars when an indicator is first
+// used. Markets are closed for part of every day and week, so the window is
+// padded well beyond warmup bars of continuous trading.
+func warmupWindow(frame time.Duration, warmup int) time.Duration {
+	window := time.Duration(3*warmup+10) * frame
+	if frame < 24*time.Hour {
+		return 4 * window
+	}
+	return window*3/2 + 7*24*time.Hour
+}
+
+// isSeriesCondition reports whether a leaf condition is resolved from a bar
+// series rather than from the latest bar or quote
+func isSeriesCondition(condition RuleCondition) bool {
+	if isLeggedCondition(condition.Type) {
+		return len(condition.Legs) == 2 && isSeriesCondition(condition.Legs[0]) && isSeriesCondition(condition.Legs[1])
+	}
+	if condition.Type == indicators.KindPrice {
+		return condition.TimeFrame != ""
+	}
+	return indicators.IsKind(condition.Type)
+}
+
+// indicatorParams decodes the free-form params of a condition
+func indicatorParams(condition RuleCondition) (indicators.Params, error) {
+	var params indicators.Params
+	if condition.Params == nil {
+		return params, nil
+	}
+
+	data, err := json.Marshal(condition.Params)
+	if err != nil {
+		return params, err
+	}
+	if err := json.Unmarshal(data, &params); err != nil {
+		return params, fmt.Errorf("%w: %v", indicators.ErrInvalidParams, err)
+	}
+	return params, nil
+}
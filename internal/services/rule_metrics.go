@@ -0,0 +1,205 @@
+// internal/services/rule_metrics.go
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/aquibsayyed9/sentinel/internal/models"
+	"github.com/aquibsayyed9/sentinel/internal/repository"
+)
+
+// RuleStatusErrored is the status of a rule quarantined after failing to
+// evaluate or execute too many times in a row. It is not evaluated until its
+// owner activates it again.
+const RuleStatusErrored = "errored"
+
+// RuleMetricsConfig tunes how rule evaluations are recorded
+type RuleMetricsConfig struct {
+	// How often recorded evaluations are written to the database
+	FlushInterval time.Duration
+
+	// Consecutive errors after which a rule is quarantined; 0 never
+	// quarantines rules
+	QuarantineAfter int
+
+	// Called when a rule is quarantined, with the error that tipped it over
+	OnQuarantine func(rule *models.TradingRule, err error)
+
+	// Called when metrics fail to be written or a rule fails to be quarantined
+	OnError func(err error)
+}
+
+// RuleMetrics records how each rule fares in the rule engine: how often it
+// is processed, triggers and fails, and how long processing takes. Records
+// are batched in memory and flushed periodically; a batch that fails to
+// flush is dropped. Rules that keep failing are quarantined and their
+// owners notified.
+type RuleMetrics struct {
+	ruleRepo   repository.RuleRepository
+	notifier   Notifier
+	transactor repository.Transactor
+	config     RuleMetricsConfig
+
+	mu      sync.Mutex
+	pending map[uuid.UUID]*ruleMetricsBatch
+}
+
+// ruleMetricsBatch is what has been recorded for a rule since the last flush
+type ruleMetricsBatch struct {
+	delta   repository.RuleMetricsDelta
+	rule    *models.TradingRule
+	lastErr error
+}
+
+// NewRuleMetrics creates a metrics recorder, applying defaults to unset
+// config values
+func NewRuleMetrics(ruleRepo repository.RuleRepository, notifier Notifier, transactor repository.Transactor,
+	config RuleMetricsConfig) *RuleMetrics {
+
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = 10 * time.Second
+	}
+	if config.OnQuarantine == nil {
+		config.OnQuarantine = func(*models.TradingRule, error) {}
+	}
+	if config.OnError == nil {
+		config.OnError = func(error) {}
+	}
+
+	return &RuleMetrics{
+		ruleRepo:   ruleRepo,
+		notifier:   notifier,
+		transactor: transactor,
+		config:     config,
+		pending:    make(map[uuid.UUID]*ruleMetricsBatch),
+	}
+}
+
+// Observe records one processing of a rule that took latency, and whether it
+// triggered or failed. A rule still waiting for market data has not failed,
+// and neither adds to nor ends its run of errors.
+func (m *RuleMetrics) Observe(rule *models.TradingRule, triggered bool, err error, latency time.Duration) {
+	now := time.Now()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	batch, ok := m.pending[rule.ID]
+	if !ok {
+		batch = &ruleMetricsBatch{delta: repository.RuleMetricsDelta{RuleID: rule.ID}}
+		m.pending[rule.ID] = batch
+	}
+	batch.rule = rule
+
+	delta := &batch.delta
+	delta.Evaluations++
+	delta.TotalLatency += latency
+	if latency > delta.MaxLatency {
+		delta.MaxLatency = latency
+	}
+	delta.LastEvaluatedAt = now
+
+	if !triggered && awaitingMarketData(err) {
+		return
+	}
+	if err != nil {
+		message := err.Error()
+		delta.Errors++
+		delta.TrailingErrors++
+		delta.LastError = &message
+		delta.LastErrorAt = &now
+		batch.lastErr = err
+		return
+	}
+	delta.TrailingErrors = 0
+	delta.Recovered = true
+	if triggered {
+		delta.Triggers++
+	}
+}
+
+// awaitingMarketData reports whether an evaluation failed only because the
+// market data it reads has not been ingested yet, as for an indicator whose
+// period spans more bars than are stored
+func awaitingMarketData(err error) bool {
+	return errors.Is(err, ErrIndicatorNotReady) || errors.Is(err, repository.ErrMarketDataNotFound)
+}
+
+// Flush writes the evaluations recorded since the last flush, quarantining
+// rules that have now failed too many times in a row
+func (m *RuleMetrics) Flush(ctx context.Context) {
+	m.mu.Lock()
+	pending := m.pending
+	m.pending = make(map[uuid.UUID]*ruleMetricsBatch)
+	m.mu.Unlock()
+
+	for _, batch := range pending {
+		consecutiveErrors, err := m.ruleRepo.RecordMetrics(ctx, batch.delta)
+		if err != nil {
+			m.config.OnError(fmt.Errorf("failed to record metrics of rule %s: %w", batch.rule.ID, err))
+			continue
+		}
+
+		// Only a batch that ended in an error can tip a rule over
+		if m.config.QuarantineAfter > 0 && batch.delta.TrailingErrors > 0 && consecutiveErrors >= m.config.QuarantineAfter {
+			if err := m.quarantine(ctx, batch.rule, consecutiveErrors, batch.lastErr); err != nil {
+				m.config.OnError(fmt.Errorf("failed to quarantine rule %s: %w", batch.rule.ID, err))
+			}
+		}
+	}
+}
+
+// quarantine sets the rule to errored and tells its owner, unless the rule
+// is no longer active
+func (m *RuleMetrics) quarantine(ctx context.Context, rule *models.TradingRule, consecutiveErrors int, cause error) error {
+	quarantined := false
+	err := m.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if quarantined, err = m.ruleRepo.QuarantineRule(ctx, rule.ID); err != nil || !quarantined {
+			return err
+		}
+
+		return m.notifier.Notify(ctx, &models.Notification{
+			UserID: rule.UserID,
+			RuleID: &rule.ID,
+			Kind:   NotificationKindRuleQuarantined,
+			Message: fmt.Sprintf("Rule %q was paused after failing %d times in a row. Last error: %v. "+
+				"Activate it again once the problem is fixed.", rule.Name, consecutiveErrors, cause),
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	if quarantined {
+		m.config.OnQuarantine(rule, cause)
+	}
+	return nil
+}
+
+// Run flushes recorded evaluations periodically until ctx is cancelled, then
+// flushes once more
+func (m *RuleMetrics) Run(ctx context.Context) error {
+	ticker := time.NewTicker(m.config.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			// The final flush runs after ctx is cancelled, so it is given a
+			// context of its own
+			flushCtx, cancel := context.WithTimeout(context.Background(), m.config.FlushInterval)
+			defer cancel()
+			m.Flush(flushCtx)
+			return nil
+		case <-ticker.C:
+			m.Flush(ctx)
+		}
+	}
+}
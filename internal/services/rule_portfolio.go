@@ -0,0 +1,227 @@
+// internal/services/rule_portfolio.go
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/google/uuid"
+
+	"github.com/aquibsayyed9/sentinel/internal/models"
+	"github.com/aquibsayyed9/sentinel/internal/repository"
+)
+
+var (
+	ErrNoPosition = errors.New("no position")
+)
+
+// Condition types that read the rule owner's portfolio rather than market
+// data. Positions are valued at the evaluation's market prices, falling back
+// to a holding's last known price for symbols without market data.
+const (
+	// Shares held of the symbol, 0 without a position
+	ConditionTypePositionQuantity = "position_quantity"
+
+	// Unrealized profit or loss of the position as a percent of its cost
+	ConditionTypePositionPnLPercent = "position_pnl_percent"
+
+	// Value of the position as a percent of the portfolio's total value
+	ConditionTypePositionWeight = "position_weight"
+
+	// Cash available in the portfolio
+	ConditionTypeCashBalance = "cash_balance"
+)
+
+// isPortfolioCondition reports whether a condition type reads the portfolio
+func isPortfolioCondition(conditionType string) bool {
+	switch conditionType {
+	case ConditionTypePositionQuantity, ConditionTypePositionPnLPercent,
+		ConditionTypePositionWeight, ConditionTypeCashBalance:
+		return true
+	}
+	return false
+}
+
+// portfolioView is a portfolio and its holdings as loaded for one evaluation
+// or action
+type portfolioView struct {
+	portfolio *models.Portfolio
+	holdings  []models.PortfolioHolding
+}
+
+// holding returns the holding of a symbol, or nil without a position
+func (p *portfolioView) holding(symbol string) *models.PortfolioHolding {
+	for i := range p.holdings {
+		if p.holdings[i].Symbol == symbol {
+			return &p.holdings[i]
+		}
+	}
+	return nil
+}
+
+// loadPortfolio loads a user's portfolio and holdings
+func (s *ruleEngineService) loadPortfolio(ctx context.Context, userID uuid.UUID) (*portfolioView, error) {
+	portfolio, err := s.portfolioService.GetPortfolioByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	holdings, err := s.portfolioService.GetHoldings(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &portfolioView{portfolio: portfolio, holdings: holdings}, nil
+}
+
+// snapshotPortfolio returns the rule owner's portfolio, loading it once per
+// evaluation so every condition sees the same positions
+func (s *ruleEngineService) snapshotPortfolio(ctx context.Context, snapshot *marketSnapshot, userID uuid.UUID) (*portfolioView, error) {
+	if snapshot.portfolio != nil {
+		return snapshot.portfolio, nil
+	}
+	view, err := s.loadPortfolio(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	snapshot.portfolio = view
+	return view, nil
+}
+
+// portfolioValue resolves a portfolio condition for a symbol
+func (s *ruleEngineService) portfolioValue(ctx context.Context, snapshot *marketSnapshot,
+	rule *models.TradingRule, symbol, conditionType string) (float64, error) {
+
+	view, err := s.snapshotPortfolio(ctx, snapshot, rule.UserID)
+	if err != nil {
+		return 0, err
+	}
+	if conditionType == ConditionTypeCashBalance {
+		return view.portfolio.CashBalance, nil
+	}
+
+	holding := view.holding(symbol)
+	if holding == nil {
+		if conditionType == ConditionTypePositionPnLPercent {
+			return 0, fmt.Errorf("%w in %s", ErrNoPosition, symbol)
+		}
+		return 0, nil
+	}
+
+	switch conditionType {
+	case ConditionTypePositionQuantity:
+		return holding.Quantity, nil
+	case ConditionTypePositionPnLPercent:
+		if holding.AverageCost <= 0 {
+			return 0, fmt.Errorf("%w: the position in %s has no cost", ErrNoPosition, symbol)
+		}
+		price, err := holdingPrice(ctx, snapshot, *holding)
+		if err != nil {
+			return 0, err
+		}
+		// A short position gains as the price falls
+		pnl := (price - holding.AverageCost) / holding.AverageCost * 100
+		if holding.Quantity < 0 {
+			pnl = -pnl
+		}
+		return pnl, nil
+	default:
+		total, err := portfolioTotal(ctx, snapshot, view, "", 0)
+		if err != nil {
+			return 0, err
+		}
+		price, err := holdingPrice(ctx, snapshot, *holding)
+		if err != nil {
+			return 0, err
+		}
+		if total <= 0 {
+			return 0, nil
+		}
+		return holding.Quantity * price / total * 100, nil
+	}
+}
+
+// holdingPrice returns the price of a holding as of the snapshot, or its last
+// known price when the symbol has no market data
+func holdingPrice(ctx context.Context, snapshot *marketSnapshot, holding models.PortfolioHolding) (float64, error) {
+	bar, err := snapshot.bar(ctx, holding.Symbol)
+	if err != nil {
+		if errors.Is(err, repository.ErrMarketDataNotFound) {
+			return holding.CurrentPrice, nil
+		}
+		return 0, err
+	}
+	return bar.Close, nil
+}
+
+// portfolioTotal returns the cash and the value of every holding, pricing
+// symbol at price when symbol is given
+func portfolioTotal(ctx context.Context, snapshot *marketSnapshot, view *portfolioView, symbol string, price float64) (float64, error) {
+	total := view.portfolio.CashBalance
+	for _, holding := range view.holdings {
+		if holding.Symbol == symbol {
+			total += holding.Quantity * price
+			continue
+		}
+		holdingValue, err := holdingPrice(ctx, snapshot, holding)
+		if err != nil {
+			return 0, err
+		}
+		total += holding.Quantity * holdingValue
+	}
+	return total, nil
+}
+
+// isRelativeAction reports whether an action's quantity is relative to the
+// portfolio and so resolved when the action executes
+func isRelativeAction(action RuleAction) bool {
+	return action.PositionPercent > 0 || action.CashPercent > 0 || action.TargetWeight > 0
+}
+
+// resolveActionQuantity returns the quantity an action trades at a fill
+// price. Relative quantities are resolved against the rule owner's portfolio
+// as it stands now, so earlier actions of the same rule are taken into
+// account. A result of 0 means there is nothing to trade.
+func (s *ruleEngineService) resolveActionQuantity(ctx context.Context, snapshot *marketSnapshot,
+	rule *models.TradingRule, action RuleAction, symbol string, fillPrice float64) (float64, error) {
+
+	if !isRelativeAction(action) {
+		return actionQuantity(action, fillPrice), nil
+	}
+	if fillPrice <= 0 {
+		return 0, fmt.Errorf("%w: cannot size an order at price %v", ErrInvalidRuleAction, fillPrice)
+	}
+
+	view, err := s.loadPortfolio(ctx, rule.UserID)
+	if err != nil {
+		return 0, err
+	}
+
+	held := 0.0
+	if holding := view.holding(symbol); holding != nil {
+		held = holding.Quantity
+	}
+
+	switch {
+	case action.PositionPercent > 0:
+		return math.Max(held*action.PositionPercent/100, 0), nil
+
+	case action.CashPercent > 0:
+		return math.Max(view.portfolio.CashBalance*action.CashPercent/100/fillPrice, 0), nil
+
+	default:
+		total, err := portfolioTotal(ctx, snapshot, view, symbol, fillPrice)
+		if err != nil {
+			return 0, err
+		}
+		target := total * action.TargetWeight / 100 / fillPrice
+
+		// A buy only adds up to the target weight and a sell only trims down
+		// to it
+		delta := target - held
+		if action.Type == ActionTypeSell {
+			delta = -delta
+		}
+		return math.Max(delta, 0), nil
+	}
+}
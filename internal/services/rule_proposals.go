@@ -0,0 +1,177 @@
+// internal/services/rule_proposals.go
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/aquibsayyed9/sentinel/internal/models"
+	"github.com/aquibsayyed9/sentinel/internal/repository"
+)
+
+// Statuses of a rule proposal
+const (
+	RuleProposalStatusPending    = "pending"
+	RuleProposalStatusApplied    = "applied"
+	RuleProposalStatusRejected   = "rejected"
+	RuleProposalStatusSuperseded = "superseded"
+)
+
+var (
+	ErrRuleProposalStale = errors.New("rule has changed since the proposal was made")
+)
+
+// RuleProposalService lets owners review the adjustments strategy advisors
+// propose for their AI-managed rules
+type RuleProposalService interface {
+	// Lists the proposals for one of userID's rules, newest first
+	GetProposals(ctx context.Context, userID, ruleID uuid.UUID) ([]models.RuleProposal, error)
+
+	// Applies a pending proposal as the rule's next version. If the rule has
+	// changed since the proposal was made, the proposal is superseded instead
+	// and ErrRuleProposalStale returned.
+	ApproveProposal(ctx context.Context, userID, ruleID, proposalID uuid.UUID) (*models.TradingRule, error)
+	RejectProposal(ctx context.Context, userID, ruleID, proposalID uuid.UUID) error
+}
+
+type ruleProposalService struct {
+	rules        *ruleService
+	proposalRepo repository.RuleProposalRepository
+	transactor   repository.Transactor
+}
+
+func NewRuleProposalService(ruleRepo repository.RuleRepository, proposalRepo repository.RuleProposalRepository,
+	transactor repository.Transactor) RuleProposalService {
+
+	return newRuleProposalService(ruleRepo, proposalRepo, transactor)
+}
+
+func newRuleProposalService(ruleRepo repository.RuleRepository, proposalRepo repository.RuleProposalRepository,
+	transactor repository.Transactor) *ruleProposalService {
+
+	return &ruleProposalService{
+		rules:        &ruleService{ruleRepo: ruleRepo},
+		proposalRepo: proposalRepo,
+		transactor:   transactor,
+	}
+}
+
+// DecodeProposalAdjustments returns the adjustments stored in a proposal
+func DecodeProposalAdjustments(proposal *models.RuleProposal) ([]ParameterAdjustment, error) {
+	var adjustments []ParameterAdjustment
+	if err := json.Unmarshal(proposal.Adjustments, &adjustments); err != nil {
+		return nil, err
+	}
+	return adjustments, nil
+}
+
+func (s *ruleProposalService) GetProposals(ctx context.Context, userID, ruleID uuid.UUID) ([]models.RuleProposal, error) {
+	if _, err := s.rules.ruleRepo.GetByIDAndUserID(ctx, ruleID, userID); err != nil {
+		return nil, err
+	}
+	return s.proposalRepo.GetByRuleID(ctx, ruleID)
+}
+
+func (s *ruleProposalService) ApproveProposal(ctx context.Context, userID, ruleID, proposalID uuid.UUID) (*models.TradingRule, error) {
+	var rule *models.TradingRule
+	stale := false
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		proposal, err := s.userProposal(ctx, userID, ruleID, proposalID)
+		if err != nil {
+			return err
+		}
+		if proposal.Status != RuleProposalStatusPending {
+			return repository.ErrRuleProposalDecided
+		}
+
+		if rule, err = s.rules.ruleRepo.GetByIDAndUserID(ctx, ruleID, userID); err != nil {
+			return err
+		}
+		if rule.Version != proposal.RuleVersion {
+			stale = true
+			return s.decide(ctx, proposal, RuleProposalStatusSuperseded, false)
+		}
+
+		rule, err = s.apply(ctx, rule, proposal, false)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if stale {
+		return nil, ErrRuleProposalStale
+	}
+	return rule, nil
+}
+
+func (s *ruleProposalService) RejectProposal(ctx context.Context, userID, ruleID, proposalID uuid.UUID) error {
+	proposal, err := s.userProposal(ctx, userID, ruleID, proposalID)
+	if err != nil {
+		return err
+	}
+	return s.decide(ctx, proposal, RuleProposalStatusRejected, false)
+}
+
+// userProposal loads a proposal for one of userID's rules
+func (s *ruleProposalService) userProposal(ctx context.Context, userID, ruleID, proposalID uuid.UUID) (*models.RuleProposal, error) {
+	proposal, err := s.proposalRepo.GetByIDAndUserID(ctx, proposalID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if proposal.RuleID != ruleID {
+		return nil, repository.ErrRuleProposalNotFound
+	}
+	return proposal, nil
+}
+
+// apply stores the rule with the proposal's adjustments as its next version,
+// and records the proposal as applied. The rule must be at the version the
+// proposal was made for. The values the owner set still bound later
+// auto-applied proposals, so repeated adjustments cannot drift the rule
+// further than the owner allowed.
+func (s *ruleProposalService) apply(ctx context.Context, rule *models.TradingRule, proposal *models.RuleProposal,
+	auto bool) (*models.TradingRule, error) {
+
+	adjustments, err := DecodeProposalAdjustments(proposal)
+	if err != nil {
+		return nil, err
+	}
+
+	input, err := RuleInputOf(rule)
+	if err != nil {
+		return nil, err
+	}
+	params := ruleParameters(&input)
+	for _, adjustment := range adjustments {
+		param := findParameter(params, adjustment.Path)
+		if param == nil {
+			return nil, fmt.Errorf("%w: %s is not a parameter of the rule", ErrRuleProposalStale, adjustment.Path)
+		}
+		*param.value = adjustment.To
+	}
+
+	rule, err = s.rules.updateDefinition(ctx, rule.UserID, rule, input, false)
+	if err != nil {
+		return nil, err
+	}
+
+	version := rule.Version
+	proposal.AppliedVersion = &version
+	if err := s.decide(ctx, proposal, RuleProposalStatusApplied, auto); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+func (s *ruleProposalService) decide(ctx context.Context, proposal *models.RuleProposal, status string, auto bool) error {
+	now := time.Now()
+	proposal.Status = status
+	proposal.AutoApplied = auto
+	proposal.DecidedAt = &now
+	return s.proposalRepo.Decide(ctx, proposal)
+}
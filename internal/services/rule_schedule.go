@@ -0,0 +1,237 @@
+// internal/services/rule_schedule.go
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aquibsayyed9/sentinel/internal/calendar"
+	"github.com/aquibsayyed9/sentinel/internal/models"
+)
+
+var (
+	ErrInvalidSchedule = errors.New("invalid schedule")
+)
+
+// RuleTypeScheduled rules trigger at the times given by their schedule
+// rather than when a market value crosses a threshold. Any conditions they
+// have must also hold for a run to trigger.
+const RuleTypeScheduled = "scheduled"
+
+// Catch-up policies for the runs a scheduled rule missed while the rule
+// engine was down
+const (
+	CatchUpSkip = "skip" // drop missed runs
+	CatchUpOnce = "once" // trigger once for all missed runs
+	CatchUpAll  = "all"  // trigger for every missed run, up to MaxCatchUpRuns
+)
+
+// MaxCatchUpRuns bounds how many missed runs the all policy triggers at once
+const MaxCatchUpRuns = 10
+
+// MinScheduleInterval is the shortest interval a schedule may repeat at
+const MinScheduleInterval = time.Minute
+
+// scheduleMisfireGrace is how late a run may be processed and still count as
+// on time rather than missed
+const scheduleMisfireGrace = time.Minute
+
+// ScheduleSpec gives the times a scheduled rule triggers: either a cron
+// expression, such as "30 9 * * mon" for every Monday at 9:30, or a fixed
+// interval from the time the schedule was set.
+type ScheduleSpec struct {
+	Cron            string `json:"cron,omitempty"`
+	IntervalSeconds int    `json:"interval_seconds,omitempty"`
+
+	// IANA time zone of the cron expression, the exchange's time zone by default
+	TimeZone string `json:"time_zone,omitempty"`
+
+	// Skip runs that fall on days the exchange doesn't trade
+	TradingDaysOnly bool `json:"trading_days_only,omitempty"`
+
+	// What to do about runs missed while the engine was down; skip by default
+	CatchUp string `json:"catch_up,omitempty"`
+}
+
+// Validate checks the schedule's timing and catch-up policy
+func (s ScheduleSpec) Validate() error {
+	v := &ruleValidator{}
+	v.schedule(RuleTypeScheduled, &s)
+	return v.result(ErrInvalidSchedule)
+}
+
+// Next returns the first run of the schedule after t. Interval schedules run
+// at whole intervals after t, which the caller should pass as the previous
+// run. cal gives the default time zone and the trading days.
+func (s ScheduleSpec) Next(t time.Time, cal *calendar.Calendar) (time.Time, error) {
+	if s.IntervalSeconds > 0 {
+		interval := time.Duration(s.IntervalSeconds) * time.Second
+		next := t.Add(interval)
+		for i := 0; s.TradingDaysOnly && !cal.IsTradingDay(next); i++ {
+			if i > 366*24*60*60/s.IntervalSeconds {
+				return time.Time{}, fmt.Errorf("%w: no run on a trading day within a year", ErrInvalidSchedule)
+			}
+			next = next.Add(interval)
+		}
+		return next, nil
+	}
+
+	cron, err := calendar.ParseCron(s.Cron)
+	if err != nil {
+		return time.Time{}, err
+	}
+	location := cal.Location
+	if s.TimeZone != "" {
+		if location, err = loadLocation(s.TimeZone); err != nil {
+			return time.Time{}, err
+		}
+	}
+
+	next := t.In(location)
+	for {
+		var ok bool
+		if next, ok = cron.Next(next); !ok {
+			return time.Time{}, fmt.Errorf("%w: %q never runs", ErrInvalidSchedule, s.Cron)
+		}
+		if !s.TradingDaysOnly || cal.IsTradingDay(next) {
+			return next, nil
+		}
+	}
+}
+
+// decodeSchedule returns the schedule stored on a rule, or nil if it has none
+func decodeSchedule(rule *models.TradingRule) (*ScheduleSpec, error) {
+	if len(rule.Schedule) == 0 {
+		return nil, nil
+	}
+	var schedule *ScheduleSpec
+	if err := json.Unmarshal(rule.Schedule, &schedule); err != nil {
+		return nil, fmt.Errorf("failed to parse rule schedule: %w", err)
+	}
+	return schedule, nil
+}
+
+// scheduleNextRun sets the rule's next run to the first one after now, or
+// clears it if the rule has no schedule
+func scheduleNextRun(rule *models.TradingRule, now time.Time, cal *calendar.Calendar) error {
+	schedule, err := decodeSchedule(rule)
+	if err != nil || schedule == nil {
+		rule.NextRunAt = nil
+		return err
+	}
+	next, err := schedule.Next(now, cal)
+	if err != nil {
+		return err
+	}
+	rule.NextRunAt = &next
+	return nil
+}
+
+// dueRuns returns how many runs of a schedule to trigger at now under its
+// catch-up policy, and the run after those. A rule whose next run is still
+// ahead has no runs due.
+func dueRuns(schedule ScheduleSpec, nextRunAt *time.Time, now time.Time, cal *calendar.Calendar) (int, time.Time, error) {
+	if nextRunAt == nil {
+		next, err := schedule.Next(now, cal)
+		return 0, next, err
+	}
+	if now.Before(*nextRunAt) {
+		return 0, *nextRunAt, nil
+	}
+
+	// Count the runs due by now, only walking past the cap far enough to
+	// know it was reached
+	due, latest := 1, *nextRunAt
+	for due <= MaxCatchUpRuns {
+		run, err := schedule.Next(latest, cal)
+		if err != nil {
+			return 0, time.Time{}, err
+		}
+		if run.After(now) {
+			break
+		}
+		due, latest = due+1, run
+	}
+
+	// Interval schedules keep their phase; cron schedules resume after now
+	var next time.Time
+	var err error
+	if schedule.IntervalSeconds > 0 {
+		interval := time.Duration(schedule.IntervalSeconds) * time.Second
+		next = latest.Add((now.Sub(latest)/interval + 1) * interval)
+		if schedule.TradingDaysOnly && !cal.IsTradingDay(next) {
+			next, err = schedule.Next(next, cal)
+		}
+	} else {
+		next, err = schedule.Next(now, cal)
+	}
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+
+	onTime := now.Sub(latest) <= scheduleMisfireGrace
+	switch schedule.CatchUp {
+	case CatchUpOnce:
+		return 1, next, nil
+	case CatchUpAll:
+		return min(due, MaxCatchUpRuns), next, nil
+	default:
+		if onTime {
+			return 1, next, nil
+		}
+		return 0, next, nil
+	}
+}
+
+func (v *ruleValidator) schedule(ruleType string, schedule *ScheduleSpec) {
+	if schedule == nil {
+		if ruleType == RuleTypeScheduled {
+			v.add("schedule", "scheduled rules require a schedule")
+		}
+		return
+	}
+	if ruleType != RuleTypeScheduled {
+		v.add("schedule", "rule type %q does not support a schedule", ruleType)
+		return
+	}
+	errorCount := len(v.fields)
+
+	switch {
+	case schedule.Cron != "" && schedule.IntervalSeconds != 0:
+		v.add("schedule", "exactly one of cron or interval_seconds is required")
+	case schedule.Cron != "":
+		if _, err := calendar.ParseCron(schedule.Cron); err != nil {
+			v.add("schedule.cron", "%v", err)
+		}
+	case schedule.IntervalSeconds != 0:
+		if time.Duration(schedule.IntervalSeconds)*time.Second < MinScheduleInterval {
+			v.add("schedule.interval_seconds", "must be at least %d", int(MinScheduleInterval.Seconds()))
+		}
+		if schedule.TimeZone != "" {
+			v.add("schedule.time_zone", "only applies to cron schedules")
+		}
+	default:
+		v.add("schedule", "exactly one of cron or interval_seconds is required")
+	}
+
+	if schedule.TimeZone != "" {
+		if _, err := loadLocation(schedule.TimeZone); err != nil {
+			v.add("schedule.time_zone", "unknown time zone %q", schedule.TimeZone)
+		}
+	}
+
+	switch schedule.CatchUp {
+	case "", CatchUpSkip, CatchUpOnce, CatchUpAll:
+	default:
+		v.add("schedule.catch_up", "must be %q, %q or %q", CatchUpSkip, CatchUpOnce, CatchUpAll)
+	}
+
+	// A well-formed expression may still never match, such as on 30 February
+	if len(v.fields) == errorCount {
+		if _, err := schedule.Next(time.Now(), calendar.NYSE()); err != nil {
+			v.add("schedule", "%v", err)
+		}
+	}
+}
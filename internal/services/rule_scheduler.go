@@ -0,0 +1,106 @@
+// internal/services/rule_scheduler.go
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/aquibsayyed9/sentinel/internal/models"
+	"github.com/aquibsayyed9/sentinel/internal/repository"
+)
+
+// RuleSchedulerConfig tunes the scheduled rule runner
+type RuleSchedulerConfig struct {
+	// How often due scheduled rules are looked up
+	PollInterval time.Duration
+
+	// Decides which rules this instance runs when several instances share
+	// the database. Nil runs every rule.
+	Coordinator RuleCoordinator
+
+	// Records how each rule fares and quarantines failing rules. Nil records
+	// nothing.
+	Metrics *RuleMetrics
+
+	// Called when a rule triggers
+	OnTrigger func(rule *models.TradingRule)
+
+	// Called when a rule fails to evaluate or execute. The rule is nil for
+	// errors that are not specific to a rule, such as a failed lookup.
+	OnError func(rule *models.TradingRule, err error)
+}
+
+// RuleScheduler runs scheduled rules when their next run comes due. Runs
+// missed while the engine was down are handled by each rule's catch-up policy
+// when it is next processed.
+type RuleScheduler struct {
+	ruleRepo repository.RuleRepository
+	engine   RuleEngineService
+	config   RuleSchedulerConfig
+}
+
+// NewRuleScheduler creates a scheduler, applying defaults to unset config values
+func NewRuleScheduler(ruleRepo repository.RuleRepository, engine RuleEngineService, config RuleSchedulerConfig) *RuleScheduler {
+	if config.PollInterval <= 0 {
+		config.PollInterval = 5 * time.Second
+	}
+	if config.Coordinator == nil {
+		config.Coordinator = standaloneCoordinator{}
+	}
+	if config.OnTrigger == nil {
+		config.OnTrigger = func(*models.TradingRule) {}
+	}
+	if config.OnError == nil {
+		config.OnError = func(*models.TradingRule, error) {}
+	}
+
+	return &RuleScheduler{
+		ruleRepo: ruleRepo,
+		engine:   engine,
+		config:   config,
+	}
+}
+
+// RunDue processes every scheduled rule whose next run is due and that this
+// instance owns
+func (s *RuleScheduler) RunDue(ctx context.Context) error {
+	rules, err := s.ruleRepo.GetDueScheduledRules(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+
+	for i := range rules {
+		if ctx.Err() != nil {
+			return nil
+		}
+		rule := &rules[i]
+		if !s.config.Coordinator.Owns(rule.ID) {
+			continue
+		}
+		triggered, err := processExclusive(ctx, s.config.Coordinator, s.config.Metrics, s.ruleRepo, s.engine, rule)
+		if err != nil {
+			s.config.OnError(rule, err)
+		} else if triggered {
+			s.config.OnTrigger(rule)
+		}
+	}
+	return nil
+}
+
+// Run processes due scheduled rules until ctx is cancelled
+func (s *RuleScheduler) Run(ctx context.Context) error {
+	ticker := time.NewTicker(s.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.RunDue(ctx); err != nil && ctx.Err() == nil {
+			s.config.OnError(nil, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
@@ -4,20 +4,53 @@ package services
 import (
 	"context"
 	"encoding/json"
+	"time"
 
 	"github.com/google/uuid"
 
+	"github.com/aquibsayyed9/sentinel/internal/calendar"
 	"github.com/aquibsayyed9/sentinel/internal/models"
 	"github.com/aquibsayyed9/sentinel/internal/repository"
 )
 
+// RuleCondition is a node in a rule's condition tree. A leaf compares a market
+// value or indicator against either a threshold or another market value given
+// by CompareTo; a group combines its children with all (AND), any (OR) or not.
+// The top-level conditions of a rule are implicitly ANDed.
 type RuleCondition struct {
-	Type      string      `json:"type"`
-	Symbol    string      `json:"symbol"`
-	Operator  string      `json:"operator"`
-	Value     float64     `json:"value"`
-	TimeFrame string      `json:"time_frame,omitempty"`
-	Params    interface{} `json:"params,omitempty"`
+	Type      string         `json:"type"`
+	Symbol    string         `json:"symbol"`
+	Operator  string         `json:"operator"`
+	Value     float64        `json:"value"`
+	TimeFrame string         `json:"time_frame,omitempty"`
+	Params    interface{}    `json:"params,omitempty"`
+	CompareTo *RuleCondition `json:"compare_to,omitempty"`
+
+	// The two market values a ratio or difference condition combines
+	Legs []RuleCondition `json:"legs,omitempty"`
+
+	All []RuleCondition `json:"all,omitempty"`
+	Any []RuleCondition `json:"any,omitempty"`
+	Not *RuleCondition  `json:"not,omitempty"`
+}
+
+// IsGroup reports whether the condition combines child conditions rather than
+// comparing a market value itself
+func (c RuleCondition) IsGroup() bool {
+	return c.All != nil || c.Any != nil || c.Not != nil
+}
+
+// MarshalJSON omits the leaf fields from group nodes
+func (c RuleCondition) MarshalJSON() ([]byte, error) {
+	type leaf RuleCondition
+	if !c.IsGroup() {
+		return json.Marshal(leaf(c))
+	}
+	return json.Marshal(struct {
+		All []RuleCondition `json:"all,omitempty"`
+		Any []RuleCondition `json:"any,omitempty"`
+		Not *RuleCondition  `json:"not,omitempty"`
+	}{c.All, c.Any, c.Not})
 }
 
 type RuleAction struct {
@@ -27,17 +60,85 @@ type RuleAction struct {
 	OrderType string  `json:"order_type"`
 	Limit     float64 `json:"limit,omitempty"`
 	Stop      float64 `json:"stop,omitempty"`
+
+	// The rule an activate_rule or deactivate_rule action acts on, which must
+	// belong to the same user
+	RuleID *uuid.UUID `json:"rule_id,omitempty"`
+
+	// The follow-up rule a create_rule action creates
+	Rule *RuleInput `json:"rule,omitempty"`
+
+	// Cash amount to trade in place of Quantity. The quantity is the amount
+	// divided by the fill price.
+	Notional float64 `json:"notional,omitempty"`
+
+	// Quantities relative to the portfolio, resolved when the action executes,
+	// in place of Quantity: a percent of the position held in the symbol, a
+	// percent of the cash balance to spend, or the percent of the portfolio's
+	// value to bring the position to. A buy only adds up to a target weight
+	// and a sell only trims down to it.
+	PositionPercent float64 `json:"position_percent,omitempty"`
+	CashPercent     float64 `json:"cash_percent,omitempty"`
+	TargetWeight    float64 `json:"target_weight,omitempty"`
+}
+
+// RuleInput is the user-defined part of a rule: everything that is stored in
+// a rule version
+type RuleInput struct {
+	Name          string          `json:"name"`
+	Description   string          `json:"description"`
+	Symbol        string          `json:"symbol"`
+	RuleType      string          `json:"rule_type"`
+	Conditions    []RuleCondition `json:"conditions"`
+	Actions       []RuleAction    `json:"actions"`
+	TriggerPolicy TriggerPolicy   `json:"trigger_policy"`
+
+	// Stop level, for stop_loss, take_profit and trailing_stop rules
+	Stop *StopSpec `json:"stop,omitempty"`
+
+	// When the rule may trigger; nil if it may trigger at any time
+	TimeConstraints *TimeConstraints `json:"time_constraints,omitempty"`
+
+	// When a scheduled rule triggers
+	Schedule *ScheduleSpec `json:"schedule,omitempty"`
+
+	// How a strategy advisor may tune the rule; nil if the rule is not
+	// AI-managed
+	AIManagement *AIManagement `json:"ai_management,omitempty"`
+
+	// Conditions and actions written as a rule expression, in place of
+	// Conditions and Actions. BuildRule compiles it, so it is never stored.
+	Expression string `json:"expression,omitempty"`
 }
 
 type RuleService interface {
-	CreateRule(ctx context.Context, userID uuid.UUID, name, description, symbol, ruleType string,
-		conditions []RuleCondition, actions []RuleAction) (*models.TradingRule, error)
-	GetRuleByID(ctx context.Context, id uuid.UUID) (*models.TradingRule, error)
+	CreateRule(ctx context.Context, userID uuid.UUID, input RuleInput) (*models.TradingRule, error)
+	// Validates input and builds the rule it describes without saving it
+	BuildRule(userID uuid.UUID, input RuleInput) (*models.TradingRule, error)
 	GetRulesByUserID(ctx context.Context, userID uuid.UUID) ([]models.TradingRule, error)
 	UpdateRule(ctx context.Context, rule *models.TradingRule) error
-	DeleteRule(ctx context.Context, id uuid.UUID) error
-	ActivateRule(ctx context.Context, id uuid.UUID) error
-	DeactivateRule(ctx context.Context, id uuid.UUID) error
+
+	// Methods that look up a rule by ID act only on rules owned by userID,
+	// returning repository.ErrRuleNotFound for anyone else's
+	GetRuleByID(ctx context.Context, userID, id uuid.UUID) (*models.TradingRule, error)
+	DeleteRule(ctx context.Context, userID, id uuid.UUID) error
+	ActivateRule(ctx context.Context, userID, id uuid.UUID) error
+	DeactivateRule(ctx context.Context, userID, id uuid.UUID) error
+
+	// Applies a partial update to the rule's definition as a new version
+	PatchRule(ctx context.Context, userID, id uuid.UUID, patch RulePatch) (*models.TradingRule, error)
+	GetRuleVersions(ctx context.Context, userID, id uuid.UUID) ([]models.RuleVersion, error)
+	GetRuleVersion(ctx context.Context, userID, id uuid.UUID, version int) (*models.RuleVersion, error)
+	// Restores the definition of an earlier version as a new version
+	RollbackRule(ctx context.Context, userID, id uuid.UUID, version int) (*models.TradingRule, error)
+
+	// Returns the engine's metrics for those of the rules owned by userID that
+	// have been evaluated, keyed by rule ID
+	GetRuleMetrics(ctx context.Context, userID uuid.UUID, ruleIDs []uuid.UUID) (map[uuid.UUID]*models.RuleMetrics, error)
+
+	GetRuleTemplates() []RuleTemplate
+	// Creates a rule from a built-in template, validating params against it
+	CreateRuleFromTemplate(ctx context.Context, userID uuid.UUID, templateID string, params map[string]interface{}) (*models.TradingRule, error)
 }
 
 type ruleService struct {
@@ -50,72 +151,198 @@ func NewRuleService(ruleRepo repository.RuleRepository) RuleService {
 	}
 }
 
-func (s *ruleService) CreateRule(ctx context.Context, userID uuid.UUID, name, description, symbol, ruleType string,
-	conditions []RuleCondition, actions []RuleAction) (*models.TradingRule, error) {
+func (s *ruleService) CreateRule(ctx context.Context, userID uuid.UUID, input RuleInput) (*models.TradingRule, error) {
+	rule, err := s.BuildRule(userID, input)
+	if err != nil {
+		return nil, err
+	}
+
+	version, err := newRuleVersion(rule, userID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.ruleRepo.CreateWithVersion(ctx, rule, version); err != nil {
+		return nil, err
+	}
+
+	return rule, nil
+}
+
+func (s *ruleService) BuildRule(userID uuid.UUID, input RuleInput) (*models.TradingRule, error) {
+	return buildRule(userID, input)
+}
+
+// buildRule validates input and builds the rule it describes
+func buildRule(userID uuid.UUID, input RuleInput) (*models.TradingRule, error) {
+	if input.Expression != "" {
+		var err error
+		if input, err = compileExpression(input); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := ValidateRule(input); err != nil {
+		return nil, err
+	}
+
+	if input.Conditions == nil {
+		input.Conditions = []RuleCondition{}
+	}
 
 	// Convert conditions to JSON
-	conditionsBytes, err := json.Marshal(conditions)
+	conditionsBytes, err := json.Marshal(input.Conditions)
 	if err != nil {
 		return nil, err
 	}
 
 	// Convert actions to JSON
-	actionsBytes, err := json.Marshal(actions)
+	actionsBytes, err := json.Marshal(input.Actions)
 	if err != nil {
 		return nil, err
 	}
 
+	var stopBytes []byte
+	if input.Stop != nil {
+		stopBytes, err = json.Marshal(input.Stop)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	rule := &models.TradingRule{
 		UserID:      userID,
-		Name:        name,
-		Description: description,
-		Symbol:      symbol,
-		RuleType:    ruleType,
+		Name:        input.Name,
+		Description: input.Description,
+		Symbol:      input.Symbol,
+		RuleType:    input.RuleType,
 		Conditions:  conditionsBytes,
 		Actions:     actionsBytes,
+		Stop:        stopBytes,
 		Status:      "active", // Default status
-		IsAIManaged: false,    // Default to not AI-managed
+		IsAIManaged: input.AIManagement != nil,
+		Version:     1,
 	}
+	policy := input.TriggerPolicy
+	// A stop stays met while the price is beyond its level, so a stop rule
+	// whose policy sets no limit triggers once rather than on every quote
+	if IsStopRuleType(input.RuleType) && policy == (TriggerPolicy{}) {
+		policy.OneShot = true
+	}
+	applyTriggerPolicy(rule, policy)
 
-	if err := s.ruleRepo.Create(ctx, rule); err != nil {
-		return nil, err
+	// Empty constraints are stored as none, so a patch can clear them
+	if input.TimeConstraints != nil && !input.TimeConstraints.IsZero() {
+		if rule.TimeConstraints, err = json.Marshal(input.TimeConstraints); err != nil {
+			return nil, err
+		}
+	}
+
+	if input.Schedule != nil {
+		if rule.Schedule, err = json.Marshal(input.Schedule); err != nil {
+			return nil, err
+		}
+		if err := scheduleNextRun(rule, time.Now(), calendar.NYSE()); err != nil {
+			return nil, err
+		}
+	}
+
+	if input.AIManagement != nil {
+		if rule.AIManagement, err = json.Marshal(input.AIManagement); err != nil {
+			return nil, err
+		}
+		if rule.AIBaseline, err = aiBaseline(input); err != nil {
+			return nil, err
+		}
 	}
 
 	return rule, nil
 }
 
-func (s *ruleService) GetRuleByID(ctx context.Context, id uuid.UUID) (*models.TradingRule, error) {
-	return s.ruleRepo.GetByID(ctx, id)
+func (s *ruleService) GetRuleByID(ctx context.Context, userID, id uuid.UUID) (*models.TradingRule, error) {
+	return s.ruleRepo.GetByIDAndUserID(ctx, id, userID)
 }
 
 func (s *ruleService) GetRulesByUserID(ctx context.Context, userID uuid.UUID) ([]models.TradingRule, error) {
 	return s.ruleRepo.GetByUserID(ctx, userID)
 }
 
+func (s *ruleService) GetRuleMetrics(ctx context.Context, userID uuid.UUID, ruleIDs []uuid.UUID) (map[uuid.UUID]*models.RuleMetrics, error) {
+	metrics, err := s.ruleRepo.GetMetrics(ctx, userID, ruleIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	byRule := make(map[uuid.UUID]*models.RuleMetrics, len(metrics))
+	for i := range metrics {
+		byRule[metrics[i].RuleID] = &metrics[i]
+	}
+	return byRule, nil
+}
+
 func (s *ruleService) UpdateRule(ctx context.Context, rule *models.TradingRule) error {
 	return s.ruleRepo.Update(ctx, rule)
 }
 
-func (s *ruleService) DeleteRule(ctx context.Context, id uuid.UUID) error {
+func (s *ruleService) DeleteRule(ctx context.Context, userID, id uuid.UUID) error {
+	if _, err := s.ruleRepo.GetByIDAndUserID(ctx, id, userID); err != nil {
+		return err
+	}
 	return s.ruleRepo.Delete(ctx, id)
 }
 
-func (s *ruleService) ActivateRule(ctx context.Context, id uuid.UUID) error {
-	rule, err := s.ruleRepo.GetByID(ctx, id)
+func (s *ruleService) ActivateRule(ctx context.Context, userID, id uuid.UUID) error {
+	rule, err := s.ruleRepo.GetByIDAndUserID(ctx, id, userID)
 	if err != nil {
 		return err
 	}
+	if rule.Status == "active" {
+		return nil
+	}
+
+	if err := activateRule(rule, time.Now()); err != nil {
+		return err
+	}
+	return s.ruleRepo.UpdateStatus(ctx, rule)
+}
 
+// activateRule marks a rule active. A reactivated schedule resumes from now
+// rather than catching up on the runs it missed while inactive.
+func activateRule(rule *models.TradingRule, now time.Time) error {
+	if rule.Status != "active" {
+		if err := scheduleNextRun(rule, now, calendar.NYSE()); err != nil {
+			return err
+		}
+	}
 	rule.Status = "active"
-	return s.ruleRepo.Update(ctx, rule)
+	return nil
 }
 
-func (s *ruleService) DeactivateRule(ctx context.Context, id uuid.UUID) error {
-	rule, err := s.ruleRepo.GetByID(ctx, id)
+func (s *ruleService) DeactivateRule(ctx context.Context, userID, id uuid.UUID) error {
+	rule, err := s.ruleRepo.GetByIDAndUserID(ctx, id, userID)
 	if err != nil {
 		return err
 	}
 
 	rule.Status = "inactive"
-	return s.ruleRepo.Update(ctx, rule)
+	return s.ruleRepo.UpdateStatus(ctx, rule)
+}
+
+// compileExpression replaces the input's expression with the conditions and
+// actions it describes
+func compileExpression(input RuleInput) (RuleInput, error) {
+	if len(input.Conditions) > 0 || len(input.Actions) > 0 {
+		return input, &ValidationError{Err: ErrInvalidRule, Fields: []FieldError{
+			{Field: "expression", Message: "cannot be combined with conditions or actions"},
+		}}
+	}
+
+	conditions, actions, err := ParseRuleExpression(input.Expression)
+	if err != nil {
+		return input, err
+	}
+	input.Conditions = conditions
+	input.Actions = actions
+	input.Expression = ""
+	return input, nil
 }
@@ -0,0 +1,162 @@
+// internal/services/rule_stops.go
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/aquibsayyed9/sentinel/internal/models"
+)
+
+var (
+	ErrInvalidStopSpec = errors.New("invalid stop specification")
+)
+
+// Rule types with built-in exit semantics
+const (
+	RuleTypeStopLoss     = "stop_loss"
+	RuleTypeTakeProfit   = "take_profit"
+	RuleTypeTrailingStop = "trailing_stop"
+)
+
+// Position sides a stop protects
+const (
+	StopSideLong  = "long"
+	StopSideShort = "short"
+)
+
+// StopSpec configures a stop_loss, take_profit or trailing_stop rule. The
+// trigger level is either an absolute Price, or a Percent or absolute Offset
+// away from a reference price. For stop_loss and take_profit the reference is
+// the entry price, taken from ReferencePrice or the first price the engine
+// sees. For trailing_stop it is the high-water mark of a long position (the
+// low-water mark of a short one), which follows the price as it moves.
+type StopSpec struct {
+	Side           string  `json:"side,omitempty"`
+	Price          float64 `json:"price,omitempty"`
+	Percent        float64 `json:"percent,omitempty"`
+	Offset         float64 `json:"offset,omitempty"`
+	ReferencePrice float64 `json:"reference_price,omitempty"`
+}
+
+// IsStopRuleType reports whether rule type has built-in stop semantics
+func IsStopRuleType(ruleType string) bool {
+	switch ruleType {
+	case RuleTypeStopLoss, RuleTypeTakeProfit, RuleTypeTrailingStop:
+		return true
+	default:
+		return false
+	}
+}
+
+// ValidateStop checks a stop specification against the rule type it is used with
+func ValidateStop(ruleType string, stop *StopSpec) error {
+	v := &ruleValidator{}
+	v.stop(ruleType, stop)
+	return v.result(ErrInvalidStopSpec)
+}
+
+// decodeStopSpec returns the rule's stop, or nil for rules without one
+func decodeStopSpec(rule *models.TradingRule) (*StopSpec, error) {
+	if len(rule.Stop) == 0 || string(rule.Stop) == "null" {
+		return nil, nil
+	}
+	var stop StopSpec
+	if err := json.Unmarshal(rule.Stop, &stop); err != nil {
+		return nil, fmt.Errorf("failed to parse rule stop: %w", err)
+	}
+	return &stop, nil
+}
+
+// StopEvaluation describes the state of a stop at one evaluation
+type StopEvaluation struct {
+	Price          float64  `json:"price"`
+	ReferencePrice *float64 `json:"reference_price,omitempty"`
+	Level          float64  `json:"level"`
+	Met            bool     `json:"met"`
+}
+
+// evaluateStop compares the latest price of the rule's symbol with the stop
+// level, first bringing the reference price up to date. Reference changes are
+// persisted only when persist is set, so trailing stops resume from their
+// high-water mark after a restart.
+func (s *ruleEngineService) evaluateStop(ctx context.Context, snapshot *marketSnapshot,
+	rule *models.TradingRule, stop StopSpec, persist bool) (StopEvaluation, error) {
+
+	bar, err := snapshot.bar(ctx, rule.Symbol)
+	if err != nil {
+		return StopEvaluation{}, err
+	}
+	price := bar.Close
+	short := stop.Side == StopSideShort
+
+	result := StopEvaluation{Price: price, Level: stop.Price}
+	if stop.Price == 0 {
+		reference, changed := nextReferencePrice(rule, stop, price)
+		if changed {
+			rule.ReferencePrice = &reference
+			if persist {
+				if err := s.ruleRepo.UpdateReferencePrice(ctx, rule.ID, reference); err != nil {
+					return StopEvaluation{}, err
+				}
+			}
+		}
+		result.ReferencePrice = &reference
+		result.Level = stopLevel(rule.RuleType, stop, reference)
+	}
+
+	// Take-profit exits on a move in the position's favour; the other types
+	// exit on an adverse move
+	if rule.RuleType == RuleTypeTakeProfit {
+		short = !short
+	}
+	if short {
+		result.Met = price >= result.Level-floatTolerance
+	} else {
+		result.Met = price <= result.Level+floatTolerance
+	}
+	return result, nil
+}
+
+// nextReferencePrice returns the stop's reference price given the latest
+// price, and whether it differs from the reference stored on the rule
+func nextReferencePrice(rule *models.TradingRule, stop StopSpec, price float64) (float64, bool) {
+	if rule.ReferencePrice == nil {
+		if stop.ReferencePrice > 0 {
+			return stop.ReferencePrice, true
+		}
+		return price, true
+	}
+
+	reference := *rule.ReferencePrice
+	if rule.RuleType != RuleTypeTrailingStop {
+		return reference, false
+	}
+	if stop.Side == StopSideShort {
+		if price < reference {
+			return price, true
+		}
+	} else if price > reference {
+		return price, true
+	}
+	return reference, false
+}
+
+// stopLevel returns the price at which a percent or offset stop triggers
+func stopLevel(ruleType string, stop StopSpec, reference float64) float64 {
+	// Distance from the reference in the direction that triggers the stop
+	sign := -1.0
+	if stop.Side == StopSideShort {
+		sign = 1
+	}
+	if ruleType == RuleTypeTakeProfit {
+		sign = -sign
+	}
+
+	if stop.Percent > 0 {
+		return reference * (1 + sign*stop.Percent/100)
+	}
+	return reference + sign*stop.Offset
+}
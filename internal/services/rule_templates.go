@@ -0,0 +1,404 @@
+// internal/services/rule_templates.go
+package services
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/aquibsayyed9/sentinel/internal/calendar"
+	"github.com/aquibsayyed9/sentinel/internal/indicators"
+	"github.com/aquibsayyed9/sentinel/internal/models"
+)
+
+var (
+	ErrRuleTemplateNotFound  = errors.New("rule template not found")
+	ErrInvalidTemplateParams = errors.New("invalid template parameters")
+)
+
+// Template parameter types
+const (
+	TemplateParamString  = "string"
+	TemplateParamNumber  = "number"
+	TemplateParamInteger = "integer"
+)
+
+// TemplateParameter describes one parameter a rule template accepts.
+// Parameters without a default are required. Min and Max are inclusive
+// bounds; ExclusiveMin and ExclusiveMax exclude the bound itself.
+type TemplateParameter struct {
+	Name         string      `json:"name"`
+	Type         string      `json:"type"`
+	Description  string      `json:"description"`
+	Default      interface{} `json:"default,omitempty"`
+	Min          *float64    `json:"min,omitempty"`
+	Max          *float64    `json:"max,omitempty"`
+	ExclusiveMin *float64    `json:"exclusive_min,omitempty"`
+	ExclusiveMax *float64    `json:"exclusive_max,omitempty"`
+}
+
+// RuleTemplate is a parameterized rule definition for a common trading pattern
+type RuleTemplate struct {
+	ID          string              `json:"id"`
+	Name        string              `json:"name"`
+	Description string              `json:"description"`
+	Parameters  []TemplateParameter `json:"parameters"`
+
+	build func(p templateValues) RuleInput
+
+	// Checks constraints between parameters, if the template has any
+	validate func(p templateValues, v *ruleValidator)
+}
+
+// templateValues holds a template's parameters after validation and defaults
+type templateValues map[string]interface{}
+
+func (v templateValues) string(name string) string {
+	return v[name].(string)
+}
+
+func (v templateValues) number(name string) float64 {
+	return v[name].(float64)
+}
+
+func (v templateValues) integer(name string) int {
+	return int(v[name].(float64))
+}
+
+func bound(value float64) *float64 {
+	return &value
+}
+
+// Parameters shared by every template
+var (
+	symbolParam = TemplateParameter{
+		Name: "symbol", Type: TemplateParamString,
+		Description: "Symbol the rule trades",
+	}
+	quantityParam = TemplateParameter{
+		Name: "quantity", Type: TemplateParamNumber, ExclusiveMin: bound(0),
+		Description: "Number of shares each action trades",
+	}
+	timeFrameParam = TemplateParameter{
+		Name: "time_frame", Type: TemplateParamString, Default: "1d",
+		Description: "Bar time frame the indicators are computed on",
+	}
+)
+
+// barCooldown returns a trigger policy that lets a rule trigger once per bar
+// of a time frame, so a crossover on one bar buys once
+func barCooldown(timeFrame string) TriggerPolicy {
+	frame, _ := models.TimeFrameDuration(timeFrame)
+	return TriggerPolicy{CooldownSeconds: int(frame / time.Second)}
+}
+
+func nameParam(defaultName string) TemplateParameter {
+	return TemplateParameter{
+		Name: "name", Type: TemplateParamString, Default: defaultName,
+		Description: "Name of the created rule",
+	}
+}
+
+// ruleTemplates is the built-in template catalog
+var ruleTemplates = []RuleTemplate{
+	{
+		ID:          "percent_stop_loss",
+		Name:        "Percent stop-loss",
+		Description: "Sells a long position once the price falls a given percentage below the entry price.",
+		Parameters: []TemplateParameter{
+			nameParam("Percent stop-loss"),
+			symbolParam,
+			quantityParam,
+			{Name: "percent", Type: TemplateParamNumber, ExclusiveMin: bound(0), ExclusiveMax: bound(100),
+				Description: "Distance below the entry price, in percent"},
+			{Name: "entry_price", Type: TemplateParamNumber, Default: 0.0, Min: bound(0),
+				Description: "Entry price of the position; 0 uses the first price the engine sees"},
+		},
+		build: func(p templateValues) RuleInput {
+			return RuleInput{
+				Name:        p.string("name"),
+				Description: "Sell when the price falls " + formatNumber(p.number("percent")) + "% below entry",
+				Symbol:      p.string("symbol"),
+				RuleType:    RuleTypeStopLoss,
+				Actions:     []RuleAction{{Type: ActionTypeSell, Quantity: p.number("quantity"), OrderType: OrderTypeMarket}},
+				TriggerPolicy: TriggerPolicy{
+					OneShot: true,
+				},
+				Stop: &StopSpec{
+					Side:           StopSideLong,
+					Percent:        p.number("percent"),
+					ReferencePrice: p.number("entry_price"),
+				},
+			}
+		},
+	},
+	{
+		ID:          "golden_cross",
+		Name:        "Golden cross",
+		Description: "Buys when the fast simple moving average crosses above the slow one.",
+		Parameters: []TemplateParameter{
+			nameParam("Golden cross"),
+			symbolParam,
+			quantityParam,
+			timeFrameParam,
+			{Name: "fast_period", Type: TemplateParamInteger, Default: 50.0, Min: bound(1),
+				Description: "Period of the fast moving average"},
+			{Name: "slow_period", Type: TemplateParamInteger, Default: 200.0, Min: bound(2),
+				Description: "Period of the slow moving average"},
+		},
+		validate: func(p templateValues, v *ruleValidator) {
+			if p.integer("fast_period") >= p.integer("slow_period") {
+				v.add("params.fast_period", "must be less than slow_period")
+			}
+		},
+		build: func(p templateValues) RuleInput {
+			return RuleInput{
+				Name:        p.string("name"),
+				Description: "Buy when SMA(" + formatNumber(p.number("fast_period")) + ") crosses above SMA(" + formatNumber(p.number("slow_period")) + ")",
+				Symbol:      p.string("symbol"),
+				RuleType:    "buy",
+				Conditions: []RuleCondition{{
+					Type:      indicators.KindSMA,
+					TimeFrame: p.string("time_frame"),
+					Params:    indicators.Params{Period: p.integer("fast_period")},
+					Operator:  OperatorCrossesAbove,
+					CompareTo: &RuleCondition{
+						Type:      indicators.KindSMA,
+						TimeFrame: p.string("time_frame"),
+						Params:    indicators.Params{Period: p.integer("slow_period")},
+					},
+				}},
+				Actions:       []RuleAction{{Type: ActionTypeBuy, Quantity: p.number("quantity"), OrderType: OrderTypeMarket}},
+				TriggerPolicy: barCooldown(p.string("time_frame")),
+			}
+		},
+	},
+	{
+		ID:          "rsi_mean_reversion",
+		Name:        "RSI mean reversion",
+		Description: "Buys when the RSI crosses back above the oversold level.",
+		Parameters: []TemplateParameter{
+			nameParam("RSI mean reversion"),
+			symbolParam,
+			quantityParam,
+			timeFrameParam,
+			{Name: "period", Type: TemplateParamInteger, Default: 14.0, Min: bound(2),
+				Description: "RSI period"},
+			{Name: "oversold", Type: TemplateParamNumber, Default: 30.0, Min: bound(0), Max: bound(100),
+				Description: "RSI level below which the symbol is oversold"},
+		},
+		build: func(p templateValues) RuleInput {
+			return RuleInput{
+				Name:        p.string("name"),
+				Description: "Buy when RSI(" + formatNumber(p.number("period")) + ") crosses above " + formatNumber(p.number("oversold")),
+				Symbol:      p.string("symbol"),
+				RuleType:    "buy",
+				Conditions: []RuleCondition{{
+					Type:      indicators.KindRSI,
+					TimeFrame: p.string("time_frame"),
+					Params:    indicators.Params{Period: p.integer("period")},
+					Operator:  OperatorCrossesAbove,
+					Value:     p.number("oversold"),
+				}},
+				Actions:       []RuleAction{{Type: ActionTypeBuy, Quantity: p.number("quantity"), OrderType: OrderTypeMarket}},
+				TriggerPolicy: barCooldown(p.string("time_frame")),
+			}
+		},
+	},
+	{
+		ID:          "breakout_high",
+		Name:        "Breakout above N-day high",
+		Description: "Buys when the daily close is above the highest close of the previous N days.",
+		Parameters: []TemplateParameter{
+			nameParam("Breakout above N-day high"),
+			symbolParam,
+			quantityParam,
+			{Name: "days", Type: TemplateParamInteger, Default: 20.0, Min: bound(1),
+				Description: "Number of previous days the close must exceed"},
+		},
+		build: func(p templateValues) RuleInput {
+			// The rolling high includes the current bar, so a close at the
+			// high of days+1 bars is above every one of the previous days
+			return RuleInput{
+				Name:        p.string("name"),
+				Description: "Buy on a close above the " + formatNumber(p.number("days")) + "-day high",
+				Symbol:      p.string("symbol"),
+				RuleType:    "buy",
+				Conditions: []RuleCondition{{
+					Type:      ConditionTypePrice,
+					TimeFrame: "1d",
+					Operator:  OperatorGreaterThanOrEqual,
+					CompareTo: &RuleCondition{
+						Type:      indicators.KindHighest,
+						TimeFrame: "1d",
+						Params:    indicators.Params{Period: p.integer("days") + 1, Source: indicators.SourceClose},
+					},
+				}},
+				Actions: []RuleAction{{Type: ActionTypeBuy, Quantity: p.number("quantity"), OrderType: OrderTypeMarket}},
+				TriggerPolicy: TriggerPolicy{
+					CooldownSeconds: 24 * 60 * 60,
+				},
+			}
+		},
+	},
+	{
+		ID:          "weekly_dca",
+		Name:        "Weekly dollar-cost averaging",
+		Description: "Buys a fixed quantity once a week, whatever the price.",
+		Parameters: []TemplateParameter{
+			nameParam("Weekly dollar-cost averaging"),
+			symbolParam,
+			quantityParam,
+			{Name: "cron", Type: TemplateParamString, Default: "35 9 * * mon",
+				Description: "When to buy each week, as a cron expression in exchange time"},
+		},
+		validate: func(p templateValues, v *ruleValidator) {
+			if _, err := calendar.ParseCron(p.string("cron")); err != nil {
+				v.add("params.cron", "%v", err)
+			}
+		},
+		build: func(p templateValues) RuleInput {
+			return RuleInput{
+				Name:        p.string("name"),
+				Description: "Buy " + formatNumber(p.number("quantity")) + " shares every week",
+				Symbol:      p.string("symbol"),
+				RuleType:    RuleTypeScheduled,
+				Actions:     []RuleAction{{Type: ActionTypeBuy, Quantity: p.number("quantity"), OrderType: OrderTypeMarket}},
+				// A week missed while the engine was down is bought once
+				Schedule: &ScheduleSpec{
+					Cron:    p.string("cron"),
+					CatchUp: CatchUpOnce,
+				},
+			}
+		},
+	},
+}
+
+// RuleTemplates returns the built-in template catalog
+func RuleTemplates() []RuleTemplate {
+	templates := make([]RuleTemplate, len(ruleTemplates))
+	copy(templates, ruleTemplates)
+	return templates
+}
+
+// InstantiateTemplate validates params against a template and returns the
+// rule definition it produces
+func InstantiateTemplate(templateID string, params map[string]interface{}) (RuleInput, error) {
+	var template *RuleTemplate
+	for i := range ruleTemplates {
+		if ruleTemplates[i].ID == templateID {
+			template = &ruleTemplates[i]
+			break
+		}
+	}
+	if template == nil {
+		return RuleInput{}, ErrRuleTemplateNotFound
+	}
+
+	values, err := template.values(params)
+	if err != nil {
+		return RuleInput{}, err
+	}
+	return template.build(values), nil
+}
+
+// values checks params against the template's parameters and fills in defaults
+func (t *RuleTemplate) values(params map[string]interface{}) (templateValues, error) {
+	v := &ruleValidator{}
+	values := templateValues{}
+
+	known := map[string]bool{}
+	for _, param := range t.Parameters {
+		known[param.Name] = true
+		path := "params." + param.Name
+
+		value, ok := params[param.Name]
+		if !ok || value == nil {
+			if param.Default == nil {
+				v.add(path, "is required")
+				continue
+			}
+			value = param.Default
+		}
+
+		switch param.Type {
+		case TemplateParamString:
+			s, ok := value.(string)
+			if !ok || strings.TrimSpace(s) == "" {
+				v.add(path, "must be a non-empty string")
+				continue
+			}
+			values[param.Name] = s
+		case TemplateParamNumber, TemplateParamInteger:
+			n, ok := value.(float64)
+			if !ok {
+				v.add(path, "must be a number")
+				continue
+			}
+			if param.Type == TemplateParamInteger && n != math.Trunc(n) {
+				v.add(path, "must be a whole number")
+				continue
+			}
+			if message := param.outOfRange(n); message != "" {
+				v.add(path, message)
+				continue
+			}
+			values[param.Name] = n
+		}
+	}
+
+	unknown := make([]string, 0)
+	for name := range params {
+		if !known[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	for _, name := range unknown {
+		v.add("params."+name, "is not a parameter of template %q", t.ID)
+	}
+
+	// Constraints between parameters only make sense once each one is valid
+	if len(v.fields) == 0 && t.validate != nil {
+		t.validate(values, v)
+	}
+
+	if err := v.result(ErrInvalidTemplateParams); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// outOfRange describes how n violates the parameter's bounds, or returns ""
+func (p TemplateParameter) outOfRange(n float64) string {
+	switch {
+	case p.Min != nil && n < *p.Min:
+		return "must be at least " + formatNumber(*p.Min)
+	case p.Max != nil && n > *p.Max:
+		return "must be at most " + formatNumber(*p.Max)
+	case p.ExclusiveMin != nil && n <= *p.ExclusiveMin:
+		return "must be greater than " + formatNumber(*p.ExclusiveMin)
+	case p.ExclusiveMax != nil && n >= *p.ExclusiveMax:
+		return "must be less than " + formatNumber(*p.ExclusiveMax)
+	}
+	return ""
+}
+
+func (s *ruleService) GetRuleTemplates() []RuleTemplate {
+	return RuleTemplates()
+}
+
+func (s *ruleService) CreateRuleFromTemplate(ctx context.Context, userID uuid.UUID, templateID string,
+	params map[string]interface{}) (*models.TradingRule, error) {
+
+	input, err := InstantiateTemplate(templateID, params)
+	if err != nil {
+		return nil, err
+	}
+	return s.CreateRule(ctx, userID, input)
+}
@@ -0,0 +1,219 @@
+// internal/services/rule_time_constraints.go
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aquibsayyed9/sentinel/internal/calendar"
+	"github.com/aquibsayyed9/sentinel/internal/models"
+)
+
+var (
+	ErrInvalidTimeConstraints = errors.New("invalid time constraints")
+)
+
+// TimeConstraints limit when a rule may trigger. A rule may trigger on its
+// active days, within any of its windows, and, if RegularSessionOnly is set,
+// while the exchange is in its regular session. Empty fields don't constrain.
+type TimeConstraints struct {
+	// Days of the week as "mon" to "sun". A window spanning midnight belongs
+	// to the day it starts on, so with "fri" a window from 22:00 to 02:00 runs
+	// into Saturday morning.
+	Days []string `json:"days,omitempty"`
+
+	// Times of day as "HH:MM". A window whose end is before its start spans
+	// midnight.
+	Windows []TimeWindow `json:"windows,omitempty"`
+
+	// IANA time zone of Days and Windows, the exchange's time zone by default
+	TimeZone string `json:"time_zone,omitempty"`
+
+	// Only trigger during the exchange's regular trading hours, skipping
+	// weekends, holidays and the hours after an early close
+	RegularSessionOnly bool `json:"regular_session_only,omitempty"`
+}
+
+// TimeWindow is a span of the day, from Start up to but not including End
+type TimeWindow struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// IsZero reports whether the constraints allow every time
+func (c TimeConstraints) IsZero() bool {
+	return len(c.Days) == 0 && len(c.Windows) == 0 && c.TimeZone == "" && !c.RegularSessionOnly
+}
+
+// Validate checks the days, windows and time zone
+func (c TimeConstraints) Validate() error {
+	v := &ruleValidator{}
+	v.timeConstraints(&c)
+	return v.result(ErrInvalidTimeConstraints)
+}
+
+// Allows reports whether a rule with these constraints may trigger at t, using
+// cal for the time zone default and the regular session. Constraints that
+// fail validation allow nothing.
+func (c TimeConstraints) Allows(t time.Time, cal *calendar.Calendar) bool {
+	if c.RegularSessionOnly && !cal.IsOpen(t) {
+		return false
+	}
+
+	location := cal.Location
+	if c.TimeZone != "" {
+		var err error
+		if location, err = loadLocation(c.TimeZone); err != nil {
+			return false
+		}
+	}
+	local := t.In(location)
+
+	if len(c.Windows) == 0 {
+		return c.allowsDay(local.Weekday())
+	}
+	minute := local.Hour()*60 + local.Minute()
+	for _, window := range c.Windows {
+		if day, ok := window.openedOn(local.Weekday(), minute); ok && c.allowsDay(day) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowsDay reports whether the constraints allow a day of the week
+func (c TimeConstraints) allowsDay(weekday time.Weekday) bool {
+	if len(c.Days) == 0 {
+		return true
+	}
+	for _, day := range c.Days {
+		if named, ok := weekdayNames[strings.ToLower(day)]; ok && named == weekday {
+			return true
+		}
+	}
+	return false
+}
+
+// openedOn returns the day of the week on which the window holding a minute of
+// day opened, or false if the window doesn't hold it. Past midnight, a window
+// spanning midnight opened the day before.
+func (w TimeWindow) openedOn(day time.Weekday, minute int) (time.Weekday, bool) {
+	start, err := parseClock(w.Start)
+	if err != nil {
+		return day, false
+	}
+	end, err := parseClock(w.End)
+	if err != nil {
+		return day, false
+	}
+	switch {
+	case start <= end:
+		return day, minute >= start && minute < end
+	case minute >= start:
+		return day, true
+	case minute < end:
+		return (day + 6) % 7, true
+	}
+	return day, false
+}
+
+// locations caches time zones by name, as loading one reads the zone database
+var locations sync.Map
+
+// loadLocation returns the time zone with an IANA name
+func loadLocation(name string) (*time.Location, error) {
+	if location, ok := locations.Load(name); ok {
+		return location.(*time.Location), nil
+	}
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, err
+	}
+	locations.Store(name, location)
+	return location, nil
+}
+
+// parseClock parses a time of day given as "HH:MM" into minutes since midnight
+func parseClock(value string) (int, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("must be a time of day as HH:MM")
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// decodeTimeConstraints returns the time constraints stored on a rule, or nil
+// if it has none
+func decodeTimeConstraints(rule *models.TradingRule) (*TimeConstraints, error) {
+	if len(rule.TimeConstraints) == 0 {
+		return nil, nil
+	}
+	var constraints *TimeConstraints
+	if err := json.Unmarshal(rule.TimeConstraints, &constraints); err != nil {
+		return nil, fmt.Errorf("failed to parse rule time constraints: %w", err)
+	}
+	return constraints, nil
+}
+
+// withinTimeConstraints reports whether the rule's time constraints allow it
+// to trigger at now
+func withinTimeConstraints(rule *models.TradingRule, now time.Time, cal *calendar.Calendar) (bool, error) {
+	constraints, err := decodeTimeConstraints(rule)
+	if err != nil || constraints == nil {
+		return err == nil, err
+	}
+	return constraints.Allows(now, cal), nil
+}
+
+func (v *ruleValidator) timeConstraints(c *TimeConstraints) {
+	if c == nil {
+		return
+	}
+
+	seen := map[string]bool{}
+	for i, day := range c.Days {
+		path := fmt.Sprintf("time_constraints.days[%d]", i)
+		name := strings.ToLower(day)
+		if _, ok := weekdayNames[name]; !ok {
+			v.add(path, "must be one of mon, tue, wed, thu, fri, sat or sun")
+		} else if seen[name] {
+			v.add(path, "%q is listed more than once", day)
+		}
+		seen[name] = true
+	}
+
+	for i, window := range c.Windows {
+		path := fmt.Sprintf("time_constraints.windows[%d]", i)
+		start, startErr := parseClock(window.Start)
+		if startErr != nil {
+			v.add(path+".start", "%v", startErr)
+		}
+		end, endErr := parseClock(window.End)
+		if endErr != nil {
+			v.add(path+".end", "%v", endErr)
+		}
+		if startErr == nil && endErr == nil && start == end {
+			v.add(path, "start and end must differ")
+		}
+	}
+
+	if c.TimeZone != "" {
+		if _, err := loadLocation(c.TimeZone); err != nil {
+			v.add("time_constraints.time_zone", "unknown time zone %q", c.TimeZone)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+// internal/services/rule_triggers.go
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/aquibsayyed9/sentinel/internal/models"
+)
+
+var (
+	ErrInvalidTriggerPolicy = errors.New("invalid trigger policy")
+)
+
+// TriggerPolicy limits how often a rule may trigger. The zero value lets a
+// rule trigger on every evaluation where its conditions are met.
+type TriggerPolicy struct {
+	OneShot           bool `json:"one_shot"`
+	CooldownSeconds   int  `json:"cooldown_seconds"`
+	MaxTriggersPerDay int  `json:"max_triggers_per_day"`
+}
+
+// Validate checks the policy's limits
+func (p TriggerPolicy) Validate() error {
+	v := &ruleValidator{}
+	v.triggerPolicy(p)
+	return v.result(ErrInvalidTriggerPolicy)
+}
+
+// TriggerPolicyOf returns the trigger policy stored on a rule
+func TriggerPolicyOf(rule *models.TradingRule) TriggerPolicy {
+	return TriggerPolicy{
+		OneShot:           rule.OneShot,
+		CooldownSeconds:   rule.CooldownSeconds,
+		MaxTriggersPerDay: rule.MaxTriggersPerDay,
+	}
+}
+
+// applyTriggerPolicy stores a trigger policy on a rule
+func applyTriggerPolicy(rule *models.TradingRule, policy TriggerPolicy) {
+	rule.OneShot = policy.OneShot
+	rule.CooldownSeconds = policy.CooldownSeconds
+	rule.MaxTriggersPerDay = policy.MaxTriggersPerDay
+}
+
+// canTrigger reports whether the rule's trigger policy allows it to trigger at now
+func canTrigger(rule *models.TradingRule, now time.Time) bool {
+	if rule.LastTriggeredAt == nil {
+		return true
+	}
+	last := *rule.LastTriggeredAt
+
+	if rule.CooldownSeconds > 0 && now.Sub(last) < time.Duration(rule.CooldownSeconds)*time.Second {
+		return false
+	}
+	if rule.MaxTriggersPerDay > 0 && sameUTCDay(last, now) && rule.TriggersToday >= rule.MaxTriggersPerDay {
+		return false
+	}
+	return true
+}
+
+// recordTrigger updates the rule's trigger state for a trigger at now,
+// deactivating one-shot rules
+func recordTrigger(rule *models.TradingRule, now time.Time) {
+	if rule.LastTriggeredAt == nil || !sameUTCDay(*rule.LastTriggeredAt, now) {
+		rule.TriggersToday = 0
+	}
+	rule.LastTriggeredAt = &now
+	rule.TriggerCount++
+	rule.TriggersToday++
+
+	if rule.OneShot {
+		rule.Status = "inactive"
+	}
+}
+
+func sameUTCDay(a, b time.Time) bool {
+	ay, am, ad := a.UTC().Date()
+	by, bm, bd := b.UTC().Date()
+	return ay == by && am == bm && ad == bd
+}
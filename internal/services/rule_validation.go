@@ -0,0 +1,422 @@
+// internal/services/rule_validation.go
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aquibsayyed9/sentinel/internal/indicators"
+	"github.com/aquibsayyed9/sentinel/internal/models"
+)
+
+var (
+	ErrInvalidRule = errors.New("invalid rule")
+)
+
+// FieldError describes a problem with one field of a rule definition. Field
+// is a path into the request body, such as "conditions[0].any[1].operator".
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError lists every problem found in a rule definition. It wraps
+// the sentinel error for the part of the rule that was validated.
+type ValidationError struct {
+	Err    error
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	details := make([]string, len(e.Fields))
+	for i, field := range e.Fields {
+		details[i] = field.Field + ": " + field.Message
+	}
+	return e.Err.Error() + ": " + strings.Join(details, "; ")
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// conditionSchema describes the fields a condition type accepts
+type conditionSchema struct {
+	// Resolved from a bar series, so it accepts a time frame
+	series bool
+
+	// Only resolved from a bar series when a time frame is given
+	optionalSeries bool
+
+	// Combines the two market values given as its legs
+	legs bool
+}
+
+// conditionSchemas lists every condition type the rule engine understands
+var conditionSchemas = map[string]conditionSchema{
+	ConditionTypePrice:         {optionalSeries: true},
+	ConditionTypeVolume:        {},
+	ConditionTypeBid:           {},
+	ConditionTypeAsk:           {},
+	ConditionTypeSpread:        {},
+	ConditionTypeChangePercent: {},
+	ConditionTypeRatio:         {legs: true},
+	ConditionTypeDifference:    {legs: true},
+	indicators.KindSMA:         {series: true},
+	indicators.KindEMA:         {series: true},
+	indicators.KindRSI:         {series: true},
+	indicators.KindMACD:        {series: true},
+	indicators.KindBollinger:   {series: true},
+	indicators.KindATR:         {series: true},
+	indicators.KindVWAP:        {series: true},
+	indicators.KindHighest:     {series: true},
+	indicators.KindLowest:      {series: true},
+
+	ConditionTypePositionQuantity:   {},
+	ConditionTypePositionPnLPercent: {},
+	ConditionTypePositionWeight:     {},
+	ConditionTypeCashBalance:        {},
+}
+
+// ValidateRule checks a complete rule definition against the schema of the
+// rule engine, collecting every problem rather than stopping at the first
+func ValidateRule(input RuleInput) error {
+	v := &ruleValidator{}
+	v.rule(input)
+	return v.result(ErrInvalidRule)
+}
+
+// ruleValidator accumulates field errors while walking a rule definition
+type ruleValidator struct {
+	fields []FieldError
+
+	// How deeply the rule being checked is nested in create_rule actions
+	depth int
+}
+
+func (v *ruleValidator) rule(input RuleInput) {
+	if strings.TrimSpace(input.Name) == "" {
+		v.add("name", "is required")
+	}
+	if strings.TrimSpace(input.Symbol) == "" {
+		v.add("symbol", "is required")
+	}
+	if strings.TrimSpace(input.RuleType) == "" {
+		v.add("rule_type", "is required")
+	}
+
+	// A stop rule's level and a scheduled rule's schedule are conditions in
+	// themselves, so further conditions are optional
+	if (input.Stop == nil && input.Schedule == nil) || len(input.Conditions) > 0 {
+		v.conditions(input.Conditions)
+	}
+	v.stop(input.RuleType, input.Stop)
+	v.schedule(input.RuleType, input.Schedule)
+	v.triggerPolicy(input.TriggerPolicy)
+	v.timeConstraints(input.TimeConstraints)
+	v.actions(input.Symbol, input.Actions)
+	v.aiManagement(input.AIManagement)
+}
+
+func (v *ruleValidator) add(field, format string, args ...interface{}) {
+	v.fields = append(v.fields, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *ruleValidator) result(err error) error {
+	if len(v.fields) == 0 {
+		return nil
+	}
+	return &ValidationError{Err: err, Fields: v.fields}
+}
+
+func (v *ruleValidator) conditions(conditions []RuleCondition) {
+	if len(conditions) == 0 {
+		v.add("conditions", "at least one condition is required")
+		return
+	}
+	for i, condition := range conditions {
+		v.conditionNode(condition, fmt.Sprintf("conditions[%d]", i), 1)
+	}
+}
+
+func (v *ruleValidator) conditionNode(node RuleCondition, path string, depth int) {
+	if depth > MaxConditionDepth {
+		v.add(path, "groups may be nested at most %d levels deep", MaxConditionDepth)
+		return
+	}
+
+	if !node.IsGroup() {
+		v.leaf(node, path)
+		return
+	}
+
+	groups := 0
+	if node.All != nil {
+		groups++
+	}
+	if node.Any != nil {
+		groups++
+	}
+	if node.Not != nil {
+		groups++
+	}
+	if groups > 1 {
+		v.add(path, "a group must use exactly one of all, any or not")
+		return
+	}
+	if node.Type != "" || node.Operator != "" || node.CompareTo != nil || node.Legs != nil {
+		v.add(path, "a group cannot also be a leaf condition")
+		return
+	}
+
+	switch {
+	case node.All != nil:
+		v.conditionGroup(node.All, path+".all", depth)
+	case node.Any != nil:
+		v.conditionGroup(node.Any, path+".any", depth)
+	default:
+		v.conditionNode(*node.Not, path+".not", depth+1)
+	}
+}
+
+func (v *ruleValidator) conditionGroup(children []RuleCondition, path string, depth int) {
+	if len(children) == 0 {
+		v.add(path, "group must contain at least one condition")
+		return
+	}
+	for i, child := range children {
+		v.conditionNode(child, fmt.Sprintf("%s[%d]", path, i), depth+1)
+	}
+}
+
+func (v *ruleValidator) leaf(node RuleCondition, path string) {
+	v.operand(node, path)
+
+	switch {
+	case node.Operator == "":
+		v.add(path+".operator", "is required")
+	case !isSupportedOperator(node.Operator):
+		v.add(path+".operator", "unsupported operator %q", node.Operator)
+	case node.Operator == OperatorCrossesAbove || node.Operator == OperatorCrossesBelow:
+		if node.Type != "" && !isSeriesCondition(node) {
+			v.add(path+".operator", "%s requires a series such as an indicator or a price with a time_frame", node.Operator)
+		}
+		if node.CompareTo != nil && node.CompareTo.Type != "" && !isSeriesCondition(compareTarget(node)) {
+			v.add(path+".compare_to", "%s requires a series to compare against", node.Operator)
+		}
+	}
+
+	if node.CompareTo == nil {
+		return
+	}
+	target := *node.CompareTo
+	if target.IsGroup() || target.Operator != "" || target.CompareTo != nil {
+		v.add(path+".compare_to", "must describe a market value, not a condition")
+		return
+	}
+	v.operand(compareTarget(node), path+".compare_to")
+}
+
+// operand checks the market value a leaf or compare_to describes
+func (v *ruleValidator) operand(node RuleCondition, path string) {
+	if node.Type == "" {
+		v.add(path+".type", "is required")
+		return
+	}
+	schema, ok := conditionSchemas[node.Type]
+	if !ok {
+		v.add(path+".type", "unknown condition type %q", node.Type)
+		return
+	}
+
+	if node.TimeFrame != "" {
+		if !schema.series && !schema.optionalSeries {
+			v.add(path+".time_frame", "is not supported by %s conditions", node.Type)
+		} else if _, err := models.TimeFrameDuration(node.TimeFrame); err != nil {
+			v.add(path+".time_frame", "unsupported time frame %q", node.TimeFrame)
+		}
+	}
+
+	if schema.legs {
+		v.legs(node, path)
+		return
+	}
+	if node.Legs != nil {
+		v.add(path+".legs", "are only supported by %s and %s conditions", ConditionTypeRatio, ConditionTypeDifference)
+	}
+
+	if !isSeriesCondition(node) {
+		if node.Params != nil {
+			v.add(path+".params", "are not supported by %s conditions without a time_frame", node.Type)
+		}
+		return
+	}
+
+	params, err := strictIndicatorParams(node)
+	if err != nil {
+		v.add(path+".params", "%v", err)
+		return
+	}
+	if _, err := indicators.New(node.Type, params); err != nil {
+		v.add(path+".params", "%v", err)
+	}
+}
+
+// strictIndicatorParams decodes a condition's params, rejecting unknown keys
+func strictIndicatorParams(condition RuleCondition) (indicators.Params, error) {
+	var params indicators.Params
+	if condition.Params == nil {
+		return params, nil
+	}
+
+	data, err := json.Marshal(condition.Params)
+	if err != nil {
+		return params, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&params); err != nil {
+		return params, fmt.Errorf("%w: %v", indicators.ErrInvalidParams, err)
+	}
+	return params, nil
+}
+
+func (v *ruleValidator) actions(ruleSymbol string, actions []RuleAction) {
+	if len(actions) == 0 {
+		v.add("actions", "at least one action is required")
+		return
+	}
+
+	for i, action := range actions {
+		path := fmt.Sprintf("actions[%d]", i)
+
+		if IsRuleActionType(action.Type) {
+			v.ruleAction(action, path)
+			continue
+		}
+		if action.Type != ActionTypeBuy && action.Type != ActionTypeSell {
+			v.add(path+".type", "must be %q, %q, %q, %q or %q", ActionTypeBuy, ActionTypeSell,
+				ActionTypeActivateRule, ActionTypeDeactivateRule, ActionTypeCreateRule)
+		}
+		if action.RuleID != nil || action.Rule != nil {
+			v.add(path, "rule_id and rule only apply to rule actions")
+		}
+		if action.Symbol != "" && action.Symbol != ruleSymbol {
+			v.add(path+".symbol", "must match the rule symbol %q", ruleSymbol)
+		}
+		v.actionAmount(action, path)
+
+		switch action.OrderType {
+		case "", OrderTypeMarket:
+			if action.Limit != 0 || action.Stop != 0 {
+				v.add(path+".order_type", "market orders do not take a limit or stop price")
+			}
+		case OrderTypeLimit:
+			if action.Limit <= 0 {
+				v.add(path+".limit", "limit orders require a positive limit price")
+			}
+		case OrderTypeStop:
+			if action.Stop <= 0 {
+				v.add(path+".stop", "stop orders require a positive stop price")
+			}
+		default:
+			v.add(path+".order_type", "unsupported order type %q", action.OrderType)
+		}
+	}
+}
+
+// actionAmount checks that an action sizes its order in exactly one way
+func (v *ruleValidator) actionAmount(action RuleAction, path string) {
+	amounts := 0
+	for _, amount := range []struct {
+		field string
+		value float64
+	}{
+		{"notional", action.Notional},
+		{"position_percent", action.PositionPercent},
+		{"cash_percent", action.CashPercent},
+		{"target_weight", action.TargetWeight},
+	} {
+		if amount.value < 0 {
+			v.add(path+"."+amount.field, "must be positive")
+		}
+		if amount.value != 0 {
+			amounts++
+		}
+	}
+
+	switch {
+	case amounts > 1 || (amounts == 1 && action.Quantity != 0):
+		v.add(path, "give only one of quantity, notional, position_percent, cash_percent or target_weight")
+		return
+	case amounts == 0 && action.Quantity <= 0:
+		v.add(path+".quantity", "must be positive")
+		return
+	}
+
+	if action.PositionPercent > 100 {
+		v.add(path+".position_percent", "cannot exceed 100")
+	}
+	if action.CashPercent > 100 {
+		v.add(path+".cash_percent", "cannot exceed 100")
+	}
+	if action.CashPercent != 0 && action.Type == ActionTypeSell {
+		v.add(path+".cash_percent", "only applies to buy actions")
+	}
+	if action.TargetWeight >= 100 {
+		v.add(path+".target_weight", "must be below 100")
+	}
+}
+
+func (v *ruleValidator) stop(ruleType string, stop *StopSpec) {
+	if stop == nil {
+		if ruleType == RuleTypeTrailingStop {
+			v.add("stop", "trailing_stop rules require a stop")
+		}
+		return
+	}
+	if !IsStopRuleType(ruleType) {
+		v.add("stop", "rule type %q does not support a stop", ruleType)
+		return
+	}
+
+	if stop.Side != "" && stop.Side != StopSideLong && stop.Side != StopSideShort {
+		v.add("stop.side", "must be %q or %q", StopSideLong, StopSideShort)
+	}
+	if stop.Price < 0 || stop.Percent < 0 || stop.Offset < 0 || stop.ReferencePrice < 0 {
+		v.add("stop", "prices and offsets cannot be negative")
+		return
+	}
+
+	levels := 0
+	for _, level := range []float64{stop.Price, stop.Percent, stop.Offset} {
+		if level > 0 {
+			levels++
+		}
+	}
+	if levels != 1 {
+		v.add("stop", "exactly one of price, percent or offset is required")
+	}
+	if ruleType == RuleTypeTrailingStop && stop.Price > 0 {
+		v.add("stop.price", "trailing stops trail by percent or offset, not a fixed price")
+	}
+	// Only a level below the reference price is bounded, by zero
+	below := (stop.Side == StopSideShort) == (ruleType == RuleTypeTakeProfit)
+	if below && stop.Percent >= 100 {
+		v.add("stop.percent", "must be below 100")
+	}
+	if stop.Price > 0 && stop.ReferencePrice > 0 {
+		v.add("stop.reference_price", "only applies to percent and offset stops")
+	}
+}
+
+func (v *ruleValidator) triggerPolicy(policy TriggerPolicy) {
+	if policy.CooldownSeconds < 0 {
+		v.add("trigger_policy.cooldown_seconds", "cannot be negative")
+	}
+	if policy.MaxTriggersPerDay < 0 {
+		v.add("trigger_policy.max_triggers_per_day", "cannot be negative")
+	}
+}
@@ -0,0 +1,372 @@
+// internal/services/rule_versions.go
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"sort"
+
+	"github.com/google/uuid"
+
+	"github.com/aquibsayyed9/sentinel/internal/models"
+)
+
+// RulePatch lists the parts of a rule definition to change. Nil fields, and
+// nullable fields that are not set, are left as they are.
+type RulePatch struct {
+	Name          *string          `json:"name"`
+	Description   *string          `json:"description"`
+	Symbol        *string          `json:"symbol"`
+	RuleType      *string          `json:"rule_type"`
+	Conditions    *[]RuleCondition `json:"conditions"`
+	Actions       *[]RuleAction    `json:"actions"`
+	TriggerPolicy *TriggerPolicy   `json:"trigger_policy"`
+
+	// Null removes the rule's stop
+	Stop Nullable[StopSpec] `json:"stop"`
+
+	// An empty object removes the rule's time constraints
+	TimeConstraints *TimeConstraints `json:"time_constraints"`
+	Schedule        *ScheduleSpec    `json:"schedule"`
+
+	// Makes the rule AI-managed, or changes how it is managed. Set ai_managed
+	// to false to stop managing it.
+	AIManagement *AIManagement `json:"ai_management"`
+	AIManaged    *bool         `json:"ai_managed"`
+}
+
+// Nullable is a patch field that can be set to null, unlike a plain pointer
+// field, for which null and absent are the same. Set reports whether the
+// field was given, and Value is nil if it was null.
+type Nullable[T any] struct {
+	Set   bool
+	Value *T
+}
+
+func (n *Nullable[T]) UnmarshalJSON(data []byte) error {
+	n.Set = true
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		n.Value = nil
+		return nil
+	}
+	var value T
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	n.Value = &value
+	return nil
+}
+
+// FieldChange records how one field of a rule definition changed between versions
+type FieldChange struct {
+	Field string          `json:"field"`
+	Old   json.RawMessage `json:"old"`
+	New   json.RawMessage `json:"new"`
+}
+
+// RuleInputOf decodes the definition of a stored rule
+func RuleInputOf(rule *models.TradingRule) (RuleInput, error) {
+	conditions, err := decodeRuleConditions(rule)
+	if err != nil {
+		return RuleInput{}, err
+	}
+	actions, err := decodeRuleActions(rule)
+	if err != nil {
+		return RuleInput{}, err
+	}
+	stop, err := decodeStopSpec(rule)
+	if err != nil {
+		return RuleInput{}, err
+	}
+	timeConstraints, err := decodeTimeConstraints(rule)
+	if err != nil {
+		return RuleInput{}, err
+	}
+	schedule, err := decodeSchedule(rule)
+	if err != nil {
+		return RuleInput{}, err
+	}
+	aiManagement, err := decodeAIManagement(rule)
+	if err != nil {
+		return RuleInput{}, err
+	}
+
+	return RuleInput{
+		Name:            rule.Name,
+		Description:     rule.Description,
+		Symbol:          rule.Symbol,
+		RuleType:        rule.RuleType,
+		Conditions:      conditions,
+		Actions:         actions,
+		TriggerPolicy:   TriggerPolicyOf(rule),
+		Stop:            stop,
+		TimeConstraints: timeConstraints,
+		Schedule:        schedule,
+		AIManagement:    aiManagement,
+	}, nil
+}
+
+// DecodeRuleVersion returns the definition stored in a rule version and the
+// changes it made to the previous one
+func DecodeRuleVersion(version *models.RuleVersion) (RuleInput, []FieldChange, error) {
+	var input RuleInput
+	if err := json.Unmarshal(version.Definition, &input); err != nil {
+		return RuleInput{}, nil, err
+	}
+
+	changes := []FieldChange{}
+	if len(version.Changes) > 0 {
+		if err := json.Unmarshal(version.Changes, &changes); err != nil {
+			return RuleInput{}, nil, err
+		}
+	}
+	return input, changes, nil
+}
+
+// Apply returns input with the patch applied
+func (p RulePatch) Apply(input RuleInput) RuleInput {
+	if p.Name != nil {
+		input.Name = *p.Name
+	}
+	if p.Description != nil {
+		input.Description = *p.Description
+	}
+	if p.Symbol != nil {
+		input.Symbol = *p.Symbol
+	}
+	if p.RuleType != nil {
+		input.RuleType = *p.RuleType
+	}
+	if p.Conditions != nil {
+		input.Conditions = *p.Conditions
+	}
+	if p.Actions != nil {
+		input.Actions = *p.Actions
+	}
+	if p.TriggerPolicy != nil {
+		input.TriggerPolicy = *p.TriggerPolicy
+	}
+	if p.Stop.Set {
+		input.Stop = p.Stop.Value
+	}
+	if p.TimeConstraints != nil {
+		input.TimeConstraints = p.TimeConstraints
+	}
+	if p.Schedule != nil {
+		input.Schedule = p.Schedule
+	}
+	if p.AIManagement != nil {
+		input.AIManagement = p.AIManagement
+	}
+	if p.AIManaged != nil {
+		if !*p.AIManaged {
+			input.AIManagement = nil
+		} else if input.AIManagement == nil {
+			input.AIManagement = &AIManagement{}
+		}
+	}
+	return input
+}
+
+func (s *ruleService) PatchRule(ctx context.Context, userID, id uuid.UUID, patch RulePatch) (*models.TradingRule, error) {
+	rule, err := s.ruleRepo.GetByIDAndUserID(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	input, err := RuleInputOf(rule)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.updateDefinition(ctx, userID, rule, patch.Apply(input), true)
+}
+
+func (s *ruleService) GetRuleVersions(ctx context.Context, userID, id uuid.UUID) ([]models.RuleVersion, error) {
+	if _, err := s.ruleRepo.GetByIDAndUserID(ctx, id, userID); err != nil {
+		return nil, err
+	}
+	return s.ruleRepo.GetVersions(ctx, id)
+}
+
+func (s *ruleService) GetRuleVersion(ctx context.Context, userID, id uuid.UUID, version int) (*models.RuleVersion, error) {
+	if _, err := s.ruleRepo.GetByIDAndUserID(ctx, id, userID); err != nil {
+		return nil, err
+	}
+	return s.ruleRepo.GetVersion(ctx, id, version)
+}
+
+func (s *ruleService) RollbackRule(ctx context.Context, userID, id uuid.UUID, version int) (*models.TradingRule, error) {
+	rule, err := s.ruleRepo.GetByIDAndUserID(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	target, err := s.ruleRepo.GetVersion(ctx, id, version)
+	if err != nil {
+		return nil, err
+	}
+
+	input, _, err := DecodeRuleVersion(target)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.updateDefinition(ctx, userID, rule, input, true)
+}
+
+// updateDefinition validates input and, if it differs from the rule's current
+// definition, stores it as the rule's next version. Definitions the owner
+// sets rebaseline an AI-managed rule; those applied from an advisor's
+// proposal are still bounded by the values the owner set.
+func (s *ruleService) updateDefinition(ctx context.Context, userID uuid.UUID,
+	rule *models.TradingRule, input RuleInput, rebaseline bool) (*models.TradingRule, error) {
+
+	current, err := RuleInputOf(rule)
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := s.BuildRule(rule.UserID, input)
+	if err != nil {
+		return nil, err
+	}
+	next, err := RuleInputOf(updated)
+	if err != nil {
+		return nil, err
+	}
+
+	changes, err := diffRuleInputs(current, next)
+	if err != nil {
+		return nil, err
+	}
+	if len(changes) == 0 {
+		return rule, nil
+	}
+
+	for _, change := range changes {
+		// A stop measured against a different symbol or level starts afresh
+		if change.Field == "symbol" || change.Field == "rule_type" || change.Field == "stop" {
+			rule.ReferencePrice = nil
+		}
+		// A changed schedule runs next at its own next time
+		if change.Field == "schedule" {
+			rule.NextRunAt = updated.NextRunAt
+		}
+	}
+
+	copyRuleDefinition(rule, updated)
+	if rebaseline || !updated.IsAIManaged {
+		rule.AIBaseline = updated.AIBaseline
+	}
+	rule.Version++
+
+	version, err := newRuleVersion(rule, userID, changes)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.ruleRepo.UpdateDefinition(ctx, rule, version); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+// copyRuleDefinition copies the versioned fields of src onto dst
+func copyRuleDefinition(dst, src *models.TradingRule) {
+	dst.Name = src.Name
+	dst.Description = src.Description
+	dst.Symbol = src.Symbol
+	dst.RuleType = src.RuleType
+	dst.Conditions = src.Conditions
+	dst.Actions = src.Actions
+	dst.Stop = src.Stop
+	dst.TimeConstraints = src.TimeConstraints
+	dst.Schedule = src.Schedule
+	dst.OneShot = src.OneShot
+	dst.CooldownSeconds = src.CooldownSeconds
+	dst.MaxTriggersPerDay = src.MaxTriggersPerDay
+	dst.IsAIManaged = src.IsAIManaged
+	dst.AIManagement = src.AIManagement
+}
+
+// newRuleVersion snapshots the rule's current definition
+func newRuleVersion(rule *models.TradingRule, author uuid.UUID, changes []FieldChange) (*models.RuleVersion, error) {
+	input, err := RuleInputOf(rule)
+	if err != nil {
+		return nil, err
+	}
+	definition, err := json.Marshal(input)
+	if err != nil {
+		return nil, err
+	}
+
+	if changes == nil {
+		changes = []FieldChange{}
+	}
+	changesJSON, err := json.Marshal(changes)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.RuleVersion{
+		RuleID:     rule.ID,
+		Version:    rule.Version,
+		Definition: definition,
+		Changes:    changesJSON,
+		CreatedBy:  author,
+	}, nil
+}
+
+// diffRuleInputs lists the top-level fields that differ between two definitions
+func diffRuleInputs(old, new RuleInput) ([]FieldChange, error) {
+	oldFields, err := definitionFields(old)
+	if err != nil {
+		return nil, err
+	}
+	newFields, err := definitionFields(new)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(newFields))
+	for name := range newFields {
+		names = append(names, name)
+	}
+	for name := range oldFields {
+		if _, ok := newFields[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var changes []FieldChange
+	for _, name := range names {
+		oldValue, newValue := oldFields[name], newFields[name]
+		if bytes.Equal(oldValue, newValue) {
+			continue
+		}
+		changes = append(changes, FieldChange{Field: name, Old: nullIfEmpty(oldValue), New: nullIfEmpty(newValue)})
+	}
+	return changes, nil
+}
+
+func definitionFields(input RuleInput) (map[string]json.RawMessage, error) {
+	data, err := json.Marshal(input)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+func nullIfEmpty(value json.RawMessage) json.RawMessage {
+	if len(value) == 0 {
+		return json.RawMessage("null")
+	}
+	return value
+}
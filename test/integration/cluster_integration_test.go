@@ -0,0 +1,94 @@
+// test/integration/cluster_integration_test.go
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aquibsayyed9/sentinel/internal/repository"
+	"github.com/aquibsayyed9/sentinel/internal/services"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/suite"
+)
+
+// ClusterIntegrationTestSuite runs several engine instances against the test
+// database, as separate processes would
+type ClusterIntegrationTestSuite struct {
+	suite.Suite
+	clusterRepo repository.ClusterRepository
+}
+
+func (s *ClusterIntegrationTestSuite) SetupSuite() {
+	s.clusterRepo = repository.NewClusterRepository(GetTestDB())
+}
+
+func (s *ClusterIntegrationTestSuite) SetupTest() {
+	s.Require().NoError(GetTestDB().Exec("DELETE FROM engine_instances").Error)
+}
+
+func TestClusterIntegrationSuite(t *testing.T) {
+	suite.Run(t, new(ClusterIntegrationTestSuite))
+}
+
+func (s *ClusterIntegrationTestSuite) newInstance(name string) *services.RuleCluster {
+	return services.NewRuleCluster(s.clusterRepo, services.RuleClusterConfig{
+		Name:              name,
+		HeartbeatInterval: 100 * time.Millisecond,
+		LeaseTTL:          500 * time.Millisecond,
+	})
+}
+
+func (s *ClusterIntegrationTestSuite) TestInstancesShareRulesAndTakeOverFailedShards() {
+	ctx := context.Background()
+	a := s.newInstance("a")
+	b := s.newInstance("b")
+	s.Require().NoError(a.Join(ctx))
+	s.Require().NoError(b.Join(ctx))
+	s.Require().NoError(a.Heartbeat(ctx))
+
+	// The first instance to start leads, and each rule has one owner
+	s.True(a.IsLeader())
+	s.False(b.IsLeader())
+	rules := make([]uuid.UUID, 50)
+	for i := range rules {
+		rules[i] = uuid.New()
+		s.NotEqual(a.Owns(rules[i]), b.Owns(rules[i]))
+	}
+
+	// a stops heartbeating, as if its process died, so b takes over its rules
+	// and its lead once the lease expires
+	time.Sleep(600 * time.Millisecond)
+	s.Require().NoError(b.Heartbeat(ctx))
+	s.True(b.IsLeader())
+	for _, id := range rules {
+		s.True(b.Owns(id))
+	}
+
+	// The leader removed a's expired lease
+	instances, err := s.clusterRepo.GetLiveInstances(ctx, time.Hour)
+	s.Require().NoError(err)
+	s.Len(instances, 1)
+	s.Equal(b.ID(), instances[0].ID)
+}
+
+func (s *ClusterIntegrationTestSuite) TestRuleLockIsExclusiveAcrossConnections() {
+	ctx := context.Background()
+	ruleID := uuid.New()
+
+	var nested bool
+	ran, err := s.clusterRepo.WithRuleLock(ctx, ruleID, func() error {
+		// Another session, as another instance would hold, cannot take the lock
+		var err error
+		nested, err = s.clusterRepo.WithRuleLock(ctx, ruleID, func() error { return nil })
+		return err
+	})
+	s.Require().NoError(err)
+	s.True(ran)
+	s.False(nested)
+
+	// The lock is released once the evaluation finishes
+	ran, err = s.clusterRepo.WithRuleLock(ctx, ruleID, func() error { return nil })
+	s.Require().NoError(err)
+	s.True(ran)
+}
@@ -0,0 +1,245 @@
+// test/integration/ownership_integration_test.go
+package integration
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aquibsayyed9/sentinel/internal/auth"
+	"github.com/aquibsayyed9/sentinel/internal/config"
+	"github.com/aquibsayyed9/sentinel/internal/handlers"
+	"github.com/aquibsayyed9/sentinel/internal/models"
+	"github.com/aquibsayyed9/sentinel/internal/repository"
+	"github.com/aquibsayyed9/sentinel/internal/server/routes"
+	"github.com/aquibsayyed9/sentinel/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// OwnershipIntegrationTestSuite checks that one user cannot see or change
+// another user's rules, portfolio or executions
+type OwnershipIntegrationTestSuite struct {
+	suite.Suite
+	router        *gin.Engine
+	executionRepo repository.ExecutionRepository
+	ownerToken    string
+	ownerID       uuid.UUID
+	otherToken    string
+}
+
+func (s *OwnershipIntegrationTestSuite) SetupSuite() {
+	// Use test database
+	db := GetTestDB()
+	userRepo := repository.NewUserRepository(db)
+	ruleRepo := repository.NewRuleRepository(db)
+	s.executionRepo = repository.NewExecutionRepository(db)
+	userService := services.NewUserService(userRepo)
+	ruleService := services.NewRuleService(ruleRepo)
+	portfolioService := services.NewPortfolioService(repository.NewPortfolioRepository(db))
+	marketDataService := services.NewMarketDataService(repository.NewMarketDataRepository(db))
+	executionService := services.NewExecutionService(s.executionRepo, ruleRepo)
+	ruleEngine := services.NewRuleEngineService(ruleRepo, marketDataService, portfolioService, executionService,
+		repository.NewTransactor(db))
+
+	cfg := &config.Config{
+		JWT: struct {
+			Secret     string `mapstructure:"secret"`
+			ExpireHour int    `mapstructure:"expire_hour"`
+		}{
+			Secret:     "test-secret-key",
+			ExpireHour: 24,
+		},
+	}
+	tokenService := auth.NewTokenService(cfg)
+
+	// Setup router with the production routes
+	gin.SetMode(gin.TestMode)
+	s.router = gin.Default()
+	routes.Setup(s.router,
+		handlers.NewAuthHandler(userService, tokenService),
+		handlers.NewRuleHandler(ruleService, ruleEngine,
+			services.NewRuleProposalService(ruleRepo, repository.NewRuleProposalRepository(db), repository.NewTransactor(db))),
+		handlers.NewPortfolioHandler(portfolioService),
+		handlers.NewExecutionHandler(executionService),
+		handlers.NewNotificationHandler(services.NewNotificationService(repository.NewNotificationRepository(db))),
+		handlers.NewMarketDataHandler(marketDataService,
+			services.NewSymbolMaster(repository.NewSymbolRepository(db), time.Minute)),
+		tokenService,
+	)
+
+	s.ownerToken, s.ownerID = s.registerUser("ownership_owner@example.com")
+	s.otherToken, _ = s.registerUser("ownership_other@example.com")
+}
+
+func TestOwnershipIntegrationSuite(t *testing.T) {
+	suite.Run(t, new(OwnershipIntegrationTestSuite))
+}
+
+func (s *OwnershipIntegrationTestSuite) registerUser(email string) (string, uuid.UUID) {
+	registerBody := map[string]interface{}{
+		"email":      email,
+		"password":   "password123",
+		"first_name": "Ownership",
+		"last_name":  "Test",
+	}
+	w := s.request("POST", "/api/v1/auth/register", "", registerBody)
+	if w.Code != http.StatusCreated {
+		s.T().Fatalf("Failed to create test user: %s", w.Body.String())
+	}
+
+	var response struct {
+		Token string `json:"token"`
+		User  struct {
+			ID uuid.UUID `json:"id"`
+		} `json:"user"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		s.T().Fatalf("Failed to parse registration response: %v", err)
+	}
+	return response.Token, response.User.ID
+}
+
+func (s *OwnershipIntegrationTestSuite) request(method, path, token string, body interface{}) *httptest.ResponseRecorder {
+	var data []byte
+	if body != nil {
+		data, _ = json.Marshal(body)
+	}
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(method, path, bytes.NewBuffer(data))
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	s.router.ServeHTTP(w, req)
+	return w
+}
+
+func (s *OwnershipIntegrationTestSuite) createRule() string {
+	createBody := map[string]interface{}{
+		"name":      "Owned Rule",
+		"symbol":    "AAPL",
+		"rule_type": "buy",
+		"conditions": []map[string]interface{}{
+			{"type": "price", "operator": "less_than", "value": 150.0},
+		},
+		"actions": []map[string]interface{}{
+			{"type": "buy", "quantity": 10.0, "order_type": "market"},
+		},
+	}
+	w := s.request("POST", "/api/v1/rules", s.ownerToken, createBody)
+	s.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+
+	var response struct {
+		Rule struct {
+			ID string `json:"id"`
+		} `json:"rule"`
+	}
+	s.Require().NoError(json.Unmarshal(w.Body.Bytes(), &response))
+	return response.Rule.ID
+}
+
+func (s *OwnershipIntegrationTestSuite) TestOtherUsersRulesAreNotFound() {
+	ruleID := s.createRule()
+	rulePath := "/api/v1/rules/" + ruleID
+
+	requests := []struct {
+		method string
+		path   string
+		body   interface{}
+	}{
+		{"GET", rulePath, nil},
+		{"PUT", rulePath + "/activate", nil},
+		{"PUT", rulePath + "/deactivate", nil},
+		{"PATCH", rulePath, map[string]interface{}{"name": "Hijacked"}},
+		{"GET", rulePath + "/versions", nil},
+		{"GET", rulePath + "/versions/1", nil},
+		{"POST", rulePath + "/rollback", map[string]interface{}{"version": 1}},
+		{"POST", rulePath + "/evaluate", nil},
+		{"DELETE", rulePath, nil},
+	}
+	for _, r := range requests {
+		w := s.request(r.method, r.path, s.otherToken, r.body)
+		assert.Equal(s.T(), http.StatusNotFound, w.Code, "%s %s", r.method, r.path)
+	}
+
+	// The rule is untouched and still visible to its owner
+	w := s.request("GET", rulePath, s.ownerToken, nil)
+	assert.Equal(s.T(), http.StatusOK, w.Code)
+
+	var response struct {
+		Rule struct {
+			Name    string `json:"name"`
+			Status  string `json:"status"`
+			Version int    `json:"version"`
+		} `json:"rule"`
+	}
+	s.Require().NoError(json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(s.T(), "Owned Rule", response.Rule.Name)
+	assert.Equal(s.T(), "active", response.Rule.Status)
+	assert.Equal(s.T(), 1, response.Rule.Version)
+
+	// Other users' rules are not listed either
+	w = s.request("GET", "/api/v1/rules", s.otherToken, nil)
+	assert.Equal(s.T(), http.StatusOK, w.Code)
+	assert.NotContains(s.T(), w.Body.String(), ruleID)
+}
+
+func (s *OwnershipIntegrationTestSuite) TestOtherUsersExecutionsAreNotFound() {
+	ruleID := s.createRule()
+	parsedRuleID := uuid.MustParse(ruleID)
+
+	execution := &models.Execution{
+		RuleID:        &parsedRuleID,
+		UserID:        s.ownerID,
+		Symbol:        "AAPL",
+		ExecutionType: "buy",
+		Quantity:      10,
+		Price:         145,
+		TotalAmount:   1450,
+		Status:        "executed",
+		ExecutionTime: time.Now(),
+	}
+	s.Require().NoError(s.executionRepo.Create(context.Background(), execution))
+	executionPath := "/api/v1/executions/" + execution.ID.String()
+
+	w := s.request("GET", executionPath, s.ownerToken, nil)
+	assert.Equal(s.T(), http.StatusOK, w.Code)
+
+	w = s.request("GET", executionPath, s.otherToken, nil)
+	assert.Equal(s.T(), http.StatusNotFound, w.Code)
+
+	w = s.request("GET", "/api/v1/executions?rule_id="+ruleID, s.otherToken, nil)
+	assert.Equal(s.T(), http.StatusNotFound, w.Code)
+
+	w = s.request("GET", "/api/v1/executions", s.otherToken, nil)
+	assert.Equal(s.T(), http.StatusOK, w.Code)
+	assert.NotContains(s.T(), w.Body.String(), execution.ID.String())
+}
+
+func (s *OwnershipIntegrationTestSuite) TestPortfolioIsScopedToCaller() {
+	w := s.request("POST", "/api/v1/portfolio", s.ownerToken, map[string]interface{}{"initial_balance": 5000.0})
+	s.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+
+	w = s.request("POST", "/api/v1/portfolio/holdings", s.ownerToken,
+		map[string]interface{}{"symbol": "MSFT", "quantity": 5.0, "price": 300.0})
+	s.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+
+	// The other user has no portfolio of their own and cannot reach the owner's
+	w = s.request("GET", "/api/v1/portfolio", s.otherToken, nil)
+	assert.Equal(s.T(), http.StatusNotFound, w.Code)
+
+	w = s.request("DELETE", "/api/v1/portfolio/holdings/MSFT", s.otherToken, nil)
+	assert.Equal(s.T(), http.StatusNotFound, w.Code)
+
+	w = s.request("GET", "/api/v1/portfolio/holdings", s.ownerToken, nil)
+	assert.Equal(s.T(), http.StatusOK, w.Code)
+	assert.Contains(s.T(), w.Body.String(), "MSFT")
+}
@@ -3,14 +3,18 @@ package integration
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/aquibsayyed9/sentinel/internal/auth"
+	"github.com/aquibsayyed9/sentinel/internal/calendar"
 	"github.com/aquibsayyed9/sentinel/internal/config"
 	"github.com/aquibsayyed9/sentinel/internal/handlers"
+	"github.com/aquibsayyed9/sentinel/internal/models"
 	"github.com/aquibsayyed9/sentinel/internal/repository"
 	"github.com/aquibsayyed9/sentinel/internal/services"
 	"github.com/gin-gonic/gin"
@@ -26,6 +30,8 @@ type RuleIntegrationTestSuite struct {
 	ruleRepo     repository.RuleRepository
 	userService  services.UserService
 	ruleService  services.RuleService
+	ruleEngine   services.RuleEngineService
+	proposalRepo repository.RuleProposalRepository
 	tokenService auth.TokenService
 	cfg          *config.Config
 	authToken    string
@@ -39,6 +45,12 @@ func (s *RuleIntegrationTestSuite) SetupSuite() {
 	s.ruleRepo = repository.NewRuleRepository(db)
 	s.userService = services.NewUserService(s.userRepo)
 	s.ruleService = services.NewRuleService(s.ruleRepo)
+	s.proposalRepo = repository.NewRuleProposalRepository(db)
+	portfolioService := services.NewPortfolioService(repository.NewPortfolioRepository(db))
+	marketDataService := services.NewMarketDataService(repository.NewMarketDataRepository(db))
+	executionService := services.NewExecutionService(repository.NewExecutionRepository(db), s.ruleRepo)
+	s.ruleEngine = services.NewRuleEngineService(s.ruleRepo, marketDataService, portfolioService, executionService,
+		repository.NewTransactor(db))
 
 	// Create test config
 	s.cfg = &config.Config{
@@ -58,7 +70,9 @@ func (s *RuleIntegrationTestSuite) SetupSuite() {
 
 	// Create handlers
 	authHandler := handlers.NewAuthHandler(s.userService, s.tokenService)
-	ruleHandler := handlers.NewRuleHandler(s.ruleService)
+	executionHandler := handlers.NewExecutionHandler(executionService)
+	ruleHandler := handlers.NewRuleHandler(s.ruleService, s.ruleEngine,
+		services.NewRuleProposalService(s.ruleRepo, s.proposalRepo, repository.NewTransactor(db)))
 
 	// Set up auth routes
 	authGroup := s.router.Group("/api/v1/auth")
@@ -74,9 +88,21 @@ func (s *RuleIntegrationTestSuite) SetupSuite() {
 		protected.POST("/rules", ruleHandler.CreateRule)
 		protected.GET("/rules", ruleHandler.GetRules)
 		protected.GET("/rules/:id", ruleHandler.GetRule)
+		protected.PATCH("/rules/:id", ruleHandler.PatchRule)
+		protected.GET("/rules/:id/versions", ruleHandler.GetRuleVersions)
+		protected.GET("/rules/:id/versions/:version", ruleHandler.GetRuleVersion)
+		protected.POST("/rules/:id/rollback", ruleHandler.RollbackRule)
 		protected.PUT("/rules/:id/activate", ruleHandler.ActivateRule)
 		protected.PUT("/rules/:id/deactivate", ruleHandler.DeactivateRule)
 		protected.DELETE("/rules/:id", ruleHandler.DeleteRule)
+		protected.POST("/rules/evaluate", ruleHandler.EvaluateUnsavedRule)
+		protected.POST("/rules/from-template", ruleHandler.CreateRuleFromTemplate)
+		protected.GET("/rule-templates", ruleHandler.GetRuleTemplates)
+		protected.POST("/rules/:id/evaluate", ruleHandler.EvaluateRule)
+		protected.GET("/rules/:id/proposals", ruleHandler.GetRuleProposals)
+		protected.POST("/rules/:id/proposals/:proposal_id/approve", ruleHandler.ApproveRuleProposal)
+		protected.POST("/rules/:id/proposals/:proposal_id/reject", ruleHandler.RejectRuleProposal)
+		protected.GET("/executions", executionHandler.GetExecutions)
 	}
 
 	// Create a test user and get auth token
@@ -336,3 +362,552 @@ func (s *RuleIntegrationTestSuite) TestDeleteRule() {
 
 	assert.Equal(s.T(), http.StatusInternalServerError, w.Code) // Should return error as rule is deleted
 }
+
+func (s *RuleIntegrationTestSuite) TestCreateRuleWithTriggerPolicy() {
+	createBody := map[string]interface{}{
+		"name":      "Policy Test Rule",
+		"symbol":    "AAPL",
+		"rule_type": "stop_loss",
+		"conditions": []map[string]interface{}{
+			{"type": "price", "operator": "less_than", "value": 150.0},
+		},
+		"actions": []map[string]interface{}{
+			{"type": "sell", "quantity": 10.0, "order_type": "market"},
+		},
+		"trigger_policy": map[string]interface{}{
+			"one_shot":             true,
+			"cooldown_seconds":     60,
+			"max_triggers_per_day": 3,
+		},
+	}
+	createJSON, _ := json.Marshal(createBody)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/api/v1/rules", bytes.NewBuffer(createJSON))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+s.authToken)
+	s.router.ServeHTTP(w, req)
+
+	var createResponse map[string]interface{}
+	err := json.Unmarshal(w.Body.Bytes(), &createResponse)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), http.StatusCreated, w.Code)
+
+	rule := createResponse["rule"].(map[string]interface{})
+	policy := rule["trigger_policy"].(map[string]interface{})
+	assert.Equal(s.T(), true, policy["one_shot"])
+	assert.Equal(s.T(), 60.0, policy["cooldown_seconds"])
+	assert.Equal(s.T(), 3.0, policy["max_triggers_per_day"])
+	assert.Equal(s.T(), 0.0, rule["trigger_count"])
+	assert.Nil(s.T(), rule["last_triggered_at"])
+
+	// Negative limits are rejected
+	createBody["trigger_policy"] = map[string]interface{}{"cooldown_seconds": -1}
+	createJSON, _ = json.Marshal(createBody)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/api/v1/rules", bytes.NewBuffer(createJSON))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+s.authToken)
+	s.router.ServeHTTP(w, req)
+
+	assert.Equal(s.T(), http.StatusBadRequest, w.Code)
+}
+
+func (s *RuleIntegrationTestSuite) TestCreateTrailingStopRule() {
+	createBody := map[string]interface{}{
+		"name":      "Trailing Stop Test Rule",
+		"symbol":    "NVDA",
+		"rule_type": "trailing_stop",
+		"stop":      map[string]interface{}{"percent": 8.0},
+		"actions": []map[string]interface{}{
+			{"type": "sell", "quantity": 3.0, "order_type": "market"},
+		},
+	}
+	createJSON, _ := json.Marshal(createBody)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/api/v1/rules", bytes.NewBuffer(createJSON))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+s.authToken)
+	s.router.ServeHTTP(w, req)
+
+	var createResponse map[string]interface{}
+	err := json.Unmarshal(w.Body.Bytes(), &createResponse)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), http.StatusCreated, w.Code)
+
+	rule := createResponse["rule"].(map[string]interface{})
+	assert.Equal(s.T(), "trailing_stop", rule["rule_type"])
+	assert.Equal(s.T(), 8.0, rule["stop"].(map[string]interface{})["percent"])
+	assert.Empty(s.T(), rule["conditions"])
+
+	// A trailing stop cannot trail a fixed price
+	createBody["stop"] = map[string]interface{}{"price": 100.0}
+	createJSON, _ = json.Marshal(createBody)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/api/v1/rules", bytes.NewBuffer(createJSON))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+s.authToken)
+	s.router.ServeHTTP(w, req)
+
+	assert.Equal(s.T(), http.StatusBadRequest, w.Code)
+}
+
+func (s *RuleIntegrationTestSuite) TestEvaluateRule() {
+	createBody := map[string]interface{}{
+		"name":      "Evaluate Test Rule",
+		"symbol":    "AMD",
+		"rule_type": "buy",
+		"conditions": []map[string]interface{}{
+			{"type": "price", "operator": "less_than", "value": 100.0},
+		},
+		"actions": []map[string]interface{}{
+			{"type": "buy", "quantity": 1.0, "order_type": "market"},
+		},
+	}
+	createJSON, _ := json.Marshal(createBody)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/api/v1/rules", bytes.NewBuffer(createJSON))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+s.authToken)
+	s.router.ServeHTTP(w, req)
+	assert.Equal(s.T(), http.StatusCreated, w.Code)
+
+	var createResponse map[string]interface{}
+	err := json.Unmarshal(w.Body.Bytes(), &createResponse)
+	assert.NoError(s.T(), err)
+	ruleID := createResponse["rule"].(map[string]interface{})["id"].(string)
+
+	// Evaluate the saved rule against a supplied price
+	evaluateJSON, _ := json.Marshal(map[string]interface{}{
+		"market_data": map[string]interface{}{"AMD": map[string]interface{}{"price": 95.0}},
+	})
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/api/v1/rules/"+ruleID+"/evaluate", bytes.NewBuffer(evaluateJSON))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+s.authToken)
+	s.router.ServeHTTP(w, req)
+	assert.Equal(s.T(), http.StatusOK, w.Code)
+
+	var evaluateResponse map[string]interface{}
+	err = json.Unmarshal(w.Body.Bytes(), &evaluateResponse)
+	assert.NoError(s.T(), err)
+	evaluation := evaluateResponse["evaluation"].(map[string]interface{})
+	assert.Equal(s.T(), true, evaluation["triggered"])
+	condition := evaluation["conditions"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(s.T(), 95.0, condition["left"].(map[string]interface{})["current"])
+	assert.Len(s.T(), evaluation["actions"], 1)
+
+	// Evaluate an unsaved variant of the rule
+	createBody["conditions"] = []map[string]interface{}{
+		{"type": "price", "operator": "less_than", "value": 90.0},
+	}
+	createBody["market_data"] = map[string]interface{}{"AMD": map[string]interface{}{"price": 95.0}}
+	evaluateJSON, _ = json.Marshal(createBody)
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/api/v1/rules/evaluate", bytes.NewBuffer(evaluateJSON))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+s.authToken)
+	s.router.ServeHTTP(w, req)
+	assert.Equal(s.T(), http.StatusOK, w.Code)
+
+	err = json.Unmarshal(w.Body.Bytes(), &evaluateResponse)
+	assert.NoError(s.T(), err)
+	evaluation = evaluateResponse["evaluation"].(map[string]interface{})
+	assert.Equal(s.T(), false, evaluation["triggered"])
+	assert.Empty(s.T(), evaluation["actions"])
+}
+
+func (s *RuleIntegrationTestSuite) TestCreateRuleValidationDetails() {
+	createBody := map[string]interface{}{
+		"name":      "Invalid Rule",
+		"symbol":    "AAPL",
+		"rule_type": "buy",
+		"conditions": []map[string]interface{}{
+			{"type": "sma", "time_frame": "1d", "operator": "less_than", "value": 150.0},
+		},
+		"actions": []map[string]interface{}{
+			{"type": "buy", "symbol": "MSFT", "quantity": 10.0, "order_type": "limit"},
+		},
+	}
+	createJSON, _ := json.Marshal(createBody)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/api/v1/rules", bytes.NewBuffer(createJSON))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+s.authToken)
+	s.router.ServeHTTP(w, req)
+
+	assert.Equal(s.T(), http.StatusBadRequest, w.Code)
+
+	var response struct {
+		Error   string `json:"error"`
+		Details []struct {
+			Field   string `json:"field"`
+			Message string `json:"message"`
+		} `json:"details"`
+	}
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), "invalid rule", response.Error)
+
+	fields := make([]string, len(response.Details))
+	for i, detail := range response.Details {
+		fields[i] = detail.Field
+	}
+	assert.Equal(s.T(), []string{"conditions[0].params", "actions[0].symbol", "actions[0].limit"}, fields)
+}
+
+func (s *RuleIntegrationTestSuite) TestPatchRuleVersionsAndRollback() {
+	createBody := map[string]interface{}{
+		"name":      "Versioned Rule",
+		"symbol":    "AAPL",
+		"rule_type": "buy",
+		"conditions": []map[string]interface{}{
+			{"type": "price", "operator": "less_than", "value": 150.0},
+		},
+		"actions": []map[string]interface{}{
+			{"type": "buy", "quantity": 10.0, "order_type": "market"},
+		},
+	}
+	ruleID := s.sendRuleRequest("POST", "/api/v1/rules", createBody, http.StatusCreated)["rule"].(map[string]interface{})["id"].(string)
+
+	// Patch the threshold
+	patchBody := map[string]interface{}{
+		"conditions": []map[string]interface{}{
+			{"type": "price", "operator": "less_than", "value": 140.0},
+		},
+	}
+	patched := s.sendRuleRequest("PATCH", "/api/v1/rules/"+ruleID, patchBody, http.StatusOK)["rule"].(map[string]interface{})
+	assert.Equal(s.T(), 2.0, patched["version"])
+
+	// Both versions are listed, newest first, with the diff on the second
+	versions := s.sendRuleRequest("GET", "/api/v1/rules/"+ruleID+"/versions", nil, http.StatusOK)["versions"].([]interface{})
+	assert.Len(s.T(), versions, 2)
+	latest := versions[0].(map[string]interface{})
+	assert.Equal(s.T(), 2.0, latest["version"])
+	changes := latest["changes"].([]interface{})
+	assert.Len(s.T(), changes, 1)
+	assert.Equal(s.T(), "conditions", changes[0].(map[string]interface{})["field"])
+
+	// An invalid patch is rejected without creating a version
+	s.sendRuleRequest("PATCH", "/api/v1/rules/"+ruleID, map[string]interface{}{"actions": []interface{}{}}, http.StatusBadRequest)
+
+	// Rolling back records a third version with the original definition
+	rolledBack := s.sendRuleRequest("POST", "/api/v1/rules/"+ruleID+"/rollback", map[string]interface{}{"version": 1}, http.StatusOK)["rule"].(map[string]interface{})
+	assert.Equal(s.T(), 3.0, rolledBack["version"])
+	condition := rolledBack["conditions"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(s.T(), 150.0, condition["value"])
+
+	version := s.sendRuleRequest("GET", "/api/v1/rules/"+ruleID+"/versions/3", nil, http.StatusOK)["version"].(map[string]interface{})
+	assert.Equal(s.T(), s.userID.String(), version["created_by"])
+
+	s.sendRuleRequest("GET", "/api/v1/rules/"+ruleID+"/versions/9", nil, http.StatusNotFound)
+}
+
+// sendRuleRequest sends an authenticated request and decodes the JSON response
+func (s *RuleIntegrationTestSuite) sendRuleRequest(method, path string, body interface{}, expectedStatus int) map[string]interface{} {
+	var reader *bytes.Buffer
+	if body != nil {
+		data, _ := json.Marshal(body)
+		reader = bytes.NewBuffer(data)
+	} else {
+		reader = bytes.NewBuffer(nil)
+	}
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(method, path, reader)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+s.authToken)
+	s.router.ServeHTTP(w, req)
+
+	s.Require().Equal(expectedStatus, w.Code, w.Body.String())
+
+	var response map[string]interface{}
+	s.Require().NoError(json.Unmarshal(w.Body.Bytes(), &response))
+	return response
+}
+
+func (s *RuleIntegrationTestSuite) TestCreateRuleFromTemplate() {
+	templates := s.sendRuleRequest("GET", "/api/v1/rule-templates", nil, http.StatusOK)["templates"].([]interface{})
+	ids := make([]string, len(templates))
+	for i, template := range templates {
+		ids[i] = template.(map[string]interface{})["id"].(string)
+	}
+	assert.Contains(s.T(), ids, "weekly_dca")
+
+	createBody := map[string]interface{}{
+		"template_id": "weekly_dca",
+		"params":      map[string]interface{}{"symbol": "VTI", "quantity": 2.0},
+	}
+	rule := s.sendRuleRequest("POST", "/api/v1/rules/from-template", createBody, http.StatusCreated)["rule"].(map[string]interface{})
+	assert.Equal(s.T(), "VTI", rule["symbol"])
+	assert.Equal(s.T(), "scheduled", rule["rule_type"])
+	assert.Equal(s.T(), "35 9 * * mon", rule["schedule"].(map[string]interface{})["cron"])
+
+	// Invalid parameters are reported per field
+	createBody["params"] = map[string]interface{}{"symbol": "VTI"}
+	response := s.sendRuleRequest("POST", "/api/v1/rules/from-template", createBody, http.StatusBadRequest)
+	assert.Equal(s.T(), "invalid template parameters", response["error"])
+
+	createBody["template_id"] = "unknown"
+	s.sendRuleRequest("POST", "/api/v1/rules/from-template", createBody, http.StatusNotFound)
+}
+
+func (s *RuleIntegrationTestSuite) TestCreateRuleFromExpression() {
+	createBody := map[string]interface{}{
+		"name":       "RSI dip",
+		"symbol":     "AAPL",
+		"rule_type":  "buy",
+		"expression": `when price("AAPL", "1h") < sma(50) and rsi(14) < 30 then buy 10 market`,
+	}
+	rule := s.sendRuleRequest("POST", "/api/v1/rules", createBody, http.StatusCreated)["rule"].(map[string]interface{})
+	assert.Len(s.T(), rule["conditions"], 2)
+	assert.Equal(s.T(), createBody["expression"], rule["expression"])
+
+	// Problems are reported with their position in the expression
+	createBody["expression"] = `when rsi(14) < 30 buy 10`
+	response := s.sendRuleRequest("POST", "/api/v1/rules", createBody, http.StatusBadRequest)
+	assert.Equal(s.T(), "invalid rule expression", response["error"])
+	details := response["details"].([]interface{})
+	assert.Equal(s.T(), `1:19: expected "then", found "buy"`, details[0].(map[string]interface{})["message"])
+}
+
+func (s *RuleIntegrationTestSuite) TestRuleTimeConstraints() {
+	createBody := map[string]interface{}{
+		"name":      "Session-only dip buyer",
+		"symbol":    "AAPL",
+		"rule_type": "buy",
+		"conditions": []map[string]interface{}{
+			{"type": "price", "operator": "less_than", "value": 150},
+		},
+		"actions": []map[string]interface{}{
+			{"type": "buy", "quantity": 10, "order_type": "market"},
+		},
+		"time_constraints": map[string]interface{}{
+			"days":                 []string{"mon", "tue", "wed", "thu", "fri"},
+			"windows":              []map[string]interface{}{{"start": "09:45", "end": "15:30"}},
+			"regular_session_only": true,
+		},
+	}
+	rule := s.sendRuleRequest("POST", "/api/v1/rules", createBody, http.StatusCreated)["rule"].(map[string]interface{})
+	ruleID := rule["id"].(string)
+	constraints := rule["time_constraints"].(map[string]interface{})
+	assert.Equal(s.T(), true, constraints["regular_session_only"])
+	assert.Len(s.T(), constraints["windows"], 1)
+
+	// An empty object removes the constraints
+	patched := s.sendRuleRequest("PATCH", "/api/v1/rules/"+ruleID,
+		map[string]interface{}{"time_constraints": map[string]interface{}{}}, http.StatusOK)["rule"].(map[string]interface{})
+	assert.Nil(s.T(), patched["time_constraints"])
+
+	createBody["time_constraints"] = map[string]interface{}{"time_zone": "Nowhere/Special"}
+	response := s.sendRuleRequest("POST", "/api/v1/rules", createBody, http.StatusBadRequest)
+	details := response["details"].([]interface{})
+	assert.Equal(s.T(), "time_constraints.time_zone", details[0].(map[string]interface{})["field"])
+}
+
+func (s *RuleIntegrationTestSuite) TestCreateScheduledRule() {
+	createBody := map[string]interface{}{
+		"name":      "Monday DCA",
+		"symbol":    "VTI",
+		"rule_type": "scheduled",
+		"actions": []map[string]interface{}{
+			{"type": "buy", "notional": 500, "order_type": "market"},
+		},
+		"schedule": map[string]interface{}{
+			"cron":              "30 10 * * mon",
+			"trading_days_only": true,
+			"catch_up":          "once",
+		},
+	}
+	rule := s.sendRuleRequest("POST", "/api/v1/rules", createBody, http.StatusCreated)["rule"].(map[string]interface{})
+	assert.Equal(s.T(), "30 10 * * mon", rule["schedule"].(map[string]interface{})["cron"])
+
+	nextRunAt, err := time.Parse(time.RFC3339, rule["next_run_at"].(string))
+	s.Require().NoError(err)
+	assert.True(s.T(), nextRunAt.After(time.Now()))
+	assert.Equal(s.T(), time.Monday, nextRunAt.In(calendar.NYSE().Location).Weekday())
+
+	// Scheduled rules need a schedule, and other rule types can't have one
+	delete(createBody, "schedule")
+	response := s.sendRuleRequest("POST", "/api/v1/rules", createBody, http.StatusBadRequest)
+	details := response["details"].([]interface{})
+	assert.Equal(s.T(), "schedule", details[0].(map[string]interface{})["field"])
+
+	createBody["schedule"] = map[string]interface{}{"interval_seconds": 10}
+	response = s.sendRuleRequest("POST", "/api/v1/rules", createBody, http.StatusBadRequest)
+	details = response["details"].([]interface{})
+	assert.Equal(s.T(), "schedule.interval_seconds", details[0].(map[string]interface{})["field"])
+}
+
+func (s *RuleIntegrationTestSuite) TestRuleMetricsAndQuarantine() {
+	ctx := context.Background()
+	rule, err := s.ruleService.CreateRule(ctx, s.userID, services.RuleInput{
+		Name:       "Metrics Test Rule",
+		Symbol:     "AAPL",
+		RuleType:   "stop_loss",
+		Conditions: []services.RuleCondition{{Type: "price", Operator: "less_than", Value: 150}},
+		Actions:    []services.RuleAction{{Type: "sell", Symbol: "AAPL", Quantity: 1, OrderType: "market"}},
+	})
+	s.Require().NoError(err)
+
+	// Two engine instances each flush a batch ending in an error
+	message := "no market data for AAPL"
+	now := time.Now()
+	for i := 0; i < 2; i++ {
+		consecutive, err := s.ruleRepo.RecordMetrics(ctx, repository.RuleMetricsDelta{
+			RuleID:          rule.ID,
+			Evaluations:     3,
+			Triggers:        1,
+			Errors:          1,
+			TrailingErrors:  1,
+			TotalLatency:    6 * time.Millisecond,
+			MaxLatency:      3 * time.Millisecond,
+			LastEvaluatedAt: now,
+			LastError:       &message,
+			LastErrorAt:     &now,
+		})
+		s.Require().NoError(err)
+		s.Equal(i+1, consecutive)
+	}
+
+	quarantined, err := s.ruleRepo.QuarantineRule(ctx, rule.ID)
+	s.Require().NoError(err)
+	s.True(quarantined)
+	quarantined, err = s.ruleRepo.QuarantineRule(ctx, rule.ID)
+	s.Require().NoError(err)
+	s.False(quarantined)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/v1/rules/"+rule.ID.String(), nil)
+	req.Header.Set("Authorization", "Bearer "+s.authToken)
+	s.router.ServeHTTP(w, req)
+	s.Require().Equal(http.StatusOK, w.Code)
+
+	var response struct {
+		Rule struct {
+			Status  string `json:"status"`
+			Metrics struct {
+				Evaluations       int64   `json:"evaluations"`
+				Triggers          int64   `json:"triggers"`
+				Errors            int64   `json:"errors"`
+				ConsecutiveErrors int     `json:"consecutive_errors"`
+				AvgLatencyMs      float64 `json:"avg_latency_ms"`
+				MaxLatencyMs      float64 `json:"max_latency_ms"`
+				LastError         string  `json:"last_error"`
+			} `json:"metrics"`
+		} `json:"rule"`
+	}
+	s.Require().NoError(json.Unmarshal(w.Body.Bytes(), &response))
+	s.Equal(services.RuleStatusErrored, response.Rule.Status)
+	s.Equal(int64(6), response.Rule.Metrics.Evaluations)
+	s.Equal(int64(2), response.Rule.Metrics.Triggers)
+	s.Equal(int64(2), response.Rule.Metrics.Errors)
+	s.Equal(0, response.Rule.Metrics.ConsecutiveErrors)
+	s.InDelta(2.0, response.Rule.Metrics.AvgLatencyMs, 0.001)
+	s.InDelta(3.0, response.Rule.Metrics.MaxLatencyMs, 0.001)
+	s.Equal(message, response.Rule.Metrics.LastError)
+}
+
+func (s *RuleIntegrationTestSuite) TestApproveAndRejectRuleProposals() {
+	createBody := map[string]interface{}{
+		"name":      "AI-Managed Rule",
+		"symbol":    "AAPL",
+		"rule_type": "buy",
+		"conditions": []map[string]interface{}{
+			{"type": "price", "operator": "less_than", "value": 150.0},
+		},
+		"actions": []map[string]interface{}{
+			{"type": "buy", "quantity": 10.0, "order_type": "market"},
+		},
+		"ai_management": map[string]interface{}{"max_change_percent": 5.0},
+	}
+	created := s.sendRuleRequest("POST", "/api/v1/rules", createBody, http.StatusCreated)["rule"].(map[string]interface{})
+	s.Equal(true, created["is_ai_managed"])
+	ruleID := uuid.MustParse(created["id"].(string))
+
+	propose := func(to float64) string {
+		adjustments, _ := json.Marshal([]services.ParameterAdjustment{
+			{Path: "conditions[0].value", From: 150, To: to, Reason: "test"},
+		})
+		proposal := &models.RuleProposal{
+			RuleID:      ruleID,
+			UserID:      s.userID,
+			RuleVersion: 1,
+			Advisor:     "test",
+			Adjustments: adjustments,
+			Status:      services.RuleProposalStatusPending,
+		}
+		s.Require().NoError(s.proposalRepo.Create(context.Background(), proposal))
+		return proposal.ID.String()
+	}
+
+	rejected := propose(140)
+	s.sendRuleRequest("POST", "/api/v1/rules/"+ruleID.String()+"/proposals/"+rejected+"/reject", nil, http.StatusOK)
+	s.sendRuleRequest("POST", "/api/v1/rules/"+ruleID.String()+"/proposals/"+rejected+"/approve", nil, http.StatusConflict)
+
+	approved := propose(145)
+	rule := s.sendRuleRequest("POST", "/api/v1/rules/"+ruleID.String()+"/proposals/"+approved+"/approve", nil, http.StatusOK)["rule"].(map[string]interface{})
+	s.Equal(2.0, rule["version"])
+	condition := rule["conditions"].([]interface{})[0].(map[string]interface{})
+	s.Equal(145.0, condition["value"])
+
+	// A proposal made for an earlier version is superseded rather than applied
+	stale := propose(148)
+	s.sendRuleRequest("POST", "/api/v1/rules/"+ruleID.String()+"/proposals/"+stale+"/approve", nil, http.StatusConflict)
+
+	proposals := s.sendRuleRequest("GET", "/api/v1/rules/"+ruleID.String()+"/proposals", nil, http.StatusOK)["proposals"].([]interface{})
+	s.Require().Len(proposals, 3)
+	statuses := map[string]string{}
+	for _, p := range proposals {
+		proposal := p.(map[string]interface{})
+		statuses[proposal["id"].(string)] = proposal["status"].(string)
+	}
+	s.Equal(services.RuleProposalStatusRejected, statuses[rejected])
+	s.Equal(services.RuleProposalStatusApplied, statuses[approved])
+	s.Equal(services.RuleProposalStatusSuperseded, statuses[stale])
+
+	// The advisor's change keeps the owner's value as the baseline
+	stored, err := s.ruleRepo.GetByID(context.Background(), ruleID)
+	s.Require().NoError(err)
+	var baseline map[string]float64
+	s.Require().NoError(json.Unmarshal(stored.AIBaseline, &baseline))
+	s.Equal(150.0, baseline["conditions[0].value"])
+}
+
+func (s *RuleIntegrationTestSuite) TestChainedRuleActionIsInExecutionHistory() {
+	ctx := context.Background()
+	entry, err := s.ruleService.CreateRule(ctx, s.userID, services.RuleInput{
+		Name:       "Chained entry",
+		Symbol:     "MSFT",
+		RuleType:   "buy",
+		Conditions: []services.RuleCondition{{Type: "price", Operator: "less_than", Value: 300}},
+		Actions:    []services.RuleAction{{Type: "buy", Quantity: 1, OrderType: "market"}},
+	})
+	s.Require().NoError(err)
+	s.Require().NoError(s.ruleService.DeactivateRule(ctx, s.userID, entry.ID))
+
+	trigger, err := s.ruleService.CreateRule(ctx, s.userID, services.RuleInput{
+		Name:       "Arms the entry",
+		Symbol:     "AAPL",
+		RuleType:   "buy",
+		Conditions: []services.RuleCondition{{Type: "price", Operator: "less_than", Value: 150}},
+		Actions:    []services.RuleAction{{Type: services.ActionTypeActivateRule, RuleID: &entry.ID}},
+	})
+	s.Require().NoError(err)
+	s.Require().NoError(s.ruleEngine.ExecuteRule(ctx, trigger, 140))
+
+	response := s.sendRuleRequest("GET", "/api/v1/executions?rule_id="+trigger.ID.String(), nil, http.StatusOK)
+	executions := response["executions"].([]interface{})
+	s.Require().Len(executions, 1)
+	execution := executions[0].(map[string]interface{})
+	s.Equal(services.ActionTypeActivateRule, execution["execution_type"])
+	s.Equal(entry.ID.String(), execution["target_rule_id"])
+
+	rule := s.sendRuleRequest("GET", "/api/v1/rules/"+entry.ID.String(), nil, http.StatusOK)["rule"].(map[string]interface{})
+	s.Equal("active", rule["status"])
+}
@@ -33,9 +33,17 @@ func SetupIntegrationTest() (*gorm.DB, error) {
 	err = db.AutoMigrate(
 		&models.User{},
 		&models.TradingRule{},
+		&models.RuleVersion{},
 		&models.Execution{},
 		&models.Portfolio{},
 		&models.PortfolioHolding{},
+		&models.MarketData{},
+		&models.Quote{},
+		&models.EngineInstance{},
+		&models.RuleMetrics{},
+		&models.Notification{},
+		&models.RuleProposal{},
+		&models.Symbol{},
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to migrate test database: %w", err)
@@ -57,7 +65,7 @@ func cleanTestDatabase(db *gorm.DB) error {
 	}
 
 	// Truncate tables
-	if err := db.Exec("TRUNCATE users, trading_rules, executions, portfolios, portfolio_holdings RESTART IDENTITY CASCADE;").Error; err != nil {
+	if err := db.Exec("TRUNCATE users, trading_rules, rule_versions, executions, portfolios, portfolio_holdings, market_data, quotes, engine_instances, rule_metrics, notifications, rule_proposals, symbols RESTART IDENTITY CASCADE;").Error; err != nil {
 		return err
 	}
 
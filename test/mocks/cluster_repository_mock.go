@@ -0,0 +1,44 @@
+// test/mocks/cluster_repository_mock.go
+package mocks
+
+import (
+	"context"
+	"time"
+
+	"github.com/aquibsayyed9/sentinel/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockClusterRepository struct {
+	mock.Mock
+}
+
+func (m *MockClusterRepository) Heartbeat(ctx context.Context, id uuid.UUID, name string) error {
+	args := m.Called(ctx, id, name)
+	return args.Error(0)
+}
+
+func (m *MockClusterRepository) GetLiveInstances(ctx context.Context, ttl time.Duration) ([]models.EngineInstance, error) {
+	args := m.Called(ctx, ttl)
+	return args.Get(0).([]models.EngineInstance), args.Error(1)
+}
+
+func (m *MockClusterRepository) DeleteExpiredInstances(ctx context.Context, ttl time.Duration) error {
+	args := m.Called(ctx, ttl)
+	return args.Error(0)
+}
+
+func (m *MockClusterRepository) Deregister(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+// WithRuleLock runs fn when the mocked lock is acquired
+func (m *MockClusterRepository) WithRuleLock(ctx context.Context, ruleID uuid.UUID, fn func() error) (bool, error) {
+	args := m.Called(ctx, ruleID)
+	if !args.Bool(0) || args.Error(1) != nil {
+		return args.Bool(0), args.Error(1)
+	}
+	return true, fn()
+}
@@ -0,0 +1,60 @@
+// test/mocks/execution_repository_mock.go
+package mocks
+
+import (
+	"context"
+
+	"github.com/aquibsayyed9/sentinel/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockExecutionRepository struct {
+	mock.Mock
+}
+
+func (m *MockExecutionRepository) Create(ctx context.Context, execution *models.Execution) error {
+	args := m.Called(ctx, execution)
+	return args.Error(0)
+}
+
+func (m *MockExecutionRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Execution, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Execution), args.Error(1)
+}
+
+func (m *MockExecutionRepository) GetByIDAndUserID(ctx context.Context, id, userID uuid.UUID) (*models.Execution, error) {
+	args := m.Called(ctx, id, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Execution), args.Error(1)
+}
+
+func (m *MockExecutionRepository) GetByUserID(ctx context.Context, userID uuid.UUID, limit, offset int) ([]models.Execution, error) {
+	args := m.Called(ctx, userID, limit, offset)
+	return args.Get(0).([]models.Execution), args.Error(1)
+}
+
+func (m *MockExecutionRepository) GetByRuleID(ctx context.Context, ruleID uuid.UUID) ([]models.Execution, error) {
+	args := m.Called(ctx, ruleID)
+	return args.Get(0).([]models.Execution), args.Error(1)
+}
+
+func (m *MockExecutionRepository) GetRecentExecutions(ctx context.Context, limit int) ([]models.Execution, error) {
+	args := m.Called(ctx, limit)
+	return args.Get(0).([]models.Execution), args.Error(1)
+}
+
+func (m *MockExecutionRepository) Update(ctx context.Context, execution *models.Execution) error {
+	args := m.Called(ctx, execution)
+	return args.Error(0)
+}
+
+func (m *MockExecutionRepository) CountByUserID(ctx context.Context, userID uuid.UUID) (int64, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(int64), args.Error(1)
+}
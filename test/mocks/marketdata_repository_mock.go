@@ -0,0 +1,66 @@
+// test/mocks/marketdata_repository_mock.go
+package mocks
+
+import (
+	"context"
+	"time"
+
+	"github.com/aquibsayyed9/sentinel/internal/models"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockMarketDataRepository struct {
+	mock.Mock
+}
+
+func (m *MockMarketDataRepository) SaveMarketData(ctx context.Context, data *models.MarketData) error {
+	args := m.Called(ctx, data)
+	return args.Error(0)
+}
+
+func (m *MockMarketDataRepository) GetLatestPrice(ctx context.Context, symbol string) (*models.MarketData, error) {
+	args := m.Called(ctx, symbol)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.MarketData), args.Error(1)
+}
+
+func (m *MockMarketDataRepository) GetPriceAt(ctx context.Context, symbol string, at time.Time) (*models.MarketData, error) {
+	args := m.Called(ctx, symbol, at)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.MarketData), args.Error(1)
+}
+
+func (m *MockMarketDataRepository) GetHistoricalData(ctx context.Context, symbol string, start, end time.Time, timeframe string) ([]models.MarketData, error) {
+	args := m.Called(ctx, symbol, start, end, timeframe)
+	return args.Get(0).([]models.MarketData), args.Error(1)
+}
+
+func (m *MockMarketDataRepository) GetBars(ctx context.Context, symbol, timeFrame string, start, end time.Time, limit int) ([]models.MarketData, error) {
+	args := m.Called(ctx, symbol, timeFrame, start, end, limit)
+	return args.Get(0).([]models.MarketData), args.Error(1)
+}
+
+func (m *MockMarketDataRepository) SaveQuote(ctx context.Context, quote *models.Quote) error {
+	args := m.Called(ctx, quote)
+	return args.Error(0)
+}
+
+func (m *MockMarketDataRepository) GetLatestQuote(ctx context.Context, symbol string) (*models.Quote, error) {
+	args := m.Called(ctx, symbol)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Quote), args.Error(1)
+}
+
+func (m *MockMarketDataRepository) GetQuoteAt(ctx context.Context, symbol string, at time.Time) (*models.Quote, error) {
+	args := m.Called(ctx, symbol, at)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Quote), args.Error(1)
+}
@@ -0,0 +1,30 @@
+// test/mocks/notification_repository_mock.go
+package mocks
+
+import (
+	"context"
+	"time"
+
+	"github.com/aquibsayyed9/sentinel/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockNotificationRepository struct {
+	mock.Mock
+}
+
+func (m *MockNotificationRepository) Create(ctx context.Context, notification *models.Notification) error {
+	args := m.Called(ctx, notification)
+	return args.Error(0)
+}
+
+func (m *MockNotificationRepository) GetByUserID(ctx context.Context, userID uuid.UUID, unreadOnly bool, limit, offset int) ([]models.Notification, error) {
+	args := m.Called(ctx, userID, unreadOnly, limit, offset)
+	return args.Get(0).([]models.Notification), args.Error(1)
+}
+
+func (m *MockNotificationRepository) MarkRead(ctx context.Context, id, userID uuid.UUID, readAt time.Time) error {
+	args := m.Called(ctx, id, userID, readAt)
+	return args.Error(0)
+}
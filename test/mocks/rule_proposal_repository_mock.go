@@ -0,0 +1,43 @@
+// test/mocks/rule_proposal_repository_mock.go
+package mocks
+
+import (
+	"context"
+	"time"
+
+	"github.com/aquibsayyed9/sentinel/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockRuleProposalRepository struct {
+	mock.Mock
+}
+
+func (m *MockRuleProposalRepository) Create(ctx context.Context, proposal *models.RuleProposal) error {
+	args := m.Called(ctx, proposal)
+	return args.Error(0)
+}
+
+func (m *MockRuleProposalRepository) GetByIDAndUserID(ctx context.Context, id, userID uuid.UUID) (*models.RuleProposal, error) {
+	args := m.Called(ctx, id, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.RuleProposal), args.Error(1)
+}
+
+func (m *MockRuleProposalRepository) GetByRuleID(ctx context.Context, ruleID uuid.UUID) ([]models.RuleProposal, error) {
+	args := m.Called(ctx, ruleID)
+	return args.Get(0).([]models.RuleProposal), args.Error(1)
+}
+
+func (m *MockRuleProposalRepository) Decide(ctx context.Context, proposal *models.RuleProposal) error {
+	args := m.Called(ctx, proposal)
+	return args.Error(0)
+}
+
+func (m *MockRuleProposalRepository) SupersedePending(ctx context.Context, ruleID uuid.UUID, decidedAt time.Time) error {
+	args := m.Called(ctx, ruleID, decidedAt)
+	return args.Error(0)
+}
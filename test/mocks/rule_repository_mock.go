@@ -3,8 +3,10 @@ package mocks
 
 import (
 	"context"
+	"time"
 
 	"github.com/aquibsayyed9/sentinel/internal/models"
+	"github.com/aquibsayyed9/sentinel/internal/repository"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/mock"
 )
@@ -18,6 +20,11 @@ func (m *MockRuleRepository) Create(ctx context.Context, rule *models.TradingRul
 	return args.Error(0)
 }
 
+func (m *MockRuleRepository) CreateWithVersion(ctx context.Context, rule *models.TradingRule, version *models.RuleVersion) error {
+	args := m.Called(ctx, rule, version)
+	return args.Error(0)
+}
+
 func (m *MockRuleRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.TradingRule, error) {
 	args := m.Called(ctx, id)
 	if args.Get(0) == nil {
@@ -26,6 +33,14 @@ func (m *MockRuleRepository) GetByID(ctx context.Context, id uuid.UUID) (*models
 	return args.Get(0).(*models.TradingRule), args.Error(1)
 }
 
+func (m *MockRuleRepository) GetByIDAndUserID(ctx context.Context, id, userID uuid.UUID) (*models.TradingRule, error) {
+	args := m.Called(ctx, id, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.TradingRule), args.Error(1)
+}
+
 func (m *MockRuleRepository) GetByUserID(ctx context.Context, userID uuid.UUID) ([]models.TradingRule, error) {
 	args := m.Called(ctx, userID)
 	return args.Get(0).([]models.TradingRule), args.Error(1)
@@ -36,6 +51,11 @@ func (m *MockRuleRepository) GetActiveRules(ctx context.Context) ([]models.Tradi
 	return args.Get(0).([]models.TradingRule), args.Error(1)
 }
 
+func (m *MockRuleRepository) GetDueScheduledRules(ctx context.Context, now time.Time) ([]models.TradingRule, error) {
+	args := m.Called(ctx, now)
+	return args.Get(0).([]models.TradingRule), args.Error(1)
+}
+
 func (m *MockRuleRepository) Update(ctx context.Context, rule *models.TradingRule) error {
 	args := m.Called(ctx, rule)
 	return args.Error(0)
@@ -45,3 +65,61 @@ func (m *MockRuleRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	args := m.Called(ctx, id)
 	return args.Error(0)
 }
+
+func (m *MockRuleRepository) RecordTrigger(ctx context.Context, rule *models.TradingRule, at time.Time, triggers int) error {
+	args := m.Called(ctx, rule, at, triggers)
+	return args.Error(0)
+}
+
+func (m *MockRuleRepository) UpdateStatus(ctx context.Context, rule *models.TradingRule) error {
+	args := m.Called(ctx, rule)
+	return args.Error(0)
+}
+
+func (m *MockRuleRepository) UpdateLastExecutedAt(ctx context.Context, id uuid.UUID, executedAt time.Time) error {
+	args := m.Called(ctx, id, executedAt)
+	return args.Error(0)
+}
+
+func (m *MockRuleRepository) UpdateReferencePrice(ctx context.Context, id uuid.UUID, price float64) error {
+	args := m.Called(ctx, id, price)
+	return args.Error(0)
+}
+
+func (m *MockRuleRepository) UpdateNextRunAt(ctx context.Context, id uuid.UUID, previous *time.Time, nextRunAt time.Time) (bool, error) {
+	args := m.Called(ctx, id, previous, nextRunAt)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRuleRepository) UpdateDefinition(ctx context.Context, rule *models.TradingRule, version *models.RuleVersion) error {
+	args := m.Called(ctx, rule, version)
+	return args.Error(0)
+}
+
+func (m *MockRuleRepository) GetVersions(ctx context.Context, ruleID uuid.UUID) ([]models.RuleVersion, error) {
+	args := m.Called(ctx, ruleID)
+	return args.Get(0).([]models.RuleVersion), args.Error(1)
+}
+
+func (m *MockRuleRepository) GetVersion(ctx context.Context, ruleID uuid.UUID, version int) (*models.RuleVersion, error) {
+	args := m.Called(ctx, ruleID, version)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.RuleVersion), args.Error(1)
+}
+
+func (m *MockRuleRepository) RecordMetrics(ctx context.Context, delta repository.RuleMetricsDelta) (int, error) {
+	args := m.Called(ctx, delta)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockRuleRepository) GetMetrics(ctx context.Context, userID uuid.UUID, ruleIDs []uuid.UUID) ([]models.RuleMetrics, error) {
+	args := m.Called(ctx, userID, ruleIDs)
+	return args.Get(0).([]models.RuleMetrics), args.Error(1)
+}
+
+func (m *MockRuleRepository) QuarantineRule(ctx context.Context, id uuid.UUID) (bool, error) {
+	args := m.Called(ctx, id)
+	return args.Bool(0), args.Error(1)
+}
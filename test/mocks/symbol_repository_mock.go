@@ -0,0 +1,26 @@
+// test/mocks/symbol_repository_mock.go
+package mocks
+
+import (
+	"context"
+
+	"github.com/aquibsayyed9/sentinel/internal/models"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockSymbolRepository struct {
+	mock.Mock
+}
+
+func (m *MockSymbolRepository) GetBySymbol(ctx context.Context, symbol string) (*models.Symbol, error) {
+	args := m.Called(ctx, symbol)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Symbol), args.Error(1)
+}
+
+func (m *MockSymbolRepository) Upsert(ctx context.Context, symbols []models.Symbol) error {
+	args := m.Called(ctx, symbols)
+	return args.Error(0)
+}
@@ -0,0 +1,13 @@
+// test/mocks/transactor_mock.go
+package mocks
+
+import (
+	"context"
+)
+
+// MockTransactor runs units of work directly, without a transaction
+type MockTransactor struct{}
+
+func (m *MockTransactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
@@ -0,0 +1,109 @@
+// test/unit/calendar_test.go
+package unit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aquibsayyed9/sentinel/internal/calendar"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func nyTime(t *testing.T, value string) time.Time {
+	location, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+	parsed, err := time.ParseInLocation("2006-01-02 15:04", value, location)
+	require.NoError(t, err)
+	return parsed
+}
+
+func TestNYSE_Holidays(t *testing.T) {
+	nyse := calendar.NYSE()
+
+	holidays := []string{
+		"2024-01-01", // New Year's Day
+		"2024-01-15", // Martin Luther King Jr. Day
+		"2024-02-19", // Washington's Birthday
+		"2024-03-29", // Good Friday
+		"2024-05-27", // Memorial Day
+		"2024-06-19", // Juneteenth
+		"2024-07-04", // Independence Day
+		"2024-09-02", // Labor Day
+		"2024-11-28", // Thanksgiving Day
+		"2024-12-25", // Christmas Day
+		"2025-04-18", // Good Friday
+		"2026-07-03", // Independence Day on a Saturday, observed Friday
+		"2027-12-24", // Christmas Day on a Saturday, observed Friday
+		"2028-01-17", // Martin Luther King Jr. Day
+	}
+	for _, day := range holidays {
+		t.Run(day, func(t *testing.T) {
+			noon := nyTime(t, day+" 12:00")
+			assert.True(t, nyse.IsHoliday(noon))
+			assert.False(t, nyse.IsTradingDay(noon))
+			assert.False(t, nyse.IsOpen(noon))
+		})
+	}
+
+	// New Year's Day 2028 falls on a Saturday and isn't observed the day before
+	assert.True(t, nyse.IsTradingDay(nyTime(t, "2027-12-31 12:00")))
+	// Juneteenth was first observed in 2022
+	assert.True(t, nyse.IsTradingDay(nyTime(t, "2021-06-18 12:00")))
+}
+
+func TestNYSE_Sessions(t *testing.T) {
+	nyse := calendar.NYSE()
+
+	session, ok := nyse.SessionOn(nyTime(t, "2024-03-11 08:00"))
+	require.True(t, ok)
+	assert.Equal(t, nyTime(t, "2024-03-11 09:30"), session.Open)
+	assert.Equal(t, nyTime(t, "2024-03-11 16:00"), session.Close)
+	assert.False(t, session.EarlyClose)
+
+	// The open is inclusive and the close exclusive
+	assert.False(t, nyse.IsOpen(nyTime(t, "2024-03-11 09:29")))
+	assert.True(t, nyse.IsOpen(nyTime(t, "2024-03-11 09:30")))
+	assert.True(t, nyse.IsOpen(nyTime(t, "2024-03-11 15:59")))
+	assert.False(t, nyse.IsOpen(nyTime(t, "2024-03-11 16:00")))
+
+	// Sessions follow Eastern time across daylight saving changes
+	assert.True(t, nyse.IsOpen(time.Date(2024, time.January, 10, 14, 30, 0, 0, time.UTC)))
+	assert.True(t, nyse.IsOpen(time.Date(2024, time.July, 10, 13, 30, 0, 0, time.UTC)))
+	assert.False(t, nyse.IsOpen(time.Date(2024, time.January, 10, 13, 30, 0, 0, time.UTC)))
+
+	assert.False(t, nyse.IsOpen(nyTime(t, "2024-03-09 12:00")), "Saturday")
+}
+
+func TestNYSE_EarlyCloses(t *testing.T) {
+	nyse := calendar.NYSE()
+
+	for _, day := range []string{"2024-07-03", "2024-11-29", "2024-12-24"} {
+		t.Run(day, func(t *testing.T) {
+			session, ok := nyse.SessionOn(nyTime(t, day+" 12:00"))
+			require.True(t, ok)
+			assert.True(t, session.EarlyClose)
+			assert.Equal(t, nyTime(t, day+" 13:00"), session.Close)
+			assert.False(t, nyse.IsOpen(nyTime(t, day+" 14:00")))
+		})
+	}
+
+	// July 3rd 2026 is the observed Independence Day, not an early close
+	_, ok := nyse.SessionOn(nyTime(t, "2026-07-03 12:00"))
+	assert.False(t, ok)
+	session, ok := nyse.SessionOn(nyTime(t, "2026-07-02 12:00"))
+	require.True(t, ok)
+	assert.False(t, session.EarlyClose)
+}
+
+func TestNYSE_NextSession(t *testing.T) {
+	nyse := calendar.NYSE()
+
+	// Thursday evening before Good Friday rolls over the long weekend
+	session := nyse.NextSession(nyTime(t, "2024-03-28 17:00"))
+	assert.Equal(t, nyTime(t, "2024-04-01 09:30"), session.Open)
+
+	// While open, the next session is the current one
+	session = nyse.NextSession(nyTime(t, "2024-04-01 10:00"))
+	assert.Equal(t, nyTime(t, "2024-04-01 09:30"), session.Open)
+}
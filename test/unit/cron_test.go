@@ -0,0 +1,75 @@
+// test/unit/cron_test.go
+package unit
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aquibsayyed9/sentinel/internal/calendar"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCron_Next(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+		from string // Eastern time
+		want string
+	}{
+		{name: "every minute", expr: "* * * * *", from: "2024-03-12 10:00", want: "2024-03-12 10:01"},
+		{name: "later today", expr: "30 9 * * *", from: "2024-03-12 08:00", want: "2024-03-12 09:30"},
+		{name: "strictly after", expr: "30 9 * * *", from: "2024-03-12 09:30", want: "2024-03-13 09:30"},
+		{name: "step", expr: "*/15 * * * *", from: "2024-03-12 10:07", want: "2024-03-12 10:15"},
+		{name: "range with step", expr: "0 9-17/4 * * *", from: "2024-03-12 13:01", want: "2024-03-12 17:00"},
+		{name: "list", expr: "0 10,14 * * *", from: "2024-03-12 10:30", want: "2024-03-12 14:00"},
+		{name: "weekday names", expr: "30 9 * * mon-fri", from: "2024-03-08 10:00", want: "2024-03-11 09:30"},
+		{name: "sunday as 7", expr: "0 12 * * 7", from: "2024-03-12 10:00", want: "2024-03-17 12:00"},
+		{name: "month name", expr: "0 0 1 jul *", from: "2024-03-12 10:00", want: "2024-07-01 00:00"},
+		{name: "day of month or day of week", expr: "0 12 15 * fri", from: "2024-03-12 10:00", want: "2024-03-15 12:00"},
+		{name: "day of month or day of week matches either", expr: "0 12 13 * fri", from: "2024-03-12 13:00", want: "2024-03-13 12:00"},
+		{name: "time skipped by daylight saving", expr: "30 2 * * *", from: "2024-03-09 03:00", want: "2024-03-11 02:30"},
+		{name: "leap day", expr: "0 0 29 2 *", from: "2024-03-01 00:00", want: "2028-02-29 00:00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cron, err := calendar.ParseCron(tt.expr)
+			require.NoError(t, err)
+
+			next, ok := cron.Next(nyTime(t, tt.from))
+			require.True(t, ok)
+			assert.Equal(t, nyTime(t, tt.want), next)
+		})
+	}
+}
+
+func TestCron_NeverMatches(t *testing.T) {
+	cron, err := calendar.ParseCron("0 0 30 2 *")
+	require.NoError(t, err)
+
+	_, ok := cron.Next(nyTime(t, "2024-03-12 10:00"))
+	assert.False(t, ok)
+}
+
+func TestParseCron_Invalid(t *testing.T) {
+	exprs := []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"10-5 * * * *",
+		"* * * foo *",
+	}
+	for _, expr := range exprs {
+		t.Run(expr, func(t *testing.T) {
+			_, err := calendar.ParseCron(expr)
+			assert.True(t, errors.Is(err, calendar.ErrInvalidCron), "got %v", err)
+		})
+	}
+}
@@ -0,0 +1,162 @@
+// test/unit/indicators_test.go
+package unit
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aquibsayyed9/sentinel/internal/indicators"
+	"github.com/aquibsayyed9/sentinel/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func closeBars(closes ...float64) []models.MarketData {
+	start := time.Date(2024, 3, 4, 14, 30, 0, 0, time.UTC)
+	bars := make([]models.MarketData, len(closes))
+	for i, c := range closes {
+		bars[i] = models.MarketData{
+			Symbol:    "AAPL",
+			Timestamp: start.Add(time.Duration(i) * time.Minute),
+			Open:      c,
+			High:      c,
+			Low:       c,
+			Close:     c,
+			Volume:    100,
+		}
+	}
+	return bars
+}
+
+func feed(indicator indicators.Indicator, bars []models.MarketData) {
+	for _, bar := range bars {
+		indicator.Update(bar)
+	}
+}
+
+func TestSMA(t *testing.T) {
+	sma := indicators.NewSMA(3, "")
+	feed(sma, closeBars(1, 2))
+	assert.False(t, sma.Ready())
+
+	feed(sma, closeBars(3, 4, 5))
+	assert.True(t, sma.Ready())
+	assert.InDelta(t, 4.0, sma.Value(), 1e-9)
+}
+
+func TestEMA(t *testing.T) {
+	ema := indicators.NewEMA(3, "")
+	feed(ema, closeBars(1, 2, 3))
+	assert.True(t, ema.Ready())
+	assert.InDelta(t, 2.0, ema.Value(), 1e-9)
+
+	feed(ema, closeBars(4, 5))
+	assert.InDelta(t, 4.0, ema.Value(), 1e-9)
+}
+
+func TestRSI(t *testing.T) {
+	rsi := indicators.NewRSI(3, "")
+	feed(rsi, closeBars(1, 2, 3))
+	assert.False(t, rsi.Ready())
+
+	feed(rsi, closeBars(2))
+	assert.True(t, rsi.Ready())
+	assert.InDelta(t, 66.6667, rsi.Value(), 1e-3)
+
+	feed(rsi, closeBars(3))
+	assert.InDelta(t, 77.7778, rsi.Value(), 1e-3)
+}
+
+func TestRSI_OnlyGains(t *testing.T) {
+	rsi := indicators.NewRSI(2, "")
+	feed(rsi, closeBars(1, 2, 3, 4))
+	assert.Equal(t, 100.0, rsi.Value())
+}
+
+func TestMACD(t *testing.T) {
+	line, err := indicators.NewMACD(2, 3, 2, "", indicators.OutputMACD)
+	assert.NoError(t, err)
+	signal, _ := indicators.NewMACD(2, 3, 2, "", indicators.OutputSignal)
+	histogram, _ := indicators.NewMACD(2, 3, 2, "", indicators.OutputHistogram)
+
+	bars := closeBars(1, 2, 3, 5, 8, 13)
+	feed(line, bars[:3])
+	feed(signal, bars[:3])
+	assert.True(t, line.Ready())
+	assert.False(t, signal.Ready())
+
+	feed(line, bars[3:])
+	feed(signal, bars[3:])
+	feed(histogram, bars)
+	assert.True(t, signal.Ready())
+	assert.InDelta(t, line.Value()-signal.Value(), histogram.Value(), 1e-9)
+
+	_, err = indicators.NewMACD(2, 3, 2, "", "bogus")
+	assert.True(t, errors.Is(err, indicators.ErrInvalidParams))
+}
+
+func TestBollinger(t *testing.T) {
+	upper, _ := indicators.NewBollinger(3, 2, "", indicators.OutputUpper)
+	lower, _ := indicators.NewBollinger(3, 2, "", indicators.OutputLower)
+	middle, _ := indicators.NewBollinger(3, 2, "", "")
+
+	bars := closeBars(10, 1, 2, 3)
+	feed(upper, bars)
+	feed(lower, bars)
+	feed(middle, bars)
+
+	assert.InDelta(t, 2.0, middle.Value(), 1e-9)
+	assert.InDelta(t, 3.63299, upper.Value(), 1e-4)
+	assert.InDelta(t, 0.36701, lower.Value(), 1e-4)
+}
+
+func TestATR(t *testing.T) {
+	atr := indicators.NewATR(2)
+	start := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	feed(atr, []models.MarketData{
+		{Timestamp: start, High: 10, Low: 8, Close: 9},
+		{Timestamp: start.AddDate(0, 0, 1), High: 11, Low: 9, Close: 10},
+	})
+	assert.True(t, atr.Ready())
+	assert.InDelta(t, 2.0, atr.Value(), 1e-9)
+
+	// A gap up widens the true range beyond the bar's own range
+	atr.Update(models.MarketData{Timestamp: start.AddDate(0, 0, 2), High: 15, Low: 13, Close: 14})
+	assert.InDelta(t, 3.5, atr.Value(), 1e-9)
+}
+
+func TestVWAP_ResetsEachSession(t *testing.T) {
+	vwap := indicators.NewVWAP()
+	day := time.Date(2024, 3, 4, 15, 0, 0, 0, time.UTC)
+
+	vwap.Update(models.MarketData{Timestamp: day, High: 10, Low: 10, Close: 10, Volume: 100})
+	vwap.Update(models.MarketData{Timestamp: day.Add(time.Minute), High: 20, Low: 20, Close: 20, Volume: 300})
+	assert.InDelta(t, 17.5, vwap.Value(), 1e-9)
+
+	vwap.Update(models.MarketData{Timestamp: day.AddDate(0, 0, 1), High: 30, Low: 30, Close: 30, Volume: 50})
+	assert.InDelta(t, 30.0, vwap.Value(), 1e-9)
+}
+
+func TestHighestLowest(t *testing.T) {
+	highest := indicators.NewHighest(3, indicators.SourceClose)
+	lowest := indicators.NewLowest(3, indicators.SourceClose)
+
+	bars := closeBars(5, 3, 4, 1, 2)
+	feed(highest, bars)
+	feed(lowest, bars)
+
+	assert.Equal(t, 4.0, highest.Value())
+	assert.Equal(t, 1.0, lowest.Value())
+}
+
+func TestNew_ValidatesParams(t *testing.T) {
+	_, err := indicators.New(indicators.KindSMA, indicators.Params{})
+	assert.True(t, errors.Is(err, indicators.ErrInvalidParams))
+
+	_, err = indicators.New("ichimoku", indicators.Params{Period: 9})
+	assert.True(t, errors.Is(err, indicators.ErrUnknownIndicator))
+
+	rsi, err := indicators.New(indicators.KindRSI, indicators.Params{})
+	assert.NoError(t, err)
+	assert.Equal(t, 15, rsi.WarmupPeriod())
+}
@@ -0,0 +1,220 @@
+// test/unit/marketdata_aggregate_test.go
+package unit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aquibsayyed9/sentinel/internal/calendar"
+	"github.com/aquibsayyed9/sentinel/internal/marketdata"
+	"github.com/aquibsayyed9/sentinel/internal/models"
+	"github.com/aquibsayyed9/sentinel/internal/services"
+	"github.com/aquibsayyed9/sentinel/test/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// minuteBars returns count minute bars from start, each closing 1 above the
+// last with a volume of 10
+func minuteBars(start time.Time, count int) []models.MarketData {
+	bars := make([]models.MarketData, 0, count)
+	for i := 0; i < count; i++ {
+		price := 100 + float64(i)
+		bars = append(bars, models.MarketData{
+			Symbol:    "AAPL",
+			Timestamp: start.Add(time.Duration(i) * time.Minute),
+			Open:      price - 1,
+			High:      price + 0.5,
+			Low:       price - 1.5,
+			Close:     price,
+			Volume:    10,
+			Source:    "alpaca",
+			TimeFrame: "1m",
+		})
+	}
+	return bars
+}
+
+func TestAggregate_AlignsIntradayBarsToTheSession(t *testing.T) {
+	aggregator := marketdata.NewAggregator(calendar.NYSE())
+	// Monday 4 March 2024, 9:00 to 16:30 Eastern
+	bars := minuteBars(time.Date(2024, 3, 4, 14, 0, 0, 0, time.UTC), 450)
+
+	hourly, err := aggregator.Aggregate(bars, "1h", time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+
+	var starts []time.Time
+	for _, bar := range hourly {
+		starts = append(starts, bar.Timestamp)
+	}
+	// The pre-market bar ends at the open, the session's last bar at the
+	// close, and the after-hours bar starts at the close
+	assert.Equal(t, []time.Time{
+		time.Date(2024, 3, 4, 14, 0, 0, 0, time.UTC),
+		time.Date(2024, 3, 4, 14, 30, 0, 0, time.UTC),
+		time.Date(2024, 3, 4, 15, 30, 0, 0, time.UTC),
+		time.Date(2024, 3, 4, 16, 30, 0, 0, time.UTC),
+		time.Date(2024, 3, 4, 17, 30, 0, 0, time.UTC),
+		time.Date(2024, 3, 4, 18, 30, 0, 0, time.UTC),
+		time.Date(2024, 3, 4, 19, 30, 0, 0, time.UTC),
+		time.Date(2024, 3, 4, 20, 30, 0, 0, time.UTC),
+		time.Date(2024, 3, 4, 21, 0, 0, 0, time.UTC),
+	}, starts)
+
+	first := hourly[1]
+	assert.Equal(t, "1h", first.TimeFrame)
+	assert.Equal(t, "AAPL", first.Symbol)
+	assert.Equal(t, 129.0, first.Open)
+	assert.Equal(t, 189.5, first.High)
+	assert.Equal(t, 128.5, first.Low)
+	assert.Equal(t, 189.0, first.Close)
+	assert.Equal(t, int64(600), first.Volume)
+	assert.Equal(t, int64(300), hourly[7].Volume)
+	assert.Equal(t, int64(300), hourly[0].Volume)
+}
+
+func TestAggregate_LeavesOutFormingBars(t *testing.T) {
+	aggregator := marketdata.NewAggregator(calendar.NYSE())
+	bars := minuteBars(time.Date(2024, 3, 4, 14, 30, 0, 0, time.UTC), 20)
+
+	fiveMinute, err := aggregator.Aggregate(bars, "5m", time.Date(2024, 3, 4, 14, 47, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.Len(t, fiveMinute, 3)
+	assert.Equal(t, time.Date(2024, 3, 4, 14, 40, 0, 0, time.UTC), fiveMinute[2].Timestamp)
+
+	// Built from shorter bars that divide the time frame
+	fifteen, err := aggregator.Aggregate(fiveMinute, "15m", time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.Len(t, fifteen, 1)
+	assert.Equal(t, 114.0, fifteen[0].Close)
+	assert.Equal(t, int64(150), fifteen[0].Volume)
+}
+
+func TestAggregate_DailyBarsFollowSessionsAcrossDaylightSaving(t *testing.T) {
+	aggregator := marketdata.NewAggregator(calendar.NYSE())
+	var bars []models.MarketData
+	// Friday 8 March 2024 in EST, with after-hours trading, and Monday 11
+	// March in EDT
+	bars = append(bars, minuteBars(time.Date(2024, 3, 8, 14, 30, 0, 0, time.UTC), 420)...)
+	bars = append(bars, minuteBars(time.Date(2024, 3, 11, 13, 30, 0, 0, time.UTC), 390)...)
+	// Saturday
+	bars = append(bars, minuteBars(time.Date(2024, 3, 9, 15, 0, 0, 0, time.UTC), 10)...)
+
+	daily, err := aggregator.Aggregate(bars, "1d", time.Date(2024, 3, 12, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.Len(t, daily, 2)
+
+	// Timestamped at midnight New York time
+	assert.Equal(t, time.Date(2024, 3, 8, 5, 0, 0, 0, time.UTC), daily[0].Timestamp)
+	assert.Equal(t, time.Date(2024, 3, 11, 4, 0, 0, 0, time.UTC), daily[1].Timestamp)
+	assert.Equal(t, int64(3900), daily[0].Volume)
+	assert.Equal(t, 489.0, daily[0].Close)
+
+	weekly, err := aggregator.Aggregate(bars, "1w", time.Date(2024, 3, 20, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.Len(t, weekly, 2)
+	assert.Equal(t, time.Date(2024, 3, 4, 5, 0, 0, 0, time.UTC), weekly[0].Timestamp)
+	assert.Equal(t, time.Date(2024, 3, 11, 4, 0, 0, 0, time.UTC), weekly[1].Timestamp)
+}
+
+func TestAggregate_EarlyCloseEndsTheDailyBar(t *testing.T) {
+	aggregator := marketdata.NewAggregator(calendar.NYSE())
+	// The day after Thanksgiving 2024 closes at 13:00 Eastern
+	bars := minuteBars(time.Date(2024, 11, 29, 14, 30, 0, 0, time.UTC), 210)
+
+	daily, err := aggregator.Aggregate(bars, "1d", time.Date(2024, 11, 29, 17, 59, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Empty(t, daily)
+
+	daily, err = aggregator.Aggregate(bars, "1d", time.Date(2024, 11, 29, 18, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.Len(t, daily, 1)
+	assert.Equal(t, int64(2100), daily[0].Volume)
+}
+
+func TestAggregate_RejectsUnsupportedTimeFrames(t *testing.T) {
+	aggregator := marketdata.NewAggregator(calendar.NYSE())
+	bars := minuteBars(time.Date(2024, 3, 4, 14, 30, 0, 0, time.UTC), 10)
+	tenMinute, err := aggregator.Aggregate(bars, "10m", time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	daily, err := aggregator.Aggregate(bars, "1d", time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+
+	for _, test := range []struct {
+		bars      []models.MarketData
+		timeFrame string
+	}{
+		{bars, "hourly"},
+		{bars, "2d"},
+		{bars, "1m"},
+		{tenMinute, "15m"},
+		{daily, "1w"},
+	} {
+		_, err := aggregator.Aggregate(test.bars, test.timeFrame, time.Now())
+		assert.True(t, errors.Is(err, models.ErrInvalidTimeFrame), test.timeFrame)
+	}
+}
+
+func TestMarketDataService_AggregatesTimeFramesNotStored(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2024, 3, 4, 14, 45, 0, 0, time.UTC)
+	end := time.Date(2024, 3, 4, 17, 30, 0, 0, time.UTC)
+
+	repo := new(mocks.MockMarketDataRepository)
+	repo.On("GetBars", ctx, "AAPL", "1h", start, end, 2).Return([]models.MarketData{}, nil)
+	repo.On("GetBars", ctx, "AAPL", "1d", start, end, 2).
+		Return([]models.MarketData{{Symbol: "AAPL", TimeFrame: "1d", Close: 150}}, nil)
+	repo.On("GetBars", ctx, "AAPL", "1m", start, end, 1).Return(minuteBars(start, 1), nil)
+	// Minute bars up to an hour past end complete the last bar
+	repo.On("GetHistoricalData", ctx, "AAPL", start, end.Add(time.Hour), "1m").
+		Return(minuteBars(start, 225), nil)
+
+	bars, err := services.NewMarketDataService(repo).GetBars(ctx, "AAPL", "1h", start, end, 2)
+	require.NoError(t, err)
+	// The bar from 14:30 misses the minute bars before start
+	require.Len(t, bars, 2)
+	assert.Equal(t, time.Date(2024, 3, 4, 15, 30, 0, 0, time.UTC), bars[0].Timestamp)
+	assert.Equal(t, int64(600), bars[1].Volume)
+
+	// Stored bars are served as they are
+	bars, err = services.NewMarketDataService(repo).GetBars(ctx, "AAPL", "1d", start, end, 2)
+	require.NoError(t, err)
+	require.Len(t, bars, 1)
+	assert.Equal(t, 150.0, bars[0].Close)
+	repo.AssertNumberOfCalls(t, "GetHistoricalData", 1)
+	repo.AssertNumberOfCalls(t, "GetBars", 3)
+}
+
+func TestMarketDataService_AggregatesMinuteBarsAPageAtATime(t *testing.T) {
+	ctx := context.Background()
+	// From Monday 4 March 2024 to the close on Thursday 20 March 2025, with
+	// minute bars only in the first hour of each
+	start := time.Date(2024, 3, 4, 5, 0, 0, 0, time.UTC)
+	end := time.Date(2025, 3, 20, 20, 0, 0, 0, time.UTC)
+	first := time.Date(2024, 3, 4, 14, 30, 0, 0, time.UTC)
+	last := time.Date(2025, 3, 20, 13, 30, 0, 0, time.UTC)
+	week := 7 * 24 * time.Hour
+
+	repo := new(mocks.MockMarketDataRepository)
+	repo.On("GetHistoricalData", ctx, "AAPL", start, end, "1h").Return([]models.MarketData{}, nil)
+	// Paging starts at the bar holding the first minute bar
+	repo.On("GetBars", ctx, "AAPL", "1m", start, end, 1).Return(minuteBars(first, 1), nil)
+	repo.On("GetHistoricalData", ctx, "AAPL", first, first.Add(week+time.Hour), "1m").
+		Return(minuteBars(first, 60), nil)
+	repo.On("GetHistoricalData", ctx, "AAPL", first.Add(week), first.Add(2*week+time.Hour), "1m").
+		Return([]models.MarketData{}, nil)
+	// The year without minute bars after an empty page is skipped
+	repo.On("GetBars", ctx, "AAPL", "1m", first.Add(2*week), end, 1).Return(minuteBars(last, 1), nil)
+	repo.On("GetHistoricalData", ctx, "AAPL", last, end.Add(time.Hour), "1m").
+		Return(minuteBars(last, 60), nil)
+
+	bars, err := services.NewMarketDataService(repo).GetHistoricalData(ctx, "AAPL", start, end, "1h")
+	require.NoError(t, err)
+	require.Len(t, bars, 2)
+	assert.Equal(t, first, bars[0].Timestamp)
+	assert.Equal(t, last, bars[1].Timestamp)
+	repo.AssertNumberOfCalls(t, "GetHistoricalData", 4)
+	repo.AssertNumberOfCalls(t, "GetBars", 2)
+}
@@ -0,0 +1,166 @@
+// test/unit/marketdata_handler_test.go
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aquibsayyed9/sentinel/internal/handlers"
+	"github.com/aquibsayyed9/sentinel/internal/marketdata"
+	"github.com/aquibsayyed9/sentinel/internal/models"
+	"github.com/aquibsayyed9/sentinel/internal/repository"
+	"github.com/aquibsayyed9/sentinel/internal/server/routes"
+	"github.com/aquibsayyed9/sentinel/internal/services"
+	"github.com/aquibsayyed9/sentinel/test/mocks"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type MarketDataHandlerTestSuite struct {
+	suite.Suite
+	router         *gin.Engine
+	marketDataRepo *mocks.MockMarketDataRepository
+	symbolRepo     *mocks.MockSymbolRepository
+}
+
+func (s *MarketDataHandlerTestSuite) SetupTest() {
+	s.marketDataRepo = new(mocks.MockMarketDataRepository)
+	s.symbolRepo = new(mocks.MockSymbolRepository)
+	s.symbolRepo.On("GetBySymbol", mock.Anything, "AAPL").Return(&models.Symbol{Symbol: "AAPL"}, nil)
+	s.symbolRepo.On("GetBySymbol", mock.Anything, mock.Anything).Return(nil, repository.ErrSymbolNotFound)
+
+	handler := handlers.NewMarketDataHandler(
+		services.NewCachedMarketDataService(services.NewMarketDataService(s.marketDataRepo), time.Minute),
+		services.NewSymbolMaster(s.symbolRepo, time.Minute),
+	)
+
+	gin.SetMode(gin.TestMode)
+	s.router = gin.New()
+	routes.SetupMarketDataRoutes(s.router.Group("/api/v1"), handler)
+}
+
+func TestMarketDataHandlerSuite(t *testing.T) {
+	suite.Run(t, new(MarketDataHandlerTestSuite))
+}
+
+func (s *MarketDataHandlerTestSuite) get(path string) (int, map[string]interface{}) {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, path, nil)
+	s.router.ServeHTTP(w, req)
+
+	var body map[string]interface{}
+	s.Require().NoError(json.Unmarshal(w.Body.Bytes(), &body))
+	return w.Code, body
+}
+
+func (s *MarketDataHandlerTestSuite) TestUnknownSymbol_NotFound() {
+	code, body := s.get("/api/v1/market/nope/price")
+	s.Equal(http.StatusNotFound, code)
+	s.Equal(services.ErrUnknownSymbol.Error(), body["error"])
+
+	// Unknown symbols are cached too
+	code, _ = s.get("/api/v1/market/NOPE/quote")
+	s.Equal(http.StatusNotFound, code)
+	s.symbolRepo.AssertNumberOfCalls(s.T(), "GetBySymbol", 1)
+	s.marketDataRepo.AssertNotCalled(s.T(), "GetLatestPrice", mock.Anything, mock.Anything)
+}
+
+func (s *MarketDataHandlerTestSuite) TestGetLatestPrice_CachesHotSymbols() {
+	s.marketDataRepo.On("GetLatestPrice", mock.Anything, "AAPL").
+		Return(&models.MarketData{Symbol: "AAPL", Close: 150.5}, nil).Once()
+
+	for i := 0; i < 3; i++ {
+		code, body := s.get("/api/v1/market/aapl/price")
+		s.Equal(http.StatusOK, code)
+		s.Equal(150.5, body["price"].(map[string]interface{})["close"])
+	}
+	s.marketDataRepo.AssertNumberOfCalls(s.T(), "GetLatestPrice", 1)
+	s.symbolRepo.AssertNumberOfCalls(s.T(), "GetBySymbol", 1)
+}
+
+func (s *MarketDataHandlerTestSuite) TestGetQuote_NoDataNotFound() {
+	s.marketDataRepo.On("GetLatestQuote", mock.Anything, "AAPL").Return(nil, repository.ErrQuoteNotFound)
+
+	code, _ := s.get("/api/v1/market/AAPL/quote")
+	s.Equal(http.StatusNotFound, code)
+
+	// Missing data is not cached
+	s.get("/api/v1/market/AAPL/quote")
+	s.marketDataRepo.AssertNumberOfCalls(s.T(), "GetLatestQuote", 2)
+}
+
+func (s *MarketDataHandlerTestSuite) TestGetBars_TruncatesAtLimit() {
+	start := time.Date(2024, 3, 4, 14, 30, 0, 0, time.UTC)
+	end := start.Add(time.Hour)
+	bars := []models.MarketData{{Close: 150}, {Close: 151}, {Close: 152}}
+	s.marketDataRepo.On("GetBars", mock.Anything, "AAPL", "5m", start, end, 3).Return(bars, nil)
+
+	code, body := s.get("/api/v1/market/AAPL/bars?timeframe=5m&limit=2&start=2024-03-04T14:30:00Z&end=2024-03-04T15:30:00Z")
+	s.Equal(http.StatusOK, code)
+	s.Len(body["data"], 2)
+	s.Equal(true, body["truncated"])
+}
+
+func (s *MarketDataHandlerTestSuite) TestGetBars_DefaultsToMinuteBars() {
+	s.marketDataRepo.On("GetBars", mock.Anything, "AAPL", "1m", mock.Anything, mock.Anything, 1001).
+		Return([]models.MarketData{{Close: 150}}, nil)
+
+	code, body := s.get("/api/v1/market/AAPL/bars")
+	s.Equal(http.StatusOK, code)
+	s.Len(body["data"], 1)
+	s.Equal(false, body["truncated"])
+}
+
+func (s *MarketDataHandlerTestSuite) TestGetBars_RejectsInvalidQueries() {
+	for _, query := range []string{
+		"timeframe=hourly",
+		"timeframe=0m",
+		"limit=0",
+		"limit=10001",
+		"limit=many",
+		"start=yesterday",
+		"start=2024-03-05T00:00:00Z&end=2024-03-04T00:00:00Z",
+	} {
+		code, body := s.get("/api/v1/market/AAPL/bars?" + query)
+		s.Equal(http.StatusBadRequest, code, query)
+		s.NotEmpty(body["error"], query)
+	}
+	s.marketDataRepo.AssertNotCalled(s.T(), "GetBars", mock.Anything, mock.Anything, mock.Anything,
+		mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (s *MarketDataHandlerTestSuite) TestDatabaseError_InternalServerError() {
+	s.marketDataRepo.On("GetLatestPrice", mock.Anything, "AAPL").Return(nil, errors.New("connection refused"))
+
+	code, _ := s.get("/api/v1/market/AAPL/price")
+	s.Equal(http.StatusInternalServerError, code)
+}
+
+func TestSyncSymbols_ListsProviderAndConfiguredSymbols(t *testing.T) {
+	path := writeReplayFile(t, t.TempDir(), "bars.csv", `symbol,timestamp,open,high,low,close
+msft,2024-03-04T14:30:00Z,400,401,399,400
+AAPL,2024-03-04T14:30:00Z,150,151,149,150
+`)
+	provider, err := marketdata.NewReplayProvider(marketdata.ReplayConfig{Path: path})
+	require.NoError(t, err)
+
+	repo := new(mocks.MockSymbolRepository)
+	repo.On("Upsert", mock.Anything, []models.Symbol{
+		{Symbol: "AAPL", Source: "replay"},
+		{Symbol: "MSFT", Source: "replay"},
+		{Symbol: "SPY", Source: "replay"},
+	}).Return(nil)
+
+	listed, err := marketdata.SyncSymbols(context.Background(), provider, repo, []string{"spy", "AAPL"})
+	require.NoError(t, err)
+	assert.Equal(t, 3, listed)
+	repo.AssertExpectations(t)
+}
@@ -0,0 +1,367 @@
+// test/unit/marketdata_ingestor_test.go
+package unit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aquibsayyed9/sentinel/internal/marketdata"
+	"github.com/aquibsayyed9/sentinel/internal/models"
+	"github.com/aquibsayyed9/sentinel/test/mocks"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// scriptedProvider plays one scripted session per call to Stream. Each
+// session delivers its updates and then fails with its error, or waits for
+// cancellation if it has none.
+type scriptedProvider struct {
+	sessions []scriptedSession
+
+	mu      sync.Mutex
+	calls   int
+	symbols [][]string
+}
+
+type scriptedSession struct {
+	updates []marketdata.Update
+	err     error
+}
+
+func (p *scriptedProvider) Name() string {
+	return "scripted"
+}
+
+func (p *scriptedProvider) Stream(ctx context.Context, symbols []string, handler func(marketdata.Update)) error {
+	p.mu.Lock()
+	call := p.calls
+	p.calls++
+	p.symbols = append(p.symbols, symbols)
+	p.mu.Unlock()
+
+	var session scriptedSession
+	if call < len(p.sessions) {
+		session = p.sessions[call]
+	}
+	for _, update := range session.updates {
+		handler(update)
+	}
+	if session.err == nil {
+		<-ctx.Done()
+	}
+	return session.err
+}
+
+func (p *scriptedProvider) History(ctx context.Context, symbol, timeFrame string, start, end time.Time) ([]models.MarketData, error) {
+	return nil, nil
+}
+
+func (p *scriptedProvider) Symbols(ctx context.Context) ([]string, error) {
+	return nil, nil
+}
+
+// collector records published updates
+type collector struct {
+	updates chan marketdata.Update
+}
+
+func (c *collector) Publish(update marketdata.Update) {
+	c.updates <- update
+}
+
+func (c *collector) wait(t *testing.T, n int) []marketdata.Update {
+	var updates []marketdata.Update
+	for i := 0; i < n; i++ {
+		select {
+		case update := <-c.updates:
+			updates = append(updates, update)
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timed out after %d of %d published updates", i, n)
+		}
+	}
+	return updates
+}
+
+func testBar(symbol string, price float64) marketdata.Update {
+	return marketdata.Update{Kind: marketdata.KindBar, Bar: &models.MarketData{
+		Symbol:    symbol,
+		Timestamp: time.Date(2024, 3, 4, 14, 30, 0, 0, time.UTC),
+		Open:      price,
+		High:      price + 1,
+		Low:       price - 1,
+		Close:     price,
+		Volume:    100,
+	}}
+}
+
+func TestIngestor_NormalizesStoresAndPublishes(t *testing.T) {
+	repo := new(mocks.MockMarketDataRepository)
+	repo.On("SaveMarketData", mock.Anything, mock.Anything).Return(nil)
+	repo.On("SaveQuote", mock.Anything, mock.Anything).Return(nil)
+
+	quote := marketdata.Update{Kind: marketdata.KindQuote, Quote: &models.Quote{
+		Symbol:    " msft ",
+		Timestamp: time.Date(2024, 3, 4, 9, 30, 0, 0, time.FixedZone("EST", -5*3600)),
+		Bid:       400,
+		Ask:       400.5,
+	}}
+	provider := &scriptedProvider{sessions: []scriptedSession{
+		{updates: []marketdata.Update{testBar("aapl", 150), quote}},
+	}}
+	published := &collector{updates: make(chan marketdata.Update, 10)}
+
+	ingestor := marketdata.NewIngestor(provider, repo, published, marketdata.IngestorConfig{
+		Symbols: []string{"aapl", "MSFT", "AAPL", ""},
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- ingestor.Run(ctx) }()
+
+	updates := published.wait(t, 2)
+	cancel()
+	require.NoError(t, <-done)
+
+	bar := updates[0].Bar
+	assert.Equal(t, "AAPL", bar.Symbol)
+	assert.Equal(t, marketdata.DefaultBarTimeFrame, bar.TimeFrame)
+	assert.Equal(t, "scripted", bar.Source)
+
+	q := updates[1].Quote
+	assert.Equal(t, "MSFT", q.Symbol)
+	assert.Equal(t, time.UTC, q.Timestamp.Location())
+	assert.Equal(t, time.Date(2024, 3, 4, 14, 30, 0, 0, time.UTC), q.Timestamp)
+
+	// Symbols are streamed normalized and once each
+	assert.Equal(t, []string{"AAPL", "MSFT"}, provider.symbols[0])
+	repo.AssertNumberOfCalls(t, "SaveMarketData", 1)
+	repo.AssertNumberOfCalls(t, "SaveQuote", 1)
+}
+
+func TestIngestor_SkipsInvalidAndUnstoredUpdates(t *testing.T) {
+	repo := new(mocks.MockMarketDataRepository)
+	repo.On("SaveMarketData", mock.Anything, mock.MatchedBy(func(bar *models.MarketData) bool {
+		return bar.Symbol == "MSFT"
+	})).Return(errors.New("database unavailable"))
+	repo.On("SaveMarketData", mock.Anything, mock.Anything).Return(nil)
+
+	// Close outside the bar's range
+	invalid := testBar("AAPL", 150)
+	invalid.Bar.Close = 200
+
+	provider := &scriptedProvider{sessions: []scriptedSession{
+		{updates: []marketdata.Update{invalid, testBar("MSFT", 400), testBar("AAPL", 151)}},
+	}}
+	published := &collector{updates: make(chan marketdata.Update, 10)}
+
+	var mu sync.Mutex
+	var errs []error
+	ingestor := marketdata.NewIngestor(provider, repo, published, marketdata.IngestorConfig{
+		Symbols: []string{"AAPL", "MSFT"},
+		OnError: func(err error) {
+			mu.Lock()
+			errs = append(errs, err)
+			mu.Unlock()
+		},
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- ingestor.Run(ctx) }()
+
+	updates := published.wait(t, 1)
+	cancel()
+	require.NoError(t, <-done)
+
+	assert.Equal(t, 151.0, updates[0].Bar.Close)
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, errs, 2)
+	assert.ErrorIs(t, errs[0], marketdata.ErrInvalidUpdate)
+	assert.Contains(t, errs[1].Error(), "database unavailable")
+}
+
+func TestIngestor_ReconnectsAfterStreamFails(t *testing.T) {
+	repo := new(mocks.MockMarketDataRepository)
+	repo.On("SaveMarketData", mock.Anything, mock.Anything).Return(nil)
+
+	provider := &scriptedProvider{sessions: []scriptedSession{
+		{updates: []marketdata.Update{testBar("AAPL", 150)}, err: errors.New("connection reset")},
+		{err: errors.New("connection refused")},
+		{updates: []marketdata.Update{testBar("AAPL", 151)}},
+	}}
+	published := &collector{updates: make(chan marketdata.Update, 10)}
+
+	var mu sync.Mutex
+	var errs []error
+	ingestor := marketdata.NewIngestor(provider, repo, published, marketdata.IngestorConfig{
+		Symbols:    []string{"AAPL"},
+		MinBackoff: time.Millisecond,
+		MaxBackoff: 10 * time.Millisecond,
+		OnError: func(err error) {
+			mu.Lock()
+			errs = append(errs, err)
+			mu.Unlock()
+		},
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- ingestor.Run(ctx) }()
+
+	updates := published.wait(t, 2)
+	cancel()
+	require.NoError(t, <-done)
+
+	assert.Equal(t, 150.0, updates[0].Bar.Close)
+	assert.Equal(t, 151.0, updates[1].Bar.Close)
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, errs, 2)
+	assert.Contains(t, errs[0].Error(), "connection reset")
+	assert.Contains(t, errs[1].Error(), "connection refused")
+}
+
+func TestIngestor_RequiresSymbols(t *testing.T) {
+	ingestor := marketdata.NewIngestor(&scriptedProvider{}, new(mocks.MockMarketDataRepository),
+		marketdata.NewBroadcaster(), marketdata.IngestorConfig{Symbols: []string{" "}})
+	assert.ErrorIs(t, ingestor.Run(context.Background()), marketdata.ErrNoSymbols)
+}
+
+func TestBroadcaster_DropsUpdatesForFullSubscribers(t *testing.T) {
+	broadcaster := marketdata.NewBroadcaster()
+	fast := broadcaster.Subscribe(10, nil)
+	slow := broadcaster.Subscribe(1, nil)
+
+	for i := 0; i < 3; i++ {
+		broadcaster.Publish(testBar("AAPL", 150+float64(i)))
+	}
+
+	assert.Len(t, fast.C, 3)
+	assert.Zero(t, fast.Dropped())
+	assert.Len(t, slow.C, 1)
+	assert.Equal(t, int64(2), slow.Dropped())
+
+	slow.Close()
+	broadcaster.Publish(testBar("AAPL", 160))
+	assert.Len(t, fast.C, 4)
+	<-slow.C
+	_, open := <-slow.C
+	assert.False(t, open)
+}
+
+// alpacaServer serves the Alpaca stream protocol, sending messages once a
+// client has authenticated and subscribed
+func alpacaServer(t *testing.T, messages string) *httptest.Server {
+	upgrader := websocket.Upgrader{}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		conn.WriteMessage(websocket.TextMessage, []byte(`[{"T":"success","msg":"connected"}]`))
+
+		var auth map[string]string
+		if err := conn.ReadJSON(&auth); err != nil {
+			return
+		}
+		if auth["key"] != "key" || auth["secret"] != "secret" {
+			conn.WriteMessage(websocket.TextMessage, []byte(`[{"T":"error","code":402,"msg":"auth failed"}]`))
+			return
+		}
+		conn.WriteMessage(websocket.TextMessage, []byte(`[{"T":"success","msg":"authenticated"}]`))
+
+		var subscribe struct {
+			Bars   []string `json:"bars"`
+			Quotes []string `json:"quotes"`
+		}
+		if err := conn.ReadJSON(&subscribe); err != nil {
+			return
+		}
+		assert.Equal(t, []string{"AAPL"}, subscribe.Bars)
+		assert.Equal(t, []string{"AAPL"}, subscribe.Quotes)
+		conn.WriteMessage(websocket.TextMessage, []byte(`[{"T":"subscription","bars":["AAPL"],"quotes":["AAPL"]}]`))
+
+		conn.WriteMessage(websocket.TextMessage, []byte(messages))
+		// Hold the connection open until the client goes away
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}))
+}
+
+func TestAlpacaProvider_StreamsBarsAndQuotes(t *testing.T) {
+	server := alpacaServer(t, `[
+		{"T":"b","S":"AAPL","o":150,"h":151,"l":149.5,"c":150.5,"v":1200,"t":"2024-03-04T14:30:00Z"},
+		{"T":"q","S":"AAPL","bp":150.4,"bs":3,"ap":150.6,"as":2,"t":"2024-03-04T14:30:01Z"},
+		{"T":"t","S":"AAPL","p":150.55,"s":100,"t":"2024-03-04T14:30:01Z"}
+	]`)
+	defer server.Close()
+
+	provider := marketdata.NewAlpacaProvider(marketdata.AlpacaConfig{
+		StreamURL: "ws" + strings.TrimPrefix(server.URL, "http"),
+		APIKey:    "key",
+		APISecret: "secret",
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	updates := make(chan marketdata.Update, 10)
+	done := make(chan error)
+	go func() {
+		done <- provider.Stream(ctx, []string{"AAPL"}, func(update marketdata.Update) { updates <- update })
+	}()
+
+	var received []marketdata.Update
+	for len(received) < 3 {
+		select {
+		case update := <-updates:
+			received = append(received, update)
+		case err := <-done:
+			t.Fatalf("stream ended early: %v", err)
+		case <-time.After(2 * time.Second):
+			t.Fatal("timed out waiting for updates")
+		}
+	}
+	cancel()
+	require.NoError(t, <-done)
+
+	require.Equal(t, marketdata.KindBar, received[0].Kind)
+	assert.Equal(t, "AAPL", received[0].Bar.Symbol)
+	assert.Equal(t, 150.5, received[0].Bar.Close)
+	assert.Equal(t, int64(1200), received[0].Bar.Volume)
+	assert.Equal(t, "1m", received[0].Bar.TimeFrame)
+
+	require.Equal(t, marketdata.KindQuote, received[1].Kind)
+	assert.Equal(t, 150.4, received[1].Quote.Bid)
+	assert.Equal(t, 150.6, received[1].Quote.Ask)
+	assert.Equal(t, 3, received[1].Quote.BidSize)
+
+	require.Equal(t, marketdata.KindTrade, received[2].Kind)
+	assert.Equal(t, "AAPL", received[2].Trade.Symbol)
+	assert.Equal(t, 150.55, received[2].Trade.Price)
+	assert.Equal(t, int64(100), received[2].Trade.Size)
+}
+
+func TestAlpacaProvider_FailsOnRejectedAuth(t *testing.T) {
+	server := alpacaServer(t, `[]`)
+	defer server.Close()
+
+	provider := marketdata.NewAlpacaProvider(marketdata.AlpacaConfig{
+		StreamURL: "ws" + strings.TrimPrefix(server.URL, "http"),
+		APIKey:    "wrong",
+		APISecret: "secret",
+	})
+
+	err := provider.Stream(context.Background(), []string{"AAPL"}, func(marketdata.Update) {})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "auth failed")
+}
@@ -0,0 +1,212 @@
+// test/unit/marketdata_provider_test.go
+package unit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aquibsayyed9/sentinel/internal/marketdata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeReplayFile(t *testing.T, dir, name, content string) string {
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+// replayAll streams the provider's updates for symbols until n have arrived
+func replayAll(t *testing.T, provider marketdata.Provider, symbols []string, n int) []marketdata.Update {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	updates := make(chan marketdata.Update, n)
+	done := make(chan error)
+	go func() {
+		done <- provider.Stream(ctx, symbols, func(update marketdata.Update) {
+			select {
+			case updates <- update:
+			default:
+			}
+		})
+	}()
+
+	var received []marketdata.Update
+	for len(received) < n {
+		select {
+		case update := <-updates:
+			received = append(received, update)
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timed out after %d of %d replayed updates", len(received), n)
+		}
+	}
+	cancel()
+	require.NoError(t, <-done)
+	return received
+}
+
+func TestReplayProvider_ReplaysFilesInTimeOrder(t *testing.T) {
+	dir := t.TempDir()
+	writeReplayFile(t, dir, "bars.csv", `symbol,timestamp,open,high,low,close,volume
+aapl,2024-03-04T14:31:00Z,150,151,149,150.5,1200
+msft,2024-03-04T14:31:00Z,400,401,399,400.5,800
+aapl,2024-03-04T14:32:00Z,150.5,152,150,151.5,900
+`)
+	writeReplayFile(t, dir, "quotes.json", `
+{"symbol":"AAPL","timestamp":"2024-03-04T14:31:30Z","bid":150.4,"ask":150.6,"bid_size":3,"ask_size":2}
+{"kind":"quote","symbol":"AAPL","timestamp":"2024-03-04T14:30:30Z","bid":149.9,"ask":150.1}
+`)
+	writeReplayFile(t, dir, "notes.txt", "ignored")
+
+	provider, err := marketdata.NewReplayProvider(marketdata.ReplayConfig{Path: dir, Speed: 1e6})
+	require.NoError(t, err)
+
+	updates := replayAll(t, provider, []string{"AAPL"}, 4)
+	assert.Equal(t, marketdata.KindQuote, updates[0].Kind)
+	assert.Equal(t, 149.9, updates[0].Quote.Bid)
+	assert.Equal(t, marketdata.KindBar, updates[1].Kind)
+	assert.Equal(t, "AAPL", updates[1].Bar.Symbol)
+	assert.Equal(t, "1m", updates[1].Bar.TimeFrame)
+	assert.Equal(t, "replay", updates[1].Bar.Source)
+	assert.Equal(t, marketdata.KindQuote, updates[2].Kind)
+	assert.Equal(t, 3, updates[2].Quote.BidSize)
+	assert.Equal(t, 151.5, updates[3].Bar.Close)
+
+	symbols, err := provider.Symbols(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"AAPL", "MSFT"}, symbols)
+}
+
+func TestReplayProvider_PacesUpdatesBySpeed(t *testing.T) {
+	path := writeReplayFile(t, t.TempDir(), "bars.json", `[
+		{"symbol":"AAPL","timestamp":"2024-03-04T14:30:00Z","open":150,"high":151,"low":149,"close":150},
+		{"symbol":"AAPL","timestamp":"2024-03-04T14:31:00Z","open":150,"high":151,"low":149,"close":151}
+	]`)
+
+	// A minute apart, replayed 600 times faster than real time
+	provider, err := marketdata.NewReplayProvider(marketdata.ReplayConfig{Path: path, Speed: 600})
+	require.NoError(t, err)
+
+	started := time.Now()
+	replayAll(t, provider, []string{"AAPL"}, 2)
+	assert.GreaterOrEqual(t, time.Since(started), 100*time.Millisecond)
+}
+
+func TestReplayProvider_LoopShiftsTimestampsForward(t *testing.T) {
+	path := writeReplayFile(t, t.TempDir(), "bars.csv", `symbol,timestamp,open,high,low,close
+AAPL,2024-03-04T14:30:00Z,150,151,149,150
+AAPL,2024-03-04T14:31:00Z,150,151,149,151
+`)
+	provider, err := marketdata.NewReplayProvider(marketdata.ReplayConfig{Path: path, Speed: 1e6, Loop: true})
+	require.NoError(t, err)
+
+	updates := replayAll(t, provider, []string{"AAPL"}, 4)
+	start := time.Date(2024, 3, 4, 14, 30, 0, 0, time.UTC)
+	assert.Equal(t, start, updates[0].Bar.Timestamp)
+	assert.Equal(t, start.Add(time.Minute), updates[1].Bar.Timestamp)
+	assert.Equal(t, start.Add(2*time.Minute), updates[2].Bar.Timestamp)
+	assert.Equal(t, start.Add(3*time.Minute), updates[3].Bar.Timestamp)
+	assert.Equal(t, 151.0, updates[3].Bar.Close)
+}
+
+func TestReplayProvider_History(t *testing.T) {
+	path := writeReplayFile(t, t.TempDir(), "bars.csv", `symbol,timestamp,open,high,low,close,time_frame
+AAPL,2024-03-01,150,155,148,152,1d
+AAPL,2024-03-04,152,156,151,155,1d
+AAPL,2024-03-05,155,157,153,154,1d
+AAPL,2024-03-04T14:30:00Z,150,151,149,150,1m
+MSFT,2024-03-04,400,405,398,402,1d
+`)
+	provider, err := marketdata.NewReplayProvider(marketdata.ReplayConfig{Path: path})
+	require.NoError(t, err)
+
+	bars, err := provider.History(context.Background(), "aapl", "1d",
+		time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.Len(t, bars, 1)
+	assert.Equal(t, 155.0, bars[0].Close)
+
+	_, err = provider.History(context.Background(), "AAPL", "daily", time.Time{}, time.Now())
+	assert.Error(t, err)
+}
+
+func TestReplayProvider_RejectsInvalidFiles(t *testing.T) {
+	dir := t.TempDir()
+
+	_, err := marketdata.NewReplayProvider(marketdata.ReplayConfig{Path: dir})
+	assert.ErrorIs(t, err, marketdata.ErrNoReplayData)
+
+	path := writeReplayFile(t, dir, "bars.csv", `symbol,timestamp,open,high,low,close
+AAPL,2024-03-04T14:30:00Z,150,151,149,150
+AAPL,2024-03-04T14:31:00Z,150,151,149,160
+`)
+	_, err = marketdata.NewReplayProvider(marketdata.ReplayConfig{Path: path})
+	assert.ErrorIs(t, err, marketdata.ErrInvalidUpdate)
+	assert.Contains(t, err.Error(), "record 2")
+
+	path = writeReplayFile(t, dir, "bars.csv", `symbol,timestamp,open
+AAPL,yesterday,150
+`)
+	_, err = marketdata.NewReplayProvider(marketdata.ReplayConfig{Path: path})
+	assert.ErrorIs(t, err, marketdata.ErrInvalidUpdate)
+}
+
+func TestRegistry_CreatesProvidersByName(t *testing.T) {
+	registry := marketdata.NewRegistry()
+	assert.Equal(t, []string{"alpaca", "replay"}, registry.Names())
+
+	provider, err := registry.New("Alpaca", marketdata.ProviderConfig{})
+	require.NoError(t, err)
+	assert.Equal(t, "alpaca", provider.Name())
+
+	_, err = registry.New("replay", marketdata.ProviderConfig{})
+	assert.ErrorIs(t, err, marketdata.ErrNoReplayData)
+
+	_, err = registry.New("polygon", marketdata.ProviderConfig{})
+	assert.ErrorIs(t, err, marketdata.ErrUnknownProvider)
+
+	registry.Register("scripted", func(marketdata.ProviderConfig) (marketdata.Provider, error) {
+		return &scriptedProvider{}, nil
+	})
+	provider, err = registry.New("scripted", marketdata.ProviderConfig{})
+	require.NoError(t, err)
+	assert.Equal(t, "scripted", provider.Name())
+}
+
+func TestAlpacaProvider_HistoryFollowsPages(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.Query().Get("page_token"))
+		assert.Equal(t, "/v2/stocks/AAPL/bars", r.URL.Path)
+		assert.Equal(t, "15Min", r.URL.Query().Get("timeframe"))
+		assert.Equal(t, "key", r.Header.Get("APCA-API-KEY-ID"))
+
+		if r.URL.Query().Get("page_token") == "" {
+			w.Write([]byte(`{"bars":[{"t":"2024-03-04T14:30:00Z","o":150,"h":151,"l":149,"c":150.5,"v":1200}],
+				"symbol":"AAPL","next_page_token":"next"}`))
+			return
+		}
+		w.Write([]byte(`{"bars":[{"t":"2024-03-04T14:45:00Z","o":150.5,"h":152,"l":150,"c":151,"v":900},
+			{"t":"2024-03-04T15:00:00Z","o":151,"h":152,"l":150,"c":151,"v":900}],
+			"symbol":"AAPL","next_page_token":null}`))
+	}))
+	defer server.Close()
+
+	provider := marketdata.NewAlpacaProvider(marketdata.AlpacaConfig{DataURL: server.URL, APIKey: "key"})
+	bars, err := provider.History(context.Background(), "aapl", "15m",
+		time.Date(2024, 3, 4, 14, 30, 0, 0, time.UTC), time.Date(2024, 3, 4, 15, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"", "next"}, requests)
+	require.Len(t, bars, 2)
+	assert.Equal(t, "AAPL", bars[0].Symbol)
+	assert.Equal(t, "15m", bars[0].TimeFrame)
+	assert.Equal(t, "alpaca", bars[0].Source)
+	assert.Equal(t, 151.0, bars[1].Close)
+}
@@ -0,0 +1,237 @@
+// test/unit/marketdata_stream_test.go
+package unit
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aquibsayyed9/sentinel/internal/auth"
+	"github.com/aquibsayyed9/sentinel/internal/config"
+	"github.com/aquibsayyed9/sentinel/internal/marketdata"
+	"github.com/aquibsayyed9/sentinel/internal/models"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// streamMessage is any message from the stream server
+type streamMessage struct {
+	Type          string                   `json:"type"`
+	Message       string                   `json:"message"`
+	Subscriptions map[string][]string      `json:"subscriptions"`
+	Updates       []map[string]interface{} `json:"updates"`
+	Dropped       int64                    `json:"dropped"`
+}
+
+type streamFixture struct {
+	broadcaster *marketdata.Broadcaster
+	server      *marketdata.StreamServer
+	http        *httptest.Server
+	token       string
+}
+
+func newStreamFixture(t *testing.T, streamConfig marketdata.StreamServerConfig) *streamFixture {
+	cfg := &config.Config{}
+	cfg.JWT.Secret = "test-secret"
+	cfg.JWT.ExpireHour = 1
+	tokenService := auth.NewTokenService(cfg)
+	token, err := tokenService.GenerateToken(uuid.New(), "trader@example.com")
+	require.NoError(t, err)
+
+	broadcaster := marketdata.NewBroadcaster()
+	server := marketdata.NewStreamServer(broadcaster, tokenService, streamConfig)
+	f := &streamFixture{
+		broadcaster: broadcaster,
+		server:      server,
+		http:        httptest.NewServer(server),
+		token:       token,
+	}
+	t.Cleanup(func() {
+		f.http.Close()
+		server.Close()
+	})
+	return f
+}
+
+// dial connects with the token and reads the connected message
+func (f *streamFixture) dial(t *testing.T) *websocket.Conn {
+	url := "ws" + strings.TrimPrefix(f.http.URL, "http") + "?token=" + f.token
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	assert.Equal(t, "connected", readStreamMessage(t, conn).Type)
+	return conn
+}
+
+func readStreamMessage(t *testing.T, conn *websocket.Conn) streamMessage {
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var message streamMessage
+	require.NoError(t, conn.ReadJSON(&message))
+	return message
+}
+
+func sendStreamMessage(t *testing.T, conn *websocket.Conn, message string) streamMessage {
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(message)))
+	return readStreamMessage(t, conn)
+}
+
+func testQuote(symbol string, bid float64) marketdata.Update {
+	return marketdata.Update{Kind: marketdata.KindQuote, Quote: &models.Quote{
+		Symbol:    symbol,
+		Timestamp: time.Date(2024, 3, 4, 14, 30, 0, 0, time.UTC),
+		Bid:       bid,
+		Ask:       bid + 0.1,
+		BidSize:   3,
+		AskSize:   2,
+	}}
+}
+
+func TestStreamServer_RequiresValidToken(t *testing.T) {
+	f := newStreamFixture(t, marketdata.StreamServerConfig{})
+	url := "ws" + strings.TrimPrefix(f.http.URL, "http")
+
+	_, resp, err := websocket.DefaultDialer.Dial(url, nil)
+	require.Error(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	_, resp, err = websocket.DefaultDialer.Dial(url+"?token=forged", nil)
+	require.Error(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	header := http.Header{"Authorization": {"Bearer " + f.token}}
+	conn, _, err := websocket.DefaultDialer.Dial(url, header)
+	require.NoError(t, err)
+	conn.Close()
+}
+
+func TestStreamServer_StreamsSubscribedChannelsAndSymbols(t *testing.T) {
+	f := newStreamFixture(t, marketdata.StreamServerConfig{BatchInterval: 10 * time.Millisecond})
+	conn := f.dial(t)
+
+	reply := sendStreamMessage(t, conn, `{"action":"subscribe","channels":["quotes","bars"],"symbols":["aapl","MSFT"]}`)
+	require.Equal(t, "subscribed", reply.Type)
+	assert.Equal(t, []string{"AAPL", "MSFT"}, reply.Subscriptions["quotes"])
+	assert.Equal(t, []string{"AAPL", "MSFT"}, reply.Subscriptions["bars"])
+	assert.Empty(t, reply.Subscriptions["trades"])
+
+	reply = sendStreamMessage(t, conn, `{"action":"unsubscribe","channels":["quotes"],"symbols":["MSFT"]}`)
+	assert.Equal(t, []string{"AAPL"}, reply.Subscriptions["quotes"])
+
+	hourly := testBar("MSFT", 400)
+	hourly.Bar.TimeFrame = "1h"
+	trade := marketdata.Update{Kind: marketdata.KindTrade, Trade: &marketdata.Trade{
+		Symbol: "AAPL", Timestamp: time.Now(), Price: 150, Size: 100,
+	}}
+	minute := testBar("MSFT", 400)
+	minute.Bar.TimeFrame = "1m"
+	for _, update := range []marketdata.Update{
+		testQuote("AAPL", 150), testQuote("MSFT", 400), testQuote("GOOGL", 140), trade, hourly, minute,
+	} {
+		f.broadcaster.Publish(update)
+	}
+
+	var updates []map[string]interface{}
+	for len(updates) < 2 {
+		message := readStreamMessage(t, conn)
+		require.Equal(t, "data", message.Type)
+		updates = append(updates, message.Updates...)
+	}
+	require.Len(t, updates, 2)
+	assert.Equal(t, "quotes", updates[0]["channel"])
+	assert.Equal(t, "AAPL", updates[0]["symbol"])
+	assert.Equal(t, 150.0, updates[0]["bid"])
+	assert.Equal(t, "bars", updates[1]["channel"])
+	assert.Equal(t, "MSFT", updates[1]["symbol"])
+	assert.Equal(t, "1m", updates[1]["time_frame"])
+}
+
+func TestStreamServer_BatchesUpdates(t *testing.T) {
+	f := newStreamFixture(t, marketdata.StreamServerConfig{BatchInterval: time.Hour, MaxBatch: 3})
+	conn := f.dial(t)
+	sendStreamMessage(t, conn, `{"action":"subscribe","symbols":["AAPL"]}`)
+
+	for i := 0; i < 3; i++ {
+		f.broadcaster.Publish(testQuote("AAPL", 150+float64(i)))
+	}
+
+	message := readStreamMessage(t, conn)
+	require.Equal(t, "data", message.Type)
+	require.Len(t, message.Updates, 3)
+	assert.Equal(t, 152.0, message.Updates[2]["bid"])
+}
+
+func TestStreamServer_ReportsDroppedUpdates(t *testing.T) {
+	f := newStreamFixture(t, marketdata.StreamServerConfig{Buffer: 1, BatchInterval: 50 * time.Millisecond})
+	conn := f.dial(t)
+	sendStreamMessage(t, conn, `{"action":"subscribe","channels":["quotes"],"symbols":["AAPL"]}`)
+
+	// Published together, faster than the connection's one-update buffer
+	// can be drained
+	var delivered, dropped int64
+	for i := 0; i < 1000; i++ {
+		f.broadcaster.Publish(testQuote("AAPL", 150))
+	}
+	for delivered+dropped < 1000 {
+		message := readStreamMessage(t, conn)
+		require.Equal(t, "data", message.Type)
+		delivered += int64(len(message.Updates))
+		dropped += message.Dropped
+	}
+	assert.Equal(t, int64(1000), delivered+dropped)
+	assert.Positive(t, dropped)
+}
+
+func TestStreamServer_RejectsInvalidRequests(t *testing.T) {
+	f := newStreamFixture(t, marketdata.StreamServerConfig{MaxSymbols: 2})
+	conn := f.dial(t)
+
+	reply := sendStreamMessage(t, conn, `{"action":"subscribe","channels":["options"],"symbols":["AAPL"]}`)
+	assert.Equal(t, "error", reply.Type)
+	assert.Contains(t, reply.Message, "unknown channel")
+
+	reply = sendStreamMessage(t, conn, `{"action":"subscribe","symbols":[]}`)
+	assert.Equal(t, "error", reply.Type)
+
+	reply = sendStreamMessage(t, conn, `{"action":"subscribe","symbols":["AAPL","MSFT","GOOGL"]}`)
+	assert.Equal(t, "error", reply.Type)
+	assert.Contains(t, reply.Message, "more than 2 symbols")
+
+	reply = sendStreamMessage(t, conn, `{"action":"trade"}`)
+	assert.Equal(t, "error", reply.Type)
+
+	reply = sendStreamMessage(t, conn, `not json`)
+	assert.Equal(t, "error", reply.Type)
+
+	reply = sendStreamMessage(t, conn, `{"action":"ping"}`)
+	assert.Equal(t, "pong", reply.Type)
+
+	// The connection is still usable after errors
+	reply = sendStreamMessage(t, conn, `{"action":"subscribe","symbols":["AAPL","MSFT"]}`)
+	require.Equal(t, "subscribed", reply.Type)
+	assert.Equal(t, []string{"AAPL", "MSFT"}, reply.Subscriptions["trades"])
+}
+
+func TestStreamServer_CloseDisconnectsClients(t *testing.T) {
+	f := newStreamFixture(t, marketdata.StreamServerConfig{})
+	conn := f.dial(t)
+
+	f.server.Close()
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, _, err := conn.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.CloseGoingAway), "got %v", err)
+
+	var body map[string]string
+	resp, err := http.Get(f.http.URL + "?token=" + f.token)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.NotEmpty(t, body["error"])
+}
@@ -0,0 +1,234 @@
+// test/unit/rule_advisor_test.go
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/aquibsayyed9/sentinel/internal/models"
+	"github.com/aquibsayyed9/sentinel/internal/services"
+	"github.com/aquibsayyed9/sentinel/test/mocks"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// volatilityBars returns 51 daily closes starting and ending at 100, whose
+// first 40 returns alternate by early and last 10 by recent
+func volatilityBars(early, recent float64) []models.MarketData {
+	bars := make([]models.MarketData, 0, 51)
+	price := 100.0
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	bars = append(bars, models.MarketData{Symbol: "AAPL", Timestamp: start, Close: price})
+	for i := 1; i <= 50; i++ {
+		r := early
+		if i > 40 {
+			r = recent
+		}
+		if i%2 == 0 {
+			r = -r
+		}
+		price *= math.Exp(r)
+		bars = append(bars, models.MarketData{Symbol: "AAPL", Timestamp: start.AddDate(0, 0, i), Close: price})
+	}
+	return bars
+}
+
+func adjustmentsByPath(adjustments []services.ParameterAdjustment) map[string]services.ParameterAdjustment {
+	byPath := make(map[string]services.ParameterAdjustment)
+	for _, adjustment := range adjustments {
+		byPath[adjustment.Path] = adjustment
+	}
+	return byPath
+}
+
+func TestVolatilityAdvisor_ScalesParametersWithVolatility(t *testing.T) {
+	advisor := services.NewVolatilityAdvisor(services.VolatilityAdvisorConfig{})
+	params := []services.RuleParameter{
+		{Path: "conditions[0].value", Kind: "threshold", Symbol: "AAPL", Type: "price", Operator: "less_than", Value: 85, Baseline: 90},
+		{Path: "conditions[1].value", Kind: "threshold", Symbol: "AAPL", Type: "rsi", Operator: "less_than", Value: 30, Baseline: 30},
+		{Path: "actions[0].quantity", Kind: "quantity", Symbol: "AAPL", Value: 10, Baseline: 10},
+		{Path: "actions[1].notional", Kind: "notional", Symbol: "AAPL", Value: 1000, Baseline: 1000},
+	}
+
+	// Recent volatility far above its usual level is clamped to twice it:
+	// thresholds move twice as far from the price and sizes halve, measured
+	// from the owner's values
+	adjustments, err := advisor.Advise(context.Background(), services.AdvisorInput{
+		Parameters: params,
+		Bars:       map[string][]models.MarketData{"AAPL": volatilityBars(0.0001, 0.05)},
+	})
+	require.NoError(t, err)
+	byPath := adjustmentsByPath(adjustments)
+	require.Len(t, byPath, 3)
+	assert.Equal(t, 85.0, byPath["conditions[0].value"].From)
+	assert.InDelta(t, 80, byPath["conditions[0].value"].To, 0.01)
+	assert.Equal(t, 5.0, byPath["actions[0].quantity"].To)
+	assert.InDelta(t, 500, byPath["actions[1].notional"].To, 0.01)
+	assert.NotEmpty(t, byPath["actions[0].quantity"].Reason)
+
+	// A calm market moves thresholds closer and sizes up
+	adjustments, err = advisor.Advise(context.Background(), services.AdvisorInput{
+		Parameters: params,
+		Bars:       map[string][]models.MarketData{"AAPL": volatilityBars(0.05, 0.0001)},
+	})
+	require.NoError(t, err)
+	byPath = adjustmentsByPath(adjustments)
+	assert.InDelta(t, 95, byPath["conditions[0].value"].To, 0.01)
+	assert.Equal(t, 20.0, byPath["actions[0].quantity"].To)
+
+	// Too few bars to measure volatility propose nothing
+	adjustments, err = advisor.Advise(context.Background(), services.AdvisorInput{
+		Parameters: params,
+		Bars:       map[string][]models.MarketData{"AAPL": volatilityBars(0.0001, 0.05)[:30]},
+	})
+	require.NoError(t, err)
+	assert.Empty(t, adjustments)
+}
+
+// ruleAdvisorFixture is a rule advisor over mocks, reviewing one AI-managed rule
+type ruleAdvisorFixture struct {
+	ruleRepo         *mocks.MockRuleRepository
+	proposalRepo     *mocks.MockRuleProposalRepository
+	notificationRepo *mocks.MockNotificationRepository
+	advisor          *services.RuleAdvisor
+	rule             *models.TradingRule
+}
+
+func newRuleAdvisorFixture(t *testing.T, management services.AIManagement) *ruleAdvisorFixture {
+	f := &ruleAdvisorFixture{
+		ruleRepo:         new(mocks.MockRuleRepository),
+		proposalRepo:     new(mocks.MockRuleProposalRepository),
+		notificationRepo: new(mocks.MockNotificationRepository),
+	}
+
+	rule, err := services.NewRuleService(f.ruleRepo).BuildRule(uuid.New(), services.RuleInput{
+		Name:       "AI Rule",
+		Symbol:     "AAPL",
+		RuleType:   "buy",
+		Conditions: []services.RuleCondition{{Type: "price", Operator: "less_than", Value: 90}},
+		Actions:    []services.RuleAction{{Type: "buy", Symbol: "AAPL", Quantity: 10, OrderType: "market"}},
+
+		AIManagement: &management,
+	})
+	require.NoError(t, err)
+	rule.ID = uuid.New()
+	f.rule = rule
+
+	marketDataRepo := new(mocks.MockMarketDataRepository)
+	marketDataRepo.On("GetHistoricalData", mock.Anything, "AAPL", mock.Anything, mock.Anything, "1d").
+		Return(volatilityBars(0.0001, 0.05), nil)
+	executionRepo := new(mocks.MockExecutionRepository)
+	executionRepo.On("GetByRuleID", mock.Anything, rule.ID).Return([]models.Execution{}, nil)
+	f.ruleRepo.On("GetMetrics", mock.Anything, rule.UserID, []uuid.UUID{rule.ID}).Return([]models.RuleMetrics{}, nil)
+	f.proposalRepo.On("SupersedePending", mock.Anything, rule.ID, mock.Anything).Return(nil)
+	f.proposalRepo.On("Create", mock.Anything, mock.Anything).Return(nil)
+	f.notificationRepo.On("Create", mock.Anything, mock.Anything).Return(nil)
+
+	f.advisor = services.NewRuleAdvisor(
+		services.NewVolatilityAdvisor(services.VolatilityAdvisorConfig{}),
+		f.ruleRepo,
+		f.proposalRepo,
+		services.NewMarketDataService(marketDataRepo),
+		executionRepo,
+		services.NewNotificationService(f.notificationRepo),
+		&mocks.MockTransactor{},
+		services.RuleAdvisorConfig{},
+	)
+	return f
+}
+
+func (f *ruleAdvisorFixture) notification(t *testing.T) *models.Notification {
+	require.Len(t, f.notificationRepo.Calls, 1)
+	return f.notificationRepo.Calls[0].Arguments.Get(1).(*models.Notification)
+}
+
+func TestRuleAdvisor_AutoAppliesAdjustmentsWithinBounds(t *testing.T) {
+	f := newRuleAdvisorFixture(t, services.AIManagement{AutoApply: true, MaxChangePercent: 50})
+	var stored *models.TradingRule
+	f.ruleRepo.On("UpdateDefinition", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		stored = args.Get(1).(*models.TradingRule)
+	}).Return(nil)
+	f.proposalRepo.On("Decide", mock.Anything, mock.Anything).Return(nil)
+
+	proposal, err := f.advisor.AdviseRule(context.Background(), f.rule)
+	require.NoError(t, err)
+	require.NotNil(t, proposal)
+	assert.Equal(t, services.RuleProposalStatusApplied, proposal.Status)
+	assert.True(t, proposal.AutoApplied)
+	assert.Equal(t, 1, proposal.RuleVersion)
+	require.NotNil(t, proposal.AppliedVersion)
+	assert.Equal(t, 2, *proposal.AppliedVersion)
+
+	require.NotNil(t, stored)
+	input, err := services.RuleInputOf(stored)
+	require.NoError(t, err)
+	assert.InDelta(t, 80, input.Conditions[0].Value, 0.01)
+	assert.Equal(t, 5.0, input.Actions[0].Quantity)
+	assert.NotNil(t, input.AIManagement)
+
+	// Later proposals are still bounded by the owner's values
+	var baseline map[string]float64
+	require.NoError(t, json.Unmarshal(stored.AIBaseline, &baseline))
+	assert.Equal(t, map[string]float64{"conditions[0].value": 90, "actions[0].quantity": 10}, baseline)
+
+	assert.Equal(t, services.NotificationKindRuleAdjusted, f.notification(t).Kind)
+}
+
+func TestRuleAdvisor_ProposalBeyondBoundsAwaitsApproval(t *testing.T) {
+	f := newRuleAdvisorFixture(t, services.AIManagement{AutoApply: true, MaxChangePercent: 10})
+
+	proposal, err := f.advisor.AdviseRule(context.Background(), f.rule)
+	require.NoError(t, err)
+	require.NotNil(t, proposal)
+	assert.Equal(t, services.RuleProposalStatusPending, proposal.Status)
+	assert.Equal(t, "volatility", proposal.Advisor)
+
+	adjustments, err := services.DecodeProposalAdjustments(proposal)
+	require.NoError(t, err)
+	assert.Len(t, adjustments, 2)
+
+	f.ruleRepo.AssertNotCalled(t, "UpdateDefinition", mock.Anything, mock.Anything, mock.Anything)
+	f.proposalRepo.AssertCalled(t, "SupersedePending", mock.Anything, f.rule.ID, mock.Anything)
+	assert.Equal(t, services.NotificationKindRuleProposal, f.notification(t).Kind)
+}
+
+func TestRuleAdvisor_IgnoresRulesThatAreNotAIManaged(t *testing.T) {
+	f := newRuleAdvisorFixture(t, services.AIManagement{})
+	rule := newTestRule([]services.RuleCondition{{Type: "price", Operator: "less_than", Value: 90}}, nil)
+
+	proposal, err := f.advisor.AdviseRule(context.Background(), rule)
+	require.NoError(t, err)
+	assert.Nil(t, proposal)
+	f.proposalRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestRuleProposalService_SupersedesStaleProposalOnApproval(t *testing.T) {
+	ruleRepo := new(mocks.MockRuleRepository)
+	proposalRepo := new(mocks.MockRuleProposalRepository)
+	rule := newTestRule([]services.RuleCondition{{Type: "price", Operator: "less_than", Value: 90}}, nil)
+	rule.Version = 2
+
+	adjustments, _ := json.Marshal([]services.ParameterAdjustment{{Path: "conditions[0].value", From: 90, To: 85}})
+	proposal := &models.RuleProposal{
+		ID:          uuid.New(),
+		RuleID:      rule.ID,
+		UserID:      rule.UserID,
+		RuleVersion: 1,
+		Adjustments: adjustments,
+		Status:      services.RuleProposalStatusPending,
+	}
+	proposalRepo.On("GetByIDAndUserID", mock.Anything, proposal.ID, rule.UserID).Return(proposal, nil)
+	proposalRepo.On("Decide", mock.Anything, proposal).Return(nil)
+	ruleRepo.On("GetByIDAndUserID", mock.Anything, rule.ID, rule.UserID).Return(rule, nil)
+
+	service := services.NewRuleProposalService(ruleRepo, proposalRepo, &mocks.MockTransactor{})
+	_, err := service.ApproveProposal(context.Background(), rule.UserID, rule.ID, proposal.ID)
+	assert.ErrorIs(t, err, services.ErrRuleProposalStale)
+	assert.Equal(t, services.RuleProposalStatusSuperseded, proposal.Status)
+	ruleRepo.AssertNotCalled(t, "UpdateDefinition", mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,142 @@
+// test/unit/rule_cluster_test.go
+package unit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aquibsayyed9/sentinel/internal/models"
+	"github.com/aquibsayyed9/sentinel/internal/services"
+	"github.com/aquibsayyed9/sentinel/test/mocks"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newClusterRepo() *mocks.MockClusterRepository {
+	repo := new(mocks.MockClusterRepository)
+	repo.On("Heartbeat", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	repo.On("DeleteExpiredInstances", mock.Anything, mock.Anything).Return(nil)
+	return repo
+}
+
+func instancesOf(clusters ...*services.RuleCluster) []models.EngineInstance {
+	instances := make([]models.EngineInstance, len(clusters))
+	for i, cluster := range clusters {
+		instances[i] = models.EngineInstance{ID: cluster.ID(), Name: cluster.Name()}
+	}
+	return instances
+}
+
+func TestRuleCluster_ShardsRulesAcrossLiveInstances(t *testing.T) {
+	ctx := context.Background()
+	repo := newClusterRepo()
+	a := services.NewRuleCluster(repo, services.RuleClusterConfig{Name: "a"})
+	b := services.NewRuleCluster(repo, services.RuleClusterConfig{Name: "b"})
+	repo.On("GetLiveInstances", mock.Anything, mock.Anything).Return(instancesOf(a, b), nil)
+
+	require.NoError(t, a.Join(ctx))
+	require.NoError(t, b.Join(ctx))
+	assert.True(t, a.IsLeader())
+	assert.False(t, b.IsLeader())
+
+	// Every rule has exactly one owner, and both instances get a share
+	owned := map[string]int{}
+	for i := 0; i < 200; i++ {
+		id := uuid.New()
+		require.NotEqual(t, a.Owns(id), b.Owns(id), "rule %d must have exactly one owner", i)
+		if a.Owns(id) {
+			owned["a"]++
+		} else {
+			owned["b"]++
+		}
+	}
+	assert.Greater(t, owned["a"], 50)
+	assert.Greater(t, owned["b"], 50)
+
+	// Only the leader removes expired leases
+	repo.AssertNumberOfCalls(t, "DeleteExpiredInstances", 1)
+}
+
+func TestRuleCluster_ReassignsRulesOfFailedInstance(t *testing.T) {
+	ctx := context.Background()
+	repo := newClusterRepo()
+	var changes [][]models.EngineInstance
+	a := services.NewRuleCluster(repo, services.RuleClusterConfig{
+		Name: "a",
+		OnMembershipChange: func(instances []models.EngineInstance, leader bool) {
+			changes = append(changes, instances)
+		},
+	})
+	b := services.NewRuleCluster(repo, services.RuleClusterConfig{Name: "b"})
+	repo.On("GetLiveInstances", mock.Anything, mock.Anything).Return(instancesOf(a, b), nil).Once()
+	repo.On("GetLiveInstances", mock.Anything, mock.Anything).Return(instancesOf(a), nil)
+
+	require.NoError(t, a.Join(ctx))
+	rules := make([]uuid.UUID, 100)
+	var ownedBefore []uuid.UUID
+	for i := range rules {
+		rules[i] = uuid.New()
+		if a.Owns(rules[i]) {
+			ownedBefore = append(ownedBefore, rules[i])
+		}
+	}
+	require.Less(t, len(ownedBefore), len(rules))
+
+	// b's lease expires, so a takes over its rules and keeps its own
+	require.NoError(t, a.Heartbeat(ctx))
+	for _, id := range rules {
+		assert.True(t, a.Owns(id))
+	}
+	assert.Len(t, changes, 2)
+	assert.Len(t, changes[1], 1)
+
+	// Membership that has not changed is not reported again
+	require.NoError(t, a.Heartbeat(ctx))
+	assert.Len(t, changes, 2)
+}
+
+func TestRuleCluster_OwnsNothingOnceLeaseLapses(t *testing.T) {
+	ctx := context.Background()
+	repo := new(mocks.MockClusterRepository)
+	repo.On("Heartbeat", mock.Anything, mock.Anything, mock.Anything).Return(nil).Once()
+	repo.On("Heartbeat", mock.Anything, mock.Anything, mock.Anything).Return(errors.New("connection refused"))
+	repo.On("DeleteExpiredInstances", mock.Anything, mock.Anything).Return(nil)
+
+	cluster := services.NewRuleCluster(repo, services.RuleClusterConfig{
+		HeartbeatInterval: 10 * time.Millisecond,
+		LeaseTTL:          30 * time.Millisecond,
+	})
+	repo.On("GetLiveInstances", mock.Anything, mock.Anything).Return(instancesOf(cluster), nil)
+
+	require.NoError(t, cluster.Join(ctx))
+	rule := uuid.New()
+	assert.True(t, cluster.Owns(rule))
+
+	// Without the database the lease cannot be renewed, so the instance
+	// stops evaluating rules before the other instances take them over
+	assert.Error(t, cluster.Heartbeat(ctx))
+	time.Sleep(40 * time.Millisecond)
+	assert.False(t, cluster.Owns(rule))
+	assert.False(t, cluster.IsLeader())
+}
+
+func TestRuleCluster_LeavesOnShutdown(t *testing.T) {
+	repo := newClusterRepo()
+	cluster := services.NewRuleCluster(repo, services.RuleClusterConfig{HeartbeatInterval: 10 * time.Millisecond})
+	repo.On("GetLiveInstances", mock.Anything, mock.Anything).Return(instancesOf(cluster), nil)
+	repo.On("Deregister", mock.Anything, cluster.ID()).Return(nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	require.NoError(t, cluster.Join(ctx))
+	done := make(chan error)
+	go func() { done <- cluster.Run(ctx) }()
+	cancel()
+
+	require.NoError(t, <-done)
+	repo.AssertCalled(t, "Deregister", mock.Anything, cluster.ID())
+	assert.False(t, cluster.Owns(uuid.New()))
+}
@@ -0,0 +1,188 @@
+// test/unit/rule_dispatcher_test.go
+package unit
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aquibsayyed9/sentinel/internal/models"
+	"github.com/aquibsayyed9/sentinel/internal/services"
+	"github.com/aquibsayyed9/sentinel/test/mocks"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingEngine records the rules it is asked to process. Only ProcessRule
+// is used by the dispatcher.
+type recordingEngine struct {
+	services.RuleEngineService
+
+	mu        sync.Mutex
+	processed []string
+	calls     chan string
+}
+
+func newRecordingEngine() *recordingEngine {
+	return &recordingEngine{calls: make(chan string, 100)}
+}
+
+func (e *recordingEngine) ProcessRule(ctx context.Context, rule *models.TradingRule) (bool, error) {
+	e.mu.Lock()
+	e.processed = append(e.processed, rule.Name)
+	e.mu.Unlock()
+	e.calls <- rule.Name
+	return true, nil
+}
+
+func (e *recordingEngine) wait(t *testing.T, n int) []string {
+	var names []string
+	for i := 0; i < n; i++ {
+		select {
+		case name := <-e.calls:
+			names = append(names, name)
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timed out after %d of %d rule evaluations", i, n)
+		}
+	}
+	return names
+}
+
+// expectReloads has the repository return each rule as it was loaded when it
+// is reloaded for evaluation
+func expectReloads(ruleRepo *mocks.MockRuleRepository, rules ...*models.TradingRule) {
+	for _, rule := range rules {
+		ruleRepo.On("GetByID", mock.Anything, rule.ID).Return(rule, nil)
+	}
+}
+
+func TestRuleDispatcher_EvaluatesOnlyRulesForEventSymbol(t *testing.T) {
+	aapl := newTestRule([]services.RuleCondition{{Type: "price", Operator: "less_than", Value: 150}}, nil)
+	aapl.Name = "aapl"
+	msft := newTestRule([]services.RuleCondition{{Type: "price", Operator: "less_than", Value: 300}}, nil)
+	msft.Name = "msft"
+	msft.Symbol = "MSFT"
+	// Reads both its own symbol and SPY, so SPY events must evaluate it too
+	relative := newTestRule([]services.RuleCondition{{
+		Type:      "price",
+		Operator:  "greater_than",
+		CompareTo: &services.RuleCondition{Type: "price", Symbol: "SPY"},
+	}}, nil)
+	relative.Name = "relative"
+
+	ruleRepo := new(mocks.MockRuleRepository)
+	ruleRepo.On("GetActiveRules", mock.Anything).Return([]models.TradingRule{*aapl, *msft, *relative}, nil)
+	expectReloads(ruleRepo, aapl, msft, relative)
+
+	engine := newRecordingEngine()
+	triggered := make(chan string, 10)
+	dispatcher := services.NewRuleDispatcher(ruleRepo, engine, services.RuleDispatcherConfig{
+		Workers:   4,
+		OnTrigger: func(rule *models.TradingRule) { triggered <- rule.Name },
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, dispatcher.Refresh(ctx))
+	go dispatcher.Run(ctx)
+
+	require.NoError(t, dispatcher.Dispatch(ctx, models.MarketEvent{Symbol: "SPY", Kind: "bar"}))
+	assert.Equal(t, []string{"relative"}, engine.wait(t, 1))
+	assert.Equal(t, "relative", <-triggered)
+
+	require.NoError(t, dispatcher.Dispatch(ctx, models.MarketEvent{Symbol: "AAPL", Kind: "quote"}))
+	assert.ElementsMatch(t, []string{"aapl", "relative"}, engine.wait(t, 2))
+
+	// Symbols without rules are ignored
+	require.NoError(t, dispatcher.Dispatch(ctx, models.MarketEvent{Symbol: "TSLA", Kind: "bar"}))
+	select {
+	case name := <-engine.calls:
+		t.Fatalf("unexpected evaluation of %s", name)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestRuleDispatcher_RefreshPicksUpNewRules(t *testing.T) {
+	first := newTestRule([]services.RuleCondition{{Type: "price", Operator: "less_than", Value: 150}}, nil)
+	first.Name = "first"
+	second := newTestRule([]services.RuleCondition{{Type: "price", Operator: "less_than", Value: 300}}, nil)
+	second.Name = "second"
+	second.Symbol = "MSFT"
+
+	ruleRepo := new(mocks.MockRuleRepository)
+	ruleRepo.On("GetActiveRules", mock.Anything).Return([]models.TradingRule{*first}, nil).Once()
+	ruleRepo.On("GetActiveRules", mock.Anything).Return([]models.TradingRule{*first, *second}, nil)
+	expectReloads(ruleRepo, first, second)
+
+	engine := newRecordingEngine()
+	dispatcher := services.NewRuleDispatcher(ruleRepo, engine, services.RuleDispatcherConfig{Workers: 2})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, dispatcher.Refresh(ctx))
+
+	// MSFT had no rules when the index was loaded, so nothing is queued
+	require.NoError(t, dispatcher.Dispatch(ctx, models.MarketEvent{Symbol: "MSFT", Kind: "bar"}))
+	require.NoError(t, dispatcher.Refresh(ctx))
+
+	go dispatcher.Run(ctx)
+	require.NoError(t, dispatcher.Dispatch(ctx, models.MarketEvent{Symbol: "MSFT", Kind: "bar"}))
+	assert.Equal(t, []string{"second"}, engine.wait(t, 1))
+}
+
+func TestRuleDispatcher_EvaluatesOnlyRulesItOwnsAndCanLock(t *testing.T) {
+	owned := newTestRule([]services.RuleCondition{{Type: "price", Operator: "less_than", Value: 150}}, nil)
+	owned.Name = "owned"
+	other := newTestRule([]services.RuleCondition{{Type: "price", Operator: "less_than", Value: 140}}, nil)
+	other.Name = "other"
+	busy := newTestRule([]services.RuleCondition{{Type: "price", Operator: "less_than", Value: 130}}, nil)
+	busy.Name = "busy"
+
+	ruleRepo := new(mocks.MockRuleRepository)
+	ruleRepo.On("GetActiveRules", mock.Anything).Return([]models.TradingRule{*owned, *other, *busy}, nil)
+	expectReloads(ruleRepo, owned, other, busy)
+
+	// This instance owns owned and busy, but busy is being evaluated by
+	// another instance
+	clusterRepo := newClusterRepo()
+	cluster := services.NewRuleCluster(clusterRepo, services.RuleClusterConfig{})
+	clusterRepo.On("GetLiveInstances", mock.Anything, mock.Anything).Return(instancesOf(cluster), nil)
+	clusterRepo.On("WithRuleLock", mock.Anything, owned.ID).Return(true, nil)
+	clusterRepo.On("WithRuleLock", mock.Anything, busy.ID).Return(false, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, cluster.Join(ctx))
+	coordinator := &partialCoordinator{RuleCluster: cluster, disowned: other.ID}
+
+	engine := newRecordingEngine()
+	dispatcher := services.NewRuleDispatcher(ruleRepo, engine, services.RuleDispatcherConfig{
+		Workers:     1,
+		Coordinator: coordinator,
+	})
+	require.NoError(t, dispatcher.Refresh(ctx))
+	go dispatcher.Run(ctx)
+
+	require.NoError(t, dispatcher.Dispatch(ctx, models.MarketEvent{Symbol: "AAPL", Kind: "bar"}))
+	assert.Equal(t, []string{"owned"}, engine.wait(t, 1))
+	select {
+	case name := <-engine.calls:
+		t.Fatalf("unexpected evaluation of %s", name)
+	case <-time.After(50 * time.Millisecond):
+	}
+	clusterRepo.AssertNotCalled(t, "WithRuleLock", mock.Anything, other.ID)
+}
+
+// partialCoordinator is a single-instance cluster that has handed one rule to
+// another instance
+type partialCoordinator struct {
+	*services.RuleCluster
+	disowned uuid.UUID
+}
+
+func (c *partialCoordinator) Owns(ruleID uuid.UUID) bool {
+	return ruleID != c.disowned && c.RuleCluster.Owns(ruleID)
+}
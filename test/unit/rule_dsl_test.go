@@ -0,0 +1,191 @@
+// test/unit/rule_dsl_test.go
+package unit
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/aquibsayyed9/sentinel/internal/indicators"
+	"github.com/aquibsayyed9/sentinel/internal/services"
+	"github.com/aquibsayyed9/sentinel/test/mocks"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRuleExpression_CompilesConditionsAndActions(t *testing.T) {
+	conditions, actions, err := services.ParseRuleExpression(
+		`when close("AAPL", "1h") < sma(50) and rsi(14) < 30 then buy 10 market`)
+	require.NoError(t, err)
+
+	expected := []services.RuleCondition{
+		{
+			Type: "price", Symbol: "AAPL", TimeFrame: "1h", Operator: "less_than",
+			CompareTo: &services.RuleCondition{Type: "sma", Params: indicators.Params{Period: 50}},
+		},
+		{Type: "rsi", Params: indicators.Params{Period: 14}, Operator: "less_than", Value: 30},
+	}
+	assert.Equal(t, expected, conditions)
+	assert.Equal(t, []services.RuleAction{{Type: "buy", Quantity: 10, OrderType: "market"}}, actions)
+}
+
+func TestParseRuleExpression_Groups(t *testing.T) {
+	conditions, actions, err := services.ParseRuleExpression(
+		`WHEN (price > 150 or volume >= 1000000) and not bid < 100 THEN sell 5 limit 151.5, sell 5 stop 140`)
+	require.NoError(t, err)
+
+	require.Len(t, conditions, 2)
+	require.Len(t, conditions[0].Any, 2)
+	assert.Equal(t, "volume", conditions[0].Any[1].Type)
+	assert.Equal(t, "greater_than_or_equal", conditions[0].Any[1].Operator)
+	require.NotNil(t, conditions[1].Not)
+	assert.Equal(t, "bid", conditions[1].Not.Type)
+
+	assert.Equal(t, []services.RuleAction{
+		{Type: "sell", Quantity: 5, OrderType: "limit", Limit: 151.5},
+		{Type: "sell", Quantity: 5, OrderType: "stop", Stop: 140},
+	}, actions)
+}
+
+func TestParseRuleExpression_NumberOnLeftIsFlipped(t *testing.T) {
+	conditions, _, err := services.ParseRuleExpression(`when 30 crosses_above rsi(14) then buy 1`)
+	require.NoError(t, err)
+
+	require.Len(t, conditions, 1)
+	assert.Equal(t, "rsi", conditions[0].Type)
+	assert.Equal(t, "crosses_below", conditions[0].Operator)
+	assert.Equal(t, 30.0, conditions[0].Value)
+}
+
+func TestParseRuleExpression_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		src      string
+		messages []string
+	}{
+		{
+			name:     "missing then",
+			src:      `when price < 150 buy 10`,
+			messages: []string{`1:18: expected "then", found "buy"`},
+		},
+		{
+			name:     "missing operator",
+			src:      `when price 150 then buy 10`,
+			messages: []string{`1:12: expected a comparison operator, found "150"`},
+		},
+		{
+			name:     "unterminated string",
+			src:      "when price(\"AAPL) < 1\nthen buy 1",
+			messages: []string{"1:12: unterminated string"},
+		},
+		{
+			name: "type errors are collected",
+			src:  "when foo(1) < 2 and sma(1.5) > 3\n  and rsi(14, \"AAPL\", \"1h\", \"x\") < 30 then buy 0",
+			messages: []string{
+				`1:6: unknown market value or indicator "foo"`,
+				"1:25: period must be a whole number",
+				"2:29: rsi takes at most a symbol and a time frame",
+				"2:44: quantity must be positive",
+			},
+		},
+		{
+			name:     "crossover needs a series",
+			src:      `when price crosses_above 150 then buy 1`,
+			messages: []string{"1:6: crosses_above requires a series such as an indicator or a price with a time frame"},
+		},
+		{
+			name:     "schema errors are positioned",
+			src:      `when volume(tf="1h") > 5 and sma() > 1 then buy 1`,
+			messages: []string{"1:6: time_frame: is not supported by volume conditions", "1:30: params: invalid indicator params: sma requires a positive period"},
+		},
+		{
+			name:     "two numbers",
+			src:      `when 1 < 2 then buy 1`,
+			messages: []string{"1:6: a comparison needs a market value or indicator on at least one side"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := services.ParseRuleExpression(tt.src)
+			require.Error(t, err)
+			assert.True(t, errors.Is(err, services.ErrInvalidRuleExpression))
+
+			var validationErr *services.ValidationError
+			require.True(t, errors.As(err, &validationErr))
+			messages := make([]string, len(validationErr.Fields))
+			for i, field := range validationErr.Fields {
+				assert.Equal(t, "expression", field.Field)
+				messages[i] = field.Message
+			}
+			assert.Equal(t, tt.messages, messages)
+		})
+	}
+}
+
+func TestFormatRuleExpression_RoundTrips(t *testing.T) {
+	sources := []string{
+		`when price("AAPL", "1h") < sma(50) and rsi(14) < 30 then buy 10 market`,
+		`when (price > 150 or volume >= 1000000) and not bid < 100 then sell 5 limit 151.5, sell 5 stop 140`,
+		`when macd(12, 26, 9, tf="4h", output="histogram") crosses_above 0 then buy 2 market`,
+		`when macd(slow_period=30, tf="1h") > 0 or not (ask > 10 and spread < 0.05) then buy 1 market`,
+		`when price(tf="1d") >= highest(21, source="close") then buy 3 market`,
+		`then sell 10 market`,
+		`then buy $200 market, sell $150.5 limit 30`,
+		`when position_pnl_percent <= -8 then sell 100% position market`,
+		`when position_weight("AAPL") < 5 and cash_balance > 1000 then buy 5% weight market, buy 10% cash limit 140`,
+	}
+
+	for _, src := range sources {
+		t.Run(src, func(t *testing.T) {
+			conditions, actions, err := services.ParseRuleExpression(src)
+			require.NoError(t, err)
+
+			formatted, err := services.FormatRuleExpression(conditions, actions)
+			require.NoError(t, err)
+			assert.Equal(t, src, formatted)
+		})
+	}
+}
+
+func TestFormatRuleExpression_StoredConditions(t *testing.T) {
+	// Conditions read back from the database carry params as a generic map
+	var conditions []services.RuleCondition
+	require.NoError(t, json.Unmarshal([]byte(`[
+		{"type": "ema", "time_frame": "1h", "params": {"period": 20}, "operator": "crosses_above",
+		 "compare_to": {"type": "ema", "params": {"period": 50}}}
+	]`), &conditions))
+
+	formatted, err := services.FormatRuleExpression(conditions, []services.RuleAction{{Type: "buy", Quantity: 1}})
+	require.NoError(t, err)
+	assert.Equal(t, `when ema(20, tf="1h") crosses_above ema(50) then buy 1 market`, formatted)
+}
+
+func TestBuildRule_CompilesExpression(t *testing.T) {
+	service := services.NewRuleService(new(mocks.MockRuleRepository))
+
+	rule, err := service.BuildRule(uuid.New(), services.RuleInput{
+		Name:       "Dip buyer",
+		Symbol:     "AAPL",
+		RuleType:   "buy",
+		Expression: `when price < 150 then buy 10`,
+	})
+	require.NoError(t, err)
+
+	input, err := services.RuleInputOf(rule)
+	require.NoError(t, err)
+	assert.Equal(t, []services.RuleCondition{{Type: "price", Operator: "less_than", Value: 150}}, input.Conditions)
+	assert.Equal(t, []services.RuleAction{{Type: "buy", Quantity: 10, OrderType: "market"}}, input.Actions)
+	assert.Empty(t, input.Expression)
+
+	// An expression replaces the JSON fields rather than adding to them
+	_, err = service.BuildRule(uuid.New(), services.RuleInput{
+		Name:       "Dip buyer",
+		Symbol:     "AAPL",
+		RuleType:   "buy",
+		Actions:    []services.RuleAction{{Type: "buy", Quantity: 1}},
+		Expression: `when price < 150 then buy 10`,
+	})
+	assert.True(t, errors.Is(err, services.ErrInvalidRule))
+}
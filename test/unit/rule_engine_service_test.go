@@ -0,0 +1,1005 @@
+// test/unit/rule_engine_service_test.go
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aquibsayyed9/sentinel/internal/models"
+	"github.com/aquibsayyed9/sentinel/internal/repository"
+	"github.com/aquibsayyed9/sentinel/internal/services"
+	"github.com/aquibsayyed9/sentinel/test/mocks"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type RuleEngineServiceTestSuite struct {
+	suite.Suite
+	mockRuleRepo       *mocks.MockRuleRepository
+	mockMarketDataRepo *mocks.MockMarketDataRepository
+	mockPortfolioRepo  *mocks.MockPortfolioRepository
+	mockExecutionRepo  *mocks.MockExecutionRepository
+	engine             services.RuleEngineService
+}
+
+func (s *RuleEngineServiceTestSuite) SetupTest() {
+	s.mockRuleRepo = new(mocks.MockRuleRepository)
+	s.mockMarketDataRepo = new(mocks.MockMarketDataRepository)
+	s.mockPortfolioRepo = new(mocks.MockPortfolioRepository)
+	s.mockExecutionRepo = new(mocks.MockExecutionRepository)
+
+	s.engine = services.NewRuleEngineService(
+		s.mockRuleRepo,
+		services.NewMarketDataService(s.mockMarketDataRepo),
+		services.NewPortfolioService(s.mockPortfolioRepo),
+		services.NewExecutionService(s.mockExecutionRepo, s.mockRuleRepo),
+		new(mocks.MockTransactor),
+	)
+}
+
+func TestRuleEngineServiceSuite(t *testing.T) {
+	suite.Run(t, new(RuleEngineServiceTestSuite))
+}
+
+func newTestRule(conditions []services.RuleCondition, actions []services.RuleAction) *models.TradingRule {
+	conditionsJSON, _ := json.Marshal(conditions)
+	actionsJSON, _ := json.Marshal(actions)
+	return &models.TradingRule{
+		ID:         uuid.New(),
+		UserID:     uuid.New(),
+		Name:       "Test Rule",
+		Symbol:     "AAPL",
+		RuleType:   "stop_loss",
+		Conditions: conditionsJSON,
+		Actions:    actionsJSON,
+		Status:     "active",
+	}
+}
+
+func (s *RuleEngineServiceTestSuite) TestEvaluateRule_ConditionsMet() {
+	// Arrange
+	ctx := context.Background()
+	rule := newTestRule([]services.RuleCondition{
+		{Type: "price", Operator: "less_than", Value: 150},
+		{Type: "bid", Operator: "greater_than", Value: 100},
+	}, nil)
+
+	s.mockMarketDataRepo.On("GetLatestPrice", ctx, "AAPL").Return(&models.MarketData{Symbol: "AAPL", Close: 145}, nil)
+	s.mockMarketDataRepo.On("GetLatestQuote", ctx, "AAPL").Return(&models.Quote{Symbol: "AAPL", Bid: 144.9, Ask: 145.1}, nil)
+
+	// Act
+	met, err := s.engine.EvaluateRule(ctx, rule)
+
+	// Assert
+	assert.NoError(s.T(), err)
+	assert.True(s.T(), met)
+	s.mockMarketDataRepo.AssertExpectations(s.T())
+}
+
+func (s *RuleEngineServiceTestSuite) TestEvaluateRule_ConditionNotMet() {
+	// Arrange
+	ctx := context.Background()
+	rule := newTestRule([]services.RuleCondition{
+		{Type: "price", Operator: "less_than", Value: 150},
+	}, nil)
+
+	s.mockMarketDataRepo.On("GetLatestPrice", ctx, "AAPL").Return(&models.MarketData{Symbol: "AAPL", Close: 155}, nil)
+
+	// Act
+	met, err := s.engine.EvaluateRule(ctx, rule)
+
+	// Assert
+	assert.NoError(s.T(), err)
+	assert.False(s.T(), met)
+}
+
+func (s *RuleEngineServiceTestSuite) TestEvaluateRule_InactiveRule() {
+	// Arrange
+	rule := newTestRule([]services.RuleCondition{
+		{Type: "price", Operator: "less_than", Value: 150},
+	}, nil)
+	rule.Status = "inactive"
+
+	// Act
+	met, err := s.engine.EvaluateRule(context.Background(), rule)
+
+	// Assert
+	assert.NoError(s.T(), err)
+	assert.False(s.T(), met)
+	s.mockMarketDataRepo.AssertNotCalled(s.T(), "GetLatestPrice")
+}
+
+func (s *RuleEngineServiceTestSuite) TestEvaluateRule_UnsupportedCondition() {
+	// Arrange
+	rule := newTestRule([]services.RuleCondition{
+		{Type: "astrology", Operator: "less_than", Value: 1},
+	}, nil)
+
+	// Act
+	met, err := s.engine.EvaluateRule(context.Background(), rule)
+
+	// Assert
+	assert.False(s.T(), met)
+	assert.True(s.T(), errors.Is(err, services.ErrUnsupportedCondition))
+}
+
+func (s *RuleEngineServiceTestSuite) TestExecuteRule_MarketSellUpdatesPortfolio() {
+	// Arrange
+	ctx := context.Background()
+	rule := newTestRule(nil, []services.RuleAction{
+		{Type: "sell", Symbol: "AAPL", Quantity: 10, OrderType: "market"},
+	})
+	rule.Version = 3
+	portfolio := &models.Portfolio{ID: uuid.New(), UserID: rule.UserID, CashBalance: 1000}
+	holding := &models.PortfolioHolding{ID: uuid.New(), PortfolioID: portfolio.ID, Symbol: "AAPL", Quantity: 20, AverageCost: 100}
+
+	s.mockExecutionRepo.On("Create", ctx, mock.MatchedBy(func(e *models.Execution) bool {
+		return e.Symbol == "AAPL" && e.ExecutionType == "sell" && e.Quantity == 10 &&
+			e.Price == 140 && e.Status == "executed" && *e.RuleID == rule.ID && *e.RuleVersion == 3
+	})).Return(nil)
+	s.mockRuleRepo.On("GetByID", ctx, rule.ID).Return(rule, nil)
+	s.mockRuleRepo.On("UpdateLastExecutedAt", ctx, rule.ID, mock.AnythingOfType("time.Time")).Return(nil)
+	s.mockPortfolioRepo.On("GetPortfolioByUserID", ctx, rule.UserID).Return(portfolio, nil)
+	s.mockPortfolioRepo.On("GetHolding", ctx, portfolio.ID, "AAPL").Return(holding, nil)
+	s.mockPortfolioRepo.On("UpdateHolding", ctx, holding).Return(nil)
+	s.mockPortfolioRepo.On("UpdatePortfolio", ctx, portfolio).Return(nil)
+
+	// Act
+	err := s.engine.ExecuteRule(ctx, rule, 140)
+
+	// Assert
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), 10.0, holding.Quantity)
+	assert.Equal(s.T(), 2400.0, portfolio.CashBalance)
+	s.mockExecutionRepo.AssertExpectations(s.T())
+	s.mockPortfolioRepo.AssertExpectations(s.T())
+}
+
+func (s *RuleEngineServiceTestSuite) TestExecuteRule_UnfilledLimitOrderIsPending() {
+	// Arrange
+	ctx := context.Background()
+	rule := newTestRule(nil, []services.RuleAction{
+		{Type: "buy", Symbol: "AAPL", Quantity: 5, OrderType: "limit", Limit: 130},
+	})
+
+	s.mockExecutionRepo.On("Create", ctx, mock.MatchedBy(func(e *models.Execution) bool {
+		return e.Status == "pending" && e.Price == 130
+	})).Return(nil)
+	s.mockRuleRepo.On("GetByID", ctx, rule.ID).Return(rule, nil)
+	s.mockRuleRepo.On("UpdateLastExecutedAt", ctx, rule.ID, mock.AnythingOfType("time.Time")).Return(nil)
+
+	// Act
+	err := s.engine.ExecuteRule(ctx, rule, 140)
+
+	// Assert
+	assert.NoError(s.T(), err)
+	s.mockExecutionRepo.AssertExpectations(s.T())
+	s.mockPortfolioRepo.AssertNotCalled(s.T(), "GetPortfolioByUserID")
+}
+
+func (s *RuleEngineServiceTestSuite) TestExecuteRule_NoPortfolio() {
+	// Arrange
+	ctx := context.Background()
+	rule := newTestRule(nil, []services.RuleAction{
+		{Type: "buy", Quantity: 1, OrderType: "market"},
+	})
+
+	s.mockExecutionRepo.On("Create", ctx, mock.AnythingOfType("*models.Execution")).Return(nil)
+	s.mockRuleRepo.On("GetByID", ctx, rule.ID).Return(rule, nil)
+	s.mockRuleRepo.On("UpdateLastExecutedAt", ctx, rule.ID, mock.AnythingOfType("time.Time")).Return(nil)
+	s.mockPortfolioRepo.On("GetPortfolioByUserID", ctx, rule.UserID).Return(nil, repository.ErrPortfolioNotFound)
+
+	// Act
+	err := s.engine.ExecuteRule(ctx, rule, 140)
+
+	// Assert
+	assert.NoError(s.T(), err)
+	s.mockPortfolioRepo.AssertNotCalled(s.T(), "UpdatePortfolio")
+}
+
+func (s *RuleEngineServiceTestSuite) TestEvaluateRule_ConditionTree() {
+	// Arrange: (price < 150 AND volume > 1000) OR NOT ask > 200
+	ctx := context.Background()
+	rule := newTestRule([]services.RuleCondition{
+		{Any: []services.RuleCondition{
+			{All: []services.RuleCondition{
+				{Type: "price", Operator: "less_than", Value: 150},
+				{Type: "volume", Operator: "greater_than", Value: 1000},
+			}},
+			{Not: &services.RuleCondition{Type: "ask", Operator: "greater_than", Value: 200}},
+		}},
+	}, nil)
+
+	s.mockMarketDataRepo.On("GetLatestPrice", ctx, "AAPL").Return(&models.MarketData{Symbol: "AAPL", Close: 145, Volume: 500}, nil)
+	s.mockMarketDataRepo.On("GetLatestQuote", ctx, "AAPL").Return(&models.Quote{Symbol: "AAPL", Bid: 144.9, Ask: 145.1}, nil)
+
+	// Act
+	met, err := s.engine.EvaluateRule(ctx, rule)
+
+	// Assert
+	assert.NoError(s.T(), err)
+	assert.True(s.T(), met)
+	s.mockMarketDataRepo.AssertNumberOfCalls(s.T(), "GetLatestPrice", 1)
+}
+
+func (s *RuleEngineServiceTestSuite) TestEvaluateRule_AnyGroupShortCircuits() {
+	// Arrange
+	ctx := context.Background()
+	rule := newTestRule([]services.RuleCondition{
+		{Any: []services.RuleCondition{
+			{Type: "price", Operator: "less_than", Value: 150},
+			{Type: "bid", Operator: "greater_than", Value: 100},
+		}},
+	}, nil)
+
+	s.mockMarketDataRepo.On("GetLatestPrice", ctx, "AAPL").Return(&models.MarketData{Symbol: "AAPL", Close: 145}, nil)
+
+	// Act
+	met, err := s.engine.EvaluateRule(ctx, rule)
+
+	// Assert
+	assert.NoError(s.T(), err)
+	assert.True(s.T(), met)
+	s.mockMarketDataRepo.AssertNotCalled(s.T(), "GetLatestQuote", ctx, "AAPL")
+}
+
+func (s *RuleEngineServiceTestSuite) TestEvaluateRule_NotOfFailedConditionIsNotMet() {
+	// Arrange
+	ctx := context.Background()
+	rule := newTestRule([]services.RuleCondition{
+		{Not: &services.RuleCondition{Type: "price", Operator: "greater_than", Value: 200}},
+	}, nil)
+
+	s.mockMarketDataRepo.On("GetLatestPrice", ctx, "AAPL").Return(nil, repository.ErrMarketDataNotFound)
+
+	// Act
+	met, err := s.engine.EvaluateRule(ctx, rule)
+
+	// Assert
+	assert.False(s.T(), met)
+	assert.True(s.T(), errors.Is(err, repository.ErrMarketDataNotFound))
+}
+
+func TestValidateConditions(t *testing.T) {
+	leaf := services.RuleCondition{Type: "price", Operator: "less_than", Value: 150}
+
+	tests := []struct {
+		name       string
+		conditions []services.RuleCondition
+		valid      bool
+	}{
+		{"flat list", []services.RuleCondition{leaf}, true},
+		{"nested groups", []services.RuleCondition{{Any: []services.RuleCondition{{All: []services.RuleCondition{leaf}}, {Not: &leaf}}}}, true},
+		{"empty", nil, false},
+		{"empty group", []services.RuleCondition{{All: []services.RuleCondition{}}}, false},
+		{"mixed group kinds", []services.RuleCondition{{All: []services.RuleCondition{leaf}, Not: &leaf}}, false},
+		{"group with leaf fields", []services.RuleCondition{{Type: "price", Any: []services.RuleCondition{leaf}}}, false},
+		{"missing operator", []services.RuleCondition{{Type: "price"}}, false},
+		{"unknown operator", []services.RuleCondition{{Type: "price", Operator: "roughly"}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := services.ValidateConditions(tt.conditions)
+			if tt.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.True(t, errors.Is(err, services.ErrInvalidRuleConditions))
+			}
+		})
+	}
+}
+
+func TestRuleCondition_GroupJSONRoundTrip(t *testing.T) {
+	input := `[{"any":[{"type":"price","symbol":"AAPL","operator":"less_than","value":150},{"not":{"type":"rsi","symbol":"","operator":"greater_than","value":70}}]}]`
+
+	var conditions []services.RuleCondition
+	assert.NoError(t, json.Unmarshal([]byte(input), &conditions))
+	assert.True(t, conditions[0].IsGroup())
+	assert.Len(t, conditions[0].Any, 2)
+
+	output, err := json.Marshal(conditions)
+	assert.NoError(t, err)
+	assert.JSONEq(t, input, string(output))
+}
+
+func (s *RuleEngineServiceTestSuite) TestEvaluateRule_CrossesAbove() {
+	// Arrange
+	ctx := context.Background()
+	rule := newTestRule([]services.RuleCondition{
+		{
+			Type:      "price",
+			TimeFrame: "1h",
+			Operator:  "crosses_above",
+			CompareTo: &services.RuleCondition{Type: "sma", Params: map[string]interface{}{"period": 2}},
+		},
+	}, nil)
+
+	start := time.Now().Add(-4 * time.Hour).Truncate(time.Hour)
+	bars := make([]models.MarketData, 0)
+	for i, close := range []float64{10, 10, 9, 12} {
+		bars = append(bars, models.MarketData{Symbol: "AAPL", TimeFrame: "1h", Timestamp: start.Add(time.Duration(i) * time.Hour), Close: close})
+	}
+	s.mockMarketDataRepo.On("GetHistoricalData", ctx, "AAPL", mock.Anything, mock.Anything, "1h").Return(bars, nil)
+
+	// Act
+	met, err := s.engine.EvaluateRule(ctx, rule)
+
+	// Assert: close went from 9 (below SMA 9.5) to 12 (above SMA 10.5)
+	assert.NoError(s.T(), err)
+	assert.True(s.T(), met)
+
+	// The cross is reported once, not again on every quote until the next bar
+	met, err = s.engine.EvaluateRule(ctx, rule)
+	assert.NoError(s.T(), err)
+	assert.False(s.T(), met)
+}
+
+func (s *RuleEngineServiceTestSuite) TestEvaluateRule_IndicatorNotReady() {
+	// Arrange
+	ctx := context.Background()
+	rule := newTestRule([]services.RuleCondition{
+		{Type: "rsi", TimeFrame: "1d", Operator: "less_than", Value: 30},
+	}, nil)
+
+	s.mockMarketDataRepo.On("GetHistoricalData", ctx, "AAPL", mock.Anything, mock.Anything, "1d").Return([]models.MarketData{}, nil)
+	s.mockMarketDataRepo.On("GetBars", ctx, "AAPL", "1m", mock.Anything, mock.Anything, 1).Return([]models.MarketData{}, nil)
+
+	// Act
+	met, err := s.engine.EvaluateRule(ctx, rule)
+
+	// Assert
+	assert.False(s.T(), met)
+	assert.True(s.T(), errors.Is(err, services.ErrIndicatorNotReady))
+}
+
+func (s *RuleEngineServiceTestSuite) expectMarketSell(ctx context.Context, rule *models.TradingRule) {
+	s.mockMarketDataRepo.On("GetLatestPrice", ctx, "AAPL").Return(&models.MarketData{Symbol: "AAPL", Close: 95}, nil)
+	s.mockExecutionRepo.On("Create", ctx, mock.AnythingOfType("*models.Execution")).Return(nil)
+	s.mockRuleRepo.On("GetByID", ctx, rule.ID).Return(rule, nil)
+	s.mockRuleRepo.On("UpdateLastExecutedAt", ctx, rule.ID, mock.AnythingOfType("time.Time")).Return(nil)
+	s.mockRuleRepo.On("RecordTrigger", ctx, rule, mock.AnythingOfType("time.Time"), mock.AnythingOfType("int")).Return(nil)
+	s.mockPortfolioRepo.On("GetPortfolioByUserID", ctx, rule.UserID).Return(nil, repository.ErrPortfolioNotFound)
+}
+
+func (s *RuleEngineServiceTestSuite) TestProcessRule_OneShotDeactivates() {
+	// Arrange
+	ctx := context.Background()
+	rule := newTestRule([]services.RuleCondition{
+		{Type: "price", Operator: "less_than", Value: 100},
+	}, []services.RuleAction{
+		{Type: "sell", Quantity: 10, OrderType: "market"},
+	})
+	rule.OneShot = true
+	s.expectMarketSell(ctx, rule)
+
+	// Act
+	first, err := s.engine.ProcessRule(ctx, rule)
+	assert.NoError(s.T(), err)
+	second, err := s.engine.ProcessRule(ctx, rule)
+	assert.NoError(s.T(), err)
+
+	// Assert
+	assert.True(s.T(), first)
+	assert.False(s.T(), second)
+	assert.Equal(s.T(), "inactive", rule.Status)
+	assert.Equal(s.T(), 1, rule.TriggerCount)
+	assert.NotNil(s.T(), rule.LastTriggeredAt)
+	assert.NotNil(s.T(), rule.LastExecutedAt)
+	s.mockExecutionRepo.AssertNumberOfCalls(s.T(), "Create", 1)
+	s.mockRuleRepo.AssertNumberOfCalls(s.T(), "RecordTrigger", 1)
+}
+
+func (s *RuleEngineServiceTestSuite) TestProcessRule_CooldownSuppressesTrigger() {
+	// Arrange
+	ctx := context.Background()
+	rule := newTestRule([]services.RuleCondition{
+		{Type: "price", Operator: "less_than", Value: 100},
+	}, []services.RuleAction{
+		{Type: "sell", Quantity: 10, OrderType: "market"},
+	})
+	rule.CooldownSeconds = 300
+	recent := time.Now().Add(-time.Minute)
+	rule.LastTriggeredAt = &recent
+
+	// Act
+	triggered, err := s.engine.ProcessRule(ctx, rule)
+
+	// Assert: the rule is not even evaluated while cooling down
+	assert.NoError(s.T(), err)
+	assert.False(s.T(), triggered)
+	s.mockMarketDataRepo.AssertNotCalled(s.T(), "GetLatestPrice")
+
+	// Once the cooldown has elapsed the rule triggers again
+	past := time.Now().Add(-10 * time.Minute)
+	rule.LastTriggeredAt = &past
+	s.expectMarketSell(ctx, rule)
+
+	triggered, err = s.engine.ProcessRule(ctx, rule)
+	assert.NoError(s.T(), err)
+	assert.True(s.T(), triggered)
+	assert.Equal(s.T(), "active", rule.Status)
+}
+
+func (s *RuleEngineServiceTestSuite) TestProcessRule_MaxTriggersPerDay() {
+	// Arrange
+	ctx := context.Background()
+	rule := newTestRule([]services.RuleCondition{
+		{Type: "price", Operator: "less_than", Value: 100},
+	}, []services.RuleAction{
+		{Type: "sell", Quantity: 1, OrderType: "market"},
+	})
+	rule.MaxTriggersPerDay = 2
+	s.expectMarketSell(ctx, rule)
+
+	// Act
+	var triggers int
+	for i := 0; i < 4; i++ {
+		triggered, err := s.engine.ProcessRule(ctx, rule)
+		assert.NoError(s.T(), err)
+		if triggered {
+			triggers++
+		}
+	}
+
+	// Assert
+	assert.Equal(s.T(), 2, triggers)
+	assert.Equal(s.T(), 2, rule.TriggersToday)
+
+	// Triggers on an earlier day do not count against today's limit
+	yesterday := time.Now().Add(-24 * time.Hour)
+	rule.LastTriggeredAt = &yesterday
+	triggered, err := s.engine.ProcessRule(ctx, rule)
+	assert.NoError(s.T(), err)
+	assert.True(s.T(), triggered)
+	assert.Equal(s.T(), 1, rule.TriggersToday)
+}
+
+func newStopRule(ruleType string, stop services.StopSpec) *models.TradingRule {
+	rule := newTestRule(nil, []services.RuleAction{{Type: "sell", Quantity: 10, OrderType: "market"}})
+	rule.RuleType = ruleType
+	rule.Stop, _ = json.Marshal(stop)
+	return rule
+}
+
+func (s *RuleEngineServiceTestSuite) TestEvaluateRule_TrailingStopFollowsHighWaterMark() {
+	// Arrange
+	ctx := context.Background()
+	rule := newStopRule("trailing_stop", services.StopSpec{Percent: 10})
+
+	prices := []float64{100, 120, 110, 107.9}
+	for _, price := range prices {
+		s.mockMarketDataRepo.On("GetLatestPrice", ctx, "AAPL").Return(&models.MarketData{Symbol: "AAPL", Close: price}, nil).Once()
+	}
+	s.mockRuleRepo.On("UpdateReferencePrice", ctx, rule.ID, 100.0).Return(nil).Once()
+	s.mockRuleRepo.On("UpdateReferencePrice", ctx, rule.ID, 120.0).Return(nil).Once()
+
+	// Act
+	var results []bool
+	for range prices {
+		met, err := s.engine.EvaluateRule(ctx, rule)
+		assert.NoError(s.T(), err)
+		results = append(results, met)
+	}
+
+	// Assert: the stop trails to 108 after the high of 120, and is only
+	// persisted when the high-water mark moves
+	assert.Equal(s.T(), []bool{false, false, false, true}, results)
+	assert.Equal(s.T(), 120.0, *rule.ReferencePrice)
+	s.mockRuleRepo.AssertExpectations(s.T())
+}
+
+func (s *RuleEngineServiceTestSuite) TestEvaluateRule_TrailingStopResumesFromPersistedReference() {
+	// Arrange
+	ctx := context.Background()
+	rule := newStopRule("trailing_stop", services.StopSpec{Offset: 5})
+	reference := 150.0
+	rule.ReferencePrice = &reference
+
+	s.mockMarketDataRepo.On("GetLatestPrice", ctx, "AAPL").Return(&models.MarketData{Symbol: "AAPL", Close: 144}, nil)
+
+	// Act
+	met, err := s.engine.EvaluateRule(ctx, rule)
+
+	// Assert
+	assert.NoError(s.T(), err)
+	assert.True(s.T(), met)
+	s.mockRuleRepo.AssertNotCalled(s.T(), "UpdateReferencePrice")
+}
+
+func (s *RuleEngineServiceTestSuite) TestEvaluateRule_StopLossAndTakeProfit() {
+	ctx := context.Background()
+	s.mockMarketDataRepo.On("GetLatestPrice", ctx, "AAPL").Return(&models.MarketData{Symbol: "AAPL", Close: 90}, nil)
+
+	tests := []struct {
+		name     string
+		ruleType string
+		stop     services.StopSpec
+		expected bool
+	}{
+		{"long stop loss at fixed price", "stop_loss", services.StopSpec{Price: 95}, true},
+		{"long stop loss below price", "stop_loss", services.StopSpec{Price: 85}, false},
+		{"long stop loss percent from entry", "stop_loss", services.StopSpec{Percent: 5, ReferencePrice: 100}, true},
+		{"short stop loss", "stop_loss", services.StopSpec{Side: "short", Offset: 5, ReferencePrice: 80}, true},
+		{"long take profit not reached", "take_profit", services.StopSpec{Percent: 10, ReferencePrice: 100}, false},
+		{"short take profit reached", "take_profit", services.StopSpec{Side: "short", Percent: 10, ReferencePrice: 100}, true},
+	}
+
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			rule := newStopRule(tt.ruleType, tt.stop)
+			if tt.stop.ReferencePrice > 0 {
+				s.mockRuleRepo.On("UpdateReferencePrice", ctx, rule.ID, tt.stop.ReferencePrice).Return(nil)
+			}
+
+			met, err := s.engine.EvaluateRule(ctx, rule)
+
+			assert.NoError(s.T(), err)
+			assert.Equal(s.T(), tt.expected, met)
+		})
+	}
+}
+
+func TestValidateStop(t *testing.T) {
+	tests := []struct {
+		name     string
+		ruleType string
+		stop     *services.StopSpec
+		valid    bool
+	}{
+		{"no stop on stop loss", "stop_loss", nil, true},
+		{"no stop on trailing stop", "trailing_stop", nil, false},
+		{"fixed price", "stop_loss", &services.StopSpec{Price: 95}, true},
+		{"trailing percent", "trailing_stop", &services.StopSpec{Percent: 5}, true},
+		{"trailing fixed price", "trailing_stop", &services.StopSpec{Price: 95}, false},
+		{"two levels", "take_profit", &services.StopSpec{Percent: 5, Offset: 2}, false},
+		{"no level", "stop_loss", &services.StopSpec{Side: "long"}, false},
+		{"percent too large", "stop_loss", &services.StopSpec{Percent: 100}, false},
+		{"short take profit percent too large", "take_profit", &services.StopSpec{Side: "short", Percent: 100}, false},
+		{"take profit of 150 percent", "take_profit", &services.StopSpec{Percent: 150}, true},
+		{"short stop loss of 100 percent", "stop_loss", &services.StopSpec{Side: "short", Percent: 100}, true},
+		{"unknown side", "stop_loss", &services.StopSpec{Side: "flat", Percent: 5}, false},
+		{"stop on other rule type", "buy", &services.StopSpec{Price: 95}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := services.ValidateStop(tt.ruleType, tt.stop)
+			if tt.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.True(t, errors.Is(err, services.ErrInvalidStopSpec))
+			}
+		})
+	}
+}
+
+func (s *RuleEngineServiceTestSuite) TestExplainRule_TracesEveryCondition() {
+	// Arrange
+	ctx := context.Background()
+	rule := newTestRule([]services.RuleCondition{
+		{Any: []services.RuleCondition{
+			{Type: "price", Operator: "less_than", Value: 150},
+			{Type: "bid", Operator: "greater_than", Value: 200},
+		}},
+		{Not: &services.RuleCondition{Type: "price", Symbol: "SPY", Operator: "less_than", Value: 400}},
+	}, []services.RuleAction{
+		{Type: "buy", Quantity: 2, OrderType: "limit", Limit: 140},
+	})
+
+	s.mockMarketDataRepo.On("GetLatestPrice", ctx, "AAPL").Return(&models.MarketData{Symbol: "AAPL", Close: 145}, nil)
+	s.mockMarketDataRepo.On("GetLatestPrice", ctx, "SPY").Return(nil, repository.ErrMarketDataNotFound)
+	s.mockMarketDataRepo.On("GetLatestQuote", ctx, "AAPL").Return(&models.Quote{Symbol: "AAPL", Bid: 144.9, Ask: 145.1}, nil)
+
+	// Act: SPY has no stored data, so it is supplied
+	spy := 420.0
+	explanation, err := s.engine.ExplainRule(ctx, rule, map[string]services.MarketOverride{"SPY": {Price: &spy}})
+
+	// Assert
+	assert.NoError(s.T(), err)
+	assert.True(s.T(), explanation.Triggered)
+	assert.Len(s.T(), explanation.Conditions, 2)
+
+	anyGroup := explanation.Conditions[0]
+	assert.Equal(s.T(), "any", anyGroup.Group)
+	assert.True(s.T(), anyGroup.Met)
+	// The second branch is still evaluated although the first already matched
+	assert.Len(s.T(), anyGroup.Children, 2)
+	assert.Equal(s.T(), "conditions[0].any[1]", anyGroup.Children[1].Path)
+	assert.Equal(s.T(), 144.9, anyGroup.Children[1].Left.Current)
+	assert.False(s.T(), anyGroup.Children[1].Met)
+
+	notGroup := explanation.Conditions[1]
+	assert.True(s.T(), notGroup.Met)
+	assert.Equal(s.T(), 420.0, notGroup.Children[0].Left.Current)
+	assert.Equal(s.T(), 400.0, notGroup.Children[0].Right.Current)
+
+	assert.Equal(s.T(), []services.ActionPreview{
+		{Type: "buy", Symbol: "AAPL", Quantity: 2, OrderType: "limit", Price: 140, Status: "pending"},
+	}, explanation.Actions)
+	s.mockExecutionRepo.AssertNotCalled(s.T(), "Create")
+}
+
+func (s *RuleEngineServiceTestSuite) TestExplainRule_RecordsLeafErrorsAndSkipsActions() {
+	// Arrange
+	ctx := context.Background()
+	rule := newStopRule("trailing_stop", services.StopSpec{Percent: 10})
+	reference := 200.0
+	rule.ReferencePrice = &reference
+	rule.Conditions, _ = json.Marshal([]services.RuleCondition{{Type: "unknown", Operator: "less_than", Value: 1}})
+
+	s.mockMarketDataRepo.On("GetLatestPrice", ctx, "AAPL").Return(&models.MarketData{Symbol: "AAPL", Close: 210}, nil)
+
+	// Act
+	explanation, err := s.engine.ExplainRule(ctx, rule, nil)
+
+	// Assert: the trailing reference moves in the trace but is not persisted
+	assert.NoError(s.T(), err)
+	assert.False(s.T(), explanation.Triggered)
+	assert.False(s.T(), explanation.StopMet)
+	assert.Equal(s.T(), 210.0, *explanation.Stop.ReferencePrice)
+	assert.Equal(s.T(), 189.0, explanation.Stop.Level)
+	assert.Equal(s.T(), 200.0, *rule.ReferencePrice)
+	assert.Contains(s.T(), explanation.Conditions[0].Error, "unsupported condition type")
+	assert.Empty(s.T(), explanation.Actions)
+	s.mockRuleRepo.AssertNotCalled(s.T(), "UpdateReferencePrice")
+}
+
+func (s *RuleEngineServiceTestSuite) TestProcessRule_OutsideTimeConstraints() {
+	// Arrange: a rule that may trigger on every day but today
+	ctx := context.Background()
+	rule := newTestRule([]services.RuleCondition{
+		{Type: "price", Operator: "less_than", Value: 100},
+	}, []services.RuleAction{
+		{Type: "sell", Quantity: 10, OrderType: "market"},
+	})
+	var days []string
+	today := strings.ToLower(time.Now().UTC().Weekday().String()[:3])
+	for _, day := range []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"} {
+		if day != today {
+			days = append(days, day)
+		}
+	}
+	rule.TimeConstraints, _ = json.Marshal(services.TimeConstraints{Days: days, TimeZone: "UTC"})
+
+	// Act
+	triggered, err := s.engine.ProcessRule(ctx, rule)
+
+	// Assert: the rule is not evaluated outside its time constraints
+	assert.NoError(s.T(), err)
+	assert.False(s.T(), triggered)
+	s.mockMarketDataRepo.AssertNotCalled(s.T(), "GetLatestPrice")
+
+	// Once today is an active day the rule triggers
+	rule.TimeConstraints, _ = json.Marshal(services.TimeConstraints{Days: []string{today}, TimeZone: "UTC"})
+	s.expectMarketSell(ctx, rule)
+
+	triggered, err = s.engine.ProcessRule(ctx, rule)
+	assert.NoError(s.T(), err)
+	assert.True(s.T(), triggered)
+}
+
+func (s *RuleEngineServiceTestSuite) TestEvaluateRule_RatioOfTwoSymbols() {
+	// Arrange: buy XLE when the USO/XLE ratio rises above 1.2
+	ctx := context.Background()
+	rule := newTestRule([]services.RuleCondition{
+		{
+			Type:     "ratio",
+			Operator: "greater_than",
+			Value:    1.2,
+			Legs: []services.RuleCondition{
+				{Type: "price", Symbol: "USO"},
+				{Type: "price", Symbol: "XLE"},
+			},
+		},
+	}, nil)
+	rule.Symbol = "XLE"
+
+	s.mockMarketDataRepo.On("GetLatestPrice", ctx, "USO").Return(&models.MarketData{Symbol: "USO", Close: 78}, nil)
+	s.mockMarketDataRepo.On("GetLatestPrice", ctx, "XLE").Return(&models.MarketData{Symbol: "XLE", Close: 60}, nil)
+
+	// Act
+	met, err := s.engine.EvaluateRule(ctx, rule)
+
+	// Assert: 78 / 60 = 1.3
+	assert.NoError(s.T(), err)
+	assert.True(s.T(), met)
+	s.mockMarketDataRepo.AssertExpectations(s.T())
+}
+
+func (s *RuleEngineServiceTestSuite) TestEvaluateRule_RatioWithZeroLeg() {
+	// Arrange
+	ctx := context.Background()
+	rule := newTestRule([]services.RuleCondition{
+		{
+			Type:     "ratio",
+			Operator: "greater_than",
+			Value:    1.2,
+			Legs:     []services.RuleCondition{{Type: "price", Symbol: "USO"}, {Type: "volume", Symbol: "XLE"}},
+		},
+	}, nil)
+
+	s.mockMarketDataRepo.On("GetLatestPrice", ctx, "USO").Return(&models.MarketData{Symbol: "USO", Close: 78}, nil)
+	s.mockMarketDataRepo.On("GetLatestPrice", ctx, "XLE").Return(&models.MarketData{Symbol: "XLE", Close: 60}, nil)
+
+	// Act
+	met, err := s.engine.EvaluateRule(ctx, rule)
+
+	// Assert
+	assert.False(s.T(), met)
+	assert.True(s.T(), errors.Is(err, services.ErrUndefinedRatio))
+}
+
+func (s *RuleEngineServiceTestSuite) TestEvaluateRule_ChangePercentOfOtherSymbol() {
+	// Arrange: sell AAPL if QQQ drops 2% today
+	ctx := context.Background()
+	rule := newTestRule([]services.RuleCondition{
+		{Type: "change_percent", Symbol: "QQQ", Operator: "less_than_or_equal", Value: -2},
+	}, nil)
+
+	yesterday := time.Now().AddDate(0, 0, -1)
+	s.mockMarketDataRepo.On("GetLatestPrice", ctx, "QQQ").Return(&models.MarketData{Symbol: "QQQ", Close: 490}, nil)
+	s.mockMarketDataRepo.On("GetHistoricalData", ctx, "QQQ", mock.Anything, mock.Anything, "1d").
+		Return([]models.MarketData{{Symbol: "QQQ", TimeFrame: "1d", Timestamp: yesterday, Close: 500}}, nil)
+
+	// Act
+	met, err := s.engine.EvaluateRule(ctx, rule)
+
+	// Assert: 490 is 2% below 500
+	assert.NoError(s.T(), err)
+	assert.True(s.T(), met)
+	s.mockMarketDataRepo.AssertNotCalled(s.T(), "GetLatestPrice", ctx, "AAPL")
+}
+
+func (s *RuleEngineServiceTestSuite) TestEvaluateRule_ReadsEverySymbolAtTheSameTime() {
+	// Arrange: a USO bar stored after the evaluation started is passed over
+	ctx := context.Background()
+	rule := newTestRule([]services.RuleCondition{
+		{
+			Type:     "difference",
+			Operator: "greater_than",
+			Value:    10,
+			Legs:     []services.RuleCondition{{Type: "price", Symbol: "USO"}, {Type: "price", Symbol: "XLE"}},
+		},
+	}, nil)
+
+	s.mockMarketDataRepo.On("GetLatestPrice", ctx, "USO").Return(&models.MarketData{Symbol: "USO", Close: 80, Timestamp: time.Now().Add(time.Minute)}, nil)
+	s.mockMarketDataRepo.On("GetPriceAt", ctx, "USO", mock.AnythingOfType("time.Time")).Return(&models.MarketData{Symbol: "USO", Close: 65}, nil)
+	s.mockMarketDataRepo.On("GetLatestPrice", ctx, "XLE").Return(&models.MarketData{Symbol: "XLE", Close: 60}, nil)
+
+	// Act
+	met, err := s.engine.EvaluateRule(ctx, rule)
+
+	// Assert: 65 - 60 is not above 10
+	assert.NoError(s.T(), err)
+	assert.False(s.T(), met)
+	s.mockMarketDataRepo.AssertCalled(s.T(), "GetPriceAt", ctx, "USO", mock.AnythingOfType("time.Time"))
+}
+
+func (s *RuleEngineServiceTestSuite) TestEvaluateRule_PositionDownPercent() {
+	// Arrange: sell when my AAPL position is down 8%
+	ctx := context.Background()
+	rule := newTestRule([]services.RuleCondition{
+		{Type: "position_pnl_percent", Operator: "less_than_or_equal", Value: -8},
+	}, nil)
+	portfolio := &models.Portfolio{ID: uuid.New(), UserID: rule.UserID, CashBalance: 1000}
+
+	s.mockPortfolioRepo.On("GetPortfolioByUserID", ctx, rule.UserID).Return(portfolio, nil)
+	s.mockPortfolioRepo.On("GetAllHoldings", ctx, portfolio.ID).Return([]models.PortfolioHolding{
+		{PortfolioID: portfolio.ID, Symbol: "AAPL", Quantity: 20, AverageCost: 100, CurrentPrice: 100},
+	}, nil)
+	s.mockMarketDataRepo.On("GetLatestPrice", ctx, "AAPL").Return(&models.MarketData{Symbol: "AAPL", Close: 91}, nil)
+
+	// Act
+	met, err := s.engine.EvaluateRule(ctx, rule)
+
+	// Assert
+	assert.NoError(s.T(), err)
+	assert.True(s.T(), met)
+}
+
+func (s *RuleEngineServiceTestSuite) TestEvaluateRule_PositionWeightAndCash() {
+	// Arrange: 10 AAPL at 150 and 10 MSFT at 250 with 1000 cash is 5000 in total
+	ctx := context.Background()
+	rule := newTestRule([]services.RuleCondition{
+		{Type: "position_weight", Operator: "equal", Value: 30},
+		{Type: "cash_balance", Operator: "greater_than_or_equal", Value: 1000},
+		{Type: "position_quantity", Symbol: "TSLA", Operator: "equal", Value: 0},
+	}, nil)
+	portfolio := &models.Portfolio{ID: uuid.New(), UserID: rule.UserID, CashBalance: 1000}
+
+	s.mockPortfolioRepo.On("GetPortfolioByUserID", ctx, rule.UserID).Return(portfolio, nil)
+	s.mockPortfolioRepo.On("GetAllHoldings", ctx, portfolio.ID).Return([]models.PortfolioHolding{
+		{PortfolioID: portfolio.ID, Symbol: "AAPL", Quantity: 10, AverageCost: 100},
+		{PortfolioID: portfolio.ID, Symbol: "MSFT", Quantity: 10, AverageCost: 200, CurrentPrice: 250},
+	}, nil)
+	s.mockMarketDataRepo.On("GetLatestPrice", ctx, "AAPL").Return(&models.MarketData{Symbol: "AAPL", Close: 150}, nil)
+	s.mockMarketDataRepo.On("GetLatestPrice", ctx, "MSFT").Return(nil, repository.ErrMarketDataNotFound)
+
+	// Act
+	met, err := s.engine.EvaluateRule(ctx, rule)
+
+	// Assert: the portfolio is loaded once for every condition
+	assert.NoError(s.T(), err)
+	assert.True(s.T(), met)
+	s.mockPortfolioRepo.AssertNumberOfCalls(s.T(), "GetAllHoldings", 1)
+}
+
+func (s *RuleEngineServiceTestSuite) TestEvaluateRule_PositionPnLWithoutPosition() {
+	// Arrange
+	ctx := context.Background()
+	rule := newTestRule([]services.RuleCondition{
+		{Type: "position_pnl_percent", Operator: "less_than_or_equal", Value: -8},
+	}, nil)
+	portfolio := &models.Portfolio{ID: uuid.New(), UserID: rule.UserID}
+
+	s.mockPortfolioRepo.On("GetPortfolioByUserID", ctx, rule.UserID).Return(portfolio, nil)
+	s.mockPortfolioRepo.On("GetAllHoldings", ctx, portfolio.ID).Return([]models.PortfolioHolding{}, nil)
+
+	// Act
+	met, err := s.engine.EvaluateRule(ctx, rule)
+
+	// Assert
+	assert.False(s.T(), met)
+	assert.True(s.T(), errors.Is(err, services.ErrNoPosition))
+}
+
+func (s *RuleEngineServiceTestSuite) TestExecuteRule_RelativeQuantities() {
+	tests := []struct {
+		name     string
+		action   services.RuleAction
+		quantity float64
+	}{
+		{"percent of position", services.RuleAction{Type: "sell", PositionPercent: 50}, 10},
+		{"percent of cash", services.RuleAction{Type: "buy", CashPercent: 10}, 2},
+		{"buy up to target weight", services.RuleAction{Type: "buy", TargetWeight: 80}, 12},
+		{"sell down to target weight", services.RuleAction{Type: "sell", TargetWeight: 40}, 4},
+	}
+
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			s.SetupTest()
+
+			// Arrange: 20 AAPL at 100 and 2000 cash is 4000 in total
+			ctx := context.Background()
+			rule := newTestRule(nil, []services.RuleAction{tt.action})
+			portfolio := &models.Portfolio{ID: uuid.New(), UserID: rule.UserID, CashBalance: 2000}
+			holding := &models.PortfolioHolding{ID: uuid.New(), PortfolioID: portfolio.ID, Symbol: "AAPL", Quantity: 20, AverageCost: 80}
+
+			s.mockPortfolioRepo.On("GetPortfolioByUserID", ctx, rule.UserID).Return(portfolio, nil)
+			s.mockPortfolioRepo.On("GetAllHoldings", ctx, portfolio.ID).Return([]models.PortfolioHolding{*holding}, nil)
+			s.mockPortfolioRepo.On("GetHolding", ctx, portfolio.ID, "AAPL").Return(holding, nil)
+			s.mockPortfolioRepo.On("UpdateHolding", ctx, holding).Return(nil)
+			s.mockPortfolioRepo.On("UpdatePortfolio", ctx, portfolio).Return(nil)
+			s.mockExecutionRepo.On("Create", ctx, mock.MatchedBy(func(e *models.Execution) bool {
+				return e.Quantity == tt.quantity && e.Price == 100
+			})).Return(nil)
+			s.mockRuleRepo.On("GetByID", ctx, rule.ID).Return(rule, nil)
+			s.mockRuleRepo.On("UpdateLastExecutedAt", ctx, rule.ID, mock.AnythingOfType("time.Time")).Return(nil)
+
+			// Act
+			err := s.engine.ExecuteRule(ctx, rule, 100)
+
+			// Assert
+			assert.NoError(s.T(), err)
+			s.mockExecutionRepo.AssertExpectations(s.T())
+		})
+	}
+}
+
+func (s *RuleEngineServiceTestSuite) TestExecuteRule_TargetWeightAlreadyReached() {
+	// Arrange
+	ctx := context.Background()
+	rule := newTestRule(nil, []services.RuleAction{{Type: "buy", TargetWeight: 5}})
+	portfolio := &models.Portfolio{ID: uuid.New(), UserID: rule.UserID, CashBalance: 1000}
+
+	s.mockPortfolioRepo.On("GetPortfolioByUserID", ctx, rule.UserID).Return(portfolio, nil)
+	s.mockPortfolioRepo.On("GetAllHoldings", ctx, portfolio.ID).Return([]models.PortfolioHolding{
+		{PortfolioID: portfolio.ID, Symbol: "AAPL", Quantity: 10, AverageCost: 100},
+	}, nil)
+
+	// Act
+	err := s.engine.ExecuteRule(ctx, rule, 100)
+
+	// Assert: nothing is traded
+	assert.NoError(s.T(), err)
+	s.mockExecutionRepo.AssertNotCalled(s.T(), "Create", mock.Anything, mock.Anything)
+}
+
+func (s *RuleEngineServiceTestSuite) TestExecuteRule_CreatesStopLossBelowFillPrice() {
+	// Arrange: after an entry buy, create a stop-loss 5% below the fill price
+	ctx := context.Background()
+	rule := newTestRule(nil, []services.RuleAction{
+		{Type: "buy", Quantity: 10, OrderType: "market"},
+		{Type: "create_rule", Rule: &services.RuleInput{
+			Name:     "Entry stop",
+			Symbol:   "AAPL",
+			RuleType: "stop_loss",
+			Stop:     &services.StopSpec{Percent: 5},
+			Actions:  []services.RuleAction{{Type: "sell", Quantity: 10, OrderType: "market"}},
+		}},
+	})
+
+	var created *models.TradingRule
+	s.mockExecutionRepo.On("Create", ctx, mock.MatchedBy(func(e *models.Execution) bool {
+		return e.ExecutionType == "buy"
+	})).Return(nil)
+	s.mockExecutionRepo.On("Create", ctx, mock.MatchedBy(func(e *models.Execution) bool {
+		return e.ExecutionType == "create_rule" && e.TargetRuleID != nil && *e.TargetRuleID == created.ID
+	})).Return(nil)
+	s.mockRuleRepo.On("CreateWithVersion", ctx, mock.AnythingOfType("*models.TradingRule"), mock.AnythingOfType("*models.RuleVersion")).
+		Run(func(args mock.Arguments) {
+			created = args.Get(1).(*models.TradingRule)
+			created.ID = uuid.New()
+		}).Return(nil)
+	s.mockRuleRepo.On("GetByID", ctx, rule.ID).Return(rule, nil)
+	s.mockRuleRepo.On("UpdateLastExecutedAt", ctx, rule.ID, mock.AnythingOfType("time.Time")).Return(nil)
+	s.mockPortfolioRepo.On("GetPortfolioByUserID", ctx, rule.UserID).Return(nil, repository.ErrPortfolioNotFound)
+
+	// Act
+	err := s.engine.ExecuteRule(ctx, rule, 140)
+
+	// Assert
+	assert.NoError(s.T(), err)
+	if assert.NotNil(s.T(), created) {
+		assert.Equal(s.T(), rule.UserID, created.UserID)
+		assert.JSONEq(s.T(), `{"percent": 5, "reference_price": 140}`, string(created.Stop))
+	}
+	s.mockExecutionRepo.AssertExpectations(s.T())
+}
+
+func (s *RuleEngineServiceTestSuite) TestExecuteRule_ActivatesAndDeactivatesOwnRules() {
+	// Arrange
+	ctx := context.Background()
+	entry := &models.TradingRule{ID: uuid.New(), Symbol: "MSFT", Status: "inactive"}
+	exit := &models.TradingRule{ID: uuid.New(), Symbol: "TSLA", Status: "active"}
+	rule := newTestRule(nil, []services.RuleAction{
+		{Type: "activate_rule", RuleID: &entry.ID},
+		{Type: "deactivate_rule", RuleID: &exit.ID},
+	})
+
+	s.mockRuleRepo.On("GetByIDAndUserID", ctx, entry.ID, rule.UserID).Return(entry, nil)
+	s.mockRuleRepo.On("GetByIDAndUserID", ctx, exit.ID, rule.UserID).Return(exit, nil)
+	s.mockRuleRepo.On("UpdateStatus", ctx, entry).Return(nil)
+	s.mockRuleRepo.On("UpdateStatus", ctx, exit).Return(nil)
+	s.mockRuleRepo.On("GetByID", ctx, rule.ID).Return(rule, nil)
+	s.mockRuleRepo.On("UpdateLastExecutedAt", ctx, rule.ID, mock.AnythingOfType("time.Time")).Return(nil)
+	s.mockExecutionRepo.On("Create", ctx, mock.MatchedBy(func(e *models.Execution) bool {
+		return e.ExecutionType == "activate_rule" && *e.TargetRuleID == entry.ID && e.Symbol == "MSFT"
+	})).Return(nil)
+	s.mockExecutionRepo.On("Create", ctx, mock.MatchedBy(func(e *models.Execution) bool {
+		return e.ExecutionType == "deactivate_rule" && *e.TargetRuleID == exit.ID && e.Symbol == "TSLA"
+	})).Return(nil)
+
+	// Act
+	err := s.engine.ExecuteRule(ctx, rule, 140)
+
+	// Assert
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), "active", entry.Status)
+	assert.Equal(s.T(), "inactive", exit.Status)
+	s.mockExecutionRepo.AssertExpectations(s.T())
+}
+
+func (s *RuleEngineServiceTestSuite) TestExecuteRule_CannotActOnAnotherUsersRule() {
+	// Arrange
+	ctx := context.Background()
+	otherID := uuid.New()
+	rule := newTestRule(nil, []services.RuleAction{{Type: "deactivate_rule", RuleID: &otherID}})
+
+	s.mockRuleRepo.On("GetByIDAndUserID", ctx, otherID, rule.UserID).Return(nil, repository.ErrRuleNotFound)
+
+	// Act
+	err := s.engine.ExecuteRule(ctx, rule, 140)
+
+	// Assert
+	assert.True(s.T(), errors.Is(err, repository.ErrRuleNotFound))
+	s.mockRuleRepo.AssertNotCalled(s.T(), "UpdateStatus", mock.Anything, mock.Anything)
+	s.mockExecutionRepo.AssertNotCalled(s.T(), "Create", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,180 @@
+// test/unit/rule_metrics_test.go
+package unit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aquibsayyed9/sentinel/internal/models"
+	"github.com/aquibsayyed9/sentinel/internal/repository"
+	"github.com/aquibsayyed9/sentinel/internal/services"
+	"github.com/aquibsayyed9/sentinel/test/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// failingEngine fails to process every rule. Only ProcessRule is used by the
+// scheduler.
+type failingEngine struct {
+	services.RuleEngineService
+	err error
+}
+
+func (e *failingEngine) ProcessRule(ctx context.Context, rule *models.TradingRule) (bool, error) {
+	return false, e.err
+}
+
+func newRuleMetrics(ruleRepo *mocks.MockRuleRepository, notificationRepo *mocks.MockNotificationRepository,
+	config services.RuleMetricsConfig) *services.RuleMetrics {
+	return services.NewRuleMetrics(ruleRepo, services.NewNotificationService(notificationRepo), &mocks.MockTransactor{}, config)
+}
+
+func TestRuleMetrics_BatchesEvaluationsPerRule(t *testing.T) {
+	rule := newTestRule([]services.RuleCondition{{Type: "price", Operator: "less_than", Value: 150}}, nil)
+	other := newTestRule([]services.RuleCondition{{Type: "price", Operator: "less_than", Value: 140}}, nil)
+
+	ruleRepo := new(mocks.MockRuleRepository)
+	var deltas []repository.RuleMetricsDelta
+	ruleRepo.On("RecordMetrics", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		deltas = append(deltas, args.Get(1).(repository.RuleMetricsDelta))
+	}).Return(0, nil)
+
+	metrics := newRuleMetrics(ruleRepo, new(mocks.MockNotificationRepository), services.RuleMetricsConfig{QuarantineAfter: 3})
+	metrics.Observe(rule, false, errors.New("no market data"), 2*time.Millisecond)
+	metrics.Observe(rule, true, nil, 4*time.Millisecond)
+	metrics.Observe(rule, false, nil, 3*time.Millisecond)
+	metrics.Observe(other, false, nil, time.Millisecond)
+	metrics.Flush(context.Background())
+
+	require.Len(t, deltas, 2)
+	byRule := map[string]repository.RuleMetricsDelta{}
+	for _, delta := range deltas {
+		byRule[delta.RuleID.String()] = delta
+	}
+
+	delta := byRule[rule.ID.String()]
+	assert.Equal(t, int64(3), delta.Evaluations)
+	assert.Equal(t, int64(1), delta.Triggers)
+	assert.Equal(t, int64(1), delta.Errors)
+	assert.Equal(t, 9*time.Millisecond, delta.TotalLatency)
+	assert.Equal(t, 4*time.Millisecond, delta.MaxLatency)
+	require.NotNil(t, delta.LastError)
+	assert.Equal(t, "no market data", *delta.LastError)
+
+	// The error was followed by successful evaluations, so the rule's run of
+	// errors is over
+	assert.True(t, delta.Recovered)
+	assert.Equal(t, 0, delta.TrailingErrors)
+	assert.Equal(t, int64(1), byRule[other.ID.String()].Evaluations)
+
+	// Nothing is written when nothing was recorded
+	metrics.Flush(context.Background())
+	assert.Len(t, deltas, 2)
+}
+
+func TestRuleMetrics_QuarantinesRuleAfterConsecutiveErrors(t *testing.T) {
+	ctx := context.Background()
+	rule := newTestRule([]services.RuleCondition{{Type: "price", Operator: "less_than", Value: 150}}, nil)
+	rule.Name = "Dip buyer"
+
+	ruleRepo := new(mocks.MockRuleRepository)
+	ruleRepo.On("GetDueScheduledRules", mock.Anything, mock.Anything).Return([]models.TradingRule{*rule}, nil)
+	expectReloads(ruleRepo, rule)
+	// Two earlier errors were recorded by another instance
+	ruleRepo.On("RecordMetrics", mock.Anything, mock.MatchedBy(func(delta repository.RuleMetricsDelta) bool {
+		return delta.RuleID == rule.ID && delta.TrailingErrors == 1 && !delta.Recovered
+	})).Return(3, nil)
+	ruleRepo.On("QuarantineRule", mock.Anything, rule.ID).Return(true, nil)
+
+	notificationRepo := new(mocks.MockNotificationRepository)
+	notificationRepo.On("Create", mock.Anything, mock.MatchedBy(func(n *models.Notification) bool {
+		return n.UserID == rule.UserID && *n.RuleID == rule.ID && n.Kind == services.NotificationKindRuleQuarantined
+	})).Return(nil)
+
+	var quarantined []error
+	metrics := newRuleMetrics(ruleRepo, notificationRepo, services.RuleMetricsConfig{
+		QuarantineAfter: 3,
+		OnQuarantine:    func(rule *models.TradingRule, err error) { quarantined = append(quarantined, err) },
+	})
+
+	scheduler := services.NewRuleScheduler(ruleRepo, &failingEngine{err: errors.New("failed to parse rule conditions")},
+		services.RuleSchedulerConfig{Metrics: metrics})
+	require.NoError(t, scheduler.RunDue(ctx))
+	metrics.Flush(ctx)
+
+	ruleRepo.AssertCalled(t, "QuarantineRule", mock.Anything, rule.ID)
+	notificationRepo.AssertNumberOfCalls(t, "Create", 1)
+	notification := notificationRepo.Calls[0].Arguments.Get(1).(*models.Notification)
+	assert.Contains(t, notification.Message, `"Dip buyer"`)
+	assert.Contains(t, notification.Message, "failed to parse rule conditions")
+	require.Len(t, quarantined, 1)
+	assert.EqualError(t, quarantined[0], "failed to parse rule conditions")
+}
+
+func TestRuleMetrics_RuleWaitingForMarketDataIsNeverQuarantined(t *testing.T) {
+	ctx := context.Background()
+	rule := newTestRule([]services.RuleCondition{{Type: "price", Operator: "less_than", Value: 150}}, nil)
+
+	ruleRepo := new(mocks.MockRuleRepository)
+	ruleRepo.On("GetDueScheduledRules", mock.Anything, mock.Anything).Return([]models.TradingRule{*rule}, nil)
+	expectReloads(ruleRepo, rule)
+	// Earlier failures stay on record; waiting neither adds to nor clears them
+	ruleRepo.On("RecordMetrics", mock.Anything, mock.MatchedBy(func(delta repository.RuleMetricsDelta) bool {
+		return delta.RuleID == rule.ID && delta.Evaluations == 10 && delta.Errors == 0 &&
+			delta.TrailingErrors == 0 && !delta.Recovered
+	})).Return(4, nil)
+
+	metrics := newRuleMetrics(ruleRepo, new(mocks.MockNotificationRepository), services.RuleMetricsConfig{QuarantineAfter: 5})
+	var errs []error
+	for i, err := range []error{
+		fmt.Errorf("%w: sma on AAPL 1d", services.ErrIndicatorNotReady),
+		repository.ErrMarketDataNotFound,
+	} {
+		scheduler := services.NewRuleScheduler(ruleRepo, &failingEngine{err: err}, services.RuleSchedulerConfig{
+			Metrics: metrics,
+			OnError: func(rule *models.TradingRule, err error) { errs = append(errs, err) },
+		})
+		for j := 0; j < 5; j++ {
+			require.NoError(t, scheduler.RunDue(ctx), i)
+		}
+	}
+	metrics.Flush(ctx)
+
+	assert.Empty(t, errs)
+	ruleRepo.AssertNumberOfCalls(t, "RecordMetrics", 1)
+	ruleRepo.AssertNotCalled(t, "QuarantineRule", mock.Anything, mock.Anything)
+}
+
+func TestRuleMetrics_DoesNotQuarantineRuleThatIsNoLongerActive(t *testing.T) {
+	rule := newTestRule([]services.RuleCondition{{Type: "price", Operator: "less_than", Value: 150}}, nil)
+
+	ruleRepo := new(mocks.MockRuleRepository)
+	ruleRepo.On("RecordMetrics", mock.Anything, mock.Anything).Return(5, nil)
+	// The owner deactivated the rule, or another instance quarantined it first
+	ruleRepo.On("QuarantineRule", mock.Anything, rule.ID).Return(false, nil)
+	notificationRepo := new(mocks.MockNotificationRepository)
+
+	metrics := newRuleMetrics(ruleRepo, notificationRepo, services.RuleMetricsConfig{QuarantineAfter: 5})
+	metrics.Observe(rule, false, errors.New("boom"), time.Millisecond)
+	metrics.Flush(context.Background())
+
+	notificationRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestRuleMetrics_SuccessfulBatchDoesNotQuarantine(t *testing.T) {
+	rule := newTestRule([]services.RuleCondition{{Type: "price", Operator: "less_than", Value: 150}}, nil)
+
+	ruleRepo := new(mocks.MockRuleRepository)
+	ruleRepo.On("RecordMetrics", mock.Anything, mock.Anything).Return(0, nil)
+
+	metrics := newRuleMetrics(ruleRepo, new(mocks.MockNotificationRepository), services.RuleMetricsConfig{QuarantineAfter: 1})
+	metrics.Observe(rule, false, errors.New("boom"), time.Millisecond)
+	metrics.Observe(rule, false, nil, time.Millisecond)
+	metrics.Flush(context.Background())
+
+	ruleRepo.AssertNotCalled(t, "QuarantineRule", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,295 @@
+// test/unit/rule_schedule_test.go
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aquibsayyed9/sentinel/internal/calendar"
+	"github.com/aquibsayyed9/sentinel/internal/models"
+	"github.com/aquibsayyed9/sentinel/internal/repository"
+	"github.com/aquibsayyed9/sentinel/internal/services"
+	"github.com/aquibsayyed9/sentinel/test/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScheduleSpec_Next(t *testing.T) {
+	nyse := calendar.NYSE()
+
+	tests := []struct {
+		name     string
+		schedule services.ScheduleSpec
+		from     string // Eastern time
+		want     string
+	}{
+		{
+			name:     "cron in the exchange time zone",
+			schedule: services.ScheduleSpec{Cron: "30 9 * * *"},
+			from:     "2024-03-12 10:00",
+			want:     "2024-03-13 09:30",
+		},
+		{
+			name:     "cron in another time zone",
+			schedule: services.ScheduleSpec{Cron: "0 14 * * *", TimeZone: "Europe/London"},
+			from:     "2024-01-10 08:00",
+			want:     "2024-01-10 09:00",
+		},
+		{
+			name:     "cron on trading days only skips the weekend",
+			schedule: services.ScheduleSpec{Cron: "0 16 * * *", TradingDaysOnly: true},
+			from:     "2024-03-08 17:00",
+			want:     "2024-03-11 16:00",
+		},
+		{
+			name:     "cron on trading days only skips holidays",
+			schedule: services.ScheduleSpec{Cron: "30 9 * * mon-fri", TradingDaysOnly: true},
+			from:     "2024-03-28 10:00",
+			want:     "2024-04-01 09:30",
+		},
+		{
+			name:     "interval",
+			schedule: services.ScheduleSpec{IntervalSeconds: 3600},
+			from:     "2024-03-12 10:17",
+			want:     "2024-03-12 11:17",
+		},
+		{
+			name:     "interval on trading days only",
+			schedule: services.ScheduleSpec{IntervalSeconds: 6 * 3600, TradingDaysOnly: true},
+			from:     "2024-03-15 20:00",
+			want:     "2024-03-18 02:00",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next, err := tt.schedule.Next(nyTime(t, tt.from), nyse)
+			require.NoError(t, err)
+			assert.True(t, nyTime(t, tt.want).Equal(next), "got %v", next)
+		})
+	}
+}
+
+func TestScheduleSpec_Validate(t *testing.T) {
+	tests := []struct {
+		name     string
+		schedule services.ScheduleSpec
+		field    string
+	}{
+		{name: "cron", schedule: services.ScheduleSpec{Cron: "0 10 * * mon-fri", TimeZone: "UTC", CatchUp: "once"}},
+		{name: "interval", schedule: services.ScheduleSpec{IntervalSeconds: 300, CatchUp: "all"}},
+		{name: "neither", schedule: services.ScheduleSpec{}, field: "schedule"},
+		{name: "both", schedule: services.ScheduleSpec{Cron: "* * * * *", IntervalSeconds: 60}, field: "schedule"},
+		{name: "bad cron", schedule: services.ScheduleSpec{Cron: "61 * * * *"}, field: "schedule.cron"},
+		{name: "short interval", schedule: services.ScheduleSpec{IntervalSeconds: 30}, field: "schedule.interval_seconds"},
+		{name: "time zone on an interval", schedule: services.ScheduleSpec{IntervalSeconds: 60, TimeZone: "UTC"}, field: "schedule.time_zone"},
+		{name: "unknown time zone", schedule: services.ScheduleSpec{Cron: "* * * * *", TimeZone: "Mars/Olympus"}, field: "schedule.time_zone"},
+		{name: "unknown catch-up policy", schedule: services.ScheduleSpec{Cron: "* * * * *", CatchUp: "some"}, field: "schedule.catch_up"},
+		{name: "never runs", schedule: services.ScheduleSpec{Cron: "0 0 31 4 *"}, field: "schedule"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.schedule.Validate()
+			if tt.field == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.True(t, errors.Is(err, services.ErrInvalidSchedule), "got %v", err)
+			var validationErr *services.ValidationError
+			require.True(t, errors.As(err, &validationErr))
+			assert.Equal(t, tt.field, validationErr.Fields[0].Field)
+		})
+	}
+}
+
+func newScheduledRule(schedule services.ScheduleSpec, nextRunAt *time.Time) *models.TradingRule {
+	rule := newTestRule(nil, []services.RuleAction{
+		{Type: "sell", Quantity: 10, OrderType: "market"},
+	})
+	rule.RuleType = services.RuleTypeScheduled
+	rule.Schedule, _ = json.Marshal(schedule)
+	rule.NextRunAt = nextRunAt
+	return rule
+}
+
+func (s *RuleEngineServiceTestSuite) TestProcessRule_ScheduledRuleNotDue() {
+	// Arrange
+	ctx := context.Background()
+	next := time.Now().Add(time.Hour)
+	rule := newScheduledRule(services.ScheduleSpec{IntervalSeconds: 3600}, &next)
+
+	// Act
+	triggered, err := s.engine.ProcessRule(ctx, rule)
+
+	// Assert: nothing is evaluated or persisted before the rule is due
+	assert.NoError(s.T(), err)
+	assert.False(s.T(), triggered)
+	s.mockRuleRepo.AssertNotCalled(s.T(), "UpdateNextRunAt")
+	s.mockMarketDataRepo.AssertNotCalled(s.T(), "GetLatestPrice")
+}
+
+func (s *RuleEngineServiceTestSuite) TestProcessRule_ScheduledRuleDue() {
+	// Arrange
+	ctx := context.Background()
+	due := time.Now().Add(-10 * time.Second)
+	rule := newScheduledRule(services.ScheduleSpec{IntervalSeconds: 3600}, &due)
+	s.mockRuleRepo.On("UpdateNextRunAt", ctx, rule.ID, &due, due.Add(time.Hour)).Return(true, nil)
+	s.expectMarketSell(ctx, rule)
+
+	// Act
+	triggered, err := s.engine.ProcessRule(ctx, rule)
+
+	// Assert: the run is taken and the next one keeps the interval's phase
+	assert.NoError(s.T(), err)
+	assert.True(s.T(), triggered)
+	assert.Equal(s.T(), due.Add(time.Hour), *rule.NextRunAt)
+	s.mockExecutionRepo.AssertNumberOfCalls(s.T(), "Create", 1)
+
+	// The same run is not taken twice
+	triggered, err = s.engine.ProcessRule(ctx, rule)
+	assert.NoError(s.T(), err)
+	assert.False(s.T(), triggered)
+	s.mockExecutionRepo.AssertNumberOfCalls(s.T(), "Create", 1)
+}
+
+func (s *RuleEngineServiceTestSuite) TestProcessRule_ScheduledRunTakenElsewhere() {
+	// Arrange: another instance moved the next run on after this copy was loaded
+	ctx := context.Background()
+	due := time.Now().Add(-10 * time.Second)
+	rule := newScheduledRule(services.ScheduleSpec{IntervalSeconds: 3600}, &due)
+	s.mockRuleRepo.On("UpdateNextRunAt", ctx, rule.ID, &due, due.Add(time.Hour)).Return(false, nil)
+
+	// Act
+	triggered, err := s.engine.ProcessRule(ctx, rule)
+
+	// Assert: the run is not taken a second time
+	assert.NoError(s.T(), err)
+	assert.False(s.T(), triggered)
+	assert.Equal(s.T(), due, *rule.NextRunAt)
+	s.mockRuleRepo.AssertNotCalled(s.T(), "RecordTrigger", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	s.mockExecutionRepo.AssertNotCalled(s.T(), "Create", mock.Anything, mock.Anything)
+}
+
+func (s *RuleEngineServiceTestSuite) TestProcessRule_ScheduledRuleCatchUp() {
+	tests := []struct {
+		catchUp    string
+		executions int
+	}{
+		{catchUp: services.CatchUpSkip, executions: 0},
+		{catchUp: services.CatchUpOnce, executions: 1},
+		{catchUp: services.CatchUpAll, executions: 3},
+	}
+
+	for _, tt := range tests {
+		s.Run(tt.catchUp, func() {
+			s.SetupTest()
+
+			// Arrange: three hourly runs were missed, the last 30 minutes ago
+			ctx := context.Background()
+			missed := time.Now().Add(-150 * time.Minute)
+			rule := newScheduledRule(services.ScheduleSpec{IntervalSeconds: 3600, CatchUp: tt.catchUp}, &missed)
+			s.mockRuleRepo.On("UpdateNextRunAt", ctx, rule.ID, &missed, missed.Add(3*time.Hour)).Return(true, nil)
+			s.expectMarketSell(ctx, rule)
+
+			// Act
+			triggered, err := s.engine.ProcessRule(ctx, rule)
+
+			// Assert
+			assert.NoError(s.T(), err)
+			assert.Equal(s.T(), tt.executions > 0, triggered)
+			assert.Equal(s.T(), missed.Add(3*time.Hour), *rule.NextRunAt)
+			s.mockExecutionRepo.AssertNumberOfCalls(s.T(), "Create", tt.executions)
+			assert.Equal(s.T(), tt.executions, rule.TriggerCount)
+			if tt.executions > 0 {
+				s.mockRuleRepo.AssertCalled(s.T(), "RecordTrigger", ctx, rule, mock.Anything, tt.executions)
+			}
+		})
+	}
+}
+
+func (s *RuleEngineServiceTestSuite) TestExecuteRule_NotionalBuysByValue() {
+	// Arrange
+	ctx := context.Background()
+	rule := newTestRule(nil, []services.RuleAction{
+		{Type: "buy", Notional: 200, OrderType: "market"},
+	})
+	var execution *models.Execution
+	s.mockExecutionRepo.On("Create", ctx, mock.AnythingOfType("*models.Execution")).
+		Run(func(args mock.Arguments) { execution = args.Get(1).(*models.Execution) }).
+		Return(nil)
+	s.mockRuleRepo.On("GetByID", ctx, rule.ID).Return(rule, nil)
+	s.mockRuleRepo.On("UpdateLastExecutedAt", ctx, rule.ID, mock.AnythingOfType("time.Time")).Return(nil)
+	s.mockPortfolioRepo.On("GetPortfolioByUserID", ctx, rule.UserID).Return(nil, repository.ErrPortfolioNotFound)
+
+	// Act
+	err := s.engine.ExecuteRule(ctx, rule, 50)
+
+	// Assert
+	assert.NoError(s.T(), err)
+	require.NotNil(s.T(), execution)
+	assert.Equal(s.T(), 4.0, execution.Quantity)
+	assert.Equal(s.T(), 50.0, execution.Price)
+}
+
+func TestRuleScheduler_RunDueProcessesDueRules(t *testing.T) {
+	ruleRepo := new(mocks.MockRuleRepository)
+	engine := newRecordingEngine()
+
+	first := newScheduledRule(services.ScheduleSpec{IntervalSeconds: 60}, nil)
+	first.Name = "first"
+	second := newScheduledRule(services.ScheduleSpec{IntervalSeconds: 60}, nil)
+	second.Name = "second"
+	ruleRepo.On("GetDueScheduledRules", mock.Anything, mock.AnythingOfType("time.Time")).
+		Return([]models.TradingRule{*first, *second}, nil)
+	expectReloads(ruleRepo, first, second)
+
+	var triggered []string
+	scheduler := services.NewRuleScheduler(ruleRepo, engine, services.RuleSchedulerConfig{
+		OnTrigger: func(rule *models.TradingRule) { triggered = append(triggered, rule.Name) },
+	})
+
+	require.NoError(t, scheduler.RunDue(context.Background()))
+	assert.Equal(t, []string{"first", "second"}, engine.processed)
+	assert.Equal(t, []string{"first", "second"}, triggered)
+}
+
+func TestRuleScheduler_RunDueSkipsRulesChangedSinceLoaded(t *testing.T) {
+	ruleRepo := new(mocks.MockRuleRepository)
+	engine := newRecordingEngine()
+
+	deactivated := newScheduledRule(services.ScheduleSpec{IntervalSeconds: 60}, nil)
+	deleted := newScheduledRule(services.ScheduleSpec{IntervalSeconds: 60}, nil)
+	ruleRepo.On("GetDueScheduledRules", mock.Anything, mock.AnythingOfType("time.Time")).
+		Return([]models.TradingRule{*deactivated, *deleted}, nil)
+	// Deactivated and deleted after the due rules were listed
+	inactive := *deactivated
+	inactive.Status = "inactive"
+	ruleRepo.On("GetByID", mock.Anything, deactivated.ID).Return(&inactive, nil)
+	ruleRepo.On("GetByID", mock.Anything, deleted.ID).Return(nil, repository.ErrRuleNotFound)
+
+	scheduler := services.NewRuleScheduler(ruleRepo, engine, services.RuleSchedulerConfig{
+		OnError: func(rule *models.TradingRule, err error) { t.Errorf("unexpected error: %v", err) },
+	})
+
+	require.NoError(t, scheduler.RunDue(context.Background()))
+	assert.Empty(t, engine.processed)
+}
+
+func TestRuleDispatcher_SkipsScheduledRules(t *testing.T) {
+	ruleRepo := new(mocks.MockRuleRepository)
+	engine := newRecordingEngine()
+
+	scheduled := newScheduledRule(services.ScheduleSpec{IntervalSeconds: 60}, nil)
+	ruleRepo.On("GetActiveRules", mock.Anything).Return([]models.TradingRule{*scheduled}, nil)
+
+	dispatcher := services.NewRuleDispatcher(ruleRepo, engine, services.RuleDispatcherConfig{Workers: 1})
+	require.NoError(t, dispatcher.Refresh(context.Background()))
+	require.NoError(t, dispatcher.Dispatch(context.Background(), models.MarketEvent{Symbol: "AAPL"}))
+
+	assert.Empty(t, engine.calls)
+}
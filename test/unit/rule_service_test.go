@@ -0,0 +1,255 @@
+// test/unit/rule_service_test.go
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/aquibsayyed9/sentinel/internal/models"
+	"github.com/aquibsayyed9/sentinel/internal/repository"
+	"github.com/aquibsayyed9/sentinel/internal/services"
+	"github.com/aquibsayyed9/sentinel/test/mocks"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type RuleServiceTestSuite struct {
+	suite.Suite
+	mockRuleRepo *mocks.MockRuleRepository
+	service      services.RuleService
+}
+
+func (s *RuleServiceTestSuite) SetupTest() {
+	s.mockRuleRepo = new(mocks.MockRuleRepository)
+	s.service = services.NewRuleService(s.mockRuleRepo)
+}
+
+func TestRuleServiceSuite(t *testing.T) {
+	suite.Run(t, new(RuleServiceTestSuite))
+}
+
+func newVersionedRuleInput() services.RuleInput {
+	return services.RuleInput{
+		Name:     "Buy the dip",
+		Symbol:   "AAPL",
+		RuleType: "buy",
+		Conditions: []services.RuleCondition{
+			{Type: "price", Operator: "less_than", Value: 150},
+		},
+		Actions: []services.RuleAction{
+			{Type: "buy", Quantity: 10, OrderType: "market"},
+		},
+	}
+}
+
+// storedRule builds the rule CreateRule would have saved for input
+func (s *RuleServiceTestSuite) storedRule(input services.RuleInput) *models.TradingRule {
+	rule, err := s.service.BuildRule(uuid.New(), input)
+	s.Require().NoError(err)
+	rule.ID = uuid.New()
+	return rule
+}
+
+func (s *RuleServiceTestSuite) TestCreateRule_RecordsFirstVersion() {
+	// Arrange
+	ctx := context.Background()
+	userID := uuid.New()
+
+	s.mockRuleRepo.On("CreateWithVersion", ctx, mock.AnythingOfType("*models.TradingRule"),
+		mock.MatchedBy(func(v *models.RuleVersion) bool {
+			return v.Version == 1 && v.CreatedBy == userID && string(v.Changes) == "[]"
+		})).Return(nil)
+
+	// Act
+	rule, err := s.service.CreateRule(ctx, userID, newVersionedRuleInput())
+
+	// Assert
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), 1, rule.Version)
+	s.mockRuleRepo.AssertExpectations(s.T())
+}
+
+func (s *RuleServiceTestSuite) TestPatchRule_RecordsChangedFields() {
+	// Arrange
+	ctx := context.Background()
+	rule := s.storedRule(newVersionedRuleInput())
+	author := rule.UserID
+	name := "Buy the deeper dip"
+	conditions := []services.RuleCondition{{Type: "price", Operator: "less_than", Value: 140}}
+
+	var recorded *models.RuleVersion
+	s.mockRuleRepo.On("GetByIDAndUserID", ctx, rule.ID, rule.UserID).Return(rule, nil)
+	s.mockRuleRepo.On("UpdateDefinition", ctx, rule, mock.AnythingOfType("*models.RuleVersion")).
+		Run(func(args mock.Arguments) { recorded = args.Get(2).(*models.RuleVersion) }).
+		Return(nil)
+
+	// Act
+	updated, err := s.service.PatchRule(ctx, author, rule.ID, services.RulePatch{Name: &name, Conditions: &conditions})
+
+	// Assert
+	s.Require().NoError(err)
+	assert.Equal(s.T(), 2, updated.Version)
+	assert.Equal(s.T(), name, updated.Name)
+
+	s.Require().NotNil(recorded)
+	assert.Equal(s.T(), 2, recorded.Version)
+	assert.Equal(s.T(), author, recorded.CreatedBy)
+
+	definition, changes, err := services.DecodeRuleVersion(recorded)
+	s.Require().NoError(err)
+	assert.Equal(s.T(), name, definition.Name)
+	assert.Equal(s.T(), 140.0, definition.Conditions[0].Value)
+	s.Require().Len(changes, 2)
+	assert.Equal(s.T(), "conditions", changes[0].Field)
+	assert.Equal(s.T(), "name", changes[1].Field)
+	assert.JSONEq(s.T(), `"Buy the dip"`, string(changes[1].Old))
+	assert.JSONEq(s.T(), `"Buy the deeper dip"`, string(changes[1].New))
+}
+
+func (s *RuleServiceTestSuite) TestPatchRule_UnchangedDefinitionKeepsVersion() {
+	// Arrange
+	ctx := context.Background()
+	rule := s.storedRule(newVersionedRuleInput())
+	name := rule.Name
+
+	s.mockRuleRepo.On("GetByIDAndUserID", ctx, rule.ID, rule.UserID).Return(rule, nil)
+
+	// Act
+	updated, err := s.service.PatchRule(ctx, rule.UserID, rule.ID, services.RulePatch{Name: &name})
+
+	// Assert
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), 1, updated.Version)
+	s.mockRuleRepo.AssertNotCalled(s.T(), "UpdateDefinition", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (s *RuleServiceTestSuite) TestPatchRule_InvalidPatchIsRejected() {
+	// Arrange
+	ctx := context.Background()
+	rule := s.storedRule(newVersionedRuleInput())
+	actions := []services.RuleAction{}
+
+	s.mockRuleRepo.On("GetByIDAndUserID", ctx, rule.ID, rule.UserID).Return(rule, nil)
+
+	// Act
+	_, err := s.service.PatchRule(ctx, rule.UserID, rule.ID, services.RulePatch{Actions: &actions})
+
+	// Assert
+	assert.True(s.T(), errors.Is(err, services.ErrInvalidRule))
+	s.mockRuleRepo.AssertNotCalled(s.T(), "UpdateDefinition", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (s *RuleServiceTestSuite) TestPatchRule_ConcurrentUpdateConflicts() {
+	// Arrange
+	ctx := context.Background()
+	rule := s.storedRule(newVersionedRuleInput())
+	name := "Renamed"
+
+	s.mockRuleRepo.On("GetByIDAndUserID", ctx, rule.ID, rule.UserID).Return(rule, nil)
+	s.mockRuleRepo.On("UpdateDefinition", ctx, rule, mock.AnythingOfType("*models.RuleVersion")).
+		Return(repository.ErrRuleVersionConflict)
+
+	// Act
+	_, err := s.service.PatchRule(ctx, rule.UserID, rule.ID, services.RulePatch{Name: &name})
+
+	// Assert
+	assert.True(s.T(), errors.Is(err, repository.ErrRuleVersionConflict))
+}
+
+func (s *RuleServiceTestSuite) TestRollbackRule_RestoresDefinitionAsNewVersion() {
+	// Arrange
+	ctx := context.Background()
+	original := newVersionedRuleInput()
+	current := original
+	current.Name = "Buy the deeper dip"
+	rule := s.storedRule(current)
+	rule.Version = 2
+
+	definition, _ := json.Marshal(original)
+	target := &models.RuleVersion{RuleID: rule.ID, Version: 1, Definition: definition}
+
+	s.mockRuleRepo.On("GetByIDAndUserID", ctx, rule.ID, rule.UserID).Return(rule, nil)
+	s.mockRuleRepo.On("GetVersion", ctx, rule.ID, 1).Return(target, nil)
+	s.mockRuleRepo.On("UpdateDefinition", ctx, rule, mock.MatchedBy(func(v *models.RuleVersion) bool {
+		return v.Version == 3
+	})).Return(nil)
+
+	// Act
+	updated, err := s.service.RollbackRule(ctx, rule.UserID, rule.ID, 1)
+
+	// Assert
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), 3, updated.Version)
+	assert.Equal(s.T(), original.Name, updated.Name)
+	s.mockRuleRepo.AssertExpectations(s.T())
+}
+
+func (s *RuleServiceTestSuite) TestOtherUsersRulesAreNotFound() {
+	// Arrange
+	ctx := context.Background()
+	ruleID := uuid.New()
+	otherUser := uuid.New()
+	name := "Renamed"
+
+	s.mockRuleRepo.On("GetByIDAndUserID", ctx, ruleID, otherUser).Return(nil, repository.ErrRuleNotFound)
+
+	// Act
+	_, getErr := s.service.GetRuleByID(ctx, otherUser, ruleID)
+	activateErr := s.service.ActivateRule(ctx, otherUser, ruleID)
+	deactivateErr := s.service.DeactivateRule(ctx, otherUser, ruleID)
+	deleteErr := s.service.DeleteRule(ctx, otherUser, ruleID)
+	_, patchErr := s.service.PatchRule(ctx, otherUser, ruleID, services.RulePatch{Name: &name})
+	_, versionsErr := s.service.GetRuleVersions(ctx, otherUser, ruleID)
+
+	// Assert
+	for _, err := range []error{getErr, activateErr, deactivateErr, deleteErr, patchErr, versionsErr} {
+		assert.True(s.T(), errors.Is(err, repository.ErrRuleNotFound))
+	}
+	s.mockRuleRepo.AssertNotCalled(s.T(), "Update", mock.Anything, mock.Anything)
+	s.mockRuleRepo.AssertNotCalled(s.T(), "UpdateStatus", mock.Anything, mock.Anything)
+	s.mockRuleRepo.AssertNotCalled(s.T(), "Delete", mock.Anything, mock.Anything)
+	s.mockRuleRepo.AssertNotCalled(s.T(), "GetVersions", mock.Anything, mock.Anything)
+}
+
+func (s *RuleServiceTestSuite) TestBuildRule_StopRulesTriggerOnceByDefault() {
+	input := services.RuleInput{
+		Name:     "Stop",
+		Symbol:   "AAPL",
+		RuleType: "stop_loss",
+		Stop:     &services.StopSpec{Percent: 5},
+		Actions:  []services.RuleAction{{Type: "sell", Quantity: 10, OrderType: "market"}},
+	}
+
+	rule := s.storedRule(input)
+	s.True(rule.OneShot)
+
+	input.TriggerPolicy = services.TriggerPolicy{CooldownSeconds: 3600}
+	rule = s.storedRule(input)
+	s.False(rule.OneShot)
+	s.Equal(3600, rule.CooldownSeconds)
+}
+
+func TestRulePatch_NullRemovesStop(t *testing.T) {
+	input := services.RuleInput{
+		Name:       "Stop",
+		Symbol:     "AAPL",
+		RuleType:   "stop_loss",
+		Conditions: []services.RuleCondition{{Type: "price", Operator: "less_than", Value: 90}},
+		Stop:       &services.StopSpec{Percent: 5},
+	}
+
+	var patch services.RulePatch
+	assert.NoError(t, json.Unmarshal([]byte(`{"name":"Exit"}`), &patch))
+	assert.Equal(t, &services.StopSpec{Percent: 5}, patch.Apply(input).Stop)
+
+	assert.NoError(t, json.Unmarshal([]byte(`{"stop":{"percent":8}}`), &patch))
+	assert.Equal(t, &services.StopSpec{Percent: 8}, patch.Apply(input).Stop)
+
+	patch = services.RulePatch{}
+	assert.NoError(t, json.Unmarshal([]byte(`{"stop":null}`), &patch))
+	assert.Nil(t, patch.Apply(input).Stop)
+}
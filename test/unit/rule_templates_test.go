@@ -0,0 +1,140 @@
+// test/unit/rule_templates_test.go
+package unit
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aquibsayyed9/sentinel/internal/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// minimalTemplateParams are the required parameters of each built-in template
+var minimalTemplateParams = map[string]map[string]interface{}{
+	"percent_stop_loss":  {"symbol": "AAPL", "quantity": 10.0, "percent": 5.0},
+	"golden_cross":       {"symbol": "AAPL", "quantity": 10.0},
+	"rsi_mean_reversion": {"symbol": "AAPL", "quantity": 10.0},
+	"breakout_high":      {"symbol": "AAPL", "quantity": 10.0},
+	"weekly_dca":         {"symbol": "AAPL", "quantity": 10.0},
+}
+
+func TestRuleTemplates_ProduceValidRules(t *testing.T) {
+	templates := services.RuleTemplates()
+	require.Len(t, templates, len(minimalTemplateParams))
+
+	for _, template := range templates {
+		t.Run(template.ID, func(t *testing.T) {
+			params, ok := minimalTemplateParams[template.ID]
+			require.True(t, ok, "no test parameters for template")
+
+			input, err := services.InstantiateTemplate(template.ID, params)
+			require.NoError(t, err)
+			assert.Equal(t, "AAPL", input.Symbol)
+			assert.Equal(t, template.Name, input.Name)
+			assert.NoError(t, services.ValidateRule(input))
+		})
+	}
+}
+
+func TestInstantiateTemplate_AppliesParameters(t *testing.T) {
+	input, err := services.InstantiateTemplate("golden_cross", map[string]interface{}{
+		"name": "MSFT cross", "symbol": "MSFT", "quantity": 3.0, "fast_period": 20.0, "slow_period": 100.0, "time_frame": "1h",
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "MSFT cross", input.Name)
+	require.Len(t, input.Conditions, 1)
+	condition := input.Conditions[0]
+	assert.Equal(t, "sma", condition.Type)
+	assert.Equal(t, "1h", condition.TimeFrame)
+	assert.Equal(t, services.OperatorCrossesAbove, condition.Operator)
+	require.NotNil(t, condition.CompareTo)
+	assert.Equal(t, "sma", condition.CompareTo.Type)
+	assert.Equal(t, 3.0, input.Actions[0].Quantity)
+}
+
+func TestInstantiateTemplate_WeeklyDCAIsScheduled(t *testing.T) {
+	input, err := services.InstantiateTemplate("weekly_dca", minimalTemplateParams["weekly_dca"])
+	require.NoError(t, err)
+	assert.Equal(t, services.RuleTypeScheduled, input.RuleType)
+	require.NotNil(t, input.Schedule)
+	assert.Equal(t, "35 9 * * mon", input.Schedule.Cron)
+	assert.Empty(t, input.Conditions)
+}
+
+func TestInstantiateTemplate_CrossoversTriggerOncePerBar(t *testing.T) {
+	params := map[string]interface{}{"symbol": "AAPL", "quantity": 1.0, "time_frame": "1h"}
+	for _, id := range []string{"golden_cross", "rsi_mean_reversion"} {
+		input, err := services.InstantiateTemplate(id, params)
+		require.NoError(t, err, id)
+		assert.Equal(t, 60*60, input.TriggerPolicy.CooldownSeconds, id)
+	}
+}
+
+func TestInstantiateTemplate_InvalidParameters(t *testing.T) {
+	tests := []struct {
+		name       string
+		templateID string
+		params     map[string]interface{}
+		fields     []string
+	}{
+		{
+			name:       "missing required parameters",
+			templateID: "percent_stop_loss",
+			params:     map[string]interface{}{},
+			fields:     []string{"params.symbol", "params.quantity", "params.percent"},
+		},
+		{
+			name:       "out of range and wrong types",
+			templateID: "percent_stop_loss",
+			params:     map[string]interface{}{"symbol": 5.0, "quantity": 0.0, "percent": 150.0},
+			fields:     []string{"params.symbol", "params.quantity", "params.percent"},
+		},
+		{
+			name:       "fractional period",
+			templateID: "rsi_mean_reversion",
+			params:     map[string]interface{}{"symbol": "AAPL", "quantity": 1.0, "period": 14.5},
+			fields:     []string{"params.period"},
+		},
+		{
+			name:       "fast period not below slow period",
+			templateID: "golden_cross",
+			params:     map[string]interface{}{"symbol": "AAPL", "quantity": 1.0, "fast_period": 200.0, "slow_period": 50.0},
+			fields:     []string{"params.fast_period"},
+		},
+		{
+			name:       "unknown parameter",
+			templateID: "weekly_dca",
+			params:     map[string]interface{}{"symbol": "AAPL", "quantity": 1.0, "interval": "weekly"},
+			fields:     []string{"params.interval"},
+		},
+		{
+			name:       "invalid cron",
+			templateID: "weekly_dca",
+			params:     map[string]interface{}{"symbol": "AAPL", "quantity": 1.0, "cron": "every monday"},
+			fields:     []string{"params.cron"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := services.InstantiateTemplate(tt.templateID, tt.params)
+			require.Error(t, err)
+			assert.True(t, errors.Is(err, services.ErrInvalidTemplateParams))
+
+			var validationErr *services.ValidationError
+			require.True(t, errors.As(err, &validationErr))
+			fields := make([]string, len(validationErr.Fields))
+			for i, field := range validationErr.Fields {
+				fields[i] = field.Field
+			}
+			assert.Equal(t, tt.fields, fields)
+		})
+	}
+}
+
+func TestInstantiateTemplate_UnknownTemplate(t *testing.T) {
+	_, err := services.InstantiateTemplate("martingale", nil)
+	assert.True(t, errors.Is(err, services.ErrRuleTemplateNotFound))
+}
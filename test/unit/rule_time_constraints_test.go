@@ -0,0 +1,168 @@
+// test/unit/rule_time_constraints_test.go
+package unit
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aquibsayyed9/sentinel/internal/calendar"
+	"github.com/aquibsayyed9/sentinel/internal/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeConstraints_Allows(t *testing.T) {
+	nyse := calendar.NYSE()
+
+	tests := []struct {
+		name        string
+		constraints services.TimeConstraints
+		at          string // Eastern time
+		allowed     bool
+	}{
+		{
+			name:        "no constraints",
+			constraints: services.TimeConstraints{},
+			at:          "2024-03-09 03:00",
+			allowed:     true,
+		},
+		{
+			name:        "active day",
+			constraints: services.TimeConstraints{Days: []string{"mon", "wed"}},
+			at:          "2024-03-13 12:00",
+			allowed:     true,
+		},
+		{
+			name:        "inactive day",
+			constraints: services.TimeConstraints{Days: []string{"mon", "wed"}},
+			at:          "2024-03-12 12:00",
+			allowed:     false,
+		},
+		{
+			name:        "within window",
+			constraints: services.TimeConstraints{Windows: []services.TimeWindow{{Start: "10:00", End: "11:00"}}},
+			at:          "2024-03-12 10:00",
+			allowed:     true,
+		},
+		{
+			name:        "window end is exclusive",
+			constraints: services.TimeConstraints{Windows: []services.TimeWindow{{Start: "10:00", End: "11:00"}}},
+			at:          "2024-03-12 11:00",
+			allowed:     false,
+		},
+		{
+			name:        "any window",
+			constraints: services.TimeConstraints{Windows: []services.TimeWindow{{Start: "09:30", End: "10:00"}, {Start: "15:30", End: "16:00"}}},
+			at:          "2024-03-12 15:45",
+			allowed:     true,
+		},
+		{
+			name:        "window spanning midnight",
+			constraints: services.TimeConstraints{Windows: []services.TimeWindow{{Start: "22:00", End: "02:00"}}},
+			at:          "2024-03-12 01:00",
+			allowed:     true,
+		},
+		{
+			// Friday 8 March 2024 22:00 to Saturday 02:00
+			name:        "window spanning midnight into a day not listed",
+			constraints: services.TimeConstraints{Days: []string{"fri"}, Windows: []services.TimeWindow{{Start: "22:00", End: "02:00"}}},
+			at:          "2024-03-09 01:00",
+			allowed:     true,
+		},
+		{
+			name:        "window spanning midnight from a day not listed",
+			constraints: services.TimeConstraints{Days: []string{"fri"}, Windows: []services.TimeWindow{{Start: "22:00", End: "02:00"}}},
+			at:          "2024-03-08 01:00",
+			allowed:     false,
+		},
+		{
+			name:        "window spanning midnight on a listed day",
+			constraints: services.TimeConstraints{Days: []string{"fri"}, Windows: []services.TimeWindow{{Start: "22:00", End: "02:00"}}},
+			at:          "2024-03-08 23:00",
+			allowed:     true,
+		},
+		{
+			name: "window in another time zone",
+			constraints: services.TimeConstraints{
+				Windows:  []services.TimeWindow{{Start: "08:00", End: "09:00"}},
+				TimeZone: "Europe/London",
+			},
+			at:      "2024-01-10 03:30",
+			allowed: true,
+		},
+		{
+			name:        "day in another time zone",
+			constraints: services.TimeConstraints{Days: []string{"wed"}, TimeZone: "Asia/Tokyo"},
+			at:          "2024-03-12 20:00",
+			allowed:     true,
+		},
+		{
+			name:        "regular session",
+			constraints: services.TimeConstraints{RegularSessionOnly: true},
+			at:          "2024-03-12 09:30",
+			allowed:     true,
+		},
+		{
+			name:        "before the open",
+			constraints: services.TimeConstraints{RegularSessionOnly: true},
+			at:          "2024-03-12 09:00",
+			allowed:     false,
+		},
+		{
+			name:        "holiday",
+			constraints: services.TimeConstraints{RegularSessionOnly: true},
+			at:          "2024-12-25 12:00",
+			allowed:     false,
+		},
+		{
+			name:        "after an early close",
+			constraints: services.TimeConstraints{RegularSessionOnly: true},
+			at:          "2024-11-29 14:00",
+			allowed:     false,
+		},
+		{
+			name: "session and window together",
+			constraints: services.TimeConstraints{
+				RegularSessionOnly: true,
+				Windows:            []services.TimeWindow{{Start: "15:00", End: "17:00"}},
+			},
+			at:      "2024-03-12 16:30",
+			allowed: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.allowed, tt.constraints.Allows(nyTime(t, tt.at), nyse))
+		})
+	}
+}
+
+func TestTimeConstraints_Validate(t *testing.T) {
+	constraints := services.TimeConstraints{
+		Days:     []string{"mon", "funday", "MON"},
+		Windows:  []services.TimeWindow{{Start: "9:30am", End: "10:00"}, {Start: "10:00", End: "10:00"}},
+		TimeZone: "Mars/Olympus_Mons",
+	}
+
+	err := constraints.Validate()
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, services.ErrInvalidTimeConstraints))
+
+	var validationErr *services.ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	fields := make([]string, len(validationErr.Fields))
+	for i, field := range validationErr.Fields {
+		fields[i] = field.Field
+	}
+	assert.Equal(t, []string{
+		"time_constraints.days[1]",
+		"time_constraints.days[2]",
+		"time_constraints.windows[0].start",
+		"time_constraints.windows[1]",
+		"time_constraints.time_zone",
+	}, fields)
+
+	valid := services.TimeConstraints{Days: []string{"Mon", "fri"}, Windows: []services.TimeWindow{{Start: "09:30", End: "16:00"}}, TimeZone: "UTC"}
+	assert.NoError(t, valid.Validate())
+}
@@ -0,0 +1,164 @@
+// test/unit/rule_validation_test.go
+package unit
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aquibsayyed9/sentinel/internal/services"
+	"github.com/stretchr/testify/assert"
+)
+
+func validRuleInput() services.RuleInput {
+	return services.RuleInput{
+		Name:     "Test Rule",
+		Symbol:   "AAPL",
+		RuleType: "buy",
+		Conditions: []services.RuleCondition{
+			{Type: "price", Operator: "less_than", Value: 150},
+		},
+		Actions: []services.RuleAction{
+			{Type: "buy", Symbol: "AAPL", Quantity: 10, OrderType: "market"},
+		},
+	}
+}
+
+func TestValidateRule(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(input *services.RuleInput)
+		fields []string
+	}{
+		{"valid", func(input *services.RuleInput) {}, nil},
+		{"valid indicator crossover", func(input *services.RuleInput) {
+			input.Conditions = []services.RuleCondition{{
+				Type: "ema", TimeFrame: "1h", Operator: "crosses_above", Params: map[string]interface{}{"period": 9},
+				CompareTo: &services.RuleCondition{Type: "sma", Params: map[string]interface{}{"period": 21}},
+			}}
+		}, nil},
+		{"unknown condition type", func(input *services.RuleInput) {
+			input.Conditions[0].Type = "sentiment"
+		}, []string{"conditions[0].type"}},
+		{"invalid operator", func(input *services.RuleInput) {
+			input.Conditions[0].Operator = "roughly"
+		}, []string{"conditions[0].operator"}},
+		{"missing indicator period", func(input *services.RuleInput) {
+			input.Conditions[0] = services.RuleCondition{Type: "sma", TimeFrame: "1d", Operator: "less_than", Value: 100}
+		}, []string{"conditions[0].params"}},
+		{"unknown indicator param", func(input *services.RuleInput) {
+			input.Conditions[0] = services.RuleCondition{Type: "rsi", Operator: "less_than", Value: 30, Params: map[string]interface{}{"length": 14}}
+		}, []string{"conditions[0].params"}},
+		{"invalid time frame", func(input *services.RuleInput) {
+			input.Conditions[0] = services.RuleCondition{Type: "rsi", TimeFrame: "3y", Operator: "less_than", Value: 30}
+		}, []string{"conditions[0].time_frame"}},
+		{"time frame on quote condition", func(input *services.RuleInput) {
+			input.Conditions[0] = services.RuleCondition{Type: "bid", TimeFrame: "1d", Operator: "less_than", Value: 30}
+		}, []string{"conditions[0].time_frame"}},
+		{"crossover on a snapshot value", func(input *services.RuleInput) {
+			input.Conditions[0].Operator = "crosses_above"
+		}, []string{"conditions[0].operator"}},
+		{"nested compare_to error", func(input *services.RuleInput) {
+			input.Conditions = []services.RuleCondition{{Any: []services.RuleCondition{
+				{Type: "price", Operator: "greater_than", CompareTo: &services.RuleCondition{Type: "bogus"}},
+			}}}
+		}, []string{"conditions[0].any[0].compare_to.type"}},
+		{"valid ratio of two symbols", func(input *services.RuleInput) {
+			input.Conditions[0] = services.RuleCondition{
+				Type: "ratio", Operator: "greater_than", Value: 1.2,
+				Legs: []services.RuleCondition{{Type: "price", Symbol: "USO"}, {Type: "sma", Symbol: "XLE", TimeFrame: "1d", Params: map[string]interface{}{"period": 20}}},
+			}
+		}, nil},
+		{"ratio with one leg", func(input *services.RuleInput) {
+			input.Conditions[0] = services.RuleCondition{
+				Type: "ratio", Operator: "greater_than", Value: 1.2,
+				Legs: []services.RuleCondition{{Type: "price", Symbol: "USO"}},
+			}
+		}, []string{"conditions[0].legs"}},
+		{"leg with an operator", func(input *services.RuleInput) {
+			input.Conditions[0] = services.RuleCondition{
+				Type: "difference", Operator: "greater_than", Value: 5,
+				Legs: []services.RuleCondition{{Type: "price", Symbol: "USO", Operator: "less_than"}, {Type: "bogus"}},
+			}
+		}, []string{"conditions[0].legs[0]", "conditions[0].legs[1].type"}},
+		{"legs on a price condition", func(input *services.RuleInput) {
+			input.Conditions[0].Legs = []services.RuleCondition{{Type: "price"}, {Type: "price"}}
+		}, []string{"conditions[0].legs"}},
+		{"valid portfolio condition and relative sell", func(input *services.RuleInput) {
+			input.Conditions[0] = services.RuleCondition{Type: "position_pnl_percent", Operator: "less_than_or_equal", Value: -8}
+			input.Actions[0] = services.RuleAction{Type: "sell", PositionPercent: 50, OrderType: "market"}
+		}, nil},
+		{"portfolio condition with a time frame", func(input *services.RuleInput) {
+			input.Conditions[0] = services.RuleCondition{Type: "cash_balance", TimeFrame: "1d", Operator: "greater_than", Value: 100}
+		}, []string{"conditions[0].time_frame"}},
+		{"quantity and relative amount", func(input *services.RuleInput) {
+			input.Actions[0].TargetWeight = 5
+		}, []string{"actions[0]"}},
+		{"relative amounts out of range", func(input *services.RuleInput) {
+			input.Actions[0] = services.RuleAction{Type: "sell", CashPercent: 120, OrderType: "market"}
+		}, []string{"actions[0].cash_percent", "actions[0].cash_percent"}},
+		{"valid follow-up rule", func(input *services.RuleInput) {
+			input.Actions = append(input.Actions, services.RuleAction{Type: "create_rule", Rule: &services.RuleInput{
+				Name: "Entry stop", Symbol: "AAPL", RuleType: "stop_loss", Stop: &services.StopSpec{Percent: 5},
+				Actions: []services.RuleAction{{Type: "sell", PositionPercent: 100}},
+			}})
+		}, nil},
+		{"invalid follow-up rule", func(input *services.RuleInput) {
+			input.Actions = append(input.Actions, services.RuleAction{Type: "create_rule", Rule: &services.RuleInput{
+				Name: "Entry stop", Symbol: "AAPL", RuleType: "stop_loss",
+				Actions: []services.RuleAction{{Type: "sell", Quantity: 10}},
+			}})
+		}, []string{"actions[1].rule.conditions"}},
+		{"rule action without a rule", func(input *services.RuleInput) {
+			input.Actions[0] = services.RuleAction{Type: "activate_rule", Quantity: 1}
+		}, []string{"actions[0]", "actions[0].rule_id"}},
+		{"non-positive quantity", func(input *services.RuleInput) {
+			input.Actions[0].Quantity = 0
+		}, []string{"actions[0].quantity"}},
+		{"limit order without limit", func(input *services.RuleInput) {
+			input.Actions[0].OrderType = "limit"
+		}, []string{"actions[0].limit"}},
+		{"unknown order type", func(input *services.RuleInput) {
+			input.Actions[0].OrderType = "iceberg"
+		}, []string{"actions[0].order_type"}},
+		{"action symbol mismatch", func(input *services.RuleInput) {
+			input.Actions[0].Symbol = "MSFT"
+		}, []string{"actions[0].symbol"}},
+		{"no actions", func(input *services.RuleInput) {
+			input.Actions = nil
+		}, []string{"actions"}},
+		{"valid AI management", func(input *services.RuleInput) {
+			input.AIManagement = &services.AIManagement{AutoApply: true, MaxChangePercent: 15}
+		}, nil},
+		{"AI management bound out of range", func(input *services.RuleInput) {
+			input.AIManagement = &services.AIManagement{MaxChangePercent: 150}
+		}, []string{"ai_management.max_change_percent"}},
+		{"reports every problem", func(input *services.RuleInput) {
+			input.Name = ""
+			input.Conditions[0].Type = ""
+			input.Actions[0] = services.RuleAction{Type: "short", Quantity: -1}
+		}, []string{"name", "conditions[0].type", "actions[0].type", "actions[0].quantity"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := validRuleInput()
+			tt.modify(&input)
+
+			err := services.ValidateRule(input)
+			if tt.fields == nil {
+				assert.NoError(t, err)
+				return
+			}
+
+			assert.True(t, errors.Is(err, services.ErrInvalidRule))
+			var validationErr *services.ValidationError
+			if assert.True(t, errors.As(err, &validationErr)) {
+				fields := make([]string, len(validationErr.Fields))
+				for i, field := range validationErr.Fields {
+					fields[i] = field.Field
+				}
+				assert.Equal(t, tt.fields, fields)
+			}
+		})
+	}
+}